@@ -0,0 +1,283 @@
+package ensign
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/ensql"
+)
+
+// An aggregation groups the events that match an aggregate query by the values of the
+// GROUP BY fields and computes the aggregate functions of the query for each group.
+// Events whose group by fields do not exist are grouped together under null values.
+type aggregation struct {
+	plan   *queryPlan
+	groups map[string]*group
+}
+
+// A group contains the values of the group by fields shared by all the events in the
+// group and the state of each aggregate function, keyed by the aggregate token.
+type group struct {
+	keys       []any
+	aggregates map[string]*aggregateState
+}
+
+// The running state of an aggregate function over the events in a group. Integers are
+// summed separately from floats so that the sum of integer fields is an integer.
+type aggregateState struct {
+	count   int64
+	numbers int64
+	isum    int64
+	fsum    float64
+	floats  bool
+	min     any
+	max     any
+}
+
+func newAggregation(plan *queryPlan) *aggregation {
+	return &aggregation{plan: plan, groups: make(map[string]*group)}
+}
+
+// Add the event fields to the group identified by the event's group by fields.
+func (a *aggregation) add(fields *EventFields) {
+	keys := make([]any, 0, len(a.plan.query.GroupBy))
+	for _, field := range a.plan.query.GroupBy {
+		value, _ := fields.Lookup(field.Token)
+		keys = append(keys, value)
+	}
+
+	g := a.group(keys)
+	for token, aggregate := range a.plan.query.Aggregates {
+		g.aggregates[token].update(aggregate, fields)
+	}
+}
+
+// Returns the group for the specified keys, creating it if it does not exist.
+func (a *aggregation) group(keys []any) *group {
+	key := groupKey(keys)
+	g, ok := a.groups[key]
+	if !ok {
+		g = &group{keys: keys, aggregates: make(map[string]*aggregateState, len(a.plan.query.Aggregates))}
+		for token := range a.plan.query.Aggregates {
+			g.aggregates[token] = &aggregateState{}
+		}
+		a.groups[key] = g
+	}
+	return g
+}
+
+// Returns a row for each group ordered by the values of the group by fields, with the
+// offset and limit of the query applied to the rows rather than to the events. If the
+// query has no GROUP BY clause then a single row is returned even if no events matched.
+func (a *aggregation) rows() []*api.QueryRow {
+	if len(a.plan.query.GroupBy) == 0 && len(a.groups) == 0 {
+		a.group(nil)
+	}
+
+	groups := make([]*group, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+
+	slices.SortFunc(groups, func(x, y *group) int {
+		for i := range x.keys {
+			if c := compareKeys(x.keys[i], y.keys[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	if a.plan.query.HasOffset {
+		groups = groups[min(uint64(len(groups)), a.plan.query.Offset):]
+	}
+
+	if a.plan.query.HasLimit && a.plan.query.Limit < uint64(len(groups)) {
+		groups = groups[:a.plan.query.Limit]
+	}
+
+	// Map the group by fields to their index in the group keys
+	index := make(map[string]int, len(a.plan.query.GroupBy))
+	for i, field := range a.plan.query.GroupBy {
+		index[field.Token] = i
+	}
+
+	rows := make([]*api.QueryRow, 0, len(groups))
+	for _, g := range groups {
+		row := &api.QueryRow{Columns: make([]*api.Column, 0, len(a.plan.query.Fields))}
+		for _, field := range a.plan.query.Fields {
+			name := field.Token
+			if alias, ok := a.plan.query.Aliases[field.Token]; ok {
+				name = alias
+			}
+
+			var value any
+			if field.Type == ensql.Function {
+				value = g.aggregates[field.Token].result(a.plan.query.Aggregates[field.Token])
+			} else {
+				value = g.keys[index[field.Token]]
+			}
+			row.Columns = append(row.Columns, newColumn(name, value))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Update the aggregate state with the value of the aggregated field from the event.
+// Null and missing values are ignored (except by COUNT(*)) as are values that cannot
+// be summed or compared with the values that have already been aggregated.
+func (s *aggregateState) update(aggregate ensql.Aggregate, fields *EventFields) {
+	if aggregate.Field.Type == ensql.Asterisk {
+		s.count++
+		return
+	}
+
+	value, ok := fields.Lookup(aggregate.Field.Token)
+	if !ok || value == nil {
+		return
+	}
+	s.count++
+
+	switch aggregate.Function {
+	case ensql.Sum, ensql.Avg:
+		if i, ok := asInteger(value); ok {
+			s.isum += i
+			s.numbers++
+		} else if f, ok := asFloat(value); ok {
+			s.fsum += f
+			s.floats = true
+			s.numbers++
+		}
+	case ensql.Min:
+		if s.min == nil {
+			s.min = value
+		} else if c, ok := compareValues(value, s.min); ok && c < 0 {
+			s.min = value
+		}
+	case ensql.Max:
+		if s.max == nil {
+			s.max = value
+		} else if c, ok := compareValues(value, s.max); ok && c > 0 {
+			s.max = value
+		}
+	}
+}
+
+// Returns the result of the aggregate function, which is nil if no values were
+// aggregated by a function other than COUNT.
+func (s *aggregateState) result(aggregate ensql.Aggregate) any {
+	switch aggregate.Function {
+	case ensql.Count:
+		return s.count
+	case ensql.Sum:
+		if s.numbers == 0 {
+			return nil
+		}
+		if s.floats {
+			return s.fsum + float64(s.isum)
+		}
+		return s.isum
+	case ensql.Avg:
+		if s.numbers == 0 {
+			return nil
+		}
+		return (s.fsum + float64(s.isum)) / float64(s.numbers)
+	case ensql.Min:
+		return s.min
+	case ensql.Max:
+		return s.max
+	default:
+		return nil
+	}
+}
+
+// Create a unique key for the values of the group by fields.
+func groupKey(keys []any) string {
+	if data, err := json.Marshal(keys); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%#v", keys)
+}
+
+// Compare group keys so that null values are ordered first; values that cannot be
+// compared are ordered by their string representation so that the order is stable.
+func compareKeys(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if c, ok := compareValues(a, b); ok {
+		return c
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// Compare two values if they are both numbers, strings, or timestamps; ok is false if
+// the values cannot be compared with each other.
+func compareValues(a, b any) (_ int, ok bool) {
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return cmp.Compare(av, bv), true
+		}
+		return 0, false
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv), true
+		}
+		return 0, false
+	}
+
+	if ai, ok := asInteger(a); ok {
+		if bi, ok := asInteger(b); ok {
+			return cmp.Compare(ai, bi), true
+		}
+	}
+
+	af, aok := asFloat(a)
+	bf, bok := asFloat(b)
+	if aok && bok {
+		return cmp.Compare(af, bf), true
+	}
+	return 0, false
+}
+
+// Returns the value as an int64 if it is an integer that does not overflow an int64.
+func asInteger(value any) (int64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+// Returns the value as a float64 if it is any numeric type.
+func asFloat(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -11,6 +11,7 @@ const (
 	CodeShardingFailure      = "wrong node for event sharding policy, please try again"
 	CodeRedirect             = "redirect to correct node"
 	CodeInternal             = "internal error, please wait and try again"
+	CodeTopicNotReady        = "topic is not ready to accept events, please try again later"
 	CodeUnprocessed          = "client did not process event"
 	CodeTimeout              = "client deadline exceeded"
 	CodeUnhandledMimetype    = "unhandled mimetype"
@@ -39,6 +40,8 @@ func DefaultNackMessage(code Nack_Code) string {
 		return CodeRedirect
 	case Nack_INTERNAL:
 		return CodeInternal
+	case Nack_TOPIC_NOT_READY:
+		return CodeTopicNotReady
 	case Nack_UNPROCESSED:
 		return CodeUnprocessed
 	case Nack_TIMEOUT:
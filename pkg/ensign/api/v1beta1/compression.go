@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"io"
+)
+
+// The literal width of the LZW codes used by the COMPRESS algorithm, which operates on
+// arbitrary bytes so it must be 8 bits wide.
+const lzwLitWidth = 8
+
+// Cannot publish events > 5MiB long; data is not decompressed past this size so that
+// small compressed payloads cannot expand into arbitrarily large allocations.
+const EventMaxDataSize int = 5.243e6
+
+// Enabled returns true if the compression policy uses a compression algorithm; nil
+// compression policies are not enabled.
+func (c *Compression) Enabled() bool {
+	return c.GetAlgorithm() != Compression_NONE
+}
+
+// Equals returns true if the compression policies use the same algorithm and level; a
+// nil policy is equal to a policy without compression.
+func (c *Compression) Equals(o *Compression) bool {
+	if c.GetAlgorithm() != o.GetAlgorithm() {
+		return false
+	}
+	return !c.Enabled() || c.GetLevel() == o.GetLevel()
+}
+
+// Normalize the compression policy by removing the level if the policy does not use
+// a compression algorithm. A nil policy is normalized to a policy without compression.
+func (c *Compression) Normalize() *Compression {
+	if c == nil {
+		c = &Compression{}
+	}
+
+	if !c.Enabled() {
+		c.Level = 0
+	}
+	return c
+}
+
+// Validate that data can be compressed with the algorithm and level of the policy. A
+// zero level uses the default level of the algorithm; GZIP and DEFLATE also accept the
+// levels from -2 (huffman only) to 9 (best compression) and COMPRESS has no levels.
+func (c *Compression) Validate() error {
+	switch c.GetAlgorithm() {
+	case Compression_NONE:
+		return nil
+	case Compression_GZIP, Compression_DEFLATE:
+		if c.Level < flate.HuffmanOnly || c.Level > flate.BestCompression {
+			return ErrInvalidCompressionLevel
+		}
+		return nil
+	case Compression_COMPRESS:
+		if c.Level != 0 {
+			return ErrInvalidCompressionLevel
+		}
+		return nil
+	case Compression_BROTLI:
+		return ErrUnsupportedCompression
+	default:
+		return ErrUnknownCompression
+	}
+}
+
+// Compress the data with the algorithm and level of the compression policy. If the
+// policy is not enabled then the data is returned as is.
+func (c *Compression) Compress(data []byte) (_ []byte, err error) {
+	if !c.Enabled() {
+		return data, nil
+	}
+
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
+
+	level := int(c.Level)
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	var (
+		buf = &bytes.Buffer{}
+		w   io.WriteCloser
+	)
+
+	switch c.Algorithm {
+	case Compression_GZIP:
+		w, err = gzip.NewWriterLevel(buf, level)
+	case Compression_DEFLATE:
+		w, err = flate.NewWriter(buf, level)
+	case Compression_COMPRESS:
+		w = lzw.NewWriter(buf, lzw.MSB, lzwLitWidth)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress data that was compressed with the algorithm of the compression policy. The
+// level is not required to decompress the data. If the policy is not enabled then the
+// data is returned as is. An error is returned if the decompressed data is larger than
+// the maximum size of an event.
+func (c *Compression) Decompress(data []byte) (_ []byte, err error) {
+	if !c.Enabled() {
+		return data, nil
+	}
+
+	var r io.ReadCloser
+	switch c.Algorithm {
+	case Compression_GZIP:
+		if r, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	case Compression_DEFLATE:
+		r = flate.NewReader(bytes.NewReader(data))
+	case Compression_COMPRESS:
+		r = lzw.NewReader(bytes.NewReader(data), lzw.MSB, lzwLitWidth)
+	case Compression_BROTLI:
+		return nil, ErrUnsupportedCompression
+	default:
+		return nil, ErrUnknownCompression
+	}
+
+	defer r.Close()
+	if data, err = io.ReadAll(io.LimitReader(r, int64(EventMaxDataSize)+1)); err != nil {
+		return nil, err
+	}
+
+	if len(data) > EventMaxDataSize {
+		return nil, ErrDecompressedTooLarge
+	}
+	return data, nil
+}
+
+//===========================================================================
+// Event Wrapper Compression Methods
+//===========================================================================
+
+// Compress the event bytes in the wrapper with the compression policy for storage and
+// record the policy as the storage compression of the wrapper so that the server can
+// decompress the event when it is read. Events without data and events that are already
+// compressed (e.g. by the publisher) are not compressed again.
+func (w *EventWrapper) Compress(policy *Compression) (err error) {
+	if !policy.Enabled() || w.Compression.Enabled() || w.StorageCompression.Enabled() || len(w.Event) == 0 {
+		return nil
+	}
+
+	if w.Event, err = policy.Compress(w.Event); err != nil {
+		return err
+	}
+
+	w.StorageCompression = &Compression{Algorithm: policy.Algorithm, Level: policy.Level}
+	return nil
+}
+
+// Decompress the event bytes that were compressed for storage and remove the storage
+// compression from the wrapper. Compression applied by the publisher is not removed so
+// that the event is returned as it was published. This is a no-op if the event was not
+// compressed for storage.
+func (w *EventWrapper) Decompress() (err error) {
+	if !w.StorageCompression.Enabled() {
+		return nil
+	}
+
+	if w.Event, err = w.StorageCompression.Decompress(w.Event); err != nil {
+		return err
+	}
+
+	w.StorageCompression = nil
+	return nil
+}
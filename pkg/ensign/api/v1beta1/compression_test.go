@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"bytes"
+	"testing"
+
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	// Highly compressible data similar to a JSON event
+	data := bytes.Repeat([]byte(`{"name":"sensor","reading":42.1,"status":"ok"}`), 128)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		testCases := []*api.Compression{
+			{Algorithm: api.Compression_GZIP},
+			{Algorithm: api.Compression_GZIP, Level: 9},
+			{Algorithm: api.Compression_GZIP, Level: -2},
+			{Algorithm: api.Compression_DEFLATE},
+			{Algorithm: api.Compression_DEFLATE, Level: 1},
+			{Algorithm: api.Compression_COMPRESS},
+		}
+
+		for i, policy := range testCases {
+			compressed, err := policy.Compress(data)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Less(t, len(compressed), len(data), "test case %d failed", i)
+
+			decompressed, err := policy.Decompress(compressed)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Equal(t, data, decompressed, "test case %d failed", i)
+		}
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		var policy *api.Compression
+		compressed, err := policy.Compress(data)
+		require.NoError(t, err)
+		require.Equal(t, data, compressed)
+
+		decompressed, err := (&api.Compression{}).Decompress(data)
+		require.NoError(t, err)
+		require.Equal(t, data, decompressed)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		policy := &api.Compression{Algorithm: api.Compression_BROTLI}
+		_, err := policy.Compress(data)
+		require.ErrorIs(t, err, api.ErrUnsupportedCompression)
+
+		_, err = policy.Decompress(data)
+		require.ErrorIs(t, err, api.ErrUnsupportedCompression)
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		policy := &api.Compression{Algorithm: api.Compression_GZIP}
+		_, err := policy.Decompress(data)
+		require.Error(t, err)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		policy := &api.Compression{Algorithm: api.Compression_GZIP, Level: 9}
+		bomb, err := policy.Compress(make([]byte, api.EventMaxDataSize+1))
+		require.NoError(t, err)
+
+		_, err = policy.Decompress(bomb)
+		require.ErrorIs(t, err, api.ErrDecompressedTooLarge)
+
+		limit, err := policy.Compress(make([]byte, api.EventMaxDataSize))
+		require.NoError(t, err)
+
+		decompressed, err := policy.Decompress(limit)
+		require.NoError(t, err)
+		require.Len(t, decompressed, api.EventMaxDataSize)
+	})
+
+	t.Run("Equals", func(t *testing.T) {
+		var policy *api.Compression
+		require.True(t, policy.Equals(nil))
+		require.True(t, policy.Equals(&api.Compression{}))
+		require.True(t, policy.Equals(&api.Compression{Level: 4}))
+		require.False(t, policy.Equals(&api.Compression{Algorithm: api.Compression_GZIP}))
+
+		policy = &api.Compression{Algorithm: api.Compression_GZIP, Level: 4}
+		require.True(t, policy.Equals(&api.Compression{Algorithm: api.Compression_GZIP, Level: 4}))
+		require.False(t, policy.Equals(&api.Compression{Algorithm: api.Compression_GZIP}))
+		require.False(t, policy.Equals(&api.Compression{Algorithm: api.Compression_DEFLATE, Level: 4}))
+		require.False(t, policy.Equals(nil))
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		var policy *api.Compression
+		require.Equal(t, &api.Compression{}, policy.Normalize())
+
+		policy = &api.Compression{Level: 4}
+		require.Same(t, policy, policy.Normalize())
+		require.Zero(t, policy.Level)
+
+		policy = &api.Compression{Algorithm: api.Compression_GZIP, Level: 4}
+		require.Equal(t, int64(4), policy.Normalize().Level)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		require.NoError(t, (*api.Compression)(nil).Validate())
+		require.NoError(t, (&api.Compression{}).Validate())
+		require.NoError(t, (&api.Compression{Algorithm: api.Compression_GZIP, Level: 9}).Validate())
+		require.NoError(t, (&api.Compression{Algorithm: api.Compression_DEFLATE, Level: -2}).Validate())
+		require.NoError(t, (&api.Compression{Algorithm: api.Compression_COMPRESS}).Validate())
+		require.ErrorIs(t, (&api.Compression{Algorithm: api.Compression_GZIP, Level: 10}).Validate(), api.ErrInvalidCompressionLevel)
+		require.ErrorIs(t, (&api.Compression{Algorithm: api.Compression_DEFLATE, Level: -3}).Validate(), api.ErrInvalidCompressionLevel)
+		require.ErrorIs(t, (&api.Compression{Algorithm: api.Compression_COMPRESS, Level: 1}).Validate(), api.ErrInvalidCompressionLevel)
+		require.ErrorIs(t, (&api.Compression{Algorithm: api.Compression_BROTLI}).Validate(), api.ErrUnsupportedCompression)
+		require.ErrorIs(t, (&api.Compression{Algorithm: 42}).Validate(), api.ErrUnknownCompression)
+	})
+}
+
+func TestEventWrapperCompression(t *testing.T) {
+	event := &api.Event{
+		Data:     bytes.Repeat([]byte(`{"color":"blue","size":12}`), 64),
+		Mimetype: mimetype.ApplicationJSON,
+		Type:     &api.Type{Name: "Widget", MajorVersion: 1},
+	}
+
+	wrapper := &api.EventWrapper{}
+	require.NoError(t, wrapper.Wrap(event))
+	raw := wrapper.Event
+
+	policy := &api.Compression{Algorithm: api.Compression_GZIP}
+	require.NoError(t, wrapper.Compress(policy))
+	require.Equal(t, api.Compression_GZIP, wrapper.StorageCompression.Algorithm)
+	require.NotSame(t, policy, wrapper.StorageCompression)
+	require.Nil(t, wrapper.Compression, "expected the publisher compression to be unchanged")
+	require.Less(t, len(wrapper.Event), len(raw))
+
+	// Compressed events can be unwrapped without decompressing the wrapper
+	unwrapped, err := wrapper.Unwrap()
+	require.NoError(t, err)
+	require.True(t, event.Equals(unwrapped))
+
+	// Compressed events are not compressed again
+	compressed := wrapper.Event
+	require.NoError(t, wrapper.Compress(&api.Compression{Algorithm: api.Compression_DEFLATE}))
+	require.Equal(t, compressed, wrapper.Event)
+	require.Equal(t, api.Compression_GZIP, wrapper.StorageCompression.Algorithm)
+
+	require.NoError(t, wrapper.Decompress())
+	require.Nil(t, wrapper.StorageCompression)
+	require.Equal(t, raw, wrapper.Event)
+
+	// Decompressing an uncompressed event is a no-op
+	require.NoError(t, wrapper.Decompress())
+	require.Equal(t, raw, wrapper.Event)
+
+	// Events are not compressed if the policy is not enabled or there is no event
+	require.NoError(t, wrapper.Compress(nil))
+	require.Nil(t, wrapper.StorageCompression)
+
+	empty := &api.EventWrapper{}
+	require.NoError(t, empty.Compress(policy))
+	require.Nil(t, empty.StorageCompression)
+
+	// Events compressed by the publisher are not compressed for storage and are not
+	// decompressed by the server, even if the server cannot decompress them.
+	published := &api.EventWrapper{Event: []byte("brotli"), Compression: &api.Compression{Algorithm: api.Compression_BROTLI}}
+	require.NoError(t, published.Compress(policy))
+	require.Nil(t, published.StorageCompression)
+	require.NoError(t, published.Decompress())
+	require.Equal(t, []byte("brotli"), published.Event)
+	require.Equal(t, api.Compression_BROTLI, published.Compression.Algorithm)
+
+	// Rewrapping an event removes the compression
+	require.NoError(t, wrapper.Compress(policy))
+	require.NoError(t, wrapper.Wrap(event))
+	require.Nil(t, wrapper.StorageCompression)
+	require.Equal(t, raw, wrapper.Event)
+}
@@ -36,6 +36,7 @@ const (
 	Nack_SHARDING_FAILURE        Nack_Code = 7
 	Nack_REDIRECT                Nack_Code = 8
 	Nack_INTERNAL                Nack_Code = 9
+	Nack_TOPIC_NOT_READY         Nack_Code = 10
 	// Client-side NACK codes
 	Nack_UNPROCESSED          Nack_Code = 100
 	Nack_TIMEOUT              Nack_Code = 101
@@ -58,6 +59,7 @@ var (
 		7:   "SHARDING_FAILURE",
 		8:   "REDIRECT",
 		9:   "INTERNAL",
+		10:  "TOPIC_NOT_READY",
 		100: "UNPROCESSED",
 		101: "TIMEOUT",
 		102: "UNHANDLED_MIMETYPE",
@@ -76,6 +78,7 @@ var (
 		"SHARDING_FAILURE":        7,
 		"REDIRECT":                8,
 		"INTERNAL":                9,
+		"TOPIC_NOT_READY":         10,
 		"UNPROCESSED":             100,
 		"TIMEOUT":                 101,
 		"UNHANDLED_MIMETYPE":      102,
@@ -112,6 +115,60 @@ func (Nack_Code) EnumDescriptor() ([]byte, []int) {
 	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{5, 0}
 }
 
+// Determines how the server handles events when the subscriber is not receiving
+// them as quickly as they are published and its outgoing queue is full.
+type Subscription_Overflow int32
+
+const (
+	Subscription_DROP       Subscription_Overflow = 0 // events are dropped until the queue has room
+	Subscription_BLOCK      Subscription_Overflow = 1 // the server applies backpressure to publishers until the queue has room
+	Subscription_SPILL      Subscription_Overflow = 2 // events are read from the event store until the subscriber catches up
+	Subscription_DISCONNECT Subscription_Overflow = 3 // the server closes the stream with a reason
+)
+
+// Enum value maps for Subscription_Overflow.
+var (
+	Subscription_Overflow_name = map[int32]string{
+		0: "DROP",
+		1: "BLOCK",
+		2: "SPILL",
+		3: "DISCONNECT",
+	}
+	Subscription_Overflow_value = map[string]int32{
+		"DROP":       0,
+		"BLOCK":      1,
+		"SPILL":      2,
+		"DISCONNECT": 3,
+	}
+)
+
+func (x Subscription_Overflow) Enum() *Subscription_Overflow {
+	p := new(Subscription_Overflow)
+	*p = x
+	return p
+}
+
+func (x Subscription_Overflow) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Subscription_Overflow) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1beta1_ensign_proto_enumTypes[1].Descriptor()
+}
+
+func (Subscription_Overflow) Type() protoreflect.EnumType {
+	return &file_api_v1beta1_ensign_proto_enumTypes[1]
+}
+
+func (x Subscription_Overflow) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Subscription_Overflow.Descriptor instead.
+func (Subscription_Overflow) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{10, 0}
+}
+
 type ServiceState_Status int32
 
 const (
@@ -154,11 +211,11 @@ func (x ServiceState_Status) String() string {
 }
 
 func (ServiceState_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_api_v1beta1_ensign_proto_enumTypes[1].Descriptor()
+	return file_api_v1beta1_ensign_proto_enumTypes[2].Descriptor()
 }
 
 func (ServiceState_Status) Type() protoreflect.EnumType {
-	return &file_api_v1beta1_ensign_proto_enumTypes[1]
+	return &file_api_v1beta1_ensign_proto_enumTypes[2]
 }
 
 func (x ServiceState_Status) Number() protoreflect.EnumNumber {
@@ -167,7 +224,7 @@ func (x ServiceState_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ServiceState_Status.Descriptor instead.
 func (ServiceState_Status) EnumDescriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{13, 0}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{14, 0}
 }
 
 // PublisherRequest messages are sent from the publisher to the server. Generally they
@@ -272,6 +329,7 @@ type PublisherReply struct {
 	//	*PublisherReply_Nack
 	//	*PublisherReply_Ready
 	//	*PublisherReply_CloseStream
+	//	*PublisherReply_TopicUpdate
 	Embed isPublisherReply_Embed `protobuf_oneof:"embed"`
 }
 
@@ -342,6 +400,13 @@ func (x *PublisherReply) GetCloseStream() *CloseStream {
 	return nil
 }
 
+func (x *PublisherReply) GetTopicUpdate() *TopicUpdate {
+	if x, ok := x.GetEmbed().(*PublisherReply_TopicUpdate); ok {
+		return x.TopicUpdate
+	}
+	return nil
+}
+
 type isPublisherReply_Embed interface {
 	isPublisherReply_Embed()
 }
@@ -362,6 +427,10 @@ type PublisherReply_CloseStream struct {
 	CloseStream *CloseStream `protobuf:"bytes,4,opt,name=close_stream,json=closeStream,proto3,oneof"`
 }
 
+type PublisherReply_TopicUpdate struct {
+	TopicUpdate *TopicUpdate `protobuf:"bytes,5,opt,name=topic_update,json=topicUpdate,proto3,oneof"`
+}
+
 func (*PublisherReply_Ack) isPublisherReply_Embed() {}
 
 func (*PublisherReply_Nack) isPublisherReply_Embed() {}
@@ -370,6 +439,8 @@ func (*PublisherReply_Ready) isPublisherReply_Embed() {}
 
 func (*PublisherReply_CloseStream) isPublisherReply_Embed() {}
 
+func (*PublisherReply_TopicUpdate) isPublisherReply_Embed() {}
+
 // SubscribeRequest messages are sent to the server from subscribers. Generally they are
 // responses to receiving events (e.g. ack and nack) but the first message must contain
 // subscription information about the topic and the group so that Ensign can start
@@ -482,6 +553,7 @@ type SubscribeReply struct {
 	//	*SubscribeReply_Event
 	//	*SubscribeReply_Ready
 	//	*SubscribeReply_CloseStream
+	//	*SubscribeReply_TopicUpdate
 	Embed isSubscribeReply_Embed `protobuf_oneof:"embed"`
 }
 
@@ -545,6 +617,13 @@ func (x *SubscribeReply) GetCloseStream() *CloseStream {
 	return nil
 }
 
+func (x *SubscribeReply) GetTopicUpdate() *TopicUpdate {
+	if x, ok := x.GetEmbed().(*SubscribeReply_TopicUpdate); ok {
+		return x.TopicUpdate
+	}
+	return nil
+}
+
 type isSubscribeReply_Embed interface {
 	isSubscribeReply_Embed()
 }
@@ -561,12 +640,18 @@ type SubscribeReply_CloseStream struct {
 	CloseStream *CloseStream `protobuf:"bytes,3,opt,name=close_stream,json=closeStream,proto3,oneof"`
 }
 
+type SubscribeReply_TopicUpdate struct {
+	TopicUpdate *TopicUpdate `protobuf:"bytes,4,opt,name=topic_update,json=topicUpdate,proto3,oneof"`
+}
+
 func (*SubscribeReply_Event) isSubscribeReply_Embed() {}
 
 func (*SubscribeReply_Ready) isSubscribeReply_Embed() {}
 
 func (*SubscribeReply_CloseStream) isSubscribeReply_Embed() {}
 
+func (*SubscribeReply_TopicUpdate) isSubscribeReply_Embed() {}
+
 // Ack represents the receipt and final handling of an event. This datatype should be
 // small so that throughput is not affected and generally only contains the ID of the
 // event being acknowledged. When Ensign commits an event to the log from the producer,
@@ -768,6 +853,7 @@ type CloseStream struct {
 	Topics uint64 `protobuf:"varint,2,opt,name=topics,proto3" json:"topics,omitempty"`
 	Acks   uint64 `protobuf:"varint,3,opt,name=acks,proto3" json:"acks,omitempty"`
 	Nacks  uint64 `protobuf:"varint,4,opt,name=nacks,proto3" json:"nacks,omitempty"`
+	Reason string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"` // set if the server closed the stream rather than the client
 }
 
 func (x *CloseStream) Reset() {
@@ -830,6 +916,13 @@ func (x *CloseStream) GetNacks() uint64 {
 	return 0
 }
 
+func (x *CloseStream) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 // Sent in response to an OpenStream or Subscription message so that the client knows
 // it can start sending or receiving events from the stream.
 type StreamReady struct {
@@ -897,6 +990,73 @@ func (x *StreamReady) GetTopics() map[string][]byte {
 	return nil
 }
 
+// Sent on open publish and subscribe streams when a topic in the project is created,
+// archived, or destroyed so that clients can update the topic map they received in
+// StreamReady without reconnecting. Topics that are READY can be used by the stream,
+// READONLY topics can no longer be published to, and DELETING topics are removed.
+type TopicUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string     `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	TopicId []byte     `protobuf:"bytes,2,opt,name=topic_id,json=topicId,proto3" json:"topic_id,omitempty"`
+	State   TopicState `protobuf:"varint,3,opt,name=state,proto3,enum=ensign.v1beta1.TopicState" json:"state,omitempty"`
+}
+
+func (x *TopicUpdate) Reset() {
+	*x = TopicUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopicUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicUpdate) ProtoMessage() {}
+
+func (x *TopicUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicUpdate.ProtoReflect.Descriptor instead.
+func (*TopicUpdate) Descriptor() ([]byte, []int) {
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TopicUpdate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TopicUpdate) GetTopicId() []byte {
+	if x != nil {
+		return x.TopicId
+	}
+	return nil
+}
+
+func (x *TopicUpdate) GetState() TopicState {
+	if x != nil {
+		return x.State
+	}
+	return TopicState_UNDEFINED
+}
+
 // Subscription is used to initialize a subscribe stream so that the Ensign node returns
 // the correct events to the subscriber based on the query or the topics they request.
 type Subscription struct {
@@ -904,16 +1064,20 @@ type Subscription struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClientId string         `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
-	Topics   []string       `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
-	Query    *Query         `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
-	Group    *ConsumerGroup `protobuf:"bytes,4,opt,name=group,proto3" json:"group,omitempty"`
+	ClientId string                `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Topics   []string              `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Query    *Query                `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	Group    *ConsumerGroup        `protobuf:"bytes,4,opt,name=group,proto3" json:"group,omitempty"`
+	Overflow Subscription_Overflow `protobuf:"varint,5,opt,name=overflow,proto3,enum=ensign.v1beta1.Subscription_Overflow" json:"overflow,omitempty"`
+	// If set, events that are duplicates of events already in the topic are not sent
+	// to the subscriber; they are still acknowledged on behalf of consumer groups.
+	SkipDuplicates bool `protobuf:"varint,6,opt,name=skip_duplicates,json=skipDuplicates,proto3" json:"skip_duplicates,omitempty"`
 }
 
 func (x *Subscription) Reset() {
 	*x = Subscription{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[9]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -926,7 +1090,7 @@ func (x *Subscription) String() string {
 func (*Subscription) ProtoMessage() {}
 
 func (x *Subscription) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[9]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -939,7 +1103,7 @@ func (x *Subscription) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
 func (*Subscription) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{9}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Subscription) GetClientId() string {
@@ -970,6 +1134,20 @@ func (x *Subscription) GetGroup() *ConsumerGroup {
 	return nil
 }
 
+func (x *Subscription) GetOverflow() Subscription_Overflow {
+	if x != nil {
+		return x.Overflow
+	}
+	return Subscription_DROP
+}
+
+func (x *Subscription) GetSkipDuplicates() bool {
+	if x != nil {
+		return x.SkipDuplicates
+	}
+	return false
+}
+
 // InfoRequest allows the project info to be filtered by a list of specific topics.
 type InfoRequest struct {
 	state         protoimpl.MessageState
@@ -982,7 +1160,7 @@ type InfoRequest struct {
 func (x *InfoRequest) Reset() {
 	*x = InfoRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[10]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -995,7 +1173,7 @@ func (x *InfoRequest) String() string {
 func (*InfoRequest) ProtoMessage() {}
 
 func (x *InfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[10]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1008,7 +1186,7 @@ func (x *InfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
 func (*InfoRequest) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{10}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *InfoRequest) GetTopics() [][]byte {
@@ -1039,7 +1217,7 @@ type ProjectInfo struct {
 func (x *ProjectInfo) Reset() {
 	*x = ProjectInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[11]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1052,7 +1230,7 @@ func (x *ProjectInfo) String() string {
 func (*ProjectInfo) ProtoMessage() {}
 
 func (x *ProjectInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[11]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1065,7 +1243,7 @@ func (x *ProjectInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProjectInfo.ProtoReflect.Descriptor instead.
 func (*ProjectInfo) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{11}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ProjectInfo) GetProjectId() []byte {
@@ -1132,7 +1310,7 @@ type HealthCheck struct {
 func (x *HealthCheck) Reset() {
 	*x = HealthCheck{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[12]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1145,7 +1323,7 @@ func (x *HealthCheck) String() string {
 func (*HealthCheck) ProtoMessage() {}
 
 func (x *HealthCheck) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[12]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1158,7 +1336,7 @@ func (x *HealthCheck) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthCheck.ProtoReflect.Descriptor instead.
 func (*HealthCheck) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{12}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *HealthCheck) GetAttempts() uint32 {
@@ -1199,7 +1377,7 @@ type ServiceState struct {
 func (x *ServiceState) Reset() {
 	*x = ServiceState{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[13]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1212,7 +1390,7 @@ func (x *ServiceState) String() string {
 func (*ServiceState) ProtoMessage() {}
 
 func (x *ServiceState) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[13]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1225,7 +1403,7 @@ func (x *ServiceState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServiceState.ProtoReflect.Descriptor instead.
 func (*ServiceState) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{13}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ServiceState) GetStatus() ServiceState_Status {
@@ -1276,7 +1454,7 @@ type PageInfo struct {
 func (x *PageInfo) Reset() {
 	*x = PageInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_ensign_proto_msgTypes[14]
+		mi := &file_api_v1beta1_ensign_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1289,7 +1467,7 @@ func (x *PageInfo) String() string {
 func (*PageInfo) ProtoMessage() {}
 
 func (x *PageInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_ensign_proto_msgTypes[14]
+	mi := &file_api_v1beta1_ensign_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1302,7 +1480,7 @@ func (x *PageInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PageInfo.ProtoReflect.Descriptor instead.
 func (*PageInfo) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{14}
+	return file_api_v1beta1_ensign_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *PageInfo) GetPageSize() uint32 {
@@ -1344,7 +1522,7 @@ var file_api_v1beta1_ensign_proto_rawDesc = []byte{
 	0x32, 0x1a, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
 	0x31, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x48, 0x00, 0x52, 0x0a,
 	0x6f, 0x70, 0x65, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d,
-	0x62, 0x65, 0x64, 0x22, 0xe5, 0x01, 0x0a, 0x0e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65,
+	0x62, 0x65, 0x64, 0x22, 0xa7, 0x02, 0x0a, 0x0e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65,
 	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x27, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62,
 	0x65, 0x74, 0x61, 0x31, 0x2e, 0x41, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12,
@@ -1358,210 +1536,243 @@ var file_api_v1beta1_ensign_proto_rawDesc = []byte{
 	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
 	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72,
 	0x65, 0x61, 0x6d, 0x48, 0x00, 0x52, 0x0b, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d, 0x62, 0x65, 0x64, 0x22, 0xb4, 0x01, 0x0a, 0x10,
-	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x27, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
-	0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x41,
-	0x63, 0x6b, 0x48, 0x00, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x2a, 0x0a, 0x04, 0x6e, 0x61, 0x63,
-	0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
-	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4e, 0x61, 0x63, 0x6b, 0x48, 0x00, 0x52,
-	0x04, 0x6e, 0x61, 0x63, 0x6b, 0x12, 0x42, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
-	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x65, 0x6e,
-	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x62,
-	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x73, 0x75, 0x62,
-	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d, 0x62,
-	0x65, 0x64, 0x22, 0xc6, 0x01, 0x0a, 0x0e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x34, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31,
-	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x57, 0x72, 0x61, 0x70, 0x70,
-	0x65, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a, 0x05, 0x72,
-	0x65, 0x61, 0x64, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73,
-	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x48, 0x00, 0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79,
-	0x12, 0x40, 0x0a, 0x0c, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
-	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72,
-	0x65, 0x61, 0x6d, 0x48, 0x00, 0x52, 0x0b, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d, 0x62, 0x65, 0x64, 0x22, 0x4f, 0x0a, 0x03, 0x41,
-	0x63, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02,
-	0x69, 0x64, 0x12, 0x38, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
-	0x70, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x22, 0xa1, 0x03, 0x0a,
-	0x04, 0x4e, 0x61, 0x63, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2d, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62,
-	0x65, 0x74, 0x61, 0x31, 0x2e, 0x4e, 0x61, 0x63, 0x6b, 0x2e, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04,
-	0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xc3, 0x02, 0x0a, 0x04, 0x43,
-	0x6f, 0x64, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00,
-	0x12, 0x1b, 0x0a, 0x17, 0x4d, 0x41, 0x58, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x49,
-	0x5a, 0x45, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x01, 0x12, 0x11, 0x0a,
-	0x0d, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x02,
-	0x12, 0x12, 0x0a, 0x0e, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x5f, 0x41, 0x52, 0x43, 0x48, 0x49, 0x56,
-	0x45, 0x44, 0x10, 0x03, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x5f, 0x44, 0x45,
-	0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x45, 0x52, 0x4d, 0x49,
-	0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4e, 0x49, 0x45, 0x44, 0x10, 0x05, 0x12, 0x15,
-	0x0a, 0x11, 0x43, 0x4f, 0x4e, 0x53, 0x45, 0x4e, 0x53, 0x55, 0x53, 0x5f, 0x46, 0x41, 0x49, 0x4c,
-	0x55, 0x52, 0x45, 0x10, 0x06, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x48, 0x41, 0x52, 0x44, 0x49, 0x4e,
-	0x47, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x55, 0x52, 0x45, 0x10, 0x07, 0x12, 0x0c, 0x0a, 0x08, 0x52,
-	0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x10, 0x08, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x4e, 0x54,
-	0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x09, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x50, 0x52, 0x4f,
-	0x43, 0x45, 0x53, 0x53, 0x45, 0x44, 0x10, 0x64, 0x12, 0x0b, 0x0a, 0x07, 0x54, 0x49, 0x4d, 0x45,
-	0x4f, 0x55, 0x54, 0x10, 0x65, 0x12, 0x16, 0x0a, 0x12, 0x55, 0x4e, 0x48, 0x41, 0x4e, 0x44, 0x4c,
-	0x45, 0x44, 0x5f, 0x4d, 0x49, 0x4d, 0x45, 0x54, 0x59, 0x50, 0x45, 0x10, 0x66, 0x12, 0x10, 0x0a,
-	0x0c, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x67, 0x12,
-	0x15, 0x0a, 0x11, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x41, 0x47, 0x41, 0x49, 0x4e,
-	0x5f, 0x41, 0x4e, 0x59, 0x10, 0x68, 0x12, 0x18, 0x0a, 0x14, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45,
-	0x52, 0x5f, 0x41, 0x47, 0x41, 0x49, 0x4e, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x4d, 0x45, 0x10, 0x69,
-	0x22, 0x41, 0x0a, 0x0a, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1b,
-	0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74,
-	0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x6f, 0x70,
-	0x69, 0x63, 0x73, 0x22, 0x67, 0x0a, 0x0b, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f,
-	0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69,
-	0x63, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x04, 0x61, 0x63, 0x6b, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x63, 0x6b, 0x73, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6e, 0x61, 0x63, 0x6b, 0x73, 0x22, 0xc3, 0x01, 0x0a,
-	0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x1b, 0x0a, 0x09,
-	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65,
-	0x72, 0x76, 0x65, 0x72, 0x49, 0x64, 0x12, 0x3f, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73,
-	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
-	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
-	0x61, 0x64, 0x79, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
-	0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x69, 0x63,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
-	0x38, 0x01, 0x22, 0xa5, 0x01, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
-	0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64,
-	0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x2b, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72,
-	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
-	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05,
-	0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x33, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31,
-	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x72, 0x47, 0x72,
-	0x6f, 0x75, 0x70, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x22, 0x25, 0x0a, 0x0b, 0x49, 0x6e,
-	0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70,
-	0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63,
-	0x73, 0x22, 0x8e, 0x02, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66,
-	0x6f, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x64,
-	0x12, 0x1d, 0x0a, 0x0a, 0x6e, 0x75, 0x6d, 0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x6e, 0x75, 0x6d, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12,
-	0x2e, 0x0a, 0x13, 0x6e, 0x75, 0x6d, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x6f, 0x6e, 0x6c, 0x79, 0x5f,
-	0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x11, 0x6e, 0x75,
-	0x6d, 0x52, 0x65, 0x61, 0x64, 0x6f, 0x6e, 0x6c, 0x79, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12,
-	0x16, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52,
-	0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x75, 0x70, 0x6c, 0x69,
-	0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x64, 0x75, 0x70,
-	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x64, 0x61, 0x74, 0x61, 0x5f,
-	0x73, 0x69, 0x7a, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x0d, 0x64, 0x61, 0x74, 0x61, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12,
-	0x31, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
-	0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69,
-	0x63, 0x73, 0x22, 0x6d, 0x0a, 0x0b, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63,
-	0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0d, 0x52, 0x08, 0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x42, 0x0a,
-	0x0f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x61, 0x74,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
-	0x6d, 0x70, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x41,
-	0x74, 0x22, 0xe9, 0x02, 0x0a, 0x0c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61,
-	0x74, 0x65, 0x12, 0x3b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x23, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65,
-	0x74, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65,
-	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x31, 0x0a, 0x06, 0x75, 0x70, 0x74,
-	0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x39, 0x0a, 0x0a,
-	0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x6e, 0x6f,
-	0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x61,
-	0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
-	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
-	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72,
-	0x22, 0x5b, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e,
-	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x41, 0x4c, 0x54,
-	0x48, 0x59, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48,
-	0x59, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x41, 0x4e, 0x47, 0x45, 0x52, 0x10, 0x03, 0x12,
-	0x0b, 0x0a, 0x07, 0x4f, 0x46, 0x46, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x04, 0x12, 0x0f, 0x0a, 0x0b,
-	0x4d, 0x41, 0x49, 0x4e, 0x54, 0x45, 0x4e, 0x41, 0x4e, 0x43, 0x45, 0x10, 0x05, 0x22, 0x4f, 0x0a,
-	0x08, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67,
-	0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x61,
-	0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70,
-	0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x32, 0xb6,
-	0x07, 0x0a, 0x06, 0x45, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x12, 0x51, 0x0a, 0x07, 0x50, 0x75, 0x62,
-	0x6c, 0x69, 0x73, 0x68, 0x12, 0x20, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31,
-	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
-	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65,
-	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x53, 0x0a, 0x09,
-	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x20, 0x2e, 0x65, 0x6e, 0x73, 0x69,
-	0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63,
-	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x65, 0x6e,
-	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x62,
-	0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30,
-	0x01, 0x12, 0x40, 0x0a, 0x05, 0x45, 0x6e, 0x53, 0x51, 0x4c, 0x12, 0x15, 0x2e, 0x65, 0x6e, 0x73,
-	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72,
-	0x79, 0x1a, 0x1c, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74,
-	0x61, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x22,
-	0x00, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x07, 0x45, 0x78, 0x70, 0x6c, 0x61, 0x69, 0x6e, 0x12, 0x15,
-	0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e,
-	0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x20, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
-	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x45, 0x78, 0x70, 0x6c,
-	0x61, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x0a, 0x4c, 0x69, 0x73,
-	0x74, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
-	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66,
-	0x6f, 0x1a, 0x1a, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74,
-	0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x50, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12,
-	0x3d, 0x0a, 0x0b, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x15,
+	0x61, 0x6d, 0x12, 0x40, 0x0a, 0x0c, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x5f, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d, 0x62, 0x65, 0x64, 0x22, 0xb4, 0x01,
+	0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x27, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
+	0x2e, 0x41, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x2a, 0x0a, 0x04, 0x6e,
+	0x61, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65, 0x6e, 0x73, 0x69,
+	0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4e, 0x61, 0x63, 0x6b, 0x48,
+	0x00, 0x52, 0x04, 0x6e, 0x61, 0x63, 0x6b, 0x12, 0x42, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x73,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x65,
+	0x6d, 0x62, 0x65, 0x64, 0x22, 0x88, 0x02, 0x0a, 0x0e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x62, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x34, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x57, 0x72, 0x61,
+	0x70, 0x70, 0x65, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a,
+	0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65,
+	0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x48, 0x00, 0x52, 0x05, 0x72, 0x65, 0x61,
+	0x64, 0x79, 0x12, 0x40, 0x0a, 0x0c, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x73, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x48, 0x00, 0x52, 0x0b, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x12, 0x40, 0x0a, 0x0c, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x5f, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73,
+	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69,
+	0x63, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x74, 0x6f, 0x70, 0x69, 0x63,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6d, 0x62, 0x65, 0x64, 0x22,
+	0x4f, 0x0a, 0x03, 0x41, 0x63, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x38, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
+	0x22, 0xb6, 0x03, 0x0a, 0x04, 0x4e, 0x61, 0x63, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2d, 0x0a, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
+	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4e, 0x61, 0x63, 0x6b, 0x2e, 0x43, 0x6f,
+	0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xd8,
+	0x02, 0x0a, 0x04, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x4d, 0x41, 0x58, 0x5f, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x53, 0x49, 0x5a, 0x45, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10,
+	0x01, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x5f, 0x41, 0x52,
+	0x43, 0x48, 0x49, 0x56, 0x45, 0x44, 0x10, 0x03, 0x12, 0x11, 0x0a, 0x0d, 0x54, 0x4f, 0x50, 0x49,
+	0x43, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x15, 0x0a, 0x11, 0x50,
+	0x45, 0x52, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4e, 0x49, 0x45, 0x44,
+	0x10, 0x05, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4f, 0x4e, 0x53, 0x45, 0x4e, 0x53, 0x55, 0x53, 0x5f,
+	0x46, 0x41, 0x49, 0x4c, 0x55, 0x52, 0x45, 0x10, 0x06, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x48, 0x41,
+	0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x55, 0x52, 0x45, 0x10, 0x07, 0x12,
+	0x0c, 0x0a, 0x08, 0x52, 0x45, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x10, 0x08, 0x12, 0x0c, 0x0a,
+	0x08, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x09, 0x12, 0x13, 0x0a, 0x0f, 0x54,
+	0x4f, 0x50, 0x49, 0x43, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x44, 0x59, 0x10, 0x0a,
+	0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x50, 0x52, 0x4f, 0x43, 0x45, 0x53, 0x53, 0x45, 0x44, 0x10,
+	0x64, 0x12, 0x0b, 0x0a, 0x07, 0x54, 0x49, 0x4d, 0x45, 0x4f, 0x55, 0x54, 0x10, 0x65, 0x12, 0x16,
+	0x0a, 0x12, 0x55, 0x4e, 0x48, 0x41, 0x4e, 0x44, 0x4c, 0x45, 0x44, 0x5f, 0x4d, 0x49, 0x4d, 0x45,
+	0x54, 0x59, 0x50, 0x45, 0x10, 0x66, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57,
+	0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x67, 0x12, 0x15, 0x0a, 0x11, 0x44, 0x45, 0x4c, 0x49,
+	0x56, 0x45, 0x52, 0x5f, 0x41, 0x47, 0x41, 0x49, 0x4e, 0x5f, 0x41, 0x4e, 0x59, 0x10, 0x68, 0x12,
+	0x18, 0x0a, 0x14, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45, 0x52, 0x5f, 0x41, 0x47, 0x41, 0x49, 0x4e,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x4d, 0x45, 0x10, 0x69, 0x22, 0x41, 0x0a, 0x0a, 0x4f, 0x70, 0x65,
+	0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x22, 0x7f, 0x0a, 0x0b,
+	0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61,
+	0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x63, 0x6b, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x6e, 0x61, 0x63, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x6e, 0x61, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0xc3, 0x01,
+	0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x1b, 0x0a,
+	0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x64, 0x12, 0x3f, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
+	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52,
+	0x65, 0x61, 0x64, 0x79, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x69,
+	0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x6e, 0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x49,
+	0x64, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x1a, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
+	0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x22, 0xcd, 0x02, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x2b, 0x0a, 0x05, 0x71, 0x75, 0x65,
+	0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52,
+	0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x33, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
+	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x72, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x41, 0x0a, 0x08, 0x6f,
+	0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x25, 0x2e,
+	0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4f, 0x76, 0x65, 0x72,
+	0x66, 0x6c, 0x6f, 0x77, 0x52, 0x08, 0x6f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x12, 0x27,
+	0x0a, 0x0f, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x73, 0x6b, 0x69, 0x70, 0x44, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x22, 0x3a, 0x0a, 0x08, 0x4f, 0x76, 0x65, 0x72, 0x66,
+	0x6c, 0x6f, 0x77, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x52, 0x4f, 0x50, 0x10, 0x00, 0x12, 0x09, 0x0a,
+	0x05, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x50, 0x49, 0x4c,
+	0x4c, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x44, 0x49, 0x53, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43,
+	0x54, 0x10, 0x03, 0x22, 0x25, 0x0a, 0x0b, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x22, 0x8e, 0x02, 0x0a, 0x0b, 0x50,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72,
+	0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x6e, 0x75, 0x6d,
+	0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x6e,
+	0x75, 0x6d, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x6e, 0x75, 0x6d, 0x5f,
+	0x72, 0x65, 0x61, 0x64, 0x6f, 0x6e, 0x6c, 0x79, 0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x11, 0x6e, 0x75, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x6f, 0x6e,
+	0x6c, 0x79, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73,
+	0x12, 0x26, 0x0a, 0x0f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x5f, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x64, 0x61, 0x74, 0x61, 0x53,
+	0x69, 0x7a, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x31, 0x0a, 0x06, 0x74, 0x6f, 0x70, 0x69,
+	0x63, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x22, 0x6d, 0x0a, 0x0b, 0x48,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x74,
+	0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x61, 0x74,
+	0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x42, 0x0a, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x6c, 0x61, 0x73,
+	0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x41, 0x74, 0x22, 0xe9, 0x02, 0x0a, 0x0c, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x3b, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x65, 0x6e,
+	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x31, 0x0a, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x75,
+	0x70, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x39, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66,
+	0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65,
+	0x12, 0x37, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x5b, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00,
+	0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x01, 0x12, 0x0d, 0x0a,
+	0x09, 0x55, 0x4e, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06,
+	0x44, 0x41, 0x4e, 0x47, 0x45, 0x52, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x4f, 0x46, 0x46, 0x4c,
+	0x49, 0x4e, 0x45, 0x10, 0x04, 0x12, 0x0f, 0x0a, 0x0b, 0x4d, 0x41, 0x49, 0x4e, 0x54, 0x45, 0x4e,
+	0x41, 0x4e, 0x43, 0x45, 0x10, 0x05, 0x22, 0x4f, 0x0a, 0x08, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61,
+	0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x32, 0xf8, 0x07, 0x0a, 0x06, 0x45, 0x6e, 0x73, 0x69,
+	0x67, 0x6e, 0x12, 0x51, 0x0a, 0x07, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x12, 0x20, 0x2e,
+	0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1e, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
+	0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
+	0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x53, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x62, 0x65, 0x12, 0x20, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65,
+	0x74, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31,
+	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x05, 0x45, 0x6e,
+	0x53, 0x51, 0x4c, 0x12, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62,
+	0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x1c, 0x2e, 0x65, 0x6e, 0x73,
+	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x22, 0x00, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x07,
+	0x45, 0x78, 0x70, 0x6c, 0x61, 0x69, 0x6e, 0x12, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
+	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x20,
 	0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e,
-	0x54, 0x6f, 0x70, 0x69, 0x63, 0x1a, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
-	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x22, 0x00, 0x12, 0x3f,
-	0x0a, 0x0d, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x45, 0x78, 0x70, 0x6c, 0x61, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x00, 0x12, 0x40, 0x0a, 0x09, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x6f, 0x77, 0x73, 0x12,
 	0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
-	0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x1a, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
-	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x22, 0x00, 0x12,
-	0x46, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x18,
-	0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e,
-	0x54, 0x6f, 0x70, 0x69, 0x63, 0x4d, 0x6f, 0x64, 0x1a, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
-	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x53,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x48, 0x0a, 0x0a, 0x54, 0x6f, 0x70, 0x69, 0x63,
-	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
-	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a,
-	0x1e, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
-	0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x50, 0x61, 0x67, 0x65, 0x22,
-	0x00, 0x12, 0x4b, 0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73,
-	0x12, 0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
-	0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x4e, 0x61, 0x6d, 0x65, 0x1a, 0x1f, 0x2e, 0x65, 0x6e,
+	0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x6f, 0x77,
+	0x22, 0x00, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x6f, 0x70, 0x69,
+	0x63, 0x73, 0x12, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65,
+	0x74, 0x61, 0x31, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x1a, 0x2e, 0x65,
+	0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f,
+	0x70, 0x69, 0x63, 0x73, 0x50, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x0b, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69,
+	0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63,
+	0x1a, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
+	0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x0d, 0x52, 0x65, 0x74,
+	0x72, 0x69, 0x65, 0x76, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x15, 0x2e, 0x65, 0x6e, 0x73,
+	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69,
+	0x63, 0x1a, 0x15, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74,
+	0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0b, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69,
+	0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63,
+	0x4d, 0x6f, 0x64, 0x1a, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62,
+	0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x22, 0x00, 0x12, 0x48, 0x0a, 0x0a, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x4e, 0x61, 0x6d, 0x65, 0x73,
+	0x12, 0x18, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
+	0x31, 0x2e, 0x50, 0x61, 0x67, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x1e, 0x2e, 0x65, 0x6e, 0x73,
+	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69,
+	0x63, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x50, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x0b,
+	0x54, 0x6f, 0x70, 0x69, 0x63, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12, 0x19, 0x2e, 0x65, 0x6e,
 	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70,
-	0x69, 0x63, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x4c,
-	0x0a, 0x0e, 0x53, 0x65, 0x74, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
-	0x12, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
-	0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x1a, 0x1b, 0x2e,
-	0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54,
-	0x6f, 0x70, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x04,
-	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31,
-	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74,
-	0x61, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00,
-	0x12, 0x45, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x2e, 0x65, 0x6e, 0x73,
-	0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c,
-	0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x1a, 0x1c, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e,
-	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x69, 0x63, 0x4e, 0x61, 0x6d, 0x65, 0x1a, 0x1f, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x45, 0x78, 0x69,
+	0x73, 0x74, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x0e, 0x53, 0x65, 0x74,
+	0x54, 0x6f, 0x70, 0x69, 0x63, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1b, 0x2e, 0x65, 0x6e,
+	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70,
+	0x69, 0x63, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x1a, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
+	0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x65,
+	0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x72,
+	0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x06, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
+	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x1a, 0x1c, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65,
+	0x74, 0x61, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1576,98 +1787,108 @@ func file_api_v1beta1_ensign_proto_rawDescGZIP() []byte {
 	return file_api_v1beta1_ensign_proto_rawDescData
 }
 
-var file_api_v1beta1_ensign_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_api_v1beta1_ensign_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_api_v1beta1_ensign_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_api_v1beta1_ensign_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_api_v1beta1_ensign_proto_goTypes = []any{
 	(Nack_Code)(0),                // 0: ensign.v1beta1.Nack.Code
-	(ServiceState_Status)(0),      // 1: ensign.v1beta1.ServiceState.Status
-	(*PublisherRequest)(nil),      // 2: ensign.v1beta1.PublisherRequest
-	(*PublisherReply)(nil),        // 3: ensign.v1beta1.PublisherReply
-	(*SubscribeRequest)(nil),      // 4: ensign.v1beta1.SubscribeRequest
-	(*SubscribeReply)(nil),        // 5: ensign.v1beta1.SubscribeReply
-	(*Ack)(nil),                   // 6: ensign.v1beta1.Ack
-	(*Nack)(nil),                  // 7: ensign.v1beta1.Nack
-	(*OpenStream)(nil),            // 8: ensign.v1beta1.OpenStream
-	(*CloseStream)(nil),           // 9: ensign.v1beta1.CloseStream
-	(*StreamReady)(nil),           // 10: ensign.v1beta1.StreamReady
-	(*Subscription)(nil),          // 11: ensign.v1beta1.Subscription
-	(*InfoRequest)(nil),           // 12: ensign.v1beta1.InfoRequest
-	(*ProjectInfo)(nil),           // 13: ensign.v1beta1.ProjectInfo
-	(*HealthCheck)(nil),           // 14: ensign.v1beta1.HealthCheck
-	(*ServiceState)(nil),          // 15: ensign.v1beta1.ServiceState
-	(*PageInfo)(nil),              // 16: ensign.v1beta1.PageInfo
-	nil,                           // 17: ensign.v1beta1.StreamReady.TopicsEntry
-	(*EventWrapper)(nil),          // 18: ensign.v1beta1.EventWrapper
-	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
-	(*Query)(nil),                 // 20: ensign.v1beta1.Query
-	(*ConsumerGroup)(nil),         // 21: ensign.v1beta1.ConsumerGroup
-	(*TopicInfo)(nil),             // 22: ensign.v1beta1.TopicInfo
-	(*durationpb.Duration)(nil),   // 23: google.protobuf.Duration
-	(*Topic)(nil),                 // 24: ensign.v1beta1.Topic
-	(*TopicMod)(nil),              // 25: ensign.v1beta1.TopicMod
-	(*TopicName)(nil),             // 26: ensign.v1beta1.TopicName
-	(*TopicPolicy)(nil),           // 27: ensign.v1beta1.TopicPolicy
-	(*QueryExplanation)(nil),      // 28: ensign.v1beta1.QueryExplanation
-	(*TopicsPage)(nil),            // 29: ensign.v1beta1.TopicsPage
-	(*TopicStatus)(nil),           // 30: ensign.v1beta1.TopicStatus
-	(*TopicNamesPage)(nil),        // 31: ensign.v1beta1.TopicNamesPage
-	(*TopicExistsInfo)(nil),       // 32: ensign.v1beta1.TopicExistsInfo
+	(Subscription_Overflow)(0),    // 1: ensign.v1beta1.Subscription.Overflow
+	(ServiceState_Status)(0),      // 2: ensign.v1beta1.ServiceState.Status
+	(*PublisherRequest)(nil),      // 3: ensign.v1beta1.PublisherRequest
+	(*PublisherReply)(nil),        // 4: ensign.v1beta1.PublisherReply
+	(*SubscribeRequest)(nil),      // 5: ensign.v1beta1.SubscribeRequest
+	(*SubscribeReply)(nil),        // 6: ensign.v1beta1.SubscribeReply
+	(*Ack)(nil),                   // 7: ensign.v1beta1.Ack
+	(*Nack)(nil),                  // 8: ensign.v1beta1.Nack
+	(*OpenStream)(nil),            // 9: ensign.v1beta1.OpenStream
+	(*CloseStream)(nil),           // 10: ensign.v1beta1.CloseStream
+	(*StreamReady)(nil),           // 11: ensign.v1beta1.StreamReady
+	(*TopicUpdate)(nil),           // 12: ensign.v1beta1.TopicUpdate
+	(*Subscription)(nil),          // 13: ensign.v1beta1.Subscription
+	(*InfoRequest)(nil),           // 14: ensign.v1beta1.InfoRequest
+	(*ProjectInfo)(nil),           // 15: ensign.v1beta1.ProjectInfo
+	(*HealthCheck)(nil),           // 16: ensign.v1beta1.HealthCheck
+	(*ServiceState)(nil),          // 17: ensign.v1beta1.ServiceState
+	(*PageInfo)(nil),              // 18: ensign.v1beta1.PageInfo
+	nil,                           // 19: ensign.v1beta1.StreamReady.TopicsEntry
+	(*EventWrapper)(nil),          // 20: ensign.v1beta1.EventWrapper
+	(*timestamppb.Timestamp)(nil), // 21: google.protobuf.Timestamp
+	(TopicState)(0),               // 22: ensign.v1beta1.TopicState
+	(*Query)(nil),                 // 23: ensign.v1beta1.Query
+	(*ConsumerGroup)(nil),         // 24: ensign.v1beta1.ConsumerGroup
+	(*TopicInfo)(nil),             // 25: ensign.v1beta1.TopicInfo
+	(*durationpb.Duration)(nil),   // 26: google.protobuf.Duration
+	(*Topic)(nil),                 // 27: ensign.v1beta1.Topic
+	(*TopicMod)(nil),              // 28: ensign.v1beta1.TopicMod
+	(*TopicName)(nil),             // 29: ensign.v1beta1.TopicName
+	(*TopicPolicy)(nil),           // 30: ensign.v1beta1.TopicPolicy
+	(*QueryExplanation)(nil),      // 31: ensign.v1beta1.QueryExplanation
+	(*QueryRow)(nil),              // 32: ensign.v1beta1.QueryRow
+	(*TopicsPage)(nil),            // 33: ensign.v1beta1.TopicsPage
+	(*TopicStatus)(nil),           // 34: ensign.v1beta1.TopicStatus
+	(*TopicNamesPage)(nil),        // 35: ensign.v1beta1.TopicNamesPage
+	(*TopicExistsInfo)(nil),       // 36: ensign.v1beta1.TopicExistsInfo
 }
 var file_api_v1beta1_ensign_proto_depIdxs = []int32{
-	18, // 0: ensign.v1beta1.PublisherRequest.event:type_name -> ensign.v1beta1.EventWrapper
-	8,  // 1: ensign.v1beta1.PublisherRequest.open_stream:type_name -> ensign.v1beta1.OpenStream
-	6,  // 2: ensign.v1beta1.PublisherReply.ack:type_name -> ensign.v1beta1.Ack
-	7,  // 3: ensign.v1beta1.PublisherReply.nack:type_name -> ensign.v1beta1.Nack
-	10, // 4: ensign.v1beta1.PublisherReply.ready:type_name -> ensign.v1beta1.StreamReady
-	9,  // 5: ensign.v1beta1.PublisherReply.close_stream:type_name -> ensign.v1beta1.CloseStream
-	6,  // 6: ensign.v1beta1.SubscribeRequest.ack:type_name -> ensign.v1beta1.Ack
-	7,  // 7: ensign.v1beta1.SubscribeRequest.nack:type_name -> ensign.v1beta1.Nack
-	11, // 8: ensign.v1beta1.SubscribeRequest.subscription:type_name -> ensign.v1beta1.Subscription
-	18, // 9: ensign.v1beta1.SubscribeReply.event:type_name -> ensign.v1beta1.EventWrapper
-	10, // 10: ensign.v1beta1.SubscribeReply.ready:type_name -> ensign.v1beta1.StreamReady
-	9,  // 11: ensign.v1beta1.SubscribeReply.close_stream:type_name -> ensign.v1beta1.CloseStream
-	19, // 12: ensign.v1beta1.Ack.committed:type_name -> google.protobuf.Timestamp
-	0,  // 13: ensign.v1beta1.Nack.code:type_name -> ensign.v1beta1.Nack.Code
-	17, // 14: ensign.v1beta1.StreamReady.topics:type_name -> ensign.v1beta1.StreamReady.TopicsEntry
-	20, // 15: ensign.v1beta1.Subscription.query:type_name -> ensign.v1beta1.Query
-	21, // 16: ensign.v1beta1.Subscription.group:type_name -> ensign.v1beta1.ConsumerGroup
-	22, // 17: ensign.v1beta1.ProjectInfo.topics:type_name -> ensign.v1beta1.TopicInfo
-	19, // 18: ensign.v1beta1.HealthCheck.last_checked_at:type_name -> google.protobuf.Timestamp
-	1,  // 19: ensign.v1beta1.ServiceState.status:type_name -> ensign.v1beta1.ServiceState.Status
-	23, // 20: ensign.v1beta1.ServiceState.uptime:type_name -> google.protobuf.Duration
-	19, // 21: ensign.v1beta1.ServiceState.not_before:type_name -> google.protobuf.Timestamp
-	19, // 22: ensign.v1beta1.ServiceState.not_after:type_name -> google.protobuf.Timestamp
-	2,  // 23: ensign.v1beta1.Ensign.Publish:input_type -> ensign.v1beta1.PublisherRequest
-	4,  // 24: ensign.v1beta1.Ensign.Subscribe:input_type -> ensign.v1beta1.SubscribeRequest
-	20, // 25: ensign.v1beta1.Ensign.EnSQL:input_type -> ensign.v1beta1.Query
-	20, // 26: ensign.v1beta1.Ensign.Explain:input_type -> ensign.v1beta1.Query
-	16, // 27: ensign.v1beta1.Ensign.ListTopics:input_type -> ensign.v1beta1.PageInfo
-	24, // 28: ensign.v1beta1.Ensign.CreateTopic:input_type -> ensign.v1beta1.Topic
-	24, // 29: ensign.v1beta1.Ensign.RetrieveTopic:input_type -> ensign.v1beta1.Topic
-	25, // 30: ensign.v1beta1.Ensign.DeleteTopic:input_type -> ensign.v1beta1.TopicMod
-	16, // 31: ensign.v1beta1.Ensign.TopicNames:input_type -> ensign.v1beta1.PageInfo
-	26, // 32: ensign.v1beta1.Ensign.TopicExists:input_type -> ensign.v1beta1.TopicName
-	27, // 33: ensign.v1beta1.Ensign.SetTopicPolicy:input_type -> ensign.v1beta1.TopicPolicy
-	12, // 34: ensign.v1beta1.Ensign.Info:input_type -> ensign.v1beta1.InfoRequest
-	14, // 35: ensign.v1beta1.Ensign.Status:input_type -> ensign.v1beta1.HealthCheck
-	3,  // 36: ensign.v1beta1.Ensign.Publish:output_type -> ensign.v1beta1.PublisherReply
-	5,  // 37: ensign.v1beta1.Ensign.Subscribe:output_type -> ensign.v1beta1.SubscribeReply
-	18, // 38: ensign.v1beta1.Ensign.EnSQL:output_type -> ensign.v1beta1.EventWrapper
-	28, // 39: ensign.v1beta1.Ensign.Explain:output_type -> ensign.v1beta1.QueryExplanation
-	29, // 40: ensign.v1beta1.Ensign.ListTopics:output_type -> ensign.v1beta1.TopicsPage
-	24, // 41: ensign.v1beta1.Ensign.CreateTopic:output_type -> ensign.v1beta1.Topic
-	24, // 42: ensign.v1beta1.Ensign.RetrieveTopic:output_type -> ensign.v1beta1.Topic
-	30, // 43: ensign.v1beta1.Ensign.DeleteTopic:output_type -> ensign.v1beta1.TopicStatus
-	31, // 44: ensign.v1beta1.Ensign.TopicNames:output_type -> ensign.v1beta1.TopicNamesPage
-	32, // 45: ensign.v1beta1.Ensign.TopicExists:output_type -> ensign.v1beta1.TopicExistsInfo
-	30, // 46: ensign.v1beta1.Ensign.SetTopicPolicy:output_type -> ensign.v1beta1.TopicStatus
-	13, // 47: ensign.v1beta1.Ensign.Info:output_type -> ensign.v1beta1.ProjectInfo
-	15, // 48: ensign.v1beta1.Ensign.Status:output_type -> ensign.v1beta1.ServiceState
-	36, // [36:49] is the sub-list for method output_type
-	23, // [23:36] is the sub-list for method input_type
-	23, // [23:23] is the sub-list for extension type_name
-	23, // [23:23] is the sub-list for extension extendee
-	0,  // [0:23] is the sub-list for field type_name
+	20, // 0: ensign.v1beta1.PublisherRequest.event:type_name -> ensign.v1beta1.EventWrapper
+	9,  // 1: ensign.v1beta1.PublisherRequest.open_stream:type_name -> ensign.v1beta1.OpenStream
+	7,  // 2: ensign.v1beta1.PublisherReply.ack:type_name -> ensign.v1beta1.Ack
+	8,  // 3: ensign.v1beta1.PublisherReply.nack:type_name -> ensign.v1beta1.Nack
+	11, // 4: ensign.v1beta1.PublisherReply.ready:type_name -> ensign.v1beta1.StreamReady
+	10, // 5: ensign.v1beta1.PublisherReply.close_stream:type_name -> ensign.v1beta1.CloseStream
+	12, // 6: ensign.v1beta1.PublisherReply.topic_update:type_name -> ensign.v1beta1.TopicUpdate
+	7,  // 7: ensign.v1beta1.SubscribeRequest.ack:type_name -> ensign.v1beta1.Ack
+	8,  // 8: ensign.v1beta1.SubscribeRequest.nack:type_name -> ensign.v1beta1.Nack
+	13, // 9: ensign.v1beta1.SubscribeRequest.subscription:type_name -> ensign.v1beta1.Subscription
+	20, // 10: ensign.v1beta1.SubscribeReply.event:type_name -> ensign.v1beta1.EventWrapper
+	11, // 11: ensign.v1beta1.SubscribeReply.ready:type_name -> ensign.v1beta1.StreamReady
+	10, // 12: ensign.v1beta1.SubscribeReply.close_stream:type_name -> ensign.v1beta1.CloseStream
+	12, // 13: ensign.v1beta1.SubscribeReply.topic_update:type_name -> ensign.v1beta1.TopicUpdate
+	21, // 14: ensign.v1beta1.Ack.committed:type_name -> google.protobuf.Timestamp
+	0,  // 15: ensign.v1beta1.Nack.code:type_name -> ensign.v1beta1.Nack.Code
+	19, // 16: ensign.v1beta1.StreamReady.topics:type_name -> ensign.v1beta1.StreamReady.TopicsEntry
+	22, // 17: ensign.v1beta1.TopicUpdate.state:type_name -> ensign.v1beta1.TopicState
+	23, // 18: ensign.v1beta1.Subscription.query:type_name -> ensign.v1beta1.Query
+	24, // 19: ensign.v1beta1.Subscription.group:type_name -> ensign.v1beta1.ConsumerGroup
+	1,  // 20: ensign.v1beta1.Subscription.overflow:type_name -> ensign.v1beta1.Subscription.Overflow
+	25, // 21: ensign.v1beta1.ProjectInfo.topics:type_name -> ensign.v1beta1.TopicInfo
+	21, // 22: ensign.v1beta1.HealthCheck.last_checked_at:type_name -> google.protobuf.Timestamp
+	2,  // 23: ensign.v1beta1.ServiceState.status:type_name -> ensign.v1beta1.ServiceState.Status
+	26, // 24: ensign.v1beta1.ServiceState.uptime:type_name -> google.protobuf.Duration
+	21, // 25: ensign.v1beta1.ServiceState.not_before:type_name -> google.protobuf.Timestamp
+	21, // 26: ensign.v1beta1.ServiceState.not_after:type_name -> google.protobuf.Timestamp
+	3,  // 27: ensign.v1beta1.Ensign.Publish:input_type -> ensign.v1beta1.PublisherRequest
+	5,  // 28: ensign.v1beta1.Ensign.Subscribe:input_type -> ensign.v1beta1.SubscribeRequest
+	23, // 29: ensign.v1beta1.Ensign.EnSQL:input_type -> ensign.v1beta1.Query
+	23, // 30: ensign.v1beta1.Ensign.Explain:input_type -> ensign.v1beta1.Query
+	23, // 31: ensign.v1beta1.Ensign.QueryRows:input_type -> ensign.v1beta1.Query
+	18, // 32: ensign.v1beta1.Ensign.ListTopics:input_type -> ensign.v1beta1.PageInfo
+	27, // 33: ensign.v1beta1.Ensign.CreateTopic:input_type -> ensign.v1beta1.Topic
+	27, // 34: ensign.v1beta1.Ensign.RetrieveTopic:input_type -> ensign.v1beta1.Topic
+	28, // 35: ensign.v1beta1.Ensign.DeleteTopic:input_type -> ensign.v1beta1.TopicMod
+	18, // 36: ensign.v1beta1.Ensign.TopicNames:input_type -> ensign.v1beta1.PageInfo
+	29, // 37: ensign.v1beta1.Ensign.TopicExists:input_type -> ensign.v1beta1.TopicName
+	30, // 38: ensign.v1beta1.Ensign.SetTopicPolicy:input_type -> ensign.v1beta1.TopicPolicy
+	14, // 39: ensign.v1beta1.Ensign.Info:input_type -> ensign.v1beta1.InfoRequest
+	16, // 40: ensign.v1beta1.Ensign.Status:input_type -> ensign.v1beta1.HealthCheck
+	4,  // 41: ensign.v1beta1.Ensign.Publish:output_type -> ensign.v1beta1.PublisherReply
+	6,  // 42: ensign.v1beta1.Ensign.Subscribe:output_type -> ensign.v1beta1.SubscribeReply
+	20, // 43: ensign.v1beta1.Ensign.EnSQL:output_type -> ensign.v1beta1.EventWrapper
+	31, // 44: ensign.v1beta1.Ensign.Explain:output_type -> ensign.v1beta1.QueryExplanation
+	32, // 45: ensign.v1beta1.Ensign.QueryRows:output_type -> ensign.v1beta1.QueryRow
+	33, // 46: ensign.v1beta1.Ensign.ListTopics:output_type -> ensign.v1beta1.TopicsPage
+	27, // 47: ensign.v1beta1.Ensign.CreateTopic:output_type -> ensign.v1beta1.Topic
+	27, // 48: ensign.v1beta1.Ensign.RetrieveTopic:output_type -> ensign.v1beta1.Topic
+	34, // 49: ensign.v1beta1.Ensign.DeleteTopic:output_type -> ensign.v1beta1.TopicStatus
+	35, // 50: ensign.v1beta1.Ensign.TopicNames:output_type -> ensign.v1beta1.TopicNamesPage
+	36, // 51: ensign.v1beta1.Ensign.TopicExists:output_type -> ensign.v1beta1.TopicExistsInfo
+	34, // 52: ensign.v1beta1.Ensign.SetTopicPolicy:output_type -> ensign.v1beta1.TopicStatus
+	15, // 53: ensign.v1beta1.Ensign.Info:output_type -> ensign.v1beta1.ProjectInfo
+	17, // 54: ensign.v1beta1.Ensign.Status:output_type -> ensign.v1beta1.ServiceState
+	41, // [41:55] is the sub-list for method output_type
+	27, // [27:41] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_api_v1beta1_ensign_proto_init() }
@@ -1789,7 +2010,7 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[9].Exporter = func(v any, i int) any {
-			switch v := v.(*Subscription); i {
+			switch v := v.(*TopicUpdate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1801,7 +2022,7 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[10].Exporter = func(v any, i int) any {
-			switch v := v.(*InfoRequest); i {
+			switch v := v.(*Subscription); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1813,7 +2034,7 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[11].Exporter = func(v any, i int) any {
-			switch v := v.(*ProjectInfo); i {
+			switch v := v.(*InfoRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1825,7 +2046,7 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[12].Exporter = func(v any, i int) any {
-			switch v := v.(*HealthCheck); i {
+			switch v := v.(*ProjectInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1837,7 +2058,7 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[13].Exporter = func(v any, i int) any {
-			switch v := v.(*ServiceState); i {
+			switch v := v.(*HealthCheck); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1849,6 +2070,18 @@ func file_api_v1beta1_ensign_proto_init() {
 			}
 		}
 		file_api_v1beta1_ensign_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*ServiceState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1beta1_ensign_proto_msgTypes[15].Exporter = func(v any, i int) any {
 			switch v := v.(*PageInfo); i {
 			case 0:
 				return &v.state
@@ -1870,6 +2103,7 @@ func file_api_v1beta1_ensign_proto_init() {
 		(*PublisherReply_Nack)(nil),
 		(*PublisherReply_Ready)(nil),
 		(*PublisherReply_CloseStream)(nil),
+		(*PublisherReply_TopicUpdate)(nil),
 	}
 	file_api_v1beta1_ensign_proto_msgTypes[2].OneofWrappers = []any{
 		(*SubscribeRequest_Ack)(nil),
@@ -1880,14 +2114,15 @@ func file_api_v1beta1_ensign_proto_init() {
 		(*SubscribeReply_Event)(nil),
 		(*SubscribeReply_Ready)(nil),
 		(*SubscribeReply_CloseStream)(nil),
+		(*SubscribeReply_TopicUpdate)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_v1beta1_ensign_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   16,
+			NumEnums:      3,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
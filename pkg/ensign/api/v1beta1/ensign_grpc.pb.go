@@ -23,6 +23,7 @@ const (
 	Ensign_Subscribe_FullMethodName      = "/ensign.v1beta1.Ensign/Subscribe"
 	Ensign_EnSQL_FullMethodName          = "/ensign.v1beta1.Ensign/EnSQL"
 	Ensign_Explain_FullMethodName        = "/ensign.v1beta1.Ensign/Explain"
+	Ensign_QueryRows_FullMethodName      = "/ensign.v1beta1.Ensign/QueryRows"
 	Ensign_ListTopics_FullMethodName     = "/ensign.v1beta1.Ensign/ListTopics"
 	Ensign_CreateTopic_FullMethodName    = "/ensign.v1beta1.Ensign/CreateTopic"
 	Ensign_RetrieveTopic_FullMethodName  = "/ensign.v1beta1.Ensign/RetrieveTopic"
@@ -56,9 +57,13 @@ type EnsignClient interface {
 	Subscribe(ctx context.Context, opts ...grpc.CallOption) (Ensign_SubscribeClient, error)
 	// EnSQL is a server-side streaming RPC that executes an query and returns a stream
 	// of events as a result set back from the query. It terminates once all results
-	// have been returned or the client terminates the stream.
+	// have been returned or the client terminates the stream. Continuous queries return
+	// all results and then new matching events as they are published.
 	EnSQL(ctx context.Context, in *Query, opts ...grpc.CallOption) (Ensign_EnSQLClient, error)
 	Explain(ctx context.Context, in *Query, opts ...grpc.CallOption) (*QueryExplanation, error)
+	// QueryRows executes a query like EnSQL but returns only the selected fields from
+	// each event as a row of typed columns rather than returning the entire event.
+	QueryRows(ctx context.Context, in *Query, opts ...grpc.CallOption) (Ensign_QueryRowsClient, error)
 	// This is a simple topic management interface. Right now we assume that topics are
 	// immutable, therefore there is no update topic RPC call. There are two ways to
 	// delete a topic - archiving it makes the topic readonly so that no events can be
@@ -197,6 +202,39 @@ func (c *ensignClient) Explain(ctx context.Context, in *Query, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *ensignClient) QueryRows(ctx context.Context, in *Query, opts ...grpc.CallOption) (Ensign_QueryRowsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ensign_ServiceDesc.Streams[3], Ensign_QueryRows_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ensignQueryRowsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ensign_QueryRowsClient interface {
+	Recv() (*QueryRow, error)
+	grpc.ClientStream
+}
+
+type ensignQueryRowsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ensignQueryRowsClient) Recv() (*QueryRow, error) {
+	m := new(QueryRow)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *ensignClient) ListTopics(ctx context.Context, in *PageInfo, opts ...grpc.CallOption) (*TopicsPage, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TopicsPage)
@@ -309,9 +347,13 @@ type EnsignServer interface {
 	Subscribe(Ensign_SubscribeServer) error
 	// EnSQL is a server-side streaming RPC that executes an query and returns a stream
 	// of events as a result set back from the query. It terminates once all results
-	// have been returned or the client terminates the stream.
+	// have been returned or the client terminates the stream. Continuous queries return
+	// all results and then new matching events as they are published.
 	EnSQL(*Query, Ensign_EnSQLServer) error
 	Explain(context.Context, *Query) (*QueryExplanation, error)
+	// QueryRows executes a query like EnSQL but returns only the selected fields from
+	// each event as a row of typed columns rather than returning the entire event.
+	QueryRows(*Query, Ensign_QueryRowsServer) error
 	// This is a simple topic management interface. Right now we assume that topics are
 	// immutable, therefore there is no update topic RPC call. There are two ways to
 	// delete a topic - archiving it makes the topic readonly so that no events can be
@@ -352,6 +394,9 @@ func (UnimplementedEnsignServer) EnSQL(*Query, Ensign_EnSQLServer) error {
 func (UnimplementedEnsignServer) Explain(context.Context, *Query) (*QueryExplanation, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Explain not implemented")
 }
+func (UnimplementedEnsignServer) QueryRows(*Query, Ensign_QueryRowsServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryRows not implemented")
+}
 func (UnimplementedEnsignServer) ListTopics(context.Context, *PageInfo) (*TopicsPage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListTopics not implemented")
 }
@@ -483,6 +528,27 @@ func _Ensign_Explain_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ensign_QueryRows_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Query)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EnsignServer).QueryRows(m, &ensignQueryRowsServer{ServerStream: stream})
+}
+
+type Ensign_QueryRowsServer interface {
+	Send(*QueryRow) error
+	grpc.ServerStream
+}
+
+type ensignQueryRowsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ensignQueryRowsServer) Send(m *QueryRow) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Ensign_ListTopics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PageInfo)
 	if err := dec(in); err != nil {
@@ -711,6 +777,11 @@ var Ensign_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Ensign_EnSQL_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "QueryRows",
+			Handler:       _Ensign_QueryRows_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/v1beta1/ensign.proto",
 }
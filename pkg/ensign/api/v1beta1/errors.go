@@ -5,11 +5,17 @@ import "errors"
 // Statically defined errors for error checking the type of error returned by a method
 // or function in the api package.
 var (
-	ErrNoEvent              = errors.New("event wrapper contains no event")
-	ErrNoKeys               = errors.New("no keys specified for key based hashing")
-	ErrNoFields             = errors.New("no fields specified for field based hashing")
-	ErrKeysNotAllowed       = errors.New("do not specify keys for this policy")
-	ErrFieldsNotAllowed     = errors.New("do not specify fields for this policy")
-	ErrNoGroupID            = errors.New("consumer group requires either id or name")
-	ErrDuplicatesNotAllowed = errors.New("duplicates not allowed by specified policy")
+	ErrNoEvent                 = errors.New("event wrapper contains no event")
+	ErrNoKeys                  = errors.New("no keys specified for key based hashing")
+	ErrNoFields                = errors.New("no fields specified for field based hashing")
+	ErrKeysNotAllowed          = errors.New("do not specify keys for this policy")
+	ErrFieldsNotAllowed        = errors.New("do not specify fields for this policy")
+	ErrNoGroupID               = errors.New("consumer group requires either id or name")
+	ErrDuplicatesNotAllowed    = errors.New("duplicates not allowed by specified policy")
+	ErrInvalidMaxAge           = errors.New("retention max age must be a positive duration")
+	ErrInvalidGracePeriod      = errors.New("tombstone grace period must be a positive duration")
+	ErrInvalidCompressionLevel = errors.New("compression level is not supported by the compression algorithm")
+	ErrUnsupportedCompression  = errors.New("compression algorithm is not currently supported")
+	ErrUnknownCompression      = errors.New("unknown compression algorithm")
+	ErrDecompressedTooLarge    = errors.New("decompressed data exceeds the maximum event size")
 )
@@ -22,22 +22,40 @@ var UnspecifiedType = &Type{Name: Unspecified}
 // Wrap an event inside of the event wrapper, marshaling the event into bytes and
 // storing it in its raw form so that it doesn't have to be parsed during wrapper
 // unmarshaling (the Broker uses the event wrapper metadata not the event itself).
+// The wrapped event is not compressed so any compression on the wrapper is removed.
 func (w *EventWrapper) Wrap(e *Event) (err error) {
 	if w.Event, err = proto.Marshal(e); err != nil {
 		return err
 	}
+	w.Compression = nil
+	w.StorageCompression = nil
 	return nil
 }
 
 // Unwrap an event from the event wrapper, marshaling the event bytes into an event
-// protocol buffer for event-specific processing.
+// protocol buffer for event-specific processing. If the event is compressed for storage
+// or by the publisher then it is decompressed before it is unmarshaled but the event
+// wrapper is not modified.
 func (w *EventWrapper) Unwrap() (e *Event, err error) {
 	if len(w.Event) == 0 {
 		return nil, ErrNoEvent
 	}
 
+	data := w.Event
+	if w.StorageCompression.Enabled() {
+		if data, err = w.StorageCompression.Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.Compression.Enabled() {
+		if data, err = w.Compression.Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+
 	e = &Event{}
-	if err = proto.Unmarshal(w.Event, e); err != nil {
+	if err = proto.Unmarshal(data, e); err != nil {
 		return nil, err
 	}
 	return e, nil
@@ -61,6 +79,25 @@ func (w *EventWrapper) ParseEventID() (eventID rlid.RLID, err error) {
 	return eventID, nil
 }
 
+// IsTombstone returns true if the event marks the deletion of its key in a compacted
+// topic: e.g. it is a keyed event that has no data. Duplicates are never tombstones
+// since their data is stored on the original event.
+func (w *EventWrapper) IsTombstone() bool {
+	if len(w.Key) == 0 || w.IsDuplicate {
+		return false
+	}
+
+	if len(w.Event) == 0 {
+		return true
+	}
+
+	event, err := w.Unwrap()
+	if err != nil {
+		return false
+	}
+	return len(event.Data) == 0
+}
+
 // Equals compares two events in wrappers to see if they are identical using event
 // equality. This is essentially a shortcut for unwrapping the two events and comparing
 // them directly.
@@ -50,6 +50,28 @@ func TestEventWrapper(t *testing.T) {
 	require.Error(t, err, "should not be able to unwrap non-protobuf data")
 }
 
+func TestEventWrapperIsTombstone(t *testing.T) {
+	wrap := &api.EventWrapper{Key: []byte("user:1")}
+	require.True(t, wrap.IsTombstone(), "keyed event without an event should be a tombstone")
+
+	require.NoError(t, wrap.Wrap(&api.Event{Mimetype: mimetype.ApplicationJSON, Type: &api.Type{Name: "User"}}))
+	require.True(t, wrap.IsTombstone(), "keyed event without data should be a tombstone")
+
+	wrap.IsDuplicate = true
+	require.False(t, wrap.IsTombstone(), "duplicates should not be tombstones")
+	wrap.IsDuplicate = false
+
+	require.NoError(t, wrap.Wrap(&api.Event{Data: []byte(`{"name":"alice"}`), Mimetype: mimetype.ApplicationJSON}))
+	require.False(t, wrap.IsTombstone(), "events with data should not be tombstones")
+
+	wrap.Event = []byte("foo")
+	require.False(t, wrap.IsTombstone(), "events that cannot be unwrapped should not be tombstones")
+
+	wrap = &api.EventWrapper{}
+	require.NoError(t, wrap.Wrap(&api.Event{Mimetype: mimetype.ApplicationJSON}))
+	require.False(t, wrap.IsTombstone(), "events without a key should not be tombstones")
+}
+
 func TestEventWrapperIDParsing(t *testing.T) {
 	testCases := []struct {
 		eventID  []byte
@@ -9,6 +9,8 @@ package api
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,6 +22,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type QueryExplanation_ScanStrategy int32
+
+const (
+	QueryExplanation_UNKNOWN     QueryExplanation_ScanStrategy = 0
+	QueryExplanation_FULL_SCAN   QueryExplanation_ScanStrategy = 1 // all events in the topic are read
+	QueryExplanation_OFFSET_SEEK QueryExplanation_ScanStrategy = 2 // events are read starting at the offset event id
+	QueryExplanation_TIME_RANGE  QueryExplanation_ScanStrategy = 3 // events are read starting at the created timestamp lower bound
+)
+
+// Enum value maps for QueryExplanation_ScanStrategy.
+var (
+	QueryExplanation_ScanStrategy_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "FULL_SCAN",
+		2: "OFFSET_SEEK",
+		3: "TIME_RANGE",
+	}
+	QueryExplanation_ScanStrategy_value = map[string]int32{
+		"UNKNOWN":     0,
+		"FULL_SCAN":   1,
+		"OFFSET_SEEK": 2,
+		"TIME_RANGE":  3,
+	}
+)
+
+func (x QueryExplanation_ScanStrategy) Enum() *QueryExplanation_ScanStrategy {
+	p := new(QueryExplanation_ScanStrategy)
+	*p = x
+	return p
+}
+
+func (x QueryExplanation_ScanStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueryExplanation_ScanStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1beta1_query_proto_enumTypes[0].Descriptor()
+}
+
+func (QueryExplanation_ScanStrategy) Type() protoreflect.EnumType {
+	return &file_api_v1beta1_query_proto_enumTypes[0]
+}
+
+func (x QueryExplanation_ScanStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueryExplanation_ScanStrategy.Descriptor instead.
+func (QueryExplanation_ScanStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{4, 0}
+}
+
 // Query represents a single EnSQL query with associated placeholder parameters.
 type Query struct {
 	state         protoimpl.MessageState
@@ -29,6 +83,13 @@ type Query struct {
 	Query             string       `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
 	Params            []*Parameter `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
 	IncludeDuplicates bool         `protobuf:"varint,3,opt,name=include_duplicates,json=includeDuplicates,proto3" json:"include_duplicates,omitempty"`
+	// Descriptors of the protocol buffer messages in the topic so that fields can be
+	// extracted from protobuf payloads; the message name is the event type name.
+	Descriptors *descriptorpb.FileDescriptorSet `protobuf:"bytes,4,opt,name=descriptors,proto3" json:"descriptors,omitempty"`
+	// If continuous, the query does not terminate once the events in the topic have
+	// been returned; matching events continue to be returned as they are published
+	// until the client closes the stream or the query limit is reached.
+	Continuous bool `protobuf:"varint,5,opt,name=continuous,proto3" json:"continuous,omitempty"`
 }
 
 func (x *Query) Reset() {
@@ -84,6 +145,20 @@ func (x *Query) GetIncludeDuplicates() bool {
 	return false
 }
 
+func (x *Query) GetDescriptors() *descriptorpb.FileDescriptorSet {
+	if x != nil {
+		return x.Descriptors
+	}
+	return nil
+}
+
+func (x *Query) GetContinuous() bool {
+	if x != nil {
+		return x.Continuous
+	}
+	return false
+}
+
 // Parameter holds a primitive value for passing as a placeholder to a sqlite query.
 type Parameter struct {
 	state         protoimpl.MessageState
@@ -216,18 +291,265 @@ func (*Parameter_Y) isParameter_Value() {}
 
 func (*Parameter_S) isParameter_Value() {}
 
+// QueryRow is a single result of a query that selects specific fields from events
+// rather than returning the entire event.
+type QueryRow struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventId []byte    `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Columns []*Column `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+}
+
+func (x *QueryRow) Reset() {
+	*x = QueryRow{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1beta1_query_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QueryRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRow) ProtoMessage() {}
+
+func (x *QueryRow) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1beta1_query_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRow.ProtoReflect.Descriptor instead.
+func (*QueryRow) Descriptor() ([]byte, []int) {
+	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *QueryRow) GetEventId() []byte {
+	if x != nil {
+		return x.EventId
+	}
+	return nil
+}
+
+func (x *QueryRow) GetColumns() []*Column {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+// Column holds the typed value of a selected field, named by its alias if one is given
+// in the query. If the field does not exist in the event then the value is not set.
+// Nested objects and lists are returned as json since they have no primitive type.
+type Column struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are assignable to Value:
+	//
+	//	*Column_I
+	//	*Column_U
+	//	*Column_D
+	//	*Column_B
+	//	*Column_Y
+	//	*Column_S
+	//	*Column_T
+	//	*Column_Json
+	Value isColumn_Value `protobuf_oneof:"value"`
+}
+
+func (x *Column) Reset() {
+	*x = Column{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1beta1_query_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Column) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Column) ProtoMessage() {}
+
+func (x *Column) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1beta1_query_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Column.ProtoReflect.Descriptor instead.
+func (*Column) Descriptor() ([]byte, []int) {
+	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Column) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (m *Column) GetValue() isColumn_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *Column) GetI() int64 {
+	if x, ok := x.GetValue().(*Column_I); ok {
+		return x.I
+	}
+	return 0
+}
+
+func (x *Column) GetU() uint64 {
+	if x, ok := x.GetValue().(*Column_U); ok {
+		return x.U
+	}
+	return 0
+}
+
+func (x *Column) GetD() float64 {
+	if x, ok := x.GetValue().(*Column_D); ok {
+		return x.D
+	}
+	return 0
+}
+
+func (x *Column) GetB() bool {
+	if x, ok := x.GetValue().(*Column_B); ok {
+		return x.B
+	}
+	return false
+}
+
+func (x *Column) GetY() []byte {
+	if x, ok := x.GetValue().(*Column_Y); ok {
+		return x.Y
+	}
+	return nil
+}
+
+func (x *Column) GetS() string {
+	if x, ok := x.GetValue().(*Column_S); ok {
+		return x.S
+	}
+	return ""
+}
+
+func (x *Column) GetT() *timestamppb.Timestamp {
+	if x, ok := x.GetValue().(*Column_T); ok {
+		return x.T
+	}
+	return nil
+}
+
+func (x *Column) GetJson() string {
+	if x, ok := x.GetValue().(*Column_Json); ok {
+		return x.Json
+	}
+	return ""
+}
+
+type isColumn_Value interface {
+	isColumn_Value()
+}
+
+type Column_I struct {
+	I int64 `protobuf:"zigzag64,2,opt,name=i,proto3,oneof"`
+}
+
+type Column_U struct {
+	U uint64 `protobuf:"varint,3,opt,name=u,proto3,oneof"`
+}
+
+type Column_D struct {
+	D float64 `protobuf:"fixed64,4,opt,name=d,proto3,oneof"`
+}
+
+type Column_B struct {
+	B bool `protobuf:"varint,5,opt,name=b,proto3,oneof"`
+}
+
+type Column_Y struct {
+	Y []byte `protobuf:"bytes,6,opt,name=y,proto3,oneof"`
+}
+
+type Column_S struct {
+	S string `protobuf:"bytes,7,opt,name=s,proto3,oneof"`
+}
+
+type Column_T struct {
+	T *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=t,proto3,oneof"`
+}
+
+type Column_Json struct {
+	Json string `protobuf:"bytes,9,opt,name=json,proto3,oneof"`
+}
+
+func (*Column_I) isColumn_Value() {}
+
+func (*Column_U) isColumn_Value() {}
+
+func (*Column_D) isColumn_Value() {}
+
+func (*Column_B) isColumn_Value() {}
+
+func (*Column_Y) isColumn_Value() {}
+
+func (*Column_S) isColumn_Value() {}
+
+func (*Column_T) isColumn_Value() {}
+
+func (*Column_Json) isColumn_Value() {}
+
 // Explanation returns information about the plan for executing a query and approximate
 // results or errors that might be returned.
 type QueryExplanation struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	// The query as it was parsed by the server.
+	Query *ParsedQuery `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// How events are read from the topic in order to execute the query.
+	Strategy QueryExplanation_ScanStrategy `protobuf:"varint,2,opt,name=strategy,proto3,enum=ensign.v1beta1.QueryExplanation_ScanStrategy" json:"strategy,omitempty"`
+	// An upper bound on the number of events returned by the query, estimated from the
+	// topic info so it may be stale or inaccurate for filters on payload fields.
+	EstimatedResults uint64 `protobuf:"varint,3,opt,name=estimated_results,json=estimatedResults,proto3" json:"estimated_results,omitempty"`
+	// Warnings about the query that do not prevent it from executing but that may
+	// cause unexpected results to be returned.
+	Warnings []string `protobuf:"bytes,4,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// The created timestamp bounds of the query if there are any.
+	Since *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`
+	Until *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=until,proto3" json:"until,omitempty"`
 }
 
 func (x *QueryExplanation) Reset() {
 	*x = QueryExplanation{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1beta1_query_proto_msgTypes[2]
+		mi := &file_api_v1beta1_query_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -240,7 +562,7 @@ func (x *QueryExplanation) String() string {
 func (*QueryExplanation) ProtoMessage() {}
 
 func (x *QueryExplanation) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1beta1_query_proto_msgTypes[2]
+	mi := &file_api_v1beta1_query_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -253,7 +575,201 @@ func (x *QueryExplanation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryExplanation.ProtoReflect.Descriptor instead.
 func (*QueryExplanation) Descriptor() ([]byte, []int) {
-	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{2}
+	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *QueryExplanation) GetQuery() *ParsedQuery {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *QueryExplanation) GetStrategy() QueryExplanation_ScanStrategy {
+	if x != nil {
+		return x.Strategy
+	}
+	return QueryExplanation_UNKNOWN
+}
+
+func (x *QueryExplanation) GetEstimatedResults() uint64 {
+	if x != nil {
+		return x.EstimatedResults
+	}
+	return 0
+}
+
+func (x *QueryExplanation) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *QueryExplanation) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *QueryExplanation) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+// ParsedQuery is the representation of an EnSQL query after it has been parsed.
+type ParsedQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      string            `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Topic     string            `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	TopicId   []byte            `protobuf:"bytes,3,opt,name=topic_id,json=topicId,proto3" json:"topic_id,omitempty"`
+	Schema    string            `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
+	Version   uint32            `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	Fields    []string          `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields,omitempty"`
+	Aliases   map[string]string `protobuf:"bytes,7,rep,name=aliases,proto3" json:"aliases,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Where     string            `protobuf:"bytes,8,opt,name=where,proto3" json:"where,omitempty"`
+	Offset    uint64            `protobuf:"varint,9,opt,name=offset,proto3" json:"offset,omitempty"`
+	HasOffset bool              `protobuf:"varint,10,opt,name=has_offset,json=hasOffset,proto3" json:"has_offset,omitempty"`
+	Limit     uint64            `protobuf:"varint,11,opt,name=limit,proto3" json:"limit,omitempty"`
+	HasLimit  bool              `protobuf:"varint,12,opt,name=has_limit,json=hasLimit,proto3" json:"has_limit,omitempty"`
+	GroupBy   []string          `protobuf:"bytes,13,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	OffsetId  []byte            `protobuf:"bytes,14,opt,name=offset_id,json=offsetId,proto3" json:"offset_id,omitempty"`
+}
+
+func (x *ParsedQuery) Reset() {
+	*x = ParsedQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1beta1_query_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ParsedQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParsedQuery) ProtoMessage() {}
+
+func (x *ParsedQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1beta1_query_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParsedQuery.ProtoReflect.Descriptor instead.
+func (*ParsedQuery) Descriptor() ([]byte, []int) {
+	return file_api_v1beta1_query_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ParsedQuery) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ParsedQuery) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ParsedQuery) GetTopicId() []byte {
+	if x != nil {
+		return x.TopicId
+	}
+	return nil
+}
+
+func (x *ParsedQuery) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *ParsedQuery) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ParsedQuery) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *ParsedQuery) GetAliases() map[string]string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *ParsedQuery) GetWhere() string {
+	if x != nil {
+		return x.Where
+	}
+	return ""
+}
+
+func (x *ParsedQuery) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ParsedQuery) GetHasOffset() bool {
+	if x != nil {
+		return x.HasOffset
+	}
+	return false
+}
+
+func (x *ParsedQuery) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ParsedQuery) GetHasLimit() bool {
+	if x != nil {
+		return x.HasLimit
+	}
+	return false
+}
+
+func (x *ParsedQuery) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *ParsedQuery) GetOffsetId() []byte {
+	if x != nil {
+		return x.OffsetId
+	}
+	return nil
 }
 
 var File_api_v1beta1_query_proto protoreflect.FileDescriptor
@@ -261,24 +777,106 @@ var File_api_v1beta1_query_proto protoreflect.FileDescriptor
 var file_api_v1beta1_query_proto_rawDesc = []byte{
 	0x0a, 0x17, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2f, 0x71, 0x75,
 	0x65, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x65, 0x6e, 0x73, 0x69, 0x67,
-	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x22, 0x7f, 0x0a, 0x05, 0x51, 0x75, 0x65,
-	0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61,
-	0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67,
-	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65,
-	0x74, 0x65, 0x72, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x69,
-	0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65,
-	0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x22, 0x78, 0x0a, 0x09, 0x50, 0x61,
-	0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x01, 0x69, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x12, 0x48, 0x00, 0x52, 0x01, 0x69, 0x12, 0x0e, 0x0a, 0x01, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x01, 0x48, 0x00, 0x52, 0x01, 0x64, 0x12, 0x0e, 0x0a, 0x01, 0x62, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x08, 0x48, 0x00, 0x52, 0x01, 0x62, 0x12, 0x0e, 0x0a, 0x01, 0x79, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x0c, 0x48, 0x00, 0x52, 0x01, 0x79, 0x12, 0x0e, 0x0a, 0x01, 0x73, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x09, 0x48, 0x00, 0x52, 0x01, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x22, 0x12, 0x0a, 0x10, 0x51, 0x75, 0x65, 0x72, 0x79, 0x45, 0x78, 0x70,
-	0x6c, 0x61, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72,
+	0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe5, 0x01, 0x0a,
+	0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x31, 0x0a, 0x06,
+	0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x65,
+	0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12,
+	0x2d, 0x0a, 0x12, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x64, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x44,
+	0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x6f, 0x72, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x75, 0x6f,
+	0x75, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x69, 0x6e,
+	0x75, 0x6f, 0x75, 0x73, 0x22, 0x78, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x12, 0x0e, 0x0a, 0x01, 0x69, 0x18, 0x01, 0x20, 0x01, 0x28, 0x12, 0x48, 0x00, 0x52, 0x01,
+	0x69, 0x12, 0x0e, 0x0a, 0x01, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x01,
+	0x64, 0x12, 0x0e, 0x0a, 0x01, 0x62, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x01,
+	0x62, 0x12, 0x0e, 0x0a, 0x01, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x01,
+	0x79, 0x12, 0x0e, 0x0a, 0x01, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x01,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x57,
+	0x0a, 0x08, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x6f, 0x77, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x30, 0x0a, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x52, 0x07,
+	0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x22, 0xc7, 0x01, 0x0a, 0x06, 0x43, 0x6f, 0x6c, 0x75,
+	0x6d, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x01, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x12, 0x48, 0x00, 0x52, 0x01, 0x69, 0x12, 0x0e, 0x0a, 0x01, 0x75, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x00, 0x52, 0x01, 0x75, 0x12, 0x0e, 0x0a, 0x01, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x00, 0x52, 0x01, 0x64, 0x12, 0x0e, 0x0a, 0x01, 0x62, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x48, 0x00, 0x52, 0x01, 0x62, 0x12, 0x0e, 0x0a, 0x01, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0c, 0x48, 0x00, 0x52, 0x01, 0x79, 0x12, 0x0e, 0x0a, 0x01, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x01, 0x73, 0x12, 0x2a, 0x0a, 0x01, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x48, 0x00, 0x52,
+	0x01, 0x74, 0x12, 0x14, 0x0a, 0x04, 0x6a, 0x73, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x04, 0x6a, 0x73, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x22, 0x8a, 0x03, 0x0a, 0x10, 0x51, 0x75, 0x65, 0x72, 0x79, 0x45, 0x78, 0x70, 0x6c, 0x61,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x31, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76,
+	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x73, 0x65, 0x64, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x49, 0x0a, 0x08, 0x73, 0x74, 0x72,
+	0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x65, 0x6e,
+	0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x45, 0x78, 0x70, 0x6c, 0x61, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x53, 0x63,
+	0x61, 0x6e, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x52, 0x08, 0x73, 0x74, 0x72, 0x61,
+	0x74, 0x65, 0x67, 0x79, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65,
+	0x64, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x10, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x30, 0x0a,
+	0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x12,
+	0x30, 0x0a, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x75, 0x6e, 0x74, 0x69,
+	0x6c, 0x22, 0x4b, 0x0a, 0x0c, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67,
+	0x79, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0d,
+	0x0a, 0x09, 0x46, 0x55, 0x4c, 0x4c, 0x5f, 0x53, 0x43, 0x41, 0x4e, 0x10, 0x01, 0x12, 0x0f, 0x0a,
+	0x0b, 0x4f, 0x46, 0x46, 0x53, 0x45, 0x54, 0x5f, 0x53, 0x45, 0x45, 0x4b, 0x10, 0x02, 0x12, 0x0e,
+	0x0a, 0x0a, 0x54, 0x49, 0x4d, 0x45, 0x5f, 0x52, 0x41, 0x4e, 0x47, 0x45, 0x10, 0x03, 0x22, 0xd4,
+	0x03, 0x0a, 0x0b, 0x50, 0x61, 0x72, 0x73, 0x65, 0x64, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x70, 0x69,
+	0x63, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x6f, 0x70, 0x69,
+	0x63, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18,
+	0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x42, 0x0a,
+	0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28,
+	0x2e, 0x65, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e,
+	0x50, 0x61, 0x72, 0x73, 0x65, 0x64, 0x51, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x41, 0x6c, 0x69, 0x61,
+	0x73, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x68, 0x65, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x77, 0x68, 0x65, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x68, 0x61, 0x73, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x68, 0x61, 0x73, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61, 0x73, 0x5f, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x68, 0x61, 0x73, 0x4c, 0x69, 0x6d, 0x69,
+	0x74, 0x12, 0x19, 0x0a, 0x08, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x5f, 0x62, 0x79, 0x18, 0x0d, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x07, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x42, 0x79, 0x12, 0x1b, 0x0a, 0x09,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x08, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x49, 0x64, 0x1a, 0x3a, 0x0a, 0x0c, 0x41, 0x6c, 0x69,
+	0x61, 0x73, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -293,19 +891,35 @@ func file_api_v1beta1_query_proto_rawDescGZIP() []byte {
 	return file_api_v1beta1_query_proto_rawDescData
 }
 
-var file_api_v1beta1_query_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_api_v1beta1_query_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_v1beta1_query_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_api_v1beta1_query_proto_goTypes = []any{
-	(*Query)(nil),            // 0: ensign.v1beta1.Query
-	(*Parameter)(nil),        // 1: ensign.v1beta1.Parameter
-	(*QueryExplanation)(nil), // 2: ensign.v1beta1.QueryExplanation
+	(QueryExplanation_ScanStrategy)(0),     // 0: ensign.v1beta1.QueryExplanation.ScanStrategy
+	(*Query)(nil),                          // 1: ensign.v1beta1.Query
+	(*Parameter)(nil),                      // 2: ensign.v1beta1.Parameter
+	(*QueryRow)(nil),                       // 3: ensign.v1beta1.QueryRow
+	(*Column)(nil),                         // 4: ensign.v1beta1.Column
+	(*QueryExplanation)(nil),               // 5: ensign.v1beta1.QueryExplanation
+	(*ParsedQuery)(nil),                    // 6: ensign.v1beta1.ParsedQuery
+	nil,                                    // 7: ensign.v1beta1.ParsedQuery.AliasesEntry
+	(*descriptorpb.FileDescriptorSet)(nil), // 8: google.protobuf.FileDescriptorSet
+	(*timestamppb.Timestamp)(nil),          // 9: google.protobuf.Timestamp
 }
 var file_api_v1beta1_query_proto_depIdxs = []int32{
-	1, // 0: ensign.v1beta1.Query.params:type_name -> ensign.v1beta1.Parameter
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 0: ensign.v1beta1.Query.params:type_name -> ensign.v1beta1.Parameter
+	8, // 1: ensign.v1beta1.Query.descriptors:type_name -> google.protobuf.FileDescriptorSet
+	4, // 2: ensign.v1beta1.QueryRow.columns:type_name -> ensign.v1beta1.Column
+	9, // 3: ensign.v1beta1.Column.t:type_name -> google.protobuf.Timestamp
+	6, // 4: ensign.v1beta1.QueryExplanation.query:type_name -> ensign.v1beta1.ParsedQuery
+	0, // 5: ensign.v1beta1.QueryExplanation.strategy:type_name -> ensign.v1beta1.QueryExplanation.ScanStrategy
+	9, // 6: ensign.v1beta1.QueryExplanation.since:type_name -> google.protobuf.Timestamp
+	9, // 7: ensign.v1beta1.QueryExplanation.until:type_name -> google.protobuf.Timestamp
+	7, // 8: ensign.v1beta1.ParsedQuery.aliases:type_name -> ensign.v1beta1.ParsedQuery.AliasesEntry
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_api_v1beta1_query_proto_init() }
@@ -339,6 +953,30 @@ func file_api_v1beta1_query_proto_init() {
 			}
 		}
 		file_api_v1beta1_query_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*QueryRow); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1beta1_query_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Column); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1beta1_query_proto_msgTypes[4].Exporter = func(v any, i int) any {
 			switch v := v.(*QueryExplanation); i {
 			case 0:
 				return &v.state
@@ -350,6 +988,18 @@ func file_api_v1beta1_query_proto_init() {
 				return nil
 			}
 		}
+		file_api_v1beta1_query_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ParsedQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_api_v1beta1_query_proto_msgTypes[1].OneofWrappers = []any{
 		(*Parameter_I)(nil),
@@ -358,18 +1008,29 @@ func file_api_v1beta1_query_proto_init() {
 		(*Parameter_Y)(nil),
 		(*Parameter_S)(nil),
 	}
+	file_api_v1beta1_query_proto_msgTypes[3].OneofWrappers = []any{
+		(*Column_I)(nil),
+		(*Column_U)(nil),
+		(*Column_D)(nil),
+		(*Column_B)(nil),
+		(*Column_Y)(nil),
+		(*Column_S)(nil),
+		(*Column_T)(nil),
+		(*Column_Json)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_v1beta1_query_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_api_v1beta1_query_proto_goTypes,
 		DependencyIndexes: file_api_v1beta1_query_proto_depIdxs,
+		EnumInfos:         file_api_v1beta1_query_proto_enumTypes,
 		MessageInfos:      file_api_v1beta1_query_proto_msgTypes,
 	}.Build()
 	File_api_v1beta1_query_proto = out.File
@@ -2,6 +2,7 @@ package api
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
@@ -12,6 +13,9 @@ import (
 const (
 	NameHashLength     = 16
 	MaxTopicNameLength = 512
+
+	// The default amount of time tombstones are kept in a compacted topic.
+	DefaultTombstoneGracePeriod = 24 * time.Hour
 )
 
 var topicNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\.\-\_]*$`)
@@ -104,3 +108,92 @@ func (i *TopicInfo) FindEventTypeInfo(etype *Type, mime mimetype.MIME) *EventTyp
 	i.Types = append(i.Types, einfo)
 	return einfo
 }
+
+// Enabled returns true if the retention policy limits the events kept in the topic.
+// Nil retention policies and policies without any limits are not enabled.
+func (r *Retention) Enabled() bool {
+	return r.GetMaxAge().AsDuration() > 0 || r.GetMaxBytes() > 0 || r.GetMaxEvents() > 0
+}
+
+// Expires returns true if an event that was committed at the specified time has
+// exceeded the max age of the retention policy at the current time.
+func (r *Retention) Expires(committed, now time.Time) bool {
+	maxAge := r.GetMaxAge().AsDuration()
+	if maxAge <= 0 {
+		return false
+	}
+	return now.Sub(committed) > maxAge
+}
+
+// Equals returns true if the retention policies have the same limits; a nil policy is
+// equal to a policy without any limits since both keep events forever.
+func (r *Retention) Equals(o *Retention) bool {
+	return r.GetMaxAge().AsDuration() == o.GetMaxAge().AsDuration() &&
+		r.GetMaxBytes() == o.GetMaxBytes() &&
+		r.GetMaxEvents() == o.GetMaxEvents()
+}
+
+// Normalize the retention policy by removing a zero max age so that it is not enforced.
+// A nil retention policy is normalized to a policy without any limits.
+func (r *Retention) Normalize() *Retention {
+	if r == nil {
+		r = &Retention{}
+	}
+
+	if r.MaxAge != nil && r.MaxAge.AsDuration() == 0 {
+		r.MaxAge = nil
+	}
+	return r
+}
+
+// Validate that the retention policy can be enforced.
+func (r *Retention) Validate() error {
+	if r.MaxAge != nil {
+		if err := r.MaxAge.CheckValid(); err != nil || r.MaxAge.AsDuration() < 0 {
+			return ErrInvalidMaxAge
+		}
+	}
+	return nil
+}
+
+// GracePeriod returns the amount of time tombstones are kept in the compacted topic,
+// using the default grace period if one is not specified on the policy.
+func (c *Compaction) GracePeriod() time.Duration {
+	if grace := c.GetTombstoneGracePeriod().AsDuration(); grace > 0 {
+		return grace
+	}
+	return DefaultTombstoneGracePeriod
+}
+
+// Equals returns true if the compaction policies are the same; a nil policy is equal
+// to a policy that is not enabled. The grace periods of policies that are not enabled
+// are ignored since tombstones are only removed when the topic is compacted.
+func (c *Compaction) Equals(o *Compaction) bool {
+	if c.GetEnabled() != o.GetEnabled() {
+		return false
+	}
+	return !c.GetEnabled() || c.GracePeriod() == o.GracePeriod()
+}
+
+// Normalize the compaction policy by removing a zero grace period so that the default
+// grace period is used. A nil policy is normalized to a policy that is not enabled.
+func (c *Compaction) Normalize() *Compaction {
+	if c == nil {
+		c = &Compaction{}
+	}
+
+	if c.TombstoneGracePeriod != nil && c.TombstoneGracePeriod.AsDuration() == 0 {
+		c.TombstoneGracePeriod = nil
+	}
+	return c
+}
+
+// Validate that the compaction policy can be enforced.
+func (c *Compaction) Validate() error {
+	if c.TombstoneGracePeriod != nil {
+		if err := c.TombstoneGracePeriod.CheckValid(); err != nil || c.TombstoneGracePeriod.AsDuration() < 0 {
+			return ErrInvalidGracePeriod
+		}
+	}
+	return nil
+}
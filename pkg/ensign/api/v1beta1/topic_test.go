@@ -5,12 +5,14 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestTopicULID(t *testing.T) {
@@ -205,3 +207,107 @@ func TestFindEventTypeInfo(t *testing.T) {
 	require.Equal(t, uint64(0), etype.Duplicates)
 	require.Equal(t, uint64(0), etype.DataSizeBytes)
 }
+
+func TestRetention(t *testing.T) {
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Enabled", func(t *testing.T) {
+		testCases := []struct {
+			policy   *api.Retention
+			expected bool
+		}{
+			{nil, false},
+			{&api.Retention{}, false},
+			{&api.Retention{MaxAge: durationpb.New(0)}, false},
+			{&api.Retention{MaxAge: durationpb.New(24 * time.Hour)}, true},
+			{&api.Retention{MaxBytes: 1024}, true},
+			{&api.Retention{MaxEvents: 100}, true},
+		}
+
+		for i, tc := range testCases {
+			require.Equal(t, tc.expected, tc.policy.Enabled(), "test case %d failed", i)
+		}
+	})
+
+	t.Run("Expires", func(t *testing.T) {
+		var policy *api.Retention
+		require.False(t, policy.Expires(now.Add(-720*time.Hour), now), "nil policy should never expire events")
+
+		policy = &api.Retention{MaxEvents: 100}
+		require.False(t, policy.Expires(now.Add(-720*time.Hour), now), "policy without max age should not expire events")
+
+		policy = &api.Retention{MaxAge: durationpb.New(24 * time.Hour)}
+		require.True(t, policy.Expires(now.Add(-25*time.Hour), now))
+		require.False(t, policy.Expires(now.Add(-24*time.Hour), now))
+		require.False(t, policy.Expires(now.Add(-1*time.Hour), now))
+	})
+
+	t.Run("Equals", func(t *testing.T) {
+		var policy *api.Retention
+		require.True(t, policy.Equals(nil))
+		require.True(t, policy.Equals(&api.Retention{}))
+		require.True(t, (&api.Retention{MaxAge: durationpb.New(0)}).Equals(nil))
+		require.False(t, policy.Equals(&api.Retention{MaxEvents: 100}))
+
+		policy = &api.Retention{MaxAge: durationpb.New(24 * time.Hour), MaxBytes: 1024}
+		require.True(t, policy.Equals(&api.Retention{MaxAge: durationpb.New(24 * time.Hour), MaxBytes: 1024}))
+		require.False(t, policy.Equals(&api.Retention{MaxAge: durationpb.New(48 * time.Hour), MaxBytes: 1024}))
+		require.False(t, policy.Equals(&api.Retention{MaxAge: durationpb.New(24 * time.Hour)}))
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		var policy *api.Retention
+		require.Equal(t, &api.Retention{}, policy.Normalize())
+
+		policy = &api.Retention{MaxAge: durationpb.New(0), MaxEvents: 100}
+		require.Same(t, policy, policy.Normalize())
+		require.Nil(t, policy.MaxAge)
+		require.Equal(t, uint64(100), policy.MaxEvents)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		require.NoError(t, (&api.Retention{}).Validate())
+		require.NoError(t, (&api.Retention{MaxAge: durationpb.New(720 * time.Hour)}).Validate())
+		require.ErrorIs(t, (&api.Retention{MaxAge: durationpb.New(-1 * time.Hour)}).Validate(), api.ErrInvalidMaxAge)
+		require.ErrorIs(t, (&api.Retention{MaxAge: &durationpb.Duration{Seconds: 1, Nanos: -1}}).Validate(), api.ErrInvalidMaxAge)
+	})
+}
+
+func TestCompaction(t *testing.T) {
+	t.Run("GracePeriod", func(t *testing.T) {
+		var policy *api.Compaction
+		require.Equal(t, api.DefaultTombstoneGracePeriod, policy.GracePeriod())
+		require.Equal(t, api.DefaultTombstoneGracePeriod, (&api.Compaction{Enabled: true}).GracePeriod())
+		require.Equal(t, api.DefaultTombstoneGracePeriod, (&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(0)}).GracePeriod())
+		require.Equal(t, time.Hour, (&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(time.Hour)}).GracePeriod())
+	})
+
+	t.Run("Equals", func(t *testing.T) {
+		var policy *api.Compaction
+		require.True(t, policy.Equals(nil))
+		require.True(t, policy.Equals(&api.Compaction{}))
+		require.True(t, policy.Equals(&api.Compaction{TombstoneGracePeriod: durationpb.New(time.Hour)}))
+		require.False(t, policy.Equals(&api.Compaction{Enabled: true}))
+
+		policy = &api.Compaction{Enabled: true}
+		require.True(t, policy.Equals(&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(api.DefaultTombstoneGracePeriod)}))
+		require.False(t, policy.Equals(&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(time.Hour)}))
+		require.False(t, policy.Equals(nil))
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		var policy *api.Compaction
+		require.Equal(t, &api.Compaction{}, policy.Normalize())
+
+		policy = &api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(0)}
+		require.Same(t, policy, policy.Normalize())
+		require.Nil(t, policy.TombstoneGracePeriod)
+		require.True(t, policy.Enabled)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		require.NoError(t, (&api.Compaction{}).Validate())
+		require.NoError(t, (&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(time.Hour)}).Validate())
+		require.ErrorIs(t, (&api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(-1 * time.Hour)}).Validate(), api.ErrInvalidGracePeriod)
+	})
+}
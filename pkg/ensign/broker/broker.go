@@ -2,12 +2,14 @@ package broker
 
 import (
 	"sync"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/o11y"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/utils/sentry"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -15,13 +17,20 @@ import (
 
 const BufferSize = 16384
 
-func New(events store.EventStore) *Broker {
+func New(events store.EventStore, meta store.MetaStore) *Broker {
 	return &Broker{
-		wg:     &sync.WaitGroup{},
-		pubs:   make(map[rlid.RLID]chan<- PublishResult),
-		subs:   make(map[rlid.RLID]subscription),
-		rlids:  &rlid.LockedSequence{},
-		events: events,
+		wg:      &sync.WaitGroup{},
+		pubs:    make(map[rlid.RLID]chan<- PublishResult),
+		subs:    make(map[rlid.RLID]*subscription),
+		groups:  make(map[string]*members),
+		rlids:   &rlid.LockedSequence{},
+		events:  events,
+		meta:    meta,
+		topics:  newTopicStates(meta),
+		seq:     newSequencer(events, meta),
+		dedup:   newDeduplicator(events, meta),
+		applied: &appliedIndex{meta: meta},
+		changes: make(map[ulid.ULID]*change),
 	}
 }
 
@@ -33,14 +42,48 @@ func New(events store.EventStore) *Broker {
 // events to one or more subscriber streams. The Broker uses an internal buffer that
 // applies backpressure to the publisher streams when the buffer is full.
 type Broker struct {
-	inQ    chan<- incoming                    // input queue - incoming events from publishers are written here.
-	wg     *sync.WaitGroup                    // wait for go routines to finish on shutdown.
-	pubmu  sync.RWMutex                       // guards the pubs map and the broker state
-	pubs   map[rlid.RLID]chan<- PublishResult // registered publishers with an event callback channel.
-	submu  sync.RWMutex                       // guards the subs map and the broker state
-	subs   map[rlid.RLID]subscription         // registered subscribers with an outgoing event queue.
-	rlids  *rlid.LockedSequence               // used to generate publisher and subscriber IDs
-	events store.EventStore                   // used to store events to disk
+	inQ     chan<- incoming                    // input queue - incoming events from publishers are written here.
+	wg      *sync.WaitGroup                    // wait for go routines to finish on shutdown.
+	pubmu   sync.RWMutex                       // guards the pubs map and the broker state
+	pubs    map[rlid.RLID]chan<- PublishResult // registered publishers with an event callback channel.
+	submu   sync.RWMutex                       // guards the subs map and the broker state
+	subs    map[rlid.RLID]*subscription        // registered subscribers with an outgoing event queue.
+	groups  map[string]*members                // subscribers that share a consumer group, guarded by submu
+	blocks  sync.Map                           // subscribers that apply backpressure, so they can be released without submu
+	rlids   *rlid.LockedSequence               // used to generate publisher and subscriber IDs
+	events  store.EventStore                   // used to store events to disk
+	meta    store.MetaStore                    // used to apply replicated changes to topics and groups
+	topics  *topicStates                       // the state of topics, used to reject events for topics that cannot accept writes
+	seq     *sequencer                         // assigns event IDs and topic offsets to events before they are committed
+	dedup   *deduplicator                      // detects duplicate events before they are stored
+	sealer  Sealer                             // seals events in topics that are encrypted at rest
+	quorum  Consensus                          // replicates events to the quorum before they are committed, if any
+	timeout time.Duration                      // how long to wait for a batch of events to be committed
+	applied *appliedIndex                      // the index of the last consensus log entry applied to the event store
+	propmu  sync.Mutex                         // guards the written and changes maps
+	written map[rlid.RLID]bool                 // the events of the batch being proposed and whether they were written
+	changes map[ulid.ULID]*change              // changes to the stores proposed by this node that are waiting to be committed
+}
+
+// Sealer encrypts the event bytes of an event before it is stored for topics that are
+// encrypted at rest, recording how the event was sealed on the storage encryption of
+// the event wrapper.
+type Sealer interface {
+	Seal(*api.EventWrapper) error
+}
+
+// UseSealer sets the sealer that events in encrypted topics are sealed with. Events in
+// encrypted topics are rejected if the broker does not have a sealer. This must be
+// called before the broker is run.
+func (b *Broker) UseSealer(sealer Sealer) {
+	b.sealer = sealer
+}
+
+// UseOffsetCheckpoint sets the number of events committed to a topic between writes of
+// the offset of the topic to the meta store (OffsetCheckpoint by default). This must be
+// called before the broker is run.
+func (b *Broker) UseOffsetCheckpoint(events uint64) {
+	b.seq.checkpoint = events
 }
 
 // Run the broker; any fatal errors will be sent on the specified channel.
@@ -68,42 +111,160 @@ func (b *Broker) handleIncoming(inQ <-chan incoming, outQ chan<- *api.EventWrapp
 	defer b.wg.Done()
 	defer close(outQ)
 
-	seq := rlid.Sequence(0)
-	for incoming := range inQ {
-		// Create the publish result with the localID for handling
-		result := PublishResult{LocalID: incoming.event.LocalId}
-
-		// TODO: reject event if topic is in read-only mode or deleting.
+	if b.quorum != nil {
+		b.replicate(inQ, outQ)
+		return
+	}
 
-		// TODO: sequence RLIDs over topic offset instead of globally.
-		incoming.event.Id = seq.Next().Bytes()
+	for incoming := range inQ {
+		b.backpressure(incoming.event)
+		write, result := b.prepare(incoming)
+		if result.IsNack() {
+			b.result(incoming, result)
+			continue
+		}
 
 		// Write event to disk
-		// NOTE: the insert will nil out the localID
-		if err := b.events.Insert(incoming.event); err != nil {
-			sentry.Error(nil).Err(err).Msg("could not insert event into database")
+		if err := b.write(write); err != nil {
 			result.Code = api.Nack_INTERNAL
 			b.result(incoming, result)
 			continue
 		}
 
-		// TODO: consensus
+		b.commit(incoming, result, outQ)
+	}
+}
+
+// Prepare the event to be written to disk: the event is sequenced, checked for
+// duplicates, then compressed and sealed according to the policies of its topic. If
+// the event cannot be written then the result is a nack that should be returned to the
+// publisher, otherwise the write is returned with the result to ack once committed.
+func (b *Broker) prepare(incoming incoming) (write *api.EventWrite, result PublishResult) {
+	// Create the publish result with the localID for handling
+	result = PublishResult{LocalID: incoming.event.LocalId}
+
+	// Reject events for topics that cannot accept writes, e.g. archived topics.
+	topicID, _ := incoming.event.ParseTopicID()
+	if code, err := b.topics.check(topicID); code != api.Nack_UNKNOWN {
+		if err != nil {
+			sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not check topic state")
+		}
+		result.Code = code
+		return nil, result
+	}
+
+	// Assign the event ID and the next offset in the topic to the event.
+	eventID, offset, err := b.seq.next(topicID)
+	if err != nil {
+		sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not sequence event")
+		result.Code = api.Nack_INTERNAL
+		if errors.Is(err, errors.ErrNotFound) {
+			result.Code = api.Nack_TOPIC_UNKNOWN
+		}
+		return nil, result
+	}
+
+	incoming.event.Id = eventID.Bytes()
+	incoming.event.Offset = offset
+
+	// Check if the event is a duplicate before it is written so that duplicates are
+	// stored as references to the original event; subscribers still receive the
+	// entire event. If the event cannot be checked it is committed as an original
+	// event rather than rejecting the publish.
+	dup, err := b.dedup.check(topicID, incoming.event)
+	if err != nil {
+		sentry.Warn(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not check event for duplicates")
+	}
+
+	stored := incoming.event
+	if dup.ref != nil {
+		stored = dup.ref
+		incoming.event.LocalId = nil
+	}
+
+	// Compress the stored event if the topic is compressed; if the event cannot be
+	// compressed then it is stored uncompressed rather than rejecting the publish.
+	if compressed, err := b.topics.compress(topicID, stored); err != nil {
+		sentry.Warn(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not compress event")
+	} else if compressed != stored {
+		stored = compressed
+		incoming.event.LocalId = nil
+	}
 
-		// TODO: update topic metadata
+	// Seal the stored event if the topic is encrypted at rest; the event is never
+	// written to disk in plaintext so the publish is rejected if it cannot be sealed.
+	if sealed, err := b.topics.seal(topicID, stored, b.sealer); err != nil {
+		sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not seal event")
+		result.Code = api.Nack_INTERNAL
+		return nil, result
+	} else if sealed != stored {
+		stored = sealed
+		incoming.event.LocalId = nil
+	}
 
-		// Send event on the outgoing queue
-		incoming.event.Committed = timestamppb.Now()
-		outQ <- incoming.event
+	write = &api.EventWrite{Event: stored, Hash: dup.hash}
 
-		// Send ack back to the publisher
-		b.result(incoming, result)
+	// Rewrite the earlier event as a reference if the event replaced it as the original
+	if dup.prev != nil {
+		if prev, err := b.topics.compress(topicID, dup.prev); err == nil {
+			dup.prev = prev
+		}
 
-		// Update metrics with number events
-		// TODO: update label values with topic name, publisher ID, node, and region
-		if o11y.Events != nil {
-			o11y.Events.WithLabelValues("unk", "unk").Inc()
+		if prev, err := b.topics.seal(topicID, dup.prev, b.sealer); err != nil {
+			sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not seal earlier event to rewrite as a duplicate")
+		} else {
+			write.Rewrite = prev
 		}
 	}
+	return write, result
+}
+
+// Write the prepared event to disk, index its hash, rewrite the earlier event it
+// replaced as the original, and advance the sequence of its topic. An error is only
+// returned if the event itself could not be written.
+// NOTE: the insert will nil out the localID of the stored event
+func (b *Broker) write(write *api.EventWrite) (err error) {
+	stored := write.Event
+	topicID, _ := stored.ParseTopicID()
+	if err = b.events.Insert(stored); err != nil {
+		sentry.Error(nil).Err(err).Msg("could not insert event into database")
+		return err
+	}
+
+	// Index the hash of the original event so that its duplicates can be found
+	if write.Hash != nil {
+		if err := b.events.Indash(topicID, write.Hash, rlid.RLID(stored.Id)); err != nil {
+			sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not index event hash")
+		}
+	}
+
+	if write.Rewrite != nil {
+		if err := b.events.Insert(write.Rewrite); err != nil {
+			sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not rewrite earlier event as a duplicate")
+		}
+	}
+
+	// The offset is recovered from the event log on restart if it cannot be persisted
+	if err := b.seq.commit(topicID, rlid.RLID(stored.Id), stored.Offset); err != nil {
+		sentry.Error(nil).Err(err).Str("topic_id", topicID.String()).Msg("could not update topic offset")
+	}
+	return nil
+}
+
+// Once the event has been committed, send it to the subscribers and ack the publisher.
+func (b *Broker) commit(incoming incoming, result PublishResult, outQ chan<- *api.EventWrapper) {
+	// Send event on the outgoing queue
+	incoming.event.Committed = timestamppb.Now()
+	outQ <- incoming.event
+
+	// Send ack back to the publisher
+	b.result(incoming, result)
+
+	// Update metrics with number events
+	// TODO: update label values with topic name, publisher ID, node, and region
+	if o11y.Events != nil {
+		o11y.Events.WithLabelValues("unk", "unk").Inc()
+	}
 }
 
 func (b *Broker) handleOutgoing(outQ <-chan *api.EventWrapper) {
@@ -118,17 +279,32 @@ func (b *Broker) handleOutgoing(outQ <-chan *api.EventWrapper) {
 
 		b.submu.RLock()
 		for _, sub := range b.subs {
+			// Consumer group members are handled below so that they share the events
+			if sub.group != "" {
+				continue
+			}
+
 			// Match the topic filter
 			if _, ok := sub.topics[topicID]; !ok {
 				continue
 			}
 
-			// Non-blocking send to prevent slow subscribers from interupting performance
 			nsubs++
-			select {
-			case sub.out <- event:
+			if sub.send(event) {
+				sends++
+			}
+		}
+
+		// Send the event to only one member of each consumer group
+		for _, group := range b.groups {
+			sub, ok := group.pick(event, topicID, b.subs)
+			if !ok {
+				continue
+			}
+
+			nsubs++
+			if sub.send(event) {
 				sends++
-			default:
 			}
 		}
 		b.submu.RUnlock()
@@ -136,11 +312,43 @@ func (b *Broker) handleOutgoing(outQ <-chan *api.EventWrapper) {
 	}
 }
 
+// Waits while a subscriber to the event's topic with the BLOCK overflow policy has a
+// full backlog so that publishers cannot get further ahead of the subscriber. Since the
+// publishers' queue fills up while the incoming handler waits, this applies backpressure
+// to publishers. No locks are held while waiting so that events that have already been
+// committed are still delivered and subscribers can be added and closed.
+func (b *Broker) backpressure(event *api.EventWrapper) {
+	topicID, _ := event.ParseTopicID()
+	for {
+		var full *subscription
+		b.submu.RLock()
+		for _, sub := range b.subs {
+			if _, ok := sub.topics[topicID]; ok && sub.blocked() {
+				full = sub
+				break
+			}
+		}
+		b.submu.RUnlock()
+
+		if full == nil {
+			return
+		}
+		full.wait()
+	}
+}
+
 // Gracefully shutdown the broker. If a consensus or write operation is underway, then
 // shutdown blocks until it is concluded. The broker then stops handling incoming events
 // from publishers and closes all registered publishers and subscribers. This has the
 // effect of closing any open event stream handlers.
 func (b *Broker) Shutdown() error {
+	// Release subscribers that apply backpressure so that a subscriber that has stopped
+	// receiving events cannot block the incoming handler from stopping.
+	b.blocks.Range(func(id, _ any) bool {
+		b.release(id.(rlid.RLID))
+		return true
+	})
+
 	// Acquire a lock to close the the inQ channel and signal that we're no longer running.
 	b.Lock()
 
@@ -173,9 +381,13 @@ func (b *Broker) Shutdown() error {
 
 	// Close all subscribers/consumer groups
 	for subID, subscription := range b.subs {
-		close(subscription.out)
+		subscription.close()
 		delete(b.subs, subID)
 	}
+
+	for group := range b.groups {
+		delete(b.groups, group)
+	}
 	return nil
 }
 
@@ -217,11 +429,43 @@ func (b *Broker) Publish(publisherID rlid.RLID, event *api.EventWrapper) error {
 // event wrapper channel once they are committed. If the broker is not running an error
 // is returned so that the consumer group can shutdown the stream.
 func (b *Broker) Subscribe(topics ...ulid.ULID) (rlid.RLID, <-chan *api.EventWrapper, error) {
+	sub, err := b.SubscribeWith(Options{}, topics...)
+	if err != nil {
+		return rlid.RLID{}, nil, err
+	}
+	return sub.ID, sub.Events, nil
+}
+
+// SubscribeGroup subscribes to events filtered by topic ids as a member of the consumer
+// group identified by the specified key. Rather than every subscriber receiving all of
+// the events, each event is sent to only one of the members of the group that are
+// subscribed to the event's topic. When a member is closed, the events are rebalanced
+// between the remaining members of the group.
+func (b *Broker) SubscribeGroup(group []byte, topics ...ulid.ULID) (rlid.RLID, <-chan *api.EventWrapper, error) {
+	if len(group) == 0 {
+		return rlid.RLID{}, nil, ErrNoGroup
+	}
+
+	sub, err := b.SubscribeWith(Options{Group: group}, topics...)
+	if err != nil {
+		return rlid.RLID{}, nil, err
+	}
+	return sub.ID, sub.Events, nil
+}
+
+// SubscribeWith subscribes to events filtered by topic ids using the specified options
+// to configure the consumer group and overflow policy of the subscription.
+func (b *Broker) SubscribeWith(opts Options, topics ...ulid.ULID) (*Subscriber, error) {
 	subscriberID := b.rlids.Next()
 	events := make(chan *api.EventWrapper, BufferSize)
-	sub := subscription{
-		topics: make(map[ulid.ULID]struct{}, len(topics)),
-		out:    events,
+	overflows := make(chan rlid.RLID, 1)
+	sub := &subscription{
+		topics:    make(map[ulid.ULID]struct{}, len(topics)),
+		group:     string(opts.Group),
+		policy:    opts.Overflow,
+		out:       events,
+		overflows: overflows,
+		done:      make(chan struct{}),
 	}
 
 	for _, topic := range topics {
@@ -233,15 +477,97 @@ func (b *Broker) Subscribe(topics ...ulid.ULID) (rlid.RLID, <-chan *api.EventWra
 
 	// If the broker is not running, ignore
 	if !b.isRunning() {
-		return rlid.RLID{}, nil, ErrBrokerNotRunning
+		return nil, ErrBrokerNotRunning
 	}
 
 	b.subs[subscriberID] = sub
-	return subscriberID, events, nil
+
+	if sub.group != "" {
+		if _, ok := b.groups[sub.group]; !ok {
+			b.groups[sub.group] = &members{}
+		}
+		b.groups[sub.group].add(subscriberID)
+	}
+
+	if sub.policy == api.Subscription_BLOCK {
+		sub.backlog = newBacklog()
+		b.blocks.Store(subscriberID, sub)
+		go sub.forward()
+	}
+
+	return &Subscriber{ID: subscriberID, Events: events, Overflows: overflows}, nil
+}
+
+// AddTopic adds a topic to the filter of a subscription so that the subscriber receives
+// events published to topics that were created after it subscribed.
+func (b *Broker) AddTopic(id rlid.RLID, topicID ulid.ULID) error {
+	b.submu.Lock()
+	defer b.submu.Unlock()
+
+	sub, ok := b.subs[id]
+	if !ok {
+		return ErrUnknownID
+	}
+
+	sub.topics[topicID] = struct{}{}
+	return nil
+}
+
+// ResetDeduplication discards the deduplication policy and bloom filter of the topic so
+// that they are reloaded when the next event is published to the topic. This must be
+// called when the deduplication policy of the topic changes or it is rehashed.
+func (b *Broker) ResetDeduplication(topicID ulid.ULID) {
+	b.dedup.reset(topicID)
+}
+
+// UpdateTopic refreshes the broker's view of the state of the topic so that events
+// are rejected if the topic cannot accept writes, e.g. because it has been archived or
+// is pending a policy change. This must be called whenever the topic is updated. If
+// the topic is being deleted, the topic is also removed from all subscriptions.
+func (b *Broker) UpdateTopic(topic *api.Topic) error {
+	if err := b.topics.update(topic); err != nil {
+		return err
+	}
+
+	if topic.Status == api.TopicState_DELETING {
+		topicID, _ := topic.ParseTopicID()
+		b.closeTopic(topicID)
+	}
+	return nil
+}
+
+// DeleteTopic marks the topic as deleted so that events published to it are rejected,
+// discards its sequence and deduplication state, and removes the topic from all
+// subscriptions. This must be called when the topic is deleted from the meta store.
+func (b *Broker) DeleteTopic(topicID ulid.ULID) {
+	b.topics.delete(topicID)
+	b.dedup.reset(topicID)
+	b.seq.reset(topicID)
+	b.closeTopic(topicID)
+}
+
+// Resume sending events to a subscriber that spilled; the subscriber should call
+// Resume once it has read the spilled events from the event store. Note that events
+// committed while the subscriber was reading may also need to be read from the store.
+func (b *Broker) Resume(id rlid.RLID) error {
+	b.submu.Lock()
+	defer b.submu.Unlock()
+
+	sub, ok := b.subs[id]
+	if !ok {
+		return ErrUnknownID
+	}
+
+	sub.spilling = false
+	return nil
 }
 
 // Close either a publisher or subscriber so no events will be sent from the broker.
 func (b *Broker) Close(id rlid.RLID) error {
+	// Release the subscriber first in case the incoming handler is waiting on it, which
+	// in turn may be blocking a publisher that holds the pubmu read lock.
+	b.release(id)
+
 	b.pubmu.Lock()
 	if cb, ok := b.pubs[id]; ok {
 		close(cb)
@@ -254,8 +580,7 @@ func (b *Broker) Close(id rlid.RLID) error {
 
 	b.submu.Lock()
 	if sub, ok := b.subs[id]; ok {
-		close(sub.out)
-		delete(b.subs, id)
+		b.unsubscribe(id, sub)
 		b.submu.Unlock()
 		return nil
 	}
@@ -264,6 +589,51 @@ func (b *Broker) Close(id rlid.RLID) error {
 	return ErrUnknownID
 }
 
+// Stops sending events from the topic to subscribers; subscriptions that are not
+// subscribed to any other topics are closed, which closes their event streams.
+func (b *Broker) closeTopic(topicID ulid.ULID) {
+	// Release the subscribers that will be closed before acquiring the lock so that
+	// publishers are not held back by subscribers that will no longer receive events.
+	closing := make([]rlid.RLID, 0)
+	b.submu.RLock()
+	for id, sub := range b.subs {
+		if _, ok := sub.topics[topicID]; ok && len(sub.topics) == 1 {
+			closing = append(closing, id)
+		}
+	}
+	b.submu.RUnlock()
+
+	for _, id := range closing {
+		b.release(id)
+	}
+
+	b.submu.Lock()
+	defer b.submu.Unlock()
+	for id, sub := range b.subs {
+		if _, ok := sub.topics[topicID]; !ok {
+			continue
+		}
+
+		delete(sub.topics, topicID)
+		if len(sub.topics) == 0 {
+			b.unsubscribe(id, sub)
+		}
+	}
+}
+
+// Closes the subscription and removes it from its consumer group. Must be called
+// while submu is locked and after the subscriber has been released.
+func (b *Broker) unsubscribe(id rlid.RLID, sub *subscription) {
+	sub.close()
+	delete(b.subs, id)
+
+	if group, ok := b.groups[sub.group]; ok {
+		if group.remove(id) == 0 {
+			delete(b.groups, sub.group)
+		}
+	}
+}
+
 func (b *Broker) NumPublishers() int {
 	b.pubmu.RLock()
 	defer b.pubmu.RUnlock()
@@ -276,6 +646,20 @@ func (b *Broker) NumSubscribers() int {
 	return len(b.subs)
 }
 
+func (b *Broker) NumGroups() int {
+	b.submu.RLock()
+	defer b.submu.RUnlock()
+	return len(b.groups)
+}
+
+// Stops the subscriber's forwarder and stops applying backpressure for the subscriber.
+// This does not require submu so that subscribers can be released while it is held.
+func (b *Broker) release(id rlid.RLID) {
+	if sub, ok := b.blocks.LoadAndDelete(id); ok {
+		close(sub.(*subscription).done)
+	}
+}
+
 // Returns true if the broker has been started, false otherwise. Not thread-safe.
 func (b *Broker) isRunning() bool {
 	return b.inQ != nil
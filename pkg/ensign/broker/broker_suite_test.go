@@ -3,7 +3,10 @@ package broker_test
 import (
 	"testing"
 
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/broker"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
 	"github.com/rotationalio/ensign/pkg/utils/logger"
 	"github.com/stretchr/testify/suite"
@@ -21,9 +24,17 @@ func TestBroker(t *testing.T) {
 }
 
 func (s *brokerTestSuite) SetupSuite() {
-	// Create a mock store that returns no error when events are inserted
+	// Create a mock store that returns no error when events are inserted and that
+	// sequences every topic from an empty event log.
 	s.events = &mock.Store{}
 	s.events.UseError(mock.Insert, nil)
+	s.events.UseError(mock.UpdateOffset, nil)
+	s.events.OnRetrieveTopic = func(topicID ulid.ULID) (*api.Topic, error) {
+		return &api.Topic{Id: topicID.Bytes()}, nil
+	}
+	s.events.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
 
 	// Discard all logging to prevent verbose test output
 	logger.Discard()
@@ -36,7 +47,7 @@ func (s *brokerTestSuite) TearDownSuite() {
 func (s *brokerTestSuite) BeforeTest(suiteName, testName string) {
 	// Create a new broker that isn't running before each test
 	// NOTE: tests must run the broker if they need it running.
-	s.broker = broker.New(s.events)
+	s.broker = broker.New(s.events, s.events)
 	s.echan = make(chan error, 1)
 }
 
@@ -1,6 +1,8 @@
 package broker_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"runtime"
 	"sync"
@@ -11,8 +13,13 @@ import (
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	. "github.com/rotationalio/ensign/pkg/ensign/broker"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	storeerrors "github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	raft "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (s *brokerTestSuite) TestBroker() {
@@ -177,6 +184,315 @@ func (s *brokerTestSuite) TestWriteErrors() {
 	require.Equal(nSent, nNacks, "expected a nack for every message sent")
 }
 
+func (s *brokerTestSuite) TestConsumerGroups() {
+	require := s.Require()
+	s.broker.Run(s.echan)
+
+	topicID := ulid.Make()
+	alpha, bravo := []byte("alpha"), []byte("bravo")
+
+	// Subscribers that are not in a group receive every event while subscribers that
+	// share a group split the events between them.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	received := make(map[rlid.RLID][]*api.EventWrapper)
+
+	subscribe := func(group []byte) rlid.RLID {
+		var (
+			subID rlid.RLID
+			C     <-chan *api.EventWrapper
+			err   error
+		)
+
+		if group == nil {
+			subID, C, err = s.broker.Subscribe(topicID)
+		} else {
+			subID, C, err = s.broker.SubscribeGroup(group, topicID)
+		}
+		require.NoError(err, "could not register subscriber")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range C {
+				mu.Lock()
+				received[subID] = append(received[subID], event)
+				mu.Unlock()
+			}
+		}()
+		return subID
+	}
+
+	count := func(subs ...rlid.RLID) (n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, subID := range subs {
+			n += len(received[subID])
+		}
+		return n
+	}
+
+	solo := subscribe(nil)
+	members := []rlid.RLID{subscribe(alpha), subscribe(alpha), subscribe(alpha)}
+	other := subscribe(bravo)
+
+	// A member subscribed to a different topic should not receive any events
+	_, _, err := s.broker.SubscribeGroup(alpha, ulid.Make())
+	require.NoError(err, "could not register subscriber")
+
+	require.Equal(6, s.broker.NumSubscribers())
+	require.Equal(2, s.broker.NumGroups())
+
+	pubID, _, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	// Events without keys are distributed round robin
+	for i := 0; i < 30; i++ {
+		require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:]}))
+	}
+
+	require.Eventually(func() bool { return count(solo) == 30 && count(other) == 30 && count(members...) == 30 }, time.Second, 10*time.Millisecond)
+	for _, subID := range members {
+		require.Equal(10, count(subID), "expected events to be distributed evenly")
+	}
+
+	// Events with the same key are always sent to the same member
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")}
+	for i := 0; i < 40; i++ {
+		require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:], Key: keys[i%len(keys)]}))
+	}
+
+	require.Eventually(func() bool { return count(solo) == 70 && count(other) == 70 && count(members...) == 70 }, time.Second, 10*time.Millisecond)
+
+	// Returns the member that received each key, skipping the events already checked.
+	owners := func(since map[rlid.RLID]int) map[string]rlid.RLID {
+		mu.Lock()
+		defer mu.Unlock()
+
+		owners := make(map[string]rlid.RLID)
+		for _, subID := range members {
+			for _, event := range received[subID][since[subID]:] {
+				if len(event.Key) == 0 {
+					continue
+				}
+
+				owner, ok := owners[string(event.Key)]
+				require.True(!ok || owner == subID, "expected all events with the same key to be sent to the same member")
+				owners[string(event.Key)] = subID
+			}
+		}
+		return owners
+	}
+	assigned := owners(nil)
+	require.Len(assigned, len(keys))
+
+	// When a member leaves, the remaining members handle all of the events
+	require.NoError(s.broker.Close(members[0]))
+	require.Equal(2, s.broker.NumGroups())
+
+	since := make(map[rlid.RLID]int)
+	for _, subID := range members {
+		since[subID] = count(subID)
+	}
+
+	closed := count(members[0])
+	for i := 0; i < 40; i++ {
+		require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:], Key: keys[i%len(keys)]}))
+	}
+
+	require.Eventually(func() bool { return count(members[1:]...) == 110-closed }, time.Second, 10*time.Millisecond)
+	require.Equal(closed, count(members[0]), "expected no events sent to a closed member")
+	rebalanced := owners(since)
+	require.Len(rebalanced, len(keys))
+	for key, owner := range rebalanced {
+		if assigned[key] == members[0] {
+			require.NotEqual(members[0], owner, "expected key %q to be moved to a remaining member", key)
+		} else {
+			require.Equal(assigned[key], owner, "expected key %q not to be moved", key)
+		}
+	}
+
+	// Once all members have left the group it is removed
+	require.NoError(s.broker.Close(other))
+	require.Equal(1, s.broker.NumGroups())
+
+	// Must specify a group to subscribe as a member
+	_, _, err = s.broker.SubscribeGroup(nil, topicID)
+	require.ErrorIs(err, ErrNoGroup)
+
+	require.NoError(s.broker.Shutdown())
+	wg.Wait()
+	require.Equal(0, s.broker.NumGroups())
+}
+
+func (s *brokerTestSuite) TestOverflow() {
+	require := s.Require()
+	s.broker.Run(s.echan)
+
+	topicID := ulid.Make()
+	subscribe := func(policy api.Subscription_Overflow) *Subscriber {
+		sub, err := s.broker.SubscribeWith(Options{Overflow: policy}, topicID)
+		require.NoError(err, "could not register subscriber")
+		return sub
+	}
+
+	drop := subscribe(api.Subscription_DROP)
+	block := subscribe(api.Subscription_BLOCK)
+	spill := subscribe(api.Subscription_SPILL)
+	disconnect := subscribe(api.Subscription_DISCONNECT)
+
+	pubID, _, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	publish := func(n int) {
+		for i := 0; i < n; i++ {
+			require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:]}))
+		}
+	}
+
+	// Publish more events than fit in the subscriber queues without reading any events.
+	nevents := BufferSize + 10
+	publish(nevents)
+
+	// The blocking subscriber should receive every event once it starts reading.
+	events := make([]*api.EventWrapper, 0, nevents)
+	for i := 0; i < nevents; i++ {
+		select {
+		case event := <-block.Events:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for events on blocking subscriber", "received %d events", i)
+		}
+	}
+
+	// The first event that did not fit in the queue is reported on overflow
+	overflowed := events[BufferSize].Id
+	for _, sub := range []*Subscriber{spill, disconnect} {
+		select {
+		case eventID := <-sub.Overflows:
+			require.Equal(overflowed, eventID.Bytes(), "expected the first event that was not queued")
+		case <-time.After(time.Second):
+			require.FailNow("timed out waiting for overflow")
+		}
+	}
+
+	require.Len(drop.Overflows, 0, "expected no overflow notification when dropping events")
+	for _, sub := range []*Subscriber{drop, spill, disconnect} {
+		require.Len(sub.Events, BufferSize, "expected events that do not fit in the queue not to be sent")
+		for i := 0; i < BufferSize; i++ {
+			<-sub.Events
+		}
+	}
+
+	// Spilled and disconnected subscribers do not receive events even if they have room
+	publish(1)
+	<-block.Events
+	require.Len(drop.Events, 1, "expected dropping subscriber to receive events when it has room")
+	require.Len(spill.Events, 0, "expected no events sent to spilled subscriber")
+	require.Len(disconnect.Events, 0, "expected no events sent to disconnected subscriber")
+
+	// Once resumed, spilled subscribers receive events again
+	require.NoError(s.broker.Resume(spill.ID))
+	publish(1)
+	<-block.Events
+	require.Len(spill.Events, 1, "expected resumed subscriber to receive events")
+	require.Len(disconnect.Events, 0, "expected no events sent to disconnected subscriber")
+
+	require.ErrorIs(s.broker.Resume(rlid.Make(42)), ErrUnknownID)
+}
+
+func (s *brokerTestSuite) TestCloseBlockedSubscriber() {
+	require := s.Require()
+	s.broker.Run(s.echan)
+
+	topicID := ulid.Make()
+	block, err := s.broker.SubscribeWith(Options{Overflow: api.Subscription_BLOCK}, topicID)
+	require.NoError(err, "could not register subscriber")
+
+	_, other, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	pubID, _, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	// Fill the blocking subscriber's queue so that the broker blocks on the next event
+	for i := 0; i < BufferSize+1; i++ {
+		require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:]}))
+	}
+
+	require.Eventually(func() bool { return len(block.Events) == BufferSize }, 5*time.Second, 10*time.Millisecond)
+
+	// Closing the subscriber should not deadlock with the blocked broker
+	closed := make(chan error, 1)
+	go func() {
+		closed <- s.broker.Close(block.ID)
+	}()
+
+	select {
+	case err := <-closed:
+		require.NoError(err, "could not close blocked subscriber")
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out closing blocked subscriber")
+	}
+
+	// The other subscribers continue to receive events
+	require.Eventually(func() bool { return len(other) == BufferSize }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(1, s.broker.NumSubscribers())
+}
+
+func (s *brokerTestSuite) TestSlowBlockingSubscriber() {
+	require := s.Require()
+	s.broker.Run(s.echan)
+
+	topicID := ulid.Make()
+	block, err := s.broker.SubscribeWith(Options{Overflow: api.Subscription_BLOCK}, topicID)
+	require.NoError(err, "could not register subscriber")
+
+	_, other, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	pubID, _, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	// Publish more events than fit in the blocking subscriber's queue without reading
+	nevents := BufferSize + 100
+	for i := 0; i < nevents; i++ {
+		require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID[:]}))
+	}
+
+	// The other subscriber should receive every event while the blocking subscriber is
+	// not reading, and subscribers can still be added.
+	for i := 0; i < nevents; i++ {
+		select {
+		case <-other:
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for events on other subscriber", "received %d events", i)
+		}
+	}
+
+	added := make(chan error, 1)
+	go func() {
+		_, _, err := s.broker.Subscribe(topicID)
+		added <- err
+	}()
+
+	select {
+	case err := <-added:
+		require.NoError(err, "could not register subscriber")
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out registering subscriber")
+	}
+
+	// The blocking subscriber receives every event once it starts reading
+	for i := 0; i < nevents; i++ {
+		select {
+		case <-block.Events:
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for events on blocking subscriber", "received %d events", i)
+		}
+	}
+}
+
 func (s *brokerTestSuite) TestBrokerStartupShutdown() {
 	require := s.Require()
 	nroutines := runtime.NumGoroutine()
@@ -352,3 +668,1049 @@ func (s *brokerTestSuite) TestAckCommittedTimestamp() {
 		require.False(result.Committed.AsTime().IsZero(), "committed timestamp is zero valued")
 	}
 }
+
+func (s *brokerTestSuite) TestDeduplication() {
+	require := s.Require()
+
+	// Create a broker with an in-memory store for the deduplicated topic
+	topic := &api.Topic{
+		Id:            ulids.New().Bytes(),
+		Status:        api.TopicState_READY,
+		Deduplication: &api.Deduplication{Strategy: api.Deduplication_STRICT},
+	}
+	topicID, _ := topic.ParseTopicID()
+
+	var mu sync.Mutex
+	db := &mock.Store{}
+	stored := make(map[rlid.RLID]*api.EventWrapper)
+	hashes := make(map[string]rlid.RLID)
+
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return proto.Clone(topic).(*api.Topic), nil
+	}
+	db.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) {
+		return &api.TopicInfo{TopicId: topic.Id}, nil
+	}
+	db.OnLoadIndash = func(ulid.ULID) iterator.IndashIterator {
+		keys := make([][]byte, 0, len(hashes))
+		for hash := range hashes {
+			keys = append(keys, []byte(hash))
+		}
+		return mock.NewIndashIterator(keys)
+	}
+	db.OnInsert = func(event *api.EventWrapper) error {
+		stored[rlid.RLID(event.Id)] = event
+		return nil
+	}
+	db.OnIndash = func(_ ulid.ULID, hash []byte, eventID rlid.RLID) error {
+		hashes[string(hash)] = eventID
+		return nil
+	}
+	db.OnUnhash = func(_ ulid.ULID, hash []byte) (*api.EventWrapper, error) {
+		if eventID, ok := hashes[string(hash)]; ok {
+			return stored[eventID], nil
+		}
+		return nil, storeerrors.ErrNotFound
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+	db.UseError(mock.UpdateOffset, nil)
+
+	s.broker = New(db, db)
+	s.broker.Run(s.echan)
+
+	_, events, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	original := &api.EventWrapper{TopicId: topic.Id}
+	require.NoError(original.Wrap(&api.Event{Data: []byte("revenue:42"), Type: &api.Type{Name: "Revenue", MajorVersion: 1}}))
+
+	publish := func(event *api.EventWrapper) (*api.EventWrapper, rlid.RLID) {
+		s.broker.Publish(pubID, proto.Clone(event).(*api.EventWrapper))
+		result := <-results
+		require.True(result.IsAck(), "expected the event to be committed")
+
+		event = <-events
+		return event, rlid.RLID(event.Id)
+	}
+
+	// The first event is stored as an original and its hash is indexed
+	event, origID := publish(original)
+	require.False(event.IsDuplicate)
+	require.Len(hashes, 1, "expected the hash of the original event to be indexed")
+
+	// Republishing the event stores a reference to the original event
+	event, dupID := publish(original)
+	require.True(event.IsDuplicate, "expected the subscriber to receive a duplicate")
+	require.Equal(origID.Bytes(), event.DuplicateId)
+	require.NotNil(event.Event, "expected the subscriber to receive the entire event")
+
+	ref := stored[dupID]
+	require.True(ref.IsDuplicate)
+	require.Equal(origID.Bytes(), ref.DuplicateId)
+	require.Nil(ref.Event, "expected a reference to be stored for a strict duplicate")
+	require.Len(hashes, 1, "expected the duplicate not to be indexed")
+
+	// Different events are not duplicates
+	other := proto.Clone(original).(*api.EventWrapper)
+	require.NoError(other.Wrap(&api.Event{Data: []byte("revenue:27"), Type: &api.Type{Name: "Revenue", MajorVersion: 1}}))
+	event, _ = publish(other)
+	require.False(event.IsDuplicate)
+	require.Len(hashes, 2)
+
+	// Once the policy is changed and the topic reset the events are not deduplicated
+	mu.Lock()
+	topic.Deduplication = &api.Deduplication{Strategy: api.Deduplication_NONE}
+	mu.Unlock()
+	s.broker.ResetDeduplication(topicID)
+
+	event, _ = publish(original)
+	require.False(event.IsDuplicate, "expected no deduplication after the policy was changed")
+	require.Len(stored, 4)
+
+	// With the latest offset the latest state of the account is the original event
+	mu.Lock()
+	topic.Deduplication = &api.Deduplication{Strategy: api.Deduplication_UNIQUE_KEY, Keys: []string{"account"}, Offset: api.Deduplication_OFFSET_LATEST, OverwriteDuplicate: true}
+	mu.Unlock()
+	s.broker.ResetDeduplication(topicID)
+
+	state := func(balance string) *api.EventWrapper {
+		event := &api.EventWrapper{TopicId: topic.Id}
+		require.NoError(event.Wrap(&api.Event{Data: []byte(balance), Metadata: map[string]string{"account": "1234"}}))
+		return event
+	}
+
+	_, firstID := publish(state("balance:10"))
+	event, secondID := publish(state("balance:20"))
+	require.False(event.IsDuplicate, "expected the latest event to be the original")
+	require.NotEmpty(stored[secondID].Event)
+
+	prev := stored[firstID]
+	require.True(prev.IsDuplicate, "expected the earlier event to be rewritten as a duplicate")
+	require.Equal(secondID.Bytes(), prev.DuplicateId)
+	require.Empty(prev.Event, "expected the overwritten duplicate to have no data")
+
+	_, thirdID := publish(state("balance:30"))
+	require.Equal(thirdID.Bytes(), stored[secondID].DuplicateId)
+	require.Equal(secondID.Bytes(), stored[firstID].DuplicateId, "expected the earlier duplicate not to be rewritten")
+
+	// With the earliest offset subscribers receive the original of overwritten duplicates
+	mu.Lock()
+	topic.Deduplication.Offset = api.Deduplication_OFFSET_EARLIEST
+	mu.Unlock()
+	s.broker.ResetDeduplication(topicID)
+
+	event, fourthID := publish(state("balance:40"))
+	require.True(event.IsDuplicate)
+	require.Equal(thirdID.Bytes(), event.DuplicateId)
+	require.Equal(stored[thirdID].Event, event.Event, "expected the subscriber to receive the original event")
+	require.Empty(stored[fourthID].Event)
+}
+
+func (s *brokerTestSuite) TestSequencing() {
+	require := s.Require()
+
+	// Events in the log are stamped in the future so that the broker has to sequence
+	// new events after them rather than by the current time.
+	future := rlid.Now() + 3600000
+	makeEvent := func(topicID ulid.ULID, seq uint32, offset uint64) *api.EventWrapper {
+		eventID := rlid.Make(seq)
+		eventID.SetTime(future)
+		return &api.EventWrapper{Id: eventID.Bytes(), TopicId: topicID.Bytes(), Offset: offset}
+	}
+
+	// The offset of the first topic was persisted at the third event but two more events
+	// were committed before the node stopped. The second topic has events from before
+	// topics were sequenced, which do not have an offset.
+	first, second := ulids.New(), ulids.New()
+	log := map[ulid.ULID][]*api.EventWrapper{
+		first:  {makeEvent(first, 1, 1), makeEvent(first, 2, 2), makeEvent(first, 3, 3), makeEvent(first, 4, 4), makeEvent(first, 5, 5)},
+		second: {makeEvent(second, 6, 0), makeEvent(second, 7, 0), makeEvent(second, 8, 0)},
+	}
+	topics := map[ulid.ULID]*api.Topic{
+		first:  {Id: first.Bytes(), Offset: 3, OffsetId: log[first][2].Id},
+		second: {Id: second.Bytes()},
+	}
+
+	var mu sync.Mutex
+	var failInsert bool
+	db := &mock.Store{}
+	db.OnRetrieveTopic = func(topicID ulid.ULID) (*api.Topic, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if topic, ok := topics[topicID]; ok {
+			return proto.Clone(topic).(*api.Topic), nil
+		}
+		return nil, storeerrors.ErrNotFound
+	}
+	db.OnList = func(topicID ulid.ULID) iterator.EventIterator {
+		mu.Lock()
+		defer mu.Unlock()
+		return mock.NewEventIterator(append([]*api.EventWrapper(nil), log[topicID]...))
+	}
+	db.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failInsert {
+			return errors.New("unable to write event to disk")
+		}
+
+		topicID, _ := event.ParseTopicID()
+		log[topicID] = append(log[topicID], event)
+		return nil
+	}
+	db.OnUpdateOffset = func(topicID ulid.ULID, offset uint64, eventID rlid.RLID) error {
+		mu.Lock()
+		defer mu.Unlock()
+		topics[topicID].Offset = offset
+		topics[topicID].OffsetId = eventID.Bytes()
+		return nil
+	}
+
+	// Offsets are persisted every two events so that the checkpoints can be tested.
+	s.broker = New(db, db)
+	s.broker.UseOffsetCheckpoint(2)
+	s.broker.Run(s.echan)
+
+	_, events, err := s.broker.Subscribe(first, second)
+	require.NoError(err, "could not register subscriber")
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	publish := func(topicID ulid.ULID) (*api.EventWrapper, bool) {
+		s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID.Bytes(), LocalId: ulids.New().Bytes()})
+		if result := <-results; !result.IsAck() {
+			return nil, false
+		}
+		return <-events, true
+	}
+
+	// Events are sequenced after the events in the log and the offset is only persisted
+	// once a checkpoint has been reached since it was last persisted.
+	var persisted []byte
+	for _, tc := range []struct{ offset, persisted uint64 }{{6, 6}, {7, 6}, {8, 8}} {
+		event, ok := publish(first)
+		require.True(ok, "expected the event to be committed")
+		require.Equal(tc.offset, event.Offset)
+
+		last := log[first][len(log[first])-2]
+		require.Equal(1, bytes.Compare(event.Id, last.Id), "expected event ids to increase within the topic")
+
+		if tc.offset == tc.persisted {
+			persisted = event.Id
+		}
+
+		mu.Lock()
+		require.Equal(tc.persisted, topics[first].Offset, "expected the topic offset to be persisted at checkpoints")
+		require.Equal(persisted, topics[first].OffsetId)
+		mu.Unlock()
+	}
+
+	// Events before topics were sequenced are counted by their position in the topic
+	event, ok := publish(second)
+	require.True(ok, "expected the event to be committed")
+	require.Equal(uint64(4), event.Offset)
+	require.NotEqual(log[first][len(log[first])-1].Id, event.Id, "expected event ids to be unique across topics")
+
+	// Events that are not committed do not leave a gap in the topic offsets
+	mu.Lock()
+	failInsert = true
+	mu.Unlock()
+
+	_, ok = publish(second)
+	require.False(ok, "expected the event not to be committed")
+
+	mu.Lock()
+	failInsert = false
+	mu.Unlock()
+
+	event, ok = publish(second)
+	require.True(ok, "expected the event to be committed")
+	require.Equal(uint64(5), event.Offset)
+
+	// Events cannot be published to topics that do not exist
+	s.broker.Publish(pubID, &api.EventWrapper{TopicId: ulids.New().Bytes()})
+	result := <-results
+	require.Equal(api.Nack_TOPIC_UNKNOWN, result.Code)
+}
+
+func (s *brokerTestSuite) TestTopicStates() {
+	require := s.Require()
+
+	// The archived topic is loaded from the meta store as readonly
+	ready, archived := ulids.New(), ulids.New()
+	topics := map[ulid.ULID]*api.Topic{
+		ready:    {Id: ready.Bytes(), Status: api.TopicState_READY},
+		archived: {Id: archived.Bytes(), Readonly: true, Status: api.TopicState_READONLY},
+	}
+
+	// The last event written is only read after the publisher receives its result
+	var inserted *api.EventWrapper
+	db := &mock.Store{}
+	db.UseError(mock.UpdateOffset, nil)
+	db.OnInsert = func(event *api.EventWrapper) error {
+		inserted = event
+		return nil
+	}
+	db.OnRetrieveTopic = func(topicID ulid.ULID) (*api.Topic, error) {
+		if topic, ok := topics[topicID]; ok {
+			return proto.Clone(topic).(*api.Topic), nil
+		}
+		return nil, storeerrors.ErrNotFound
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+
+	s.broker = New(db, db)
+	s.broker.Run(s.echan)
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	publish := func(topicID ulid.ULID) PublishResult {
+		s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicID.Bytes()})
+		return <-results
+	}
+
+	require.True(publish(ready).IsAck(), "expected event to be committed to a ready topic")
+	require.Equal(api.Nack_TOPIC_ARCHIVED, publish(archived).Code)
+	require.Equal(api.Nack_TOPIC_UNKNOWN, publish(ulids.New()).Code)
+
+	// Updating the topic changes the state of the topic in the broker immediately
+	states := []struct {
+		readonly bool
+		status   api.TopicState
+		code     api.Nack_Code
+	}{
+		{false, api.TopicState_PENDING, api.Nack_TOPIC_NOT_READY},
+		{false, api.TopicState_READY, api.Nack_UNKNOWN},
+		{true, api.TopicState_READONLY, api.Nack_TOPIC_ARCHIVED},
+		{false, api.TopicState_UNDEFINED, api.Nack_UNKNOWN},
+	}
+
+	for i, tc := range states {
+		require.NoError(s.broker.UpdateTopic(&api.Topic{Id: ready.Bytes(), Readonly: tc.readonly, Status: tc.status}))
+		require.Equal(tc.code, publish(ready).Code, "unexpected result for test case %d", i)
+	}
+
+	// Destroying the topic removes it from subscriptions, closing those that are not
+	// subscribed to any other topics.
+	_, single, err := s.broker.Subscribe(ready)
+	require.NoError(err, "could not register subscriber")
+	_, multi, err := s.broker.Subscribe(ready, archived)
+	require.NoError(err, "could not register subscriber")
+	require.Equal(2, s.broker.NumSubscribers())
+
+	require.NoError(s.broker.UpdateTopic(&api.Topic{Id: ready.Bytes(), Status: api.TopicState_DELETING}))
+	require.Equal(api.Nack_TOPIC_DELETED, publish(ready).Code)
+	require.Equal(1, s.broker.NumSubscribers())
+
+	_, open := <-single
+	require.False(open, "expected the subscription to the deleted topic to be closed")
+
+	s.broker.DeleteTopic(ready)
+	require.Equal(api.Nack_TOPIC_DELETED, publish(ready).Code)
+
+	// Deleting the topic discards its sequence, so it is loaded from the meta store
+	// again if events are published to a topic with the same ID.
+	topics[ready].Offset = 42
+	require.NoError(s.broker.UpdateTopic(&api.Topic{Id: ready.Bytes(), Status: api.TopicState_READY}))
+	require.True(publish(ready).IsAck(), "expected event to be committed to the recreated topic")
+	require.Equal(uint64(43), inserted.Offset, "expected the sequence to be reloaded")
+
+	require.NoError(s.broker.UpdateTopic(&api.Topic{Id: ready.Bytes(), Status: api.TopicState_DELETING}))
+	s.broker.DeleteTopic(ready)
+
+	// The remaining subscription still receives events from its other topics
+	require.NoError(s.broker.UpdateTopic(&api.Topic{Id: archived.Bytes(), Status: api.TopicState_READY}))
+	require.True(publish(archived).IsAck())
+
+	event := <-multi
+	require.Equal(archived.Bytes(), event.TopicId)
+}
+
+func (s *brokerTestSuite) TestAddTopic() {
+	s.broker.Run(s.echan)
+	require := s.Require()
+
+	// Cannot add a topic to an unknown subscription
+	err := s.broker.AddTopic(rlid.Make(42), ulids.New())
+	require.ErrorIs(err, ErrUnknownID)
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	topicA, topicB := ulids.New(), ulids.New()
+	subID, events, err := s.broker.Subscribe(topicA)
+	require.NoError(err, "could not register subscriber")
+
+	// Events published to topics that are not subscribed to are not received; the
+	// second subscriber ensures the event has been handled before the topic is added.
+	_, sync, err := s.broker.Subscribe(topicB)
+	require.NoError(err, "could not register subscriber")
+
+	s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicB.Bytes()})
+	require.True((<-results).IsAck())
+	<-sync
+
+	// Once the topic is added, events published to it are received
+	require.NoError(s.broker.AddTopic(subID, topicB))
+	s.broker.Publish(pubID, &api.EventWrapper{TopicId: topicB.Bytes()})
+	require.True((<-results).IsAck())
+
+	event := <-events
+	require.Equal(topicB.Bytes(), event.TopicId)
+	require.Empty(events, "expected only the event published after the topic was added")
+}
+
+func (s *brokerTestSuite) TestCompression() {
+	require := s.Require()
+
+	topicID := ulids.New()
+	topic := &api.Topic{Id: topicID.Bytes(), Status: api.TopicState_READY, Compression: &api.Compression{Algorithm: api.Compression_GZIP}}
+
+	var (
+		mu     sync.Mutex
+		stored []*api.EventWrapper
+	)
+
+	db := &mock.Store{}
+	db.UseError(mock.UpdateOffset, nil)
+	db.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		event.LocalId = nil
+		stored = append(stored, proto.Clone(event).(*api.EventWrapper))
+		return nil
+	}
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		return proto.Clone(topic).(*api.Topic), nil
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+
+	s.broker = New(db, db)
+	s.broker.Run(s.echan)
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	_, events, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	data := &api.Event{Data: bytes.Repeat([]byte(`{"color":"blue","size":12}`), 64)}
+	publish := func() (*api.EventWrapper, *api.EventWrapper) {
+		event := &api.EventWrapper{TopicId: topicID.Bytes(), LocalId: ulids.New().Bytes()}
+		require.NoError(event.Wrap(data), "could not wrap event")
+
+		s.broker.Publish(pubID, event)
+		require.True((<-results).IsAck(), "expected event to be committed")
+
+		mu.Lock()
+		defer mu.Unlock()
+		return <-events, stored[len(stored)-1]
+	}
+
+	// Events are compressed on disk but subscribers receive uncompressed events
+	received, compressed := publish()
+	require.Nil(received.StorageCompression, "expected subscribers to receive an uncompressed event")
+	require.Nil(received.LocalId, "expected the local id to be removed")
+	require.Nil(compressed.Compression, "expected the publisher compression to be unchanged")
+	require.Equal(api.Compression_GZIP, compressed.StorageCompression.GetAlgorithm())
+	require.Equal(received.Id, compressed.Id)
+	require.Less(len(compressed.Event), len(received.Event))
+
+	unwrapped, err := compressed.Unwrap()
+	require.NoError(err, "could not unwrap compressed event")
+	require.True(data.Equals(unwrapped))
+
+	// Changing the compression policy applies to the next event committed
+	topic.Compression = nil
+	require.NoError(s.broker.UpdateTopic(topic))
+
+	received, uncompressed := publish()
+	require.Nil(uncompressed.StorageCompression, "expected the event to be stored uncompressed")
+	require.Equal(received.Event, uncompressed.Event)
+
+	// Events compressed by the publisher are not compressed again
+	topic.Compression = &api.Compression{Algorithm: api.Compression_DEFLATE}
+	require.NoError(s.broker.UpdateTopic(topic))
+
+	event := &api.EventWrapper{TopicId: topicID.Bytes()}
+	require.NoError(event.Wrap(data), "could not wrap event")
+	event.Compression = &api.Compression{Algorithm: api.Compression_GZIP}
+	event.Event, err = event.Compression.Compress(event.Event)
+	require.NoError(err, "could not compress event")
+
+	s.broker.Publish(pubID, event)
+	require.True((<-results).IsAck(), "expected event to be committed")
+	received = <-events
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(api.Compression_GZIP, stored[len(stored)-1].Compression.GetAlgorithm())
+	require.Nil(stored[len(stored)-1].StorageCompression)
+	require.Equal(received.Event, stored[len(stored)-1].Event)
+}
+
+func (s *brokerTestSuite) TestEncryption() {
+	require := s.Require()
+
+	topicID := ulids.New()
+	topic := &api.Topic{Id: topicID.Bytes(), Status: api.TopicState_READY, Encryption: &api.EncryptionPolicy{Enabled: true}}
+
+	var (
+		mu     sync.Mutex
+		stored []*api.EventWrapper
+	)
+
+	db := &mock.Store{}
+	db.UseError(mock.UpdateOffset, nil)
+	db.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		event.LocalId = nil
+		stored = append(stored, proto.Clone(event).(*api.EventWrapper))
+		return nil
+	}
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		return proto.Clone(topic).(*api.Topic), nil
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+
+	s.broker = New(db, db)
+	s.broker.UseSealer(reverseSealer{})
+	s.broker.Run(s.echan)
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	_, events, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	data := &api.Event{Data: []byte("the eagle flies at midnight")}
+	event := &api.EventWrapper{TopicId: topicID.Bytes(), LocalId: ulids.New().Bytes()}
+	require.NoError(event.Wrap(data), "could not wrap event")
+	plaintext := event.Event
+
+	s.broker.Publish(pubID, event)
+	require.True((<-results).IsAck(), "expected event to be committed")
+	received := <-events
+
+	// Events are sealed on disk but subscribers receive the plaintext event
+	mu.Lock()
+	sealed := stored[len(stored)-1]
+	mu.Unlock()
+
+	require.Nil(received.Encryption, "expected subscribers to receive a plaintext event")
+	require.Nil(received.LocalId, "expected the local id to be removed")
+	require.Equal(plaintext, received.Event)
+	require.Nil(sealed.Encryption, "expected the publisher encryption to be unchanged")
+	require.Equal("reverse", sealed.StorageEncryption.GetPublicKeyId())
+	require.NotEqual(plaintext, sealed.Event)
+	require.Equal(received.Id, sealed.Id)
+
+	// Events encrypted by the publisher are not sealed again
+	e2e := &api.EventWrapper{TopicId: topicID.Bytes(), Event: []byte("ciphertext")}
+	e2e.Encryption = &api.Encryption{EncryptionAlgorithm: api.Encryption_AES256_GCM, SealingAlgorithm: api.Encryption_RSA_OAEP_SHA512}
+	s.broker.Publish(pubID, e2e)
+	require.True((<-results).IsAck(), "expected event to be committed")
+	<-events
+
+	mu.Lock()
+	require.Equal([]byte("ciphertext"), stored[len(stored)-1].Event)
+	require.Nil(stored[len(stored)-1].StorageEncryption)
+	mu.Unlock()
+
+	// Events in encrypted topics are rejected if the broker cannot seal them
+	require.NoError(s.broker.Shutdown(), "could not shutdown broker")
+	s.broker = New(db, db)
+	s.broker.Run(s.echan)
+
+	pubID, results, err = s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	event = &api.EventWrapper{TopicId: topicID.Bytes()}
+	require.NoError(event.Wrap(data), "could not wrap event")
+	s.broker.Publish(pubID, event)
+
+	result := <-results
+	require.True(result.IsNack(), "expected the event to be rejected")
+	require.Equal(api.Nack_INTERNAL, result.Code)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(stored, 2, "expected the plaintext event not to be stored")
+}
+
+// A test sealer that "encrypts" events by reversing the event bytes.
+type reverseSealer struct{}
+
+func (reverseSealer) Seal(event *api.EventWrapper) error {
+	sealed := make([]byte, len(event.Event))
+	for i, b := range event.Event {
+		sealed[len(sealed)-1-i] = b
+	}
+
+	event.Event = sealed
+	event.StorageEncryption = &api.Encryption{PublicKeyId: "reverse", EncryptionAlgorithm: api.Encryption_AES256_GCM}
+	return nil
+}
+
+func (s *brokerTestSuite) TestConsensus() {
+	require := s.Require()
+
+	topicID := ulids.New()
+	topic := &api.Topic{Id: topicID.Bytes()}
+
+	var (
+		mu     sync.Mutex
+		stored []*api.EventWrapper
+	)
+
+	db := &mock.Store{}
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return proto.Clone(topic).(*api.Topic), nil
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		mu.Lock()
+		defer mu.Unlock()
+		return mock.NewEventIterator(append([]*api.EventWrapper(nil), stored...))
+	}
+	db.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		event.LocalId = nil
+		stored = append(stored, proto.Clone(event).(*api.EventWrapper))
+		return nil
+	}
+	db.OnUpdateOffset = func(_ ulid.ULID, offset uint64, eventID rlid.RLID) error {
+		mu.Lock()
+		defer mu.Unlock()
+		topic.Offset = offset
+		topic.OffsetId = eventID.Bytes()
+		return nil
+	}
+
+	var applied uint64
+	db.OnAppliedIndex = func() (uint64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return applied, nil
+	}
+	db.OnUpdateAppliedIndex = func(index uint64, _ bool) error {
+		mu.Lock()
+		defer mu.Unlock()
+		applied = index
+		return nil
+	}
+
+	s.broker = New(db, db)
+	s.broker.UseOffsetCheckpoint(5)
+	quorum := &quorum{broker: s.broker}
+	s.broker.UseConsensus(quorum, 50*time.Millisecond)
+	s.broker.Run(s.echan)
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	_, events, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	publish := func() PublishResult {
+		event := &api.EventWrapper{TopicId: topicID.Bytes(), LocalId: ulids.New().Bytes(), Event: []byte("event")}
+		require.NoError(s.broker.Publish(pubID, event))
+		return <-results
+	}
+
+	// Events are written by the state machine when the batch is committed, then they
+	// are acked and sent to subscribers in the order they were published.
+	for i := 0; i < 10; i++ {
+		event := &api.EventWrapper{TopicId: topicID.Bytes(), LocalId: ulids.New().Bytes(), Event: []byte("event")}
+		require.NoError(s.broker.Publish(pubID, event))
+	}
+
+	for i := uint64(1); i <= 10; i++ {
+		result := <-results
+		require.True(result.IsAck(), "expected event to be committed")
+		require.NotNil(result.Committed)
+
+		event := <-events
+		require.Equal(i, event.Offset)
+		require.Nil(event.LocalId, "expected the local id to be removed")
+	}
+
+	mu.Lock()
+	require.Len(stored, 10)
+	require.Equal(uint64(10), topic.Offset)
+	require.Equal(quorum.last().Index, applied, "expected the applied index to be updated")
+	mu.Unlock()
+	require.LessOrEqual(quorum.proposals.Load(), uint64(10), "expected events to be proposed in batches")
+
+	// If the batch is not committed before the timeout the events are nacked and are
+	// not written or sent to subscribers.
+	quorum.unavailable.Store(true)
+	result := publish()
+	require.True(result.IsNack(), "expected event to be rejected")
+	require.Equal(api.Nack_CONSENSUS_FAILURE, result.Code)
+	require.Empty(events, "expected no events to be sent to subscribers")
+
+	mu.Lock()
+	require.Len(stored, 10, "expected the event not to be written")
+	mu.Unlock()
+
+	// The batch may still be committed after the timeout, so its offset is not reused
+	// until the batch is dropped from the log.
+	require.NoError(s.broker.DropEntry(quorum.last()), "could not drop entry")
+
+	// Proposals rejected by the quorum, e.g. because the node is not the leader, are
+	// also nacked; the offsets reserved for the batch are reused.
+	quorum.unavailable.Store(false)
+	quorum.reject(errors.New("replica is not the leader of the quorum"))
+	result = publish()
+	require.True(result.IsNack(), "expected event to be rejected")
+	require.Equal(api.Nack_CONSENSUS_FAILURE, result.Code)
+
+	quorum.reject(nil)
+	result = publish()
+	require.True(result.IsAck(), "expected event to be committed")
+	require.Equal(uint64(11), (<-events).Offset)
+
+	mu.Lock()
+	require.Len(stored, 11)
+	require.Equal(uint64(11), stored[10].Offset)
+	mu.Unlock()
+
+	// Events that cannot be written when the batch is committed are nacked and are not
+	// sent to subscribers, even though the batch was committed by the quorum.
+	db.UseError(mock.Insert, errors.New("disk is full"))
+	result = publish()
+	require.True(result.IsNack(), "expected event to be rejected")
+	require.Equal(api.Nack_INTERNAL, result.Code)
+	require.Empty(events, "expected no events to be sent to subscribers")
+
+	// The broker ignores log entries that are not batches of events
+	require.NoError(s.broker.CommitEntry(&raft.LogEntry{Index: 42, Key: []byte("other"), Value: []byte("not a batch")}))
+}
+
+func (s *brokerTestSuite) TestConsensusApplied() {
+	require := s.Require()
+
+	topicID := ulids.New()
+	applied, synced := uint64(5), false
+	var stored []*api.EventWrapper
+
+	db := &mock.Store{}
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		return &api.Topic{Id: topicID.Bytes()}, nil
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+	db.OnInsert = func(event *api.EventWrapper) error {
+		stored = append(stored, event)
+		return nil
+	}
+	db.OnAppliedIndex = func() (uint64, error) {
+		return applied, nil
+	}
+	db.OnUpdateAppliedIndex = func(index uint64, sync bool) error {
+		applied, synced = index, sync
+		return nil
+	}
+
+	broker := New(db, db)
+	entry := func(index uint64) *raft.LogEntry {
+		batch := &api.EventBatch{
+			Writes: []*api.EventWrite{
+				{Event: &api.EventWrapper{Id: rlid.Make(uint32(index)).Bytes(), TopicId: topicID.Bytes(), Offset: index}},
+			},
+		}
+
+		value, err := proto.Marshal(batch)
+		require.NoError(err, "could not marshal batch")
+		return &raft.LogEntry{Index: index, Term: 1, Key: []byte("events"), Value: value}
+	}
+
+	// Entries at or below the applied index are already in the event store, e.g. when
+	// the log is replayed on restart, so they are not written again.
+	for i := uint64(1); i <= 5; i++ {
+		require.NoError(broker.CommitEntry(entry(i)))
+	}
+	require.Empty(stored, "expected applied entries to be skipped")
+
+	require.NoError(broker.CommitEntry(entry(6)))
+	require.Len(stored, 1, "expected the entry to be written")
+	require.Equal(uint64(6), applied)
+	require.False(synced, "expected the applied index not to be synced on every entry")
+
+	// The applied index is synced when the log is snapshotted
+	data, err := broker.Snapshot()
+	require.NoError(err, "could not snapshot the broker")
+	require.True(synced, "expected the applied index to be synced")
+	require.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 6}, data)
+
+	// Snapshots at or below the applied index are already in the event store but the
+	// events of snapshots ahead of the applied index cannot be restored.
+	require.NoError(broker.Restore(data))
+	require.NoError(broker.Restore([]byte{0, 0, 0, 0, 0, 0, 0, 2}))
+	require.ErrorIs(broker.Restore([]byte{0, 0, 0, 0, 0, 0, 0, 7}), ErrSnapshotAhead)
+	require.ErrorIs(broker.Restore([]byte("foo")), ErrInvalidSnapshot)
+
+	// If the applied index cannot be loaded the entry is not applied
+	db.UseError(mock.AppliedIndex, errors.New("could not read index"))
+	broker = New(db, db)
+	require.Error(broker.CommitEntry(entry(7)))
+	require.Len(stored, 1, "expected the entry not to be written")
+}
+
+func (s *brokerTestSuite) TestConsensusDeduplication() {
+	require := s.Require()
+
+	topic := &api.Topic{
+		Id:            ulids.New().Bytes(),
+		Status:        api.TopicState_READY,
+		Deduplication: &api.Deduplication{Strategy: api.Deduplication_STRICT},
+	}
+	topicID, _ := topic.ParseTopicID()
+
+	var mu sync.Mutex
+	db := &mock.Store{}
+	stored := make(map[rlid.RLID]*api.EventWrapper)
+	hashes := make(map[string]rlid.RLID)
+
+	db.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) {
+		return proto.Clone(topic).(*api.Topic), nil
+	}
+	db.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) {
+		return &api.TopicInfo{TopicId: topic.Id}, nil
+	}
+	db.OnLoadIndash = func(ulid.ULID) iterator.IndashIterator {
+		return mock.NewIndashIterator(nil)
+	}
+	db.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		stored[rlid.RLID(event.Id)] = event
+		return nil
+	}
+	db.OnIndash = func(_ ulid.ULID, hash []byte, eventID rlid.RLID) error {
+		mu.Lock()
+		defer mu.Unlock()
+		hashes[string(hash)] = eventID
+		return nil
+	}
+	db.OnUnhash = func(_ ulid.ULID, hash []byte) (*api.EventWrapper, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if eventID, ok := hashes[string(hash)]; ok {
+			return stored[eventID], nil
+		}
+		return nil, storeerrors.ErrNotFound
+	}
+	db.OnList = func(ulid.ULID) iterator.EventIterator {
+		return mock.NewEventIterator(nil)
+	}
+	db.UseError(mock.UpdateOffset, nil)
+	db.UseError(mock.AppliedIndex, nil)
+	db.UseError(mock.UpdateAppliedIndex, nil)
+
+	s.broker = New(db, db)
+	s.broker.UseConsensus(&quorum{broker: s.broker}, time.Second)
+	s.broker.Run(s.echan)
+
+	_, events, err := s.broker.Subscribe(topicID)
+	require.NoError(err, "could not register subscriber")
+
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	// Duplicates published together are detected even if they would be in the same
+	// batch, since the duplicate is only checked once the original is committed.
+	original := &api.EventWrapper{TopicId: topic.Id}
+	require.NoError(original.Wrap(&api.Event{Data: []byte("revenue:42"), Type: &api.Type{Name: "Revenue", MajorVersion: 1}}))
+	for i := 0; i < 3; i++ {
+		require.NoError(s.broker.Publish(pubID, proto.Clone(original).(*api.EventWrapper)))
+	}
+
+	for i := 0; i < 3; i++ {
+		require.True((<-results).IsAck(), "expected the event to be committed")
+	}
+
+	first := <-events
+	require.False(first.IsDuplicate)
+	for i := 0; i < 2; i++ {
+		event := <-events
+		require.True(event.IsDuplicate, "expected the subscriber to receive a duplicate")
+		require.Equal(first.Id, event.DuplicateId)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(stored, 3)
+	require.Len(hashes, 1, "expected only the hash of the original event to be indexed")
+}
+
+func (s *brokerTestSuite) TestConsensusStores() {
+	require := s.Require()
+
+	// Without consensus the local stores are modified directly
+	events, meta := s.broker.Stores()
+	require.Same(s.events, events)
+	require.Same(s.events, meta)
+
+	var topics, replicated []*api.Topic
+	db := &mock.Store{}
+	db.OnCreateTopic = func(topic *api.Topic) error {
+		topic.Created = timestamppb.Now()
+		topics = append(topics, topic)
+		return nil
+	}
+	db.OnGetOrCreateGroup = func(*api.ConsumerGroup) (bool, error) {
+		return true, nil
+	}
+	db.UseError(mock.UpdateTopic, nil)
+	db.UseError(mock.DeleteTopic, nil)
+	db.UseError(mock.UpdateGroup, storeerrors.ErrNotFound)
+	db.UseError(mock.Destroy, nil)
+	db.UseError(mock.AppliedIndex, nil)
+	db.UseError(mock.UpdateAppliedIndex, nil)
+
+	// Another node in the quorum that applies the committed changes
+	follower := &mock.Store{}
+	follower.OnCreateTopic = func(topic *api.Topic) error {
+		replicated = append(replicated, topic)
+		return nil
+	}
+	follower.UseError(mock.DeleteTopic, nil)
+	follower.UseError(mock.UpdateGroup, errors.New("disk is full"))
+	follower.UseError(mock.AppliedIndex, nil)
+	follower.UseError(mock.UpdateAppliedIndex, nil)
+	other := New(follower, follower)
+
+	s.broker = New(db, db)
+	quorum := &quorum{broker: s.broker}
+	s.broker.UseConsensus(quorum, time.Second)
+	events, meta = s.broker.Stores()
+
+	// Topics are created with the same ID on every node; the topic of the caller is
+	// the one that is created by the local store.
+	topic := &api.Topic{ProjectId: ulids.New().Bytes(), Name: "testing.replicated"}
+	require.NoError(meta.CreateTopic(topic), "could not create topic")
+	require.Len(topics, 1)
+	require.Same(topic, topics[0])
+	require.NotNil(topic.Created, "expected the topic of the caller to be updated")
+	require.Equal(1, db.Calls(mock.CreateTopic))
+
+	require.NoError(other.CommitEntry(quorum.last()))
+	require.Len(replicated, 1)
+	require.Equal(topic.Id, replicated[0].Id)
+	require.Equal(topic.Name, replicated[0].Name)
+
+	// Updates to topics refresh the state of the topic in the broker
+	topicID, _ := topic.ParseTopicID()
+	topic.Status = api.TopicState_READONLY
+	require.NoError(meta.UpdateTopic(topic), "could not update topic")
+	require.Equal(1, db.Calls(mock.UpdateTopic))
+
+	s.broker.Run(s.echan)
+	pubID, results, err := s.broker.Register()
+	require.NoError(err, "could not register publisher")
+
+	require.NoError(s.broker.Publish(pubID, &api.EventWrapper{TopicId: topic.Id, LocalId: ulids.New().Bytes()}))
+	result := <-results
+	require.True(result.IsNack(), "expected the event to be rejected")
+	require.Equal(api.Nack_TOPIC_ARCHIVED, result.Code)
+
+	// Groups report whether they were created by the local store
+	group := &api.ConsumerGroup{ProjectId: topic.ProjectId, Name: "testing"}
+	created, err := meta.GetOrCreateGroup(group)
+	require.NoError(err, "could not get or create group")
+	require.True(created, "expected the group to be created")
+
+	// Errors applying the change locally are returned to the caller, but errors on the
+	// other nodes cannot reject the committed entry.
+	require.ErrorIs(meta.UpdateGroup(group), storeerrors.ErrNotFound)
+	require.NoError(other.CommitEntry(quorum.last()))
+	require.Equal(1, follower.Calls(mock.UpdateGroup))
+
+	// Changes that are not committed by the quorum are not applied
+	quorum.reject(errors.New("replica is not the leader of the quorum"))
+	require.EqualError(meta.DeleteTopic(topicID), "replica is not the leader of the quorum")
+	require.Equal(0, db.Calls(mock.DeleteTopic))
+
+	quorum.reject(nil)
+	require.NoError(events.Destroy(topicID), "could not destroy events")
+	require.Equal(1, db.Calls(mock.Destroy))
+
+	require.NoError(meta.DeleteTopic(topicID), "could not delete topic")
+	require.Equal(1, db.Calls(mock.DeleteTopic))
+	require.NoError(other.CommitEntry(quorum.last()))
+	require.Equal(1, follower.Calls(mock.DeleteTopic))
+
+	// Reads and other writes use the local stores
+	db.UseError(mock.UpdateOffset, nil)
+	require.NoError(meta.UpdateOffset(topicID, 42, rlid.Make(42)))
+	require.Equal(1, db.Calls(mock.UpdateOffset))
+	require.Equal(uint64(7), quorum.proposals.Load())
+}
+
+// A quorum of one that commits proposals by applying them to the broker immediately,
+// unless it is unavailable, in which case proposals wait until the context is done.
+type quorum struct {
+	broker      *Broker
+	proposals   atomic.Uint64
+	unavailable atomic.Bool
+	mu          sync.Mutex
+	err         error
+	entry       *raft.LogEntry
+}
+
+func (q *quorum) Propose(ctx context.Context, key, value []byte) (*raft.LogEntry, error) {
+	entry := &raft.LogEntry{Index: q.proposals.Add(1), Term: 1, Key: key, Value: value}
+	q.mu.Lock()
+	q.entry = entry
+	err := q.err
+	q.mu.Unlock()
+
+	if q.unavailable.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.broker.CommitEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Rejects proposals with the error until it is reset to nil.
+func (q *quorum) reject(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.err = err
+}
+
+// Returns the last entry proposed to the quorum.
+func (q *quorum) last() *raft.LogEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.entry
+}
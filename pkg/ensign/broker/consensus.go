@@ -0,0 +1,343 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	raft "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/log"
+	"github.com/rotationalio/ensign/pkg/utils/sentry"
+	"google.golang.org/protobuf/proto"
+)
+
+// The maximum number of events replicated in a single log entry. Batches are formed
+// from the events that are already queued when the previous batch is committed, so
+// batches are only large when the broker is busy.
+const maxBatchSize = 1024
+
+// The key of the log entries that batches of events are replicated with.
+var batchKey = []byte("events")
+
+// The broker is the state machine that committed batches of events are applied to.
+var _ log.StateMachine = &Broker{}
+
+// Consensus replicates commands to the quorum of Ensign nodes, e.g. a raft replica.
+// Propose must block until the command has been committed by the quorum and applied to
+// the local state machine or until the context is done.
+type Consensus interface {
+	Propose(ctx context.Context, key, value []byte) (*raft.LogEntry, error)
+}
+
+// UseConsensus replicates published events to the quorum before they are written.
+// Events are proposed in batches and are only written, acked, and sent to subscribers
+// once the batch is committed; if the batch is not committed before the timeout, the
+// events are nacked with a consensus failure. The broker must be the state machine of
+// the quorum so that committed events are written to disk on every node. This must be
+// called before the broker is run.
+//
+// Only the leader of the quorum accepts proposals so events published to other nodes
+// are nacked; subscribers only receive events that are published to the leader. Topics
+// and consumer groups must be modified through the stores returned by Stores so that
+// every node has the topics and group offsets of the events it stores.
+//
+// A batch that times out is nacked but may still be committed by the quorum later, in
+// which case its events are stored without being sent to subscribers, and the events
+// are stored again if the publisher retries them. Publishers should use deduplication
+// on topics where events must not be stored twice.
+func (b *Broker) UseConsensus(quorum Consensus, timeout time.Duration) {
+	b.quorum = quorum
+	b.timeout = timeout
+}
+
+// A batch of events that have been prepared and are waiting to be proposed.
+type batch struct {
+	events  []incoming
+	results []PublishResult
+	writes  *api.EventBatch
+	hashes  map[string]struct{}
+	topics  map[ulid.ULID]struct{}
+}
+
+func newBatch() *batch {
+	return &batch{
+		writes: &api.EventBatch{},
+		hashes: make(map[string]struct{}),
+		topics: make(map[ulid.ULID]struct{}),
+	}
+}
+
+// Replicate batches of incoming events through consensus until the input queue is
+// closed. Replaces the direct writes of the incoming routine when consensus is used.
+func (b *Broker) replicate(inQ <-chan incoming, outQ chan<- *api.EventWrapper) {
+	for in := range inQ {
+		pending := newBatch()
+		pending = b.add(pending, in, outQ)
+
+	drain:
+		for len(pending.events) < maxBatchSize {
+			select {
+			case in, ok := <-inQ:
+				if !ok {
+					break drain
+				}
+				pending = b.add(pending, in, outQ)
+			default:
+				break drain
+			}
+		}
+
+		b.propose(pending, outQ)
+	}
+}
+
+// Prepare the event and add it to the batch, returning the batch to continue adding
+// events to. Duplicates can only be detected once the original event is committed, so
+// if the event's hash matches an event in the batch, the batch is proposed first and
+// the event is checked again in a new batch.
+func (b *Broker) add(pending *batch, in incoming, outQ chan<- *api.EventWrapper) *batch {
+	b.backpressure(in.event)
+	localID := in.event.LocalId
+	write, result := b.prepare(in)
+	if result.IsNack() {
+		b.result(in, result)
+		return pending
+	}
+
+	if _, ok := pending.hashes[string(write.Hash)]; ok && write.Hash != nil {
+		b.propose(pending, outQ)
+
+		in.event.LocalId = localID
+		pending = newBatch()
+		if write, result = b.prepare(in); result.IsNack() {
+			b.result(in, result)
+			return pending
+		}
+	}
+
+	// The event is replicated without the localID, which is only used by the publisher
+	write.Event.LocalId = nil
+	in.event.LocalId = nil
+
+	// Advance the sequence of the topic so the next event in the batch is not assigned
+	// the same offset before the batch is committed.
+	topicID, _ := write.Event.ParseTopicID()
+	b.seq.reserve(topicID, rlid.RLID(write.Event.Id), write.Event.Offset)
+
+	pending.events = append(pending.events, in)
+	pending.results = append(pending.results, result)
+	pending.writes.Writes = append(pending.writes.Writes, write)
+	pending.topics[topicID] = struct{}{}
+	if write.Hash != nil {
+		pending.hashes[string(write.Hash)] = struct{}{}
+	}
+	return pending
+}
+
+// Propose the batch to the quorum and wait for it to be committed. Once committed the
+// events have been written by the state machine so they are sent to subscribers and
+// acked; otherwise the events are nacked so that the publisher can retry them. Events
+// that could not be written when the batch was committed are nacked as internal errors.
+func (b *Broker) propose(pending *batch, outQ chan<- *api.EventWrapper) {
+	if len(pending.events) == 0 {
+		return
+	}
+
+	// Track whether the events of the batch are written when it is applied locally
+	written := make(map[rlid.RLID]bool, len(pending.events))
+	for _, write := range pending.writes.Writes {
+		written[rlid.RLID(write.Event.Id)] = true
+	}
+
+	b.propmu.Lock()
+	b.written = written
+	b.propmu.Unlock()
+
+	value, err := proto.Marshal(pending.writes)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+		_, err = b.quorum.Propose(ctx, batchKey, value)
+		cancel()
+	}
+
+	b.propmu.Lock()
+	b.written = nil
+	b.propmu.Unlock()
+
+	if err != nil {
+		sentry.Warn(nil).Err(err).Int("events", len(pending.events)).Msg("could not commit events through consensus")
+
+		// If the batch was not proposed or was dropped, the sequence reserved for the
+		// batch is discarded. A batch that timed out may still be committed later so
+		// its sequence is kept; it is discarded if the batch is dropped instead.
+		if !errors.Is(err, context.DeadlineExceeded) {
+			for topicID := range pending.topics {
+				b.seq.reset(topicID)
+			}
+		}
+
+		for i, in := range pending.events {
+			result := pending.results[i]
+			result.Code = api.Nack_CONSENSUS_FAILURE
+			b.result(in, result)
+		}
+		return
+	}
+
+	for i, in := range pending.events {
+		result := pending.results[i]
+		if !written[rlid.RLID(pending.writes.Writes[i].Event.Id)] {
+			result.Code = api.Nack_INTERNAL
+			b.result(in, result)
+			continue
+		}
+		b.commit(in, result, outQ)
+	}
+}
+
+// CommitEntry writes the events of a committed batch to the event store, which happens
+// on every node in the quorum, including the leader that proposed the batch. Events
+// that cannot be written locally are nacked if this node proposed the batch; they are
+// still stored by the rest of the quorum. Committed changes to topics and consumer
+// groups are applied to the stores of every node in the same way. Entries at or below
+// the applied index have already been applied, e.g. when the log is replayed on
+// restart, so they are skipped rather than restoring events that have since been
+// expired or rewritten. Entries that are not batches of events or changes are ignored.
+func (b *Broker) CommitEntry(entry *raft.LogEntry) (err error) {
+	if !bytes.Equal(entry.Key, batchKey) && !bytes.Equal(entry.Key, changeKey) {
+		return nil
+	}
+
+	var applied uint64
+	if applied, err = b.applied.load(); err != nil {
+		return err
+	}
+
+	if entry.Index <= applied {
+		return nil
+	}
+
+	if bytes.Equal(entry.Key, changeKey) {
+		cmd := &api.StoreChange{}
+		if err = proto.Unmarshal(entry.Value, cmd); err != nil {
+			return err
+		}
+		b.applyChange(cmd)
+	} else {
+		events := &api.EventBatch{}
+		if err = proto.Unmarshal(entry.Value, events); err != nil {
+			return err
+		}
+
+		for _, write := range events.Writes {
+			if err := b.write(write); err != nil {
+				b.failed(rlid.RLID(write.Event.Id))
+			}
+		}
+	}
+
+	// The applied index is synced to disk when the log is snapshotted; if it is lost
+	// on a crash the entries since the last snapshot are written again.
+	if err := b.applied.update(entry.Index, false); err != nil {
+		sentry.Error(nil).Err(err).Msgf("could not update applied index to %d", entry.Index)
+	}
+	return nil
+}
+
+// Record that an event of the batch being proposed by this node could not be written.
+func (b *Broker) failed(eventID rlid.RLID) {
+	b.propmu.Lock()
+	defer b.propmu.Unlock()
+	if _, ok := b.written[eventID]; ok {
+		b.written[eventID] = false
+	}
+}
+
+// DropEntry discards the sequence of the topics in a batch that was dropped from the
+// log before it was committed so that the offsets reserved for the batch are reused.
+func (b *Broker) DropEntry(entry *raft.LogEntry) (err error) {
+	if !bytes.Equal(entry.Key, batchKey) {
+		return nil
+	}
+
+	events := &api.EventBatch{}
+	if err = proto.Unmarshal(entry.Value, events); err != nil {
+		return err
+	}
+
+	for _, write := range events.Writes {
+		topicID, _ := write.Event.ParseTopicID()
+		b.seq.reset(topicID)
+	}
+	return nil
+}
+
+// Snapshot syncs the applied index to disk and returns it as the snapshot data. The
+// state of the broker is the event and meta stores, which contain the events and
+// changes of every entry at or below the applied index, so the log can be compacted up
+// to the snapshot.
+func (b *Broker) Snapshot() (_ []byte, err error) {
+	var applied uint64
+	if applied, err = b.applied.load(); err != nil {
+		return nil, err
+	}
+
+	if err = b.applied.update(applied, true); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, applied)
+	return data, nil
+}
+
+// Restore checks that the event store already contains the events of the entries in
+// the snapshot, which is the case when the log is loaded from disk. Events cannot be
+// restored from a snapshot sent by the leader, so a node whose event store is behind
+// the snapshot of the quorum must be seeded with the event store of another node.
+func (b *Broker) Restore(data []byte) (err error) {
+	if len(data) != 8 {
+		return ErrInvalidSnapshot
+	}
+
+	var applied uint64
+	if applied, err = b.applied.load(); err != nil {
+		return err
+	}
+
+	if applied < binary.BigEndian.Uint64(data) {
+		return ErrSnapshotAhead
+	}
+	return nil
+}
+
+// The index of the last log entry that was applied to the event store, which is stored
+// in the meta store. The index is loaded when it is first needed since the log may be
+// loaded before the broker is configured to use consensus. The index is only accessed
+// by the state machine methods, which are called sequentially by the log.
+type appliedIndex struct {
+	meta   store.ConsensusStore
+	index  uint64
+	loaded bool
+}
+
+func (a *appliedIndex) load() (_ uint64, err error) {
+	if !a.loaded {
+		if a.index, err = a.meta.AppliedIndex(); err != nil {
+			return 0, err
+		}
+		a.loaded = true
+	}
+	return a.index, nil
+}
+
+func (a *appliedIndex) update(index uint64, sync bool) error {
+	a.index = index
+	return a.meta.UpdateAppliedIndex(index, sync)
+}
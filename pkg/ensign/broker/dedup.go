@@ -0,0 +1,215 @@
+package broker
+
+import (
+	"sync"
+
+	"github.com/bits-and-blooms/bloom/v3"
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	filterFPRate       = 0.01
+	filterMinSize uint = 10000
+)
+
+// TopicFilter loads a bloom filter with all of the event hashes for the events in the
+// specified topic. The TopicInfo for the event is read to determine how many events are
+// in the topic. The bloom filter is constructed as the larger of either 10k events or
+// twice the number of events in the topic and with a false positive rate of 1%. The
+// filter can be tested and modified as needed to detect duplicates.
+func TopicFilter(events store.EventHashStore, meta store.TopicInfoStore, topicID ulid.ULID) (_ *bloom.BloomFilter, err error) {
+	// Load the topic info to determine the bloom filter size.
+	var info *api.TopicInfo
+	if info, err = meta.TopicInfo(topicID); err != nil {
+		return nil, err
+	}
+	return loadFilter(events, topicID, info.Events)
+}
+
+// Creates a bloom filter sized for the number of events in the topic and adds all of
+// the event hashes in the topic to it.
+func loadFilter(events store.EventHashStore, topicID ulid.ULID, nEvents uint64) (_ *bloom.BloomFilter, err error) {
+	// The filter size should be the larger of twice the number of events in the topic
+	// or the minimum filter size (10k hashes by default).
+	filterSize := filterMinSize
+	if uint(nEvents*2) > filterSize {
+		filterSize = uint(nEvents * 2)
+	}
+
+	// Create the bloom filter with index hashes from the database.
+	filter := bloom.NewWithEstimates(filterSize, filterFPRate)
+	iter := events.LoadIndash(topicID)
+	defer iter.Release()
+
+	for iter.Next() {
+		var hash []byte
+		if hash, err = iter.Hash(); err != nil {
+			// NOTE: we are not skipping bad hashes because this would make it possible
+			// to miss duplicates -- however, it could be possible to relax this.
+			return nil, err
+		}
+		filter.Add(hash)
+	}
+
+	if err = iter.Error(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// The deduplicator detects duplicate events as they are published so that duplicates
+// are stored as references to the original event rather than being written in full.
+// The deduplication policy and bloom filter of a topic are loaded when the first event
+// is published to the topic and are kept until the topic is reset, e.g. because its
+// deduplication policy has changed. Candidates identified by the bloom filter are
+// confirmed by comparing the event with the original event in the event store.
+type deduplicator struct {
+	sync.Mutex
+	events store.EventStore
+	meta   store.MetaStore
+	topics map[ulid.ULID]*topicFilter
+}
+
+// The deduplication policy of a topic and the bloom filter of its event hashes. If the
+// topic is not deduplicated then the filter is nil.
+type topicFilter struct {
+	policy *api.Deduplication
+	filter *bloom.BloomFilter
+}
+
+func newDeduplicator(events store.EventStore, meta store.MetaStore) *deduplicator {
+	return &deduplicator{
+		events: events,
+		meta:   meta,
+		topics: make(map[ulid.ULID]*topicFilter),
+	}
+}
+
+// The outcome of checking a published event against the deduplication policy.
+type duplicate struct {
+	hash []byte            // the hash to index to the stored event, if any
+	ref  *api.EventWrapper // stored in place of the event if it duplicates an earlier event
+	prev *api.EventWrapper // the earlier event, rewritten as a reference to the event
+}
+
+// Check the event against the deduplication policy of its topic. If the event is a
+// duplicate of an event that has already been committed then with the earliest offset
+// policy a reference to the original is returned, which should be stored in place of
+// the event, and the event is marked as a duplicate for subscribers. With the latest
+// offset policy the event is stored as the original and the earlier event is returned
+// as a reference to the event, which should be stored in place of the earlier event.
+// The hash to index to the stored event is also returned if the topic is deduplicated.
+// If an error is returned the event could not be checked but it can still be committed
+// as an original event. The event ID must be assigned before the event is checked.
+func (d *deduplicator) check(topicID ulid.ULID, event *api.EventWrapper) (dup duplicate, err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	tf, ok := d.topics[topicID]
+	if !ok {
+		if tf, err = d.load(topicID); err != nil {
+			return dup, err
+		}
+		d.topics[topicID] = tf
+	}
+
+	if tf.filter == nil {
+		return dup, nil
+	}
+
+	var hash []byte
+	if hash, err = event.Hash(tf.policy); err != nil {
+		return dup, err
+	}
+
+	// If the hash is not in the filter then the event is definitely not a duplicate.
+	if !tf.filter.TestOrAdd(hash) {
+		return duplicate{hash: hash}, nil
+	}
+
+	var target *api.EventWrapper
+	if target, err = d.events.Unhash(topicID, hash); err != nil {
+		// A false positive from the bloom filter
+		if errors.Is(err, errors.ErrNotFound) {
+			return duplicate{hash: hash}, nil
+		}
+		return dup, err
+	}
+
+	var isDuplicate bool
+	if isDuplicate, err = event.Duplicates(target, tf.policy); err != nil {
+		return dup, err
+	}
+
+	// If the hashes collide but the events are not duplicates, the hash is indexed to
+	// the most recent event as it is when the topic is rehashed.
+	if !isDuplicate {
+		return duplicate{hash: hash}, nil
+	}
+
+	// The latest event is the original so the earlier event becomes a reference to it
+	// and the hash is indexed to the latest event.
+	if tf.policy.Offset == api.Deduplication_OFFSET_LATEST {
+		prev := proto.Clone(target).(*api.EventWrapper)
+		if err = prev.DuplicateOf(event, tf.policy); err != nil {
+			return dup, err
+		}
+		return duplicate{hash: hash, prev: prev}, nil
+	}
+
+	ref := proto.Clone(event).(*api.EventWrapper)
+	if err = ref.DuplicateOf(target, tf.policy); err != nil {
+		return dup, err
+	}
+
+	// Subscribers receive the event marked as a duplicate; if duplicates are
+	// overwritten they receive the original event as it will be read from the topic.
+	event.IsDuplicate = true
+	event.DuplicateId = ref.DuplicateId
+	if tf.policy.OverwriteDuplicate {
+		event.Event = target.Event
+		event.Encryption = target.Encryption
+		event.Compression = target.Compression
+	}
+	return duplicate{ref: ref}, nil
+}
+
+// Discard the policy and filter of the topic so that they are reloaded.
+func (d *deduplicator) reset(topicID ulid.ULID) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.topics, topicID)
+}
+
+// Load the deduplication policy of the topic and build the bloom filter of its event
+// hashes. Topics that are not ready (e.g. because they are being rehashed) are not
+// deduplicated until they are reset. Must be called while the deduplicator is locked.
+func (d *deduplicator) load(topicID ulid.ULID) (_ *topicFilter, err error) {
+	var topic *api.Topic
+	if topic, err = d.meta.RetrieveTopic(topicID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return &topicFilter{}, nil
+		}
+		return nil, err
+	}
+
+	tf := &topicFilter{policy: topic.Deduplication.Normalize()}
+	if tf.policy.Strategy == api.Deduplication_NONE || topic.Status != api.TopicState_READY {
+		return tf, nil
+	}
+
+	// The topic info may not exist yet if the topic was recently created.
+	var info *api.TopicInfo
+	if info, err = d.meta.TopicInfo(topicID); err != nil && !errors.Is(err, errors.ErrNotFound) {
+		return nil, err
+	}
+
+	if tf.filter, err = loadFilter(d.events, topicID, info.GetEvents()); err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrBrokerNotRunning = errors.New("operation could not be completed: broker is not running")
 	ErrUnknownID        = errors.New("no publisher or subscriber registered with specified id")
+	ErrNoGroup          = errors.New("a consumer group key is required to subscribe as a group member")
+	ErrNoSealer         = errors.New("topic is encrypted at rest but the broker cannot seal events")
+	ErrInvalidSnapshot  = errors.New("could not parse the applied index from the snapshot")
+	ErrSnapshotAhead    = errors.New("the event store is behind the snapshot of the quorum and must be seeded from another node")
+	ErrUnknownChange    = errors.New("unknown change to the stores in the replicated log entry")
 )
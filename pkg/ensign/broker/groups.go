@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/twmb/murmur3"
+)
+
+// Members are the subscriptions that share a consumer group. Each event is only sent to
+// one of the members whose topic filter matches the event. Events that have a key are
+// assigned to a member using rendezvous hashing so that all events with the same key
+// are handled by the same member; when a member leaves the group only the keys that
+// were assigned to it are moved to the remaining members. Events without a key are
+// distributed between the members in round robin order.
+type members struct {
+	subs       []member
+	next       uint64
+	candidates []member
+}
+
+// A member of a consumer group; the seed is derived from the subscriber ID and is used
+// to compute the rendezvous hash of event keys for the member.
+type member struct {
+	id   rlid.RLID
+	seed uint64
+}
+
+func (g *members) add(id rlid.RLID) {
+	g.subs = append(g.subs, member{id: id, seed: murmur3.Sum64(id[:])})
+}
+
+// remove the member from the group, returning the number of remaining members.
+func (g *members) remove(id rlid.RLID) int {
+	for i, m := range g.subs {
+		if m.id == id {
+			g.subs = append(g.subs[:i], g.subs[i+1:]...)
+			break
+		}
+	}
+	return len(g.subs)
+}
+
+// pick the subscription in the group that should receive the event. Members that have
+// spilled or are being disconnected are skipped so that the other members continue to
+// receive events. If no member of the group can receive the event then false is
+// returned. Not thread-safe; pick must only be called by the outgoing event handler
+// while the broker is read locked.
+func (g *members) pick(event *api.EventWrapper, topicID ulid.ULID, subs map[rlid.RLID]*subscription) (sub *subscription, ok bool) {
+	g.candidates = g.candidates[:0]
+	for _, m := range g.subs {
+		sub := subs[m.id]
+		if sub.spilling || sub.disconnected {
+			continue
+		}
+
+		if _, ok := sub.topics[topicID]; ok {
+			g.candidates = append(g.candidates, m)
+		}
+	}
+
+	if len(g.candidates) == 0 {
+		return nil, false
+	}
+
+	var pick member
+	if len(event.Key) > 0 {
+		var best uint64
+		for i, m := range g.candidates {
+			if score := murmur3.SeedSum64(m.seed, event.Key); i == 0 || score > best {
+				best = score
+				pick = m
+			}
+		}
+	} else {
+		pick = g.candidates[g.next%uint64(len(g.candidates))]
+		g.next++
+	}
+
+	return subs[pick.id], true
+}
@@ -1,8 +1,11 @@
 package broker
 
 import (
+	"sync"
+
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/o11y"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -78,9 +81,203 @@ type incoming struct {
 	event *api.EventWrapper
 }
 
+// Options configure how the broker sends events to a subscriber.
+type Options struct {
+	Group    []byte                    // The key of the consumer group the subscriber is a member of
+	Overflow api.Subscription_Overflow // How to handle events when the subscriber's queue is full
+}
+
+// Subscriber is returned when subscribing with options. Events are sent on the Events
+// channel as they are committed. If the subscriber's overflow policy is SPILL or
+// DISCONNECT, the ID of the first event that could not be sent because the queue was
+// full is sent on the Overflows channel.
+type Subscriber struct {
+	ID        rlid.RLID
+	Events    <-chan *api.EventWrapper
+	Overflows <-chan rlid.RLID
+}
+
 // A subscription includes the topic filter for events and the channel to send those
-// events on so that they can get back to the subscriber.
+// events on so that they can get back to the subscriber. If the subscription is part of
+// a consumer group, the group key is used to share events with the other members. The
+// spilling and disconnected flags are set by the outgoing handler when the queue
+// overflows and are guarded by the broker's submu. Subscriptions with the BLOCK policy
+// have a backlog that their own forwarder sends events from so that a slow subscriber
+// does not block the delivery of events to other subscribers.
 type subscription struct {
-	topics map[ulid.ULID]struct{}
-	out    chan<- *api.EventWrapper
+	topics       map[ulid.ULID]struct{}
+	group        string
+	policy       api.Subscription_Overflow
+	out          chan<- *api.EventWrapper
+	overflows    chan<- rlid.RLID
+	done         chan struct{}
+	backlog      *backlog
+	spilling     bool
+	disconnected bool
+}
+
+// Send the event to the subscriber, applying the subscriber's overflow policy if its
+// queue is full. Returns true if the event was sent on the subscriber's queue or added
+// to its backlog. Only the outgoing handler may call send and it must hold the broker's
+// submu read lock.
+func (sub *subscription) send(event *api.EventWrapper) (sent bool) {
+	// Spilled events are read by the subscriber from the event store and disconnected
+	// subscribers are waiting to be closed, so no events are queued for them.
+	if sub.spilling || sub.disconnected {
+		return false
+	}
+
+	// Blocking subscribers are sent every event by their forwarder until released.
+	if sub.backlog != nil {
+		select {
+		case <-sub.done:
+			return false
+		default:
+			sub.backlog.push(event)
+			return true
+		}
+	}
+
+	// Non-blocking send to prevent slow subscribers from interupting performance
+	select {
+	case sub.out <- event:
+		return true
+	default:
+	}
+
+	// Only events that the subscriber will never receive are counted as dropped; spilled
+	// events are read from the event store once the subscriber catches up.
+	if sub.policy != api.Subscription_SPILL && o11y.DroppedEvents != nil {
+		o11y.DroppedEvents.WithLabelValues(sub.policy.String()).Inc()
+	}
+
+	switch sub.policy {
+	case api.Subscription_SPILL:
+		sub.spilling = true
+	case api.Subscription_DISCONNECT:
+		sub.disconnected = true
+	default:
+		return false
+	}
+
+	// The overflow channel is only written to once per spill or disconnect so the
+	// subscriber is always notified with the first event that was not queued.
+	eventID, _ := event.ParseEventID()
+	select {
+	case sub.overflows <- eventID:
+	default:
+	}
+	return false
+}
+
+// Forwards events from the backlog to the subscriber's queue until the subscription is
+// released, then closes the queue. Blocking on the subscriber here rather than in the
+// outgoing handler means that other subscribers continue to receive events while the
+// subscriber catches up; publishers are only held back once the backlog is full.
+func (sub *subscription) forward() {
+	defer close(sub.out)
+	for {
+		event, ok := sub.backlog.pop()
+		if !ok {
+			select {
+			case <-sub.backlog.ready:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+
+		select {
+		case sub.out <- event:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// Closes the subscriber's queue. The queue of a subscription with a backlog is closed by
+// its forwarder once the subscription has been released.
+func (sub *subscription) close() {
+	if sub.backlog == nil {
+		close(sub.out)
+	}
+}
+
+// Returns true if the subscription has a full backlog and has not been released.
+func (sub *subscription) blocked() bool {
+	if sub.backlog == nil {
+		return false
+	}
+
+	select {
+	case <-sub.done:
+		return false
+	default:
+		return sub.backlog.full()
+	}
+}
+
+// Waits until the subscription's backlog has room or the subscription is released.
+func (sub *subscription) wait() {
+	select {
+	case <-sub.backlog.room:
+	case <-sub.done:
+	}
+}
+
+// The maximum number of events in the backlog of a blocking subscriber before the broker
+// applies backpressure to publishers when events are published to its topics.
+const BacklogSize = BufferSize
+
+// A backlog holds the events for a blocking subscriber that have not been sent on its
+// queue yet. The ready and room channels are signaled when events are added and removed.
+type backlog struct {
+	sync.Mutex
+	events []*api.EventWrapper
+	ready  chan struct{}
+	room   chan struct{}
+}
+
+func newBacklog() *backlog {
+	return &backlog{
+		ready: make(chan struct{}, 1),
+		room:  make(chan struct{}, 1),
+	}
+}
+
+func (q *backlog) push(event *api.EventWrapper) {
+	q.Lock()
+	q.events = append(q.events, event)
+	q.Unlock()
+	signal(q.ready)
+}
+
+func (q *backlog) pop() (event *api.EventWrapper, ok bool) {
+	q.Lock()
+	if len(q.events) == 0 {
+		q.Unlock()
+		return nil, false
+	}
+
+	event = q.events[0]
+	q.events[0] = nil
+	q.events = q.events[1:]
+	q.Unlock()
+
+	signal(q.room)
+	return event, true
+}
+
+func (q *backlog) full() bool {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.events) >= BacklogSize
+}
+
+// Non-blocking send on a signal channel with a buffer of one.
+func signal(c chan<- struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
 }
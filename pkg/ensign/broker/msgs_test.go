@@ -38,6 +38,7 @@ func TestPublishResultNack(t *testing.T) {
 		{api.Nack_TOPIC_UNKNOWN, "", api.CodeTopicUnknown},
 		{api.Nack_TOPIC_ARCHIVED, "", api.CodeTopicArchived},
 		{api.Nack_TOPIC_DELETED, "", api.CodeTopicDeleted},
+		{api.Nack_TOPIC_NOT_READY, "", api.CodeTopicNotReady},
 		{api.Nack_PERMISSION_DENIED, "", api.CodePermissionDenied},
 		{api.Nack_CONSENSUS_FAILURE, "", api.CodeConsensusFailure},
 		{api.Nack_SHARDING_FAILURE, "", api.CodeShardingFailure},
@@ -0,0 +1,197 @@
+package broker
+
+import (
+	"math"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+)
+
+// The sequencer assigns event IDs and offsets to events as they are committed. Every
+// topic has its own offset that starts at 1 and is incremented for every event that is
+// committed to the topic without gaps. Event IDs are strictly increasing within a topic
+// and are generated from a node-wide sequence so that they are also unique across the
+// topics on the node, which consumer groups rely on to acknowledge events by ID alone.
+//
+// The offset and the ID of the last event of the topic are persisted to the meta store
+// every checkpoint events rather than for every event, since the event log is the
+// authority for the sequence. When the first event is published to a topic after the
+// node starts, the sequence is recovered from the meta store and any events in the
+// event log after the persisted offset, so that offsets are not reused and event IDs
+// cannot collide with events committed before a restart even if the clock has moved
+// backward; at most checkpoint events are read from the log to recover the sequence.
+//
+// When events are replicated through consensus, the incoming routine reserves the
+// sequence of a batch of events before the batch is proposed and the sequence is
+// committed when the batch is applied by the state machine, so the sequencer is locked.
+type sequencer struct {
+	sync.Mutex
+	events     store.EventStore
+	meta       store.MetaStore
+	seq        rlid.Sequence
+	topics     map[ulid.ULID]*topicSequence
+	checkpoint uint64
+}
+
+// The number of events committed to a topic between writes of its offset to the meta store.
+const OffsetCheckpoint = 1024
+
+// The offset and ID of the last event committed to the topic and the offset that was
+// last persisted to the meta store.
+type topicSequence struct {
+	offset    uint64
+	last      rlid.RLID
+	persisted uint64
+}
+
+func newSequencer(events store.EventStore, meta store.MetaStore) *sequencer {
+	return &sequencer{
+		events:     events,
+		meta:       meta,
+		topics:     make(map[ulid.ULID]*topicSequence),
+		checkpoint: OffsetCheckpoint,
+	}
+}
+
+// Next returns the ID and offset for the next event in the topic. The sequence of the
+// topic is not advanced until the event is committed so that no gaps are introduced if
+// the event cannot be written.
+func (s *sequencer) next(topicID ulid.ULID) (eventID rlid.RLID, offset uint64, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	ts, ok := s.topics[topicID]
+	if !ok {
+		if ts, err = s.load(topicID); err != nil {
+			return eventID, 0, err
+		}
+		s.topics[topicID] = ts
+	}
+
+	if eventID = s.seq.Next(); eventID.Compare(ts.last) <= 0 {
+		eventID = successor(ts.last)
+	}
+	return eventID, ts.offset + 1, nil
+}
+
+// Commit advances the sequence of the topic once the event has been written and
+// persists the offset of the topic if a checkpoint has been reached. If the offset
+// cannot be persisted then it will be recovered from the event log when the node
+// restarts. Nodes that only apply replicated events do not sequence events, so the
+// sequence of the topic is loaded by the first commit to the topic.
+func (s *sequencer) commit(topicID ulid.ULID, eventID rlid.RLID, offset uint64) (err error) {
+	s.Lock()
+	ts, ok := s.topics[topicID]
+	if !ok {
+		if ts, err = s.load(topicID); err != nil {
+			s.Unlock()
+			return err
+		}
+		s.topics[topicID] = ts
+	}
+
+	if offset > ts.offset {
+		ts.offset = offset
+		ts.last = eventID
+	}
+
+	// The committed offset is persisted rather than the offset of the topic, which may
+	// have been advanced by batches that are reserved but not yet committed.
+	if offset < ts.persisted+s.checkpoint {
+		s.Unlock()
+		return nil
+	}
+
+	ts.persisted = offset
+	s.Unlock()
+
+	return s.meta.UpdateOffset(topicID, offset, eventID)
+}
+
+// Reserve advances the sequence of the topic without persisting it so that the next
+// event in a batch that is waiting to be committed is assigned the following offset.
+// The sequence is never moved backward, e.g. when an earlier batch is committed after
+// a later batch has been reserved. If the batch is not committed the topic must be reset.
+func (s *sequencer) reserve(topicID ulid.ULID, eventID rlid.RLID, offset uint64) {
+	s.Lock()
+	defer s.Unlock()
+	if ts, ok := s.topics[topicID]; ok && offset > ts.offset {
+		ts.offset = offset
+		ts.last = eventID
+	}
+}
+
+// Discard the sequence of the topic so that it is recovered from the meta store and
+// the event log when the next event is published to the topic.
+func (s *sequencer) reset(topicID ulid.ULID) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.topics, topicID)
+}
+
+// Load the sequence of the topic from the offset persisted in the meta store, then
+// scan the event log from the last persisted event forward for any events that were
+// committed after the offset was persisted. Events written before topics were
+// sequenced do not have an offset and are counted by their position in the topic.
+func (s *sequencer) load(topicID ulid.ULID) (_ *topicSequence, err error) {
+	var topic *api.Topic
+	if topic, err = s.meta.RetrieveTopic(topicID); err != nil {
+		return nil, err
+	}
+
+	ts := &topicSequence{offset: topic.Offset, persisted: topic.Offset}
+	copy(ts.last[:], topic.OffsetId)
+
+	iter := s.events.List(topicID)
+	defer iter.Release()
+
+	var ok bool
+	if rlid.IsZero(ts.last) {
+		ok = iter.Next()
+	} else {
+		ok = iter.Seek(ts.last)
+	}
+
+	for ; ok; ok = iter.Next() {
+		var event *api.EventWrapper
+		if event, err = iter.Event(); err != nil {
+			return nil, err
+		}
+
+		var eventID rlid.RLID
+		if eventID, err = event.ParseEventID(); err != nil {
+			return nil, err
+		}
+
+		if eventID.Compare(ts.last) <= 0 {
+			continue
+		}
+
+		if event.Offset > 0 {
+			ts.offset = event.Offset
+		} else {
+			ts.offset++
+		}
+		ts.last = eventID
+	}
+
+	if err = iter.Error(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Returns the smallest RLID that is greater than the specified RLID, incrementing the
+// timestamp if the sequence component would overflow.
+func successor(id rlid.RLID) rlid.RLID {
+	if seq := id.Sequence(); seq < math.MaxUint32 {
+		id.SetSequence(seq + 1)
+	} else {
+		id.SetTime(id.Time() + 1)
+		id.SetSequence(0)
+	}
+	return id
+}
@@ -0,0 +1,185 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/utils/sentry"
+	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"google.golang.org/protobuf/proto"
+)
+
+// The key of the log entries that changes to topics, consumer groups, and the events
+// of destroyed topics are replicated with.
+var changeKey = []byte("stores")
+
+// A change to the stores that has been proposed by this node and the result of
+// applying it, which is recorded by the state machine when the change is committed.
+type change struct {
+	cmd     *api.StoreChange
+	created bool
+	err     error
+}
+
+// Stores returns the event and meta stores that topics and consumer groups should be
+// modified with. If the broker uses consensus, topics and consumer groups are created,
+// updated, and deleted and the events of topics are destroyed by proposing the change
+// to the quorum, which applies it to the stores of every node, so that the node that
+// becomes the leader after a failover has the same topics and consumer group offsets.
+// Only the leader can modify topics and groups; other nodes return an error. All other
+// methods of the stores, including reads, use the local stores. If the broker does not
+// use consensus then the local stores are returned.
+func (b *Broker) Stores() (store.EventStore, store.MetaStore) {
+	if b.quorum == nil {
+		return b.events, b.meta
+	}
+	return &replicatedEvents{EventStore: b.events, broker: b}, &replicatedMeta{MetaStore: b.meta, broker: b}
+}
+
+// Propose the change to the quorum and wait until it has been committed and applied,
+// returning the result of applying the change to the local stores. If the proposal
+// times out, the change may still be committed by the quorum later.
+func (b *Broker) change(cmd *api.StoreChange) (_ *change, err error) {
+	id := ulids.New()
+	cmd.Id = id.Bytes()
+
+	var value []byte
+	if value, err = proto.Marshal(cmd); err != nil {
+		return nil, err
+	}
+
+	pending := &change{cmd: cmd}
+	b.propmu.Lock()
+	b.changes[id] = pending
+	b.propmu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	_, err = b.quorum.Propose(ctx, changeKey, value)
+	cancel()
+
+	b.propmu.Lock()
+	delete(b.changes, id)
+	b.propmu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return pending, pending.err
+}
+
+// Apply a committed change to the local stores. If this node proposed the change, the
+// change is applied to the topic or group of the caller, e.g. so that the caller has
+// the timestamps set by the meta store, and the result is recorded for the caller;
+// otherwise errors are logged since the change has been applied by the rest of the
+// quorum and the state machine cannot reject committed entries.
+func (b *Broker) applyChange(cmd *api.StoreChange) {
+	var id ulid.ULID
+	copy(id[:], cmd.Id)
+
+	b.propmu.Lock()
+	defer b.propmu.Unlock()
+
+	if pending, ok := b.changes[id]; ok {
+		pending.created, pending.err = b.apply(pending.cmd)
+		return
+	}
+
+	if _, err := b.apply(cmd); err != nil {
+		sentry.Warn(nil).Err(err).Msgf("could not apply %T change to the local stores", cmd.Change)
+	}
+}
+
+func (b *Broker) apply(cmd *api.StoreChange) (created bool, err error) {
+	switch change := cmd.Change.(type) {
+	case *api.StoreChange_CreateTopic:
+		return false, b.meta.CreateTopic(change.CreateTopic)
+	case *api.StoreChange_UpdateTopic:
+		if err = b.meta.UpdateTopic(change.UpdateTopic); err != nil {
+			return false, err
+		}
+		return false, b.UpdateTopic(change.UpdateTopic)
+	case *api.StoreChange_DeleteTopic:
+		var topicID ulid.ULID
+		if topicID, err = ulids.Parse(change.DeleteTopic); err != nil {
+			return false, err
+		}
+
+		if err = b.meta.DeleteTopic(topicID); err != nil {
+			return false, err
+		}
+		b.DeleteTopic(topicID)
+		return false, nil
+	case *api.StoreChange_CreateGroup:
+		return b.meta.GetOrCreateGroup(change.CreateGroup)
+	case *api.StoreChange_UpdateGroup:
+		return false, b.meta.UpdateGroup(change.UpdateGroup)
+	case *api.StoreChange_DeleteGroup:
+		return false, b.meta.DeleteGroup(change.DeleteGroup)
+	case *api.StoreChange_DestroyEvents:
+		var topicID ulid.ULID
+		if topicID, err = ulids.Parse(change.DestroyEvents); err != nil {
+			return false, err
+		}
+		return false, b.events.Destroy(topicID)
+	default:
+		return false, ErrUnknownChange
+	}
+}
+
+// Replicates changes to topics and consumer groups through the broker's quorum.
+type replicatedMeta struct {
+	store.MetaStore
+	broker *Broker
+}
+
+// CreateTopic assigns the ID of the topic before it is proposed so that the topic is
+// created with the same ID on every node.
+func (m *replicatedMeta) CreateTopic(topic *api.Topic) (err error) {
+	if len(topic.Id) == 0 {
+		topic.Id = ulids.New().Bytes()
+	}
+
+	_, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_CreateTopic{CreateTopic: topic}})
+	return err
+}
+
+func (m *replicatedMeta) UpdateTopic(topic *api.Topic) (err error) {
+	_, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_UpdateTopic{UpdateTopic: topic}})
+	return err
+}
+
+func (m *replicatedMeta) DeleteTopic(topicID ulid.ULID) (err error) {
+	_, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_DeleteTopic{DeleteTopic: topicID.Bytes()}})
+	return err
+}
+
+func (m *replicatedMeta) GetOrCreateGroup(group *api.ConsumerGroup) (_ bool, err error) {
+	var result *change
+	if result, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_CreateGroup{CreateGroup: group}}); err != nil {
+		return false, err
+	}
+	return result.created, nil
+}
+
+func (m *replicatedMeta) UpdateGroup(group *api.ConsumerGroup) (err error) {
+	_, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_UpdateGroup{UpdateGroup: group}})
+	return err
+}
+
+func (m *replicatedMeta) DeleteGroup(group *api.ConsumerGroup) (err error) {
+	_, err = m.broker.change(&api.StoreChange{Change: &api.StoreChange_DeleteGroup{DeleteGroup: group}})
+	return err
+}
+
+// Replicates the destruction of the events of a topic through the broker's quorum.
+type replicatedEvents struct {
+	store.EventStore
+	broker *Broker
+}
+
+func (e *replicatedEvents) Destroy(topicID ulid.ULID) (err error) {
+	_, err = e.broker.change(&api.StoreChange{Change: &api.StoreChange_DestroyEvents{DestroyEvents: topicID.Bytes()}})
+	return err
+}
@@ -0,0 +1,155 @@
+package broker
+
+import (
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// The broker's view of the state of the topics that events are published to, used to
+// reject events for topics that cannot accept writes before they are committed. The
+// state of a topic is loaded from the meta store when the first event is published to
+// it and must be refreshed by the server whenever the topic is updated or deleted.
+type topicStates struct {
+	sync.RWMutex
+	meta   store.TopicStore
+	topics map[ulid.ULID]topicState
+}
+
+type topicState struct {
+	readonly    bool
+	status      api.TopicState
+	deleted     bool
+	compression *api.Compression
+	encrypted   bool
+}
+
+func newTopicState(topic *api.Topic) topicState {
+	return topicState{
+		readonly:    topic.Readonly,
+		status:      topic.Status,
+		compression: topic.Compression,
+		encrypted:   topic.Encryption.GetEnabled(),
+	}
+}
+
+func newTopicStates(meta store.TopicStore) *topicStates {
+	return &topicStates{
+		meta:   meta,
+		topics: make(map[ulid.ULID]topicState),
+	}
+}
+
+// Check if an event can be published to the topic, returning the nack code for the
+// event if the topic cannot accept writes or Nack_UNKNOWN if it can. Topics that do
+// not exist are not cached so that events can be published once they are created.
+func (t *topicStates) check(topicID ulid.ULID) (_ api.Nack_Code, err error) {
+	t.RLock()
+	state, ok := t.topics[topicID]
+	t.RUnlock()
+
+	if !ok {
+		var topic *api.Topic
+		if topic, err = t.meta.RetrieveTopic(topicID); err != nil {
+			if errors.Is(err, errors.ErrNotFound) {
+				return api.Nack_TOPIC_UNKNOWN, nil
+			}
+			return api.Nack_INTERNAL, err
+		}
+
+		// Do not overwrite a state that was refreshed while the topic was loading
+		t.Lock()
+		if state, ok = t.topics[topicID]; !ok {
+			state = newTopicState(topic)
+			t.topics[topicID] = state
+		}
+		t.Unlock()
+	}
+	return state.nack(), nil
+}
+
+// Refresh the state of the topic after it has been updated.
+func (t *topicStates) update(topic *api.Topic) error {
+	topicID, err := topic.ParseTopicID()
+	if err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.topics[topicID] = newTopicState(topic)
+	return nil
+}
+
+// Compress the event for storage with the compression policy of the topic. A compressed
+// copy of the event is returned so that subscribers still receive the uncompressed
+// event; if the topic is not compressed or the event is not compressed (e.g. because it
+// has no data or was compressed by the publisher) then the event itself is returned.
+// The topic must have been checked so that its state is loaded.
+func (t *topicStates) compress(topicID ulid.ULID, event *api.EventWrapper) (_ *api.EventWrapper, err error) {
+	t.RLock()
+	policy := t.topics[topicID].compression
+	t.RUnlock()
+
+	if !policy.Enabled() || event.Compression.Enabled() || len(event.Event) == 0 {
+		return event, nil
+	}
+
+	compressed := proto.Clone(event).(*api.EventWrapper)
+	if err = compressed.Compress(policy); err != nil {
+		return event, err
+	}
+	return compressed, nil
+}
+
+// Seal the event for storage if the topic is encrypted at rest, returning a sealed copy
+// of the event so that subscribers still receive the plaintext event. Events without
+// data and events that were encrypted by the publisher are returned as is. The sealer
+// records how the event was sealed on the storage encryption of the sealed copy. An error is
+// returned if the event cannot be sealed since it must not be stored in plaintext.
+func (t *topicStates) seal(topicID ulid.ULID, event *api.EventWrapper, sealer Sealer) (_ *api.EventWrapper, err error) {
+	t.RLock()
+	encrypted := t.topics[topicID].encrypted
+	t.RUnlock()
+
+	if !encrypted || len(event.Event) == 0 || event.Encryption.GetEncryptionAlgorithm() != api.Encryption_PLAINTEXT {
+		return event, nil
+	}
+
+	if sealer == nil {
+		return nil, ErrNoSealer
+	}
+
+	sealed := proto.Clone(event).(*api.EventWrapper)
+	if err = sealer.Seal(sealed); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// Mark the topic as deleted so that events published to it are nacked as deleted
+// rather than as unknown topics.
+func (t *topicStates) delete(topicID ulid.ULID) {
+	t.Lock()
+	defer t.Unlock()
+	t.topics[topicID] = topicState{deleted: true}
+}
+
+// Returns the nack code for events published to a topic in this state. Topics whose
+// status is undefined were created before topic states and can accept writes.
+func (s topicState) nack() api.Nack_Code {
+	switch {
+	case s.deleted || s.status == api.TopicState_DELETING:
+		return api.Nack_TOPIC_DELETED
+	case s.readonly || s.status == api.TopicState_READONLY:
+		return api.Nack_TOPIC_ARCHIVED
+	case s.status == api.TopicState_PENDING || s.status == api.TopicState_ALLOCATING || s.status == api.TopicState_REPAIRING:
+		return api.Nack_TOPIC_NOT_READY
+	default:
+		return api.Nack_UNKNOWN
+	}
+}
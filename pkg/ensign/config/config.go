@@ -10,6 +10,7 @@ import (
 	"github.com/rotationalio/confire"
 	"github.com/rotationalio/ensign/pkg"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/middleware"
+	"github.com/rotationalio/ensign/pkg/raft"
 	"github.com/rotationalio/ensign/pkg/utils/logger"
 	"github.com/rotationalio/ensign/pkg/utils/radish"
 	"github.com/rotationalio/ensign/pkg/utils/sentry"
@@ -33,6 +34,8 @@ type Config struct {
 	MetaTopic   MetaTopicConfig     `split_words:"true"`
 	Monitoring  MonitoringConfig
 	Storage     StorageConfig
+	Encryption  EncryptionConfig
+	Consensus   ConsensusConfig
 	Auth        AuthConfig
 	Radish      radish.Config
 	Sentry      sentry.Config
@@ -67,6 +70,41 @@ type StorageConfig struct {
 	Testing  bool   `default:"false" yaml:"testing"`
 }
 
+// EncryptionConfig enables encryption at rest for topics that have an encryption
+// policy. The data keys of each topic are wrapped by a master key that is loaded from
+// the key file; the key file may contain multiple master keys to support rotation.
+type EncryptionConfig struct {
+	Enabled bool   `default:"false" yaml:"enabled"`
+	KeyFile string `split_words:"true" yaml:"key_file"`
+}
+
+// ConsensusConfig replicates published events to a quorum of Ensign nodes using raft
+// so that events are not lost if a node fails; events are only acked once they have
+// been committed by a majority of the quorum. The peers of the quorum are loaded from
+// the peers path and the raft log is stored alongside the data of the node. The log is
+// compacted after the given number of entries have been committed since the last
+// snapshot; a node whose data is behind the snapshot of the quorum must be seeded.
+// Topics with retention or compaction policies and changes to the deduplication policy
+// of a topic are rejected by nodes that use consensus. Peers authenticate each other with the mTLS certs, which are required unless the
+// node is insecure; insecure nodes accept raft requests from any host, so this should
+// only be used for testing. Peers are added to and removed from the quorum through the
+// admin service, which is only served if an admin address is configured and always
+// requires mTLS client certs.
+type ConsensusConfig struct {
+	Enabled       bool          `default:"false" yaml:"enabled"`
+	ReplicaID     uint32        `split_words:"true" yaml:"replica_id"`
+	PeersPath     string        `split_words:"true" yaml:"peers_path"`
+	Tick          time.Duration `default:"1s" yaml:"tick"`
+	Timeout       time.Duration `default:"500ms" yaml:"timeout"`
+	Aggregate     bool          `default:"true" yaml:"aggregate"`
+	CommitTimeout time.Duration `split_words:"true" default:"5s" yaml:"commit_timeout"`
+	Snapshot      uint64        `default:"1024" yaml:"snapshot"`
+	AdminAddr     string        `split_words:"true" yaml:"admin_addr"`
+	CertPath      string        `split_words:"true" yaml:"cert_path"`
+	PoolPath      string        `split_words:"true" yaml:"pool_path"`
+	Insecure      bool          `default:"false" yaml:"insecure"`
+}
+
 // AuthConfig defines how Ensign connects to Quarterdeck in order to authorize requests.
 type AuthConfig struct {
 	KeysURL            string        `split_words:"true" default:"https://auth.rotational.app/.well-known/jwks.json"`
@@ -117,6 +155,20 @@ func (c Config) Validate() (err error) {
 		return err
 	}
 
+	if err = c.Encryption.Validate(); err != nil {
+		return err
+	}
+
+	if err = c.Consensus.Validate(); err != nil {
+		return err
+	}
+
+	// Topic data keys and metadata are not replicated, so events sealed by the leader
+	// could not be unsealed by the rest of the quorum.
+	if c.Consensus.Enabled && c.Encryption.Enabled {
+		return errors.New("invalid config: encryption at rest cannot be enabled with consensus")
+	}
+
 	if err = c.Sentry.Validate(); err != nil {
 		return err
 	}
@@ -149,6 +201,60 @@ func (c StorageConfig) Validate() (err error) {
 	return nil
 }
 
+func (c EncryptionConfig) Validate() error {
+	if c.Enabled && c.KeyFile == "" {
+		return errors.New("invalid encryption config: missing key file")
+	}
+	return nil
+}
+
+func (c ConsensusConfig) Validate() error {
+	if c.Enabled {
+		if c.ReplicaID == 0 {
+			return errors.New("invalid consensus config: missing replica id")
+		}
+
+		if c.PeersPath == "" {
+			return errors.New("invalid consensus config: missing peers path")
+		}
+
+		if c.CommitTimeout <= 0 {
+			return errors.New("invalid consensus config: commit timeout must be greater than zero")
+		}
+
+		if c.Snapshot == 0 {
+			return errors.New("invalid consensus config: snapshot must be greater than zero")
+		}
+
+		if !c.Insecure && c.CertPath == "" {
+			return errors.New("invalid consensus config: mTLS certs are required unless insecure")
+		}
+
+		if c.AdminAddr != "" && c.CertPath == "" {
+			return errors.New("invalid consensus config: the admin service requires mTLS certs")
+		}
+	}
+	return nil
+}
+
+// Raft returns the configuration of the raft replica with its log stored in the data
+// path. Snapshots of the broker record the last entry written to the event store.
+func (c ConsensusConfig) Raft(dataPath string) raft.Config {
+	return raft.Config{
+		ReplicaID: c.ReplicaID,
+		Tick:      c.Tick,
+		Timeout:   c.Timeout,
+		Aggregate: c.Aggregate,
+		PeersPath: c.PeersPath,
+		DataPath:  dataPath,
+		Snapshot:  c.Snapshot,
+		AdminAddr: c.AdminAddr,
+		CertPath:  c.CertPath,
+		PoolPath:  c.PoolPath,
+		Insecure:  c.Insecure,
+	}
+}
+
 // MetaPath returns the path to the metadata store for Ensign, checking to make sure
 // that the directory exists and that it is a directory. If it doesn't exist, the
 // directory is created; an error is returned if the path is invalid or cannot be
@@ -173,6 +279,17 @@ func (c StorageConfig) EventPath() (path string, err error) {
 	return path, nil
 }
 
+// RaftPath returns the path to the raft log of the node, checking to make sure that
+// the directory exists and that it is a directory. If it doesn't exist, the directory
+// is created; an error is returned if the path is invalid or cannot be created.
+func (c StorageConfig) RaftPath() (path string, err error) {
+	path = filepath.Join(c.DataPath, "raft")
+	if err = c.checkPath(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 func (c StorageConfig) checkPath(path string) (err error) {
 	var info os.FileInfo
 	if info, err = os.Stat(path); err != nil {
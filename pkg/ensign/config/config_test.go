@@ -28,6 +28,20 @@ var testEnv = map[string]string{
 	"ENSIGN_MONITORING_NODE_ID":        "test1234",
 	"ENSIGN_STORAGE_READ_ONLY":         "true",
 	"ENSIGN_STORAGE_DATA_PATH":         "/data/db",
+	"ENSIGN_ENCRYPTION_ENABLED":        "true",
+	"ENSIGN_ENCRYPTION_KEY_FILE":       "/data/keys/master.keys",
+	"ENSIGN_CONSENSUS_ENABLED":         "true",
+	"ENSIGN_CONSENSUS_REPLICA_ID":      "2",
+	"ENSIGN_CONSENSUS_PEERS_PATH":      "/data/peers.json",
+	"ENSIGN_CONSENSUS_TICK":            "2s",
+	"ENSIGN_CONSENSUS_TIMEOUT":         "800ms",
+	"ENSIGN_CONSENSUS_AGGREGATE":       "false",
+	"ENSIGN_CONSENSUS_COMMIT_TIMEOUT":  "10s",
+	"ENSIGN_CONSENSUS_SNAPSHOT":        "512",
+	"ENSIGN_CONSENSUS_ADMIN_ADDR":      ":4437",
+	"ENSIGN_CONSENSUS_CERT_PATH":       "/data/certs/admin.pem",
+	"ENSIGN_CONSENSUS_POOL_PATH":       "/data/certs/pool.pem",
+	"ENSIGN_CONSENSUS_INSECURE":        "true",
 	"ENSIGN_AUTH_KEYS_URL":             "http://localhost:8088/.well-known/jwks.json",
 	"ENSIGN_AUTH_AUDIENCE":             "http://localhost:3000",
 	"ENSIGN_AUTH_ISSUER":               "http://localhost:8088",
@@ -71,6 +85,20 @@ func TestConfig(t *testing.T) {
 	require.False(t, conf.Storage.Testing)
 	require.True(t, conf.Storage.ReadOnly)
 	require.Equal(t, testEnv["ENSIGN_STORAGE_DATA_PATH"], conf.Storage.DataPath)
+	require.True(t, conf.Encryption.Enabled)
+	require.Equal(t, testEnv["ENSIGN_ENCRYPTION_KEY_FILE"], conf.Encryption.KeyFile)
+	require.True(t, conf.Consensus.Enabled)
+	require.Equal(t, uint32(2), conf.Consensus.ReplicaID)
+	require.Equal(t, testEnv["ENSIGN_CONSENSUS_PEERS_PATH"], conf.Consensus.PeersPath)
+	require.Equal(t, 2*time.Second, conf.Consensus.Tick)
+	require.Equal(t, 800*time.Millisecond, conf.Consensus.Timeout)
+	require.False(t, conf.Consensus.Aggregate)
+	require.Equal(t, 10*time.Second, conf.Consensus.CommitTimeout)
+	require.Equal(t, uint64(512), conf.Consensus.Snapshot)
+	require.Equal(t, testEnv["ENSIGN_CONSENSUS_ADMIN_ADDR"], conf.Consensus.AdminAddr)
+	require.Equal(t, testEnv["ENSIGN_CONSENSUS_CERT_PATH"], conf.Consensus.CertPath)
+	require.Equal(t, testEnv["ENSIGN_CONSENSUS_POOL_PATH"], conf.Consensus.PoolPath)
+	require.True(t, conf.Consensus.Insecure)
 	require.Equal(t, testEnv["ENSIGN_AUTH_KEYS_URL"], conf.Auth.KeysURL)
 	require.Equal(t, testEnv["ENSIGN_AUTH_AUDIENCE"], conf.Auth.Audience)
 	require.Equal(t, testEnv["ENSIGN_AUTH_ISSUER"], conf.Auth.Issuer)
@@ -110,6 +138,73 @@ func TestValidateMetaTopicConfig(t *testing.T) {
 	require.NoError(t, conf.Validate(), "topic name, client id, client secret are all that's required")
 }
 
+func TestValidateEncryptionConfig(t *testing.T) {
+	conf := config.EncryptionConfig{Enabled: false}
+	require.NoError(t, conf.Validate(), "disabled config should be valid")
+
+	conf.Enabled = true
+	require.EqualError(t, conf.Validate(), "invalid encryption config: missing key file")
+
+	conf.KeyFile = "testdata/master.keys"
+	require.NoError(t, conf.Validate(), "key file is all that's required")
+}
+
+func TestValidateConsensusConfig(t *testing.T) {
+	conf := config.ConsensusConfig{Enabled: false}
+	require.NoError(t, conf.Validate(), "disabled config should be valid")
+
+	conf.Enabled = true
+	require.EqualError(t, conf.Validate(), "invalid consensus config: missing replica id")
+
+	conf.ReplicaID = 1
+	require.EqualError(t, conf.Validate(), "invalid consensus config: missing peers path")
+
+	conf.PeersPath = "testdata/peers.json"
+	require.EqualError(t, conf.Validate(), "invalid consensus config: commit timeout must be greater than zero")
+
+	conf.CommitTimeout = 5 * time.Second
+	require.EqualError(t, conf.Validate(), "invalid consensus config: snapshot must be greater than zero")
+
+	conf.Snapshot = 1024
+	require.EqualError(t, conf.Validate(), "invalid consensus config: mTLS certs are required unless insecure")
+
+	conf.CertPath = "testdata/certs.pem"
+	require.NoError(t, conf.Validate(), "replica id, peers path, commit timeout, snapshot, and certs are all that's required")
+
+	conf.CertPath = ""
+	conf.Insecure = true
+	require.NoError(t, conf.Validate(), "certs are not required if insecure")
+
+	conf.AdminAddr = ":4437"
+	require.EqualError(t, conf.Validate(), "invalid consensus config: the admin service requires mTLS certs")
+
+	conf.CertPath = "testdata/admin.pem"
+	require.NoError(t, conf.Validate(), "the admin service only requires certs")
+}
+
+func TestValidateConsensusEncryption(t *testing.T) {
+	conf := config.Config{
+		Storage:    config.StorageConfig{DataPath: "testdata"},
+		Encryption: config.EncryptionConfig{Enabled: true, KeyFile: "testdata/master.keys"},
+		Consensus: config.ConsensusConfig{
+			Enabled:       true,
+			ReplicaID:     1,
+			PeersPath:     "testdata/peers.json",
+			CommitTimeout: 5 * time.Second,
+			Snapshot:      1024,
+			Insecure:      true,
+		},
+	}
+	require.EqualError(t, conf.Validate(), "invalid config: encryption at rest cannot be enabled with consensus")
+
+	conf.Encryption.Enabled = false
+	require.NoError(t, conf.Validate(), "consensus is valid without encryption")
+
+	conf.Encryption.Enabled = true
+	conf.Consensus.Enabled = false
+	require.NoError(t, conf.Validate(), "encryption is valid without consensus")
+}
+
 func TestStoragePaths(t *testing.T) {
 	dir := t.TempDir()
 	conf := config.StorageConfig{
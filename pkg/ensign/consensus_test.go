@@ -0,0 +1,267 @@
+package ensign_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/oklog/ulid/v2"
+	"github.com/rotationalio/ensign/pkg/ensign"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/mock"
+	"github.com/rotationalio/ensign/pkg/ensign/o11y"
+	"github.com/rotationalio/ensign/pkg/quarterdeck/authtest"
+	"github.com/rotationalio/ensign/pkg/quarterdeck/permissions"
+	"github.com/rotationalio/ensign/pkg/quarterdeck/tokens"
+	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rotationalio/ensign/pkg/utils/bufconn"
+	"github.com/rotationalio/ensign/pkg/utils/logger"
+	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestConsensusFailover(t *testing.T) {
+	logger.Discard()
+	t.Cleanup(logger.ResetLogger)
+
+	// Register metrics without starting the server
+	monitoring := config.MonitoringConfig{Enabled: false, NodeID: "localtest"}
+	require.NoError(t, o11y.Serve(monitoring), "could not register o11y collectors")
+
+	quarterdeck, err := authtest.NewServer()
+	require.NoError(t, err, "could not initialize authtest server")
+	t.Cleanup(quarterdeck.Close)
+
+	// Create a quorum of three nodes that replicate over TCP; the first node is the
+	// leader of the quorum when it is bootstrapped.
+	socks := make([]net.Listener, 0, 3)
+	quorum := &peers.Quorum{QID: 1, BootstrapLeader: 1}
+	for i := 1; i <= 3; i++ {
+		sock, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err, "could not listen for raft requests")
+		socks = append(socks, sock)
+
+		addr := sock.Addr().String()
+		quorum.Peers = append(quorum.Peers, &peers.Peer{PID: uint32(i), Name: addr, BindAddr: addr, Endpoint: addr})
+	}
+
+	peersPath := filepath.Join(t.TempDir(), "peers.json")
+	require.NoError(t, quorum.Dump(peersPath), "could not write peers")
+
+	servers := make([]*ensign.Server, 0, 3)
+	clients := make([]api.EnsignClient, 0, 3)
+	stopped := make(map[int]bool)
+
+	for i := 1; i <= 3; i++ {
+		conf, err := config.Config{
+			Maintenance: false,
+			LogLevel:    logger.LevelDecoder(zerolog.DebugLevel),
+			BindAddr:    "127.0.0.1:0",
+			Monitoring:  monitoring,
+			Storage: config.StorageConfig{
+				DataPath: t.TempDir(),
+			},
+			Auth: config.AuthConfig{
+				KeysURL:            quarterdeck.KeysURL(),
+				Audience:           authtest.Audience,
+				Issuer:             authtest.Issuer,
+				MinRefreshInterval: 5 * time.Minute,
+			},
+			Consensus: config.ConsensusConfig{
+				Enabled:       true,
+				ReplicaID:     uint32(i),
+				PeersPath:     peersPath,
+				Tick:          50 * time.Millisecond,
+				Timeout:       25 * time.Millisecond,
+				CommitTimeout: 5 * time.Second,
+				Snapshot:      1024,
+				Insecure:      true,
+			},
+		}.Mark()
+		require.NoError(t, err, "could not mark test configuration as valid")
+
+		srv, err := ensign.New(conf)
+		require.NoError(t, err, "could not create server with a test configuration")
+		require.NoError(t, srv.RunReplica(socks[i-1]), "could not run raft replica")
+		srv.RunBroker()
+		srv.RunTasks()
+
+		conn := bufconn.New()
+		go srv.Run(conn.Sock())
+
+		cc, err := conn.Connect(grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err, "could not connect to bufconn")
+
+		servers = append(servers, srv)
+		clients = append(clients, api.NewEnsignClient(cc))
+	}
+
+	t.Cleanup(func() {
+		for i, srv := range servers {
+			if !stopped[i] {
+				srv.Shutdown()
+			}
+		}
+	})
+
+	projectID := ulids.New()
+	token, err := quarterdeck.CreateAccessToken(&tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: ulids.New().String()},
+		OrgID:            ulids.New().String(),
+		ProjectID:        projectID.String(),
+		Permissions:      []string{permissions.CreateTopics, permissions.ReadTopics, permissions.Publisher, permissions.Subscriber},
+	})
+	require.NoError(t, err, "could not create access token")
+
+	// Topics can only be created through the leader of the quorum.
+	var (
+		leader int
+		topic  *api.Topic
+	)
+
+	require.Eventually(t, func() bool {
+		for i, client := range clients {
+			if topic, err = client.CreateTopic(context.Background(), &api.Topic{Name: "failover"}, mock.PerRPCToken(token)); err == nil {
+				leader = i
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 50*time.Millisecond, "could not create topic through the leader")
+
+	topicID, err := topic.ParseTopicID()
+	require.NoError(t, err, "could not parse topic id")
+
+	// Retention and compaction policies are rejected since they would be enforced by
+	// every node independently.
+	_, err = clients[leader].CreateTopic(context.Background(), &api.Topic{Name: "retained", Retention: &api.Retention{MaxEvents: 10}}, mock.PerRPCToken(token))
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Consume an event published to the leader with a consumer group so that the offset
+	// of the group is committed; new groups start from the end of the topic.
+	sub := subscribeGroup(t, clients[leader], token, topicID)
+	rep, err := publishEvent(clients[leader], token, topicID)
+	require.NoError(t, err, "could not publish event")
+	require.NotNil(t, rep.GetAck(), "expected the event to be acked by the leader")
+
+	event := recvEvent(t, sub)
+	require.Equal(t, uint64(1), event.Offset)
+	require.NoError(t, sub.Send(&api.SubscribeRequest{Embed: &api.SubscribeRequest_Ack{Ack: &api.Ack{Id: event.Id}}}))
+	require.NoError(t, sub.CloseSend())
+
+	// Kill the leader; the ack of the group is handled before the server stops.
+	require.NoError(t, servers[leader].Shutdown(), "could not shutdown the leader")
+	stopped[leader] = true
+
+	// Once a new leader is elected it accepts events for the existing topic.
+	require.Eventually(t, func() bool {
+		for i, client := range clients {
+			if stopped[i] {
+				continue
+			}
+
+			if rep, err := publishEvent(client, token, topicID); err == nil && rep.GetAck() != nil {
+				leader = i
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 100*time.Millisecond, "no leader accepted events for the existing topic")
+
+	// The follower knows the topic but cannot commit events to it.
+	for i, client := range clients {
+		if stopped[i] || i == leader {
+			continue
+		}
+
+		rep, err := publishEvent(client, token, topicID)
+		require.NoError(t, err, "could not publish event")
+		require.Equal(t, api.Nack_CONSENSUS_FAILURE, rep.GetNack().GetCode(), "expected followers to nack events as consensus failures")
+	}
+
+	// The group resumes from the offset it committed on the previous leader, so the
+	// first event is not redelivered.
+	sub = subscribeGroup(t, clients[leader], token, topicID)
+	event = recvEvent(t, sub)
+	require.Equal(t, uint64(2), event.Offset, "expected the group to resume from its committed offset")
+	require.NoError(t, sub.CloseSend())
+
+	// Both events can be read from the new leader.
+	stream, err := clients[leader].EnSQL(context.Background(), &api.Query{Query: "SELECT * FROM failover"}, mock.PerRPCToken(token))
+	require.NoError(t, err, "could not query topic")
+
+	var offsets []uint64
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err, "could not receive query result")
+		offsets = append(offsets, event.Offset)
+	}
+	require.Equal(t, []uint64{1, 2}, offsets)
+}
+
+// Publish a single event to the topic and return the reply of the server.
+func publishEvent(client api.EnsignClient, token string, topicID ulid.ULID) (rep *api.PublisherReply, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var stream api.Ensign_PublishClient
+	if stream, err = client.Publish(ctx, mock.PerRPCToken(token)); err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	if err = stream.Send(&api.PublisherRequest{Embed: &api.PublisherRequest_OpenStream{OpenStream: &api.OpenStream{ClientId: "failover"}}}); err != nil {
+		return nil, err
+	}
+
+	if rep, err = stream.Recv(); err != nil {
+		return nil, err
+	}
+
+	if rep.GetReady() == nil {
+		return nil, errors.New("expected the publish stream to be ready")
+	}
+
+	if err = stream.Send(&api.PublisherRequest{Embed: &api.PublisherRequest_Event{Event: MakeEmpty(topicID.String())}}); err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+// Subscribe to the topic as a member of the failover consumer group.
+func subscribeGroup(t *testing.T, client api.EnsignClient, token string, topicID ulid.ULID) api.Ensign_SubscribeClient {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	stream, err := client.Subscribe(ctx, mock.PerRPCToken(token))
+	require.NoError(t, err, "could not open subscribe stream")
+
+	sub := &api.Subscription{ClientId: "failover", Topics: []string{topicID.String()}, Group: &api.ConsumerGroup{Name: "failover"}}
+	require.NoError(t, stream.Send(&api.SubscribeRequest{Embed: &api.SubscribeRequest_Subscription{Subscription: sub}}))
+
+	rep, err := stream.Recv()
+	require.NoError(t, err, "could not open subscribe stream")
+	require.NotNil(t, rep.GetReady(), "expected the stream to be ready")
+	return stream
+}
+
+func recvEvent(t *testing.T, stream api.Ensign_SubscribeClient) *api.EventWrapper {
+	rep, err := stream.Recv()
+	require.NoError(t, err, "could not receive event")
+	require.NotNil(t, rep.GetEvent(), "expected an event from the subscribe stream")
+	return rep.GetEvent()
+}
@@ -8,13 +8,14 @@ import (
 	"github.com/bits-and-blooms/bloom/v3"
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/broker"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"google.golang.org/protobuf/proto"
 )
 
-const (
-	filterFPRate       = 0.01
-	filterMinSize uint = 10000
-)
+const filterFPRate = 0.01
 
 // TopicFilter loads a bloom filter with all of the event hashes for the events in the
 // specified topic. The TopicInfo for the event is read to determine how many events are
@@ -22,43 +23,16 @@ const (
 // twice the number of events in the topic and with a false positive rate of 1%. The
 // filter can be tested and modified as needed to detect duplicates.
 func (s *Server) TopicFilter(topicID ulid.ULID) (_ *bloom.BloomFilter, err error) {
-	// Load the topic info to determine the bloom filter size.
-	var info *api.TopicInfo
-	if info, err = s.meta.TopicInfo(topicID); err != nil {
-		return nil, err
-	}
-
-	// The filter size should be the larger of twice the number of events in the topic
-	// or the minimum filter size (10k hashes by default).
-	filterSize := filterMinSize
-	if uint(info.Events*2) > filterSize {
-		filterSize = uint(info.Events * 2)
-	}
-
-	// Create the bloom filter with index hashes from the database.
-	filter := bloom.NewWithEstimates(filterSize, filterFPRate)
-	iter := s.data.LoadIndash(topicID)
-	defer iter.Release()
-
-	for iter.Next() {
-		var hash []byte
-		if hash, err = iter.Hash(); err != nil {
-			// NOTE: we are not skipping bad hashes because this would make it possible
-			// to miss duplicates -- however, it could be possible to relax this.
-			return nil, err
-		}
-		filter.Add(hash)
-	}
-
-	if err = iter.Error(); err != nil {
-		return nil, err
-	}
-	return filter, nil
+	return broker.TopicFilter(s.data, s.meta, topicID)
 }
 
 // Rehash clears the old event hashes and recomputes the hashes with the new policy.
+// The topic is rehashed in two passes over the events in the topic info snapshot. The
+// first pass restores all duplicate references to complete events and indexes the hash
+// of the original event of each set of duplicates: the first event with the hash for
+// the earliest offset policy or the last event with the hash for the latest offset
+// policy. The second pass rewrites every other event as a reference to its original.
 // TODO: this method operates on a snapshot of the database and is not concurrency safe.
-// TODO: rehash does not take offset policies into account.
 func (s *Server) Rehash(ctx context.Context, topicID ulid.ULID, policy *api.Deduplication) (err error) {
 	// Clear old hashes from the database.
 	if err = s.data.ClearIndash(topicID); err != nil {
@@ -84,107 +58,115 @@ func (s *Server) Rehash(ctx context.Context, topicID ulid.ULID, policy *api.Dedu
 		etype.Duplicates = 0
 	}
 
-	// Respect context cancellation before moving into iteration
-	if err = ctx.Err(); err != nil {
-		return err
-	}
-
-	// Iterate over all of the events in the database and re-compute hashes.
-	iter := s.data.List(topicID)
-	defer iter.Release()
+	// Restore the duplicates and index the hashes of the original events.
+	if err = s.rehashEvents(ctx, topicID, info, func(event *api.EventWrapper) (err error) {
+		// Events are restored even if the topic is not deduplicated so that there are
+		// no references left in the topic from the previous policy.
+		if event.IsDuplicate {
+			if err = dereference(s.data, topicID, event); err != nil {
+				return fmt.Errorf("could not fetch duplicate from original: %w", err)
+			}
 
-deduplication:
-	for iter.Next() {
-		// Respect context cancellation and deadlines
-		if err := ctx.Err(); err != nil {
-			return err
+			event.IsDuplicate = false
+			event.DuplicateId = nil
+			if err = s.data.Insert(event); err != nil {
+				return fmt.Errorf("could not save restored duplicate to database: %w", err)
+			}
 		}
 
-		event, err := iter.Event()
-		if err != nil {
-			return fmt.Errorf("could not fetch next event in topic: %w", err)
+		// If none then skip over the deduplication checking step.
+		if policy.Strategy == api.Deduplication_NONE {
+			return nil
 		}
 
-		// If we've reached the end of the events specified by the topic info snapshot
-		// then stop looping otherwise we may inject a consistency issue
-		if bytes.Equal(event.Id, info.EventOffsetId) {
-			break deduplication
+		// Compute the hash of the event given the deduplication policy
+		var hash []byte
+		if hash, err = event.Hash(policy); err != nil {
+			return fmt.Errorf("could not compute hash of event: %w", err)
 		}
 
-		// If none then skip over the deduplication checking step.
-		if policy.Strategy != api.Deduplication_NONE {
-			// Compute the hash of the event given the deduplication policy
-			hash, err := event.Hash(policy)
-			if err != nil {
-				return fmt.Errorf("could not compute hash of event: %w", err)
+		// For the earliest offset policy only the first of the duplicates is indexed,
+		// otherwise the hash is reindexed to each event so the last event is indexed.
+		if policy.Offset != api.Deduplication_OFFSET_LATEST && filter.TestOrAdd(hash) {
+			// Load the identified duplicate, verify that it is a duplicate; if the hash
+			// is not found then the filter returned a false positive.
+			var target *api.EventWrapper
+			if target, err = s.data.Unhash(topicID, hash); err != nil && !errors.Is(err, errors.ErrNotFound) {
+				return fmt.Errorf("could not unhash event: %w", err)
 			}
 
-			// Check if the event is a duplicate of another event already
-			if filter.TestOrAdd(hash) {
-				// Load the identified duplicate, verify that it is a duplicate
-				var target *api.EventWrapper
-				if target, err = s.data.Unhash(topicID, hash); err != nil {
-					return fmt.Errorf("could not unhash event: %w", err)
-				}
-
+			if target != nil {
 				var isDuplicate bool
 				if isDuplicate, err = event.Duplicates(target, policy); err != nil {
 					return fmt.Errorf("could not identify duplicate: %w", err)
 				}
 
 				if isDuplicate {
-					// Mark the event as a duplicate and save back to database
-					// TODO: handle offset -- e.g. is the target the duplicate or the event?
-					if err = event.DuplicateOf(target, policy); err != nil {
-						return fmt.Errorf("could not mark duplicate: %w", err)
-					}
-
-					// Save the duplicate back to the database
-					if err = s.data.Insert(event); err != nil {
-						return fmt.Errorf("could not save duplicate: %w", err)
-					}
-
-					// Update the duplicate counts on the topic info
-					info.Duplicates++
-					if e, err := event.Unwrap(); err == nil {
-						etype := info.FindEventTypeInfo(e.ResolveType(), e.Mimetype)
-						etype.Duplicates++
-					}
-
-					// NOTE: We assume that everything from this point on in the loop that
-					// the event is not a duplicate and should be treated as original.
-					continue deduplication
+					return nil
 				}
 			}
+		}
 
-			// If the topic is not a duplicate store the hash in the database.
-			if err := s.data.Indash(topicID, hash, rlid.RLID(event.Id)); err != nil {
-				return fmt.Errorf("could not store hash in database: %w", err)
-			}
+		// Store the hash of the original event in the database.
+		if err = s.data.Indash(topicID, hash, rlid.RLID(event.Id)); err != nil {
+			return fmt.Errorf("could not store hash in database: %w", err)
 		}
+		return nil
+	}); err != nil {
+		return err
+	}
 
-		// From this point on, we assume that the event is not a duplicate.
-		// Ensure the topic is not marked as a duplicate; if it is, mark it as a non-duplicate.
-		if event.IsDuplicate {
-			var orig *api.EventWrapper
-			if orig, err = s.data.Retrieve(topicID, rlid.RLID(event.DuplicateId)); err != nil {
-				return fmt.Errorf("could not retrieve event: %w", err)
+	// Mark every event that is not indexed as a duplicate of the indexed event.
+	if policy.Strategy != api.Deduplication_NONE {
+		if err = s.rehashEvents(ctx, topicID, info, func(event *api.EventWrapper) (err error) {
+			var hash []byte
+			if hash, err = event.Hash(policy); err != nil {
+				return fmt.Errorf("could not compute hash of event: %w", err)
 			}
 
-			// TODO: do we need to handle the offset here?
-			if err = event.DuplicateFrom(orig); err != nil {
-				return fmt.Errorf("could not fetch duplicate from original: %w", err)
+			var target *api.EventWrapper
+			if target, err = s.data.Unhash(topicID, hash); err != nil {
+				if errors.Is(err, errors.ErrNotFound) {
+					return nil
+				}
+				return fmt.Errorf("could not unhash event: %w", err)
+			}
+
+			// The indexed event is the original event
+			if bytes.Equal(target.Id, event.Id) {
+				return nil
+			}
+
+			// If the hashes collide but the events are not duplicates, then the event
+			// is treated as an original even though its hash is not indexed.
+			var isDuplicate bool
+			if isDuplicate, err = event.Duplicates(target, policy); err != nil {
+				return fmt.Errorf("could not identify duplicate: %w", err)
+			}
+
+			if !isDuplicate {
+				return nil
+			}
+
+			// Mark the event as a duplicate and save back to database
+			if err = event.DuplicateOf(target, policy); err != nil {
+				return fmt.Errorf("could not mark duplicate: %w", err)
 			}
 
-			// Save the duplicate back to the database
 			if err = s.data.Insert(event); err != nil {
-				return fmt.Errorf("could not save duplicate to database: %w", err)
+				return fmt.Errorf("could not save duplicate: %w", err)
 			}
-		}
-	}
 
-	if err = iter.Error(); err != nil {
-		return err
+			// Update the duplicate counts on the topic info
+			info.Duplicates++
+			if e, err := target.Unwrap(); err == nil {
+				etype := info.FindEventTypeInfo(e.ResolveType(), e.Mimetype)
+				etype.Duplicates++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
 	}
 
 	// Save the topic info back to disk so that it can be carried on later.
@@ -193,3 +175,69 @@ deduplication:
 	}
 	return nil
 }
+
+// Iterate over all of the events in the topic up to the end of the topic info snapshot,
+// stopping if the context is canceled or if an error is returned by the callback.
+func (s *Server) rehashEvents(ctx context.Context, topicID ulid.ULID, info *api.TopicInfo, fn func(*api.EventWrapper) error) (err error) {
+	// Respect context cancellation before moving into iteration
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	iter := s.data.List(topicID)
+	defer iter.Release()
+
+	for iter.Next() {
+		// Respect context cancellation and deadlines
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		var event *api.EventWrapper
+		if event, err = iter.Event(); err != nil {
+			return fmt.Errorf("could not fetch next event in topic: %w", err)
+		}
+
+		// If we've reached the end of the events specified by the topic info snapshot
+		// then stop looping otherwise we may inject a consistency issue
+		if bytes.Equal(event.Id, info.EventOffsetId) {
+			break
+		}
+
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+
+	return iter.Error()
+}
+
+// Dereference populates the duplicate event with the data from the original event it
+// references so that there is correct event information; the event is still marked as
+// a duplicate. When the latest offset policy is used, the original event may itself be
+// rewritten as a reference to a later event when it is duplicated, so references are
+// followed until the original event is found.
+func dereference(data store.EventStore, topicID ulid.ULID, event *api.EventWrapper) (err error) {
+	refs := []*api.EventWrapper{event}
+	seen := map[string]struct{}{string(event.Id): {}}
+
+	for ref := event; ref.IsDuplicate; {
+		if _, ok := seen[string(ref.DuplicateId)]; ok {
+			return fmt.Errorf("duplicate %x references itself", ref.DuplicateId)
+		}
+		seen[string(ref.DuplicateId)] = struct{}{}
+
+		if ref, err = data.Retrieve(topicID, rlid.RLID(ref.DuplicateId)); err != nil {
+			return err
+		}
+		refs = append(refs, proto.Clone(ref).(*api.EventWrapper))
+	}
+
+	// Populate each reference from the event it references, starting with the original.
+	for i := len(refs) - 2; i >= 0; i-- {
+		if err = refs[i].DuplicateFrom(refs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
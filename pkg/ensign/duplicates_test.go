@@ -1,15 +1,25 @@
 package ensign_test
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/bits-and-blooms/bloom/v3"
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	store "github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (s *serverTestSuite) TestTopicFilter() {
@@ -98,3 +108,261 @@ func (s *serverTestSuite) TestTopicFilter() {
 		}
 	})
 }
+
+func (s *serverTestSuite) TestRehash() {
+	require := s.Require()
+	defer s.store.Reset()
+	topicID := ulid.MustParse("01HCZHJ1DP6W0WVQHXXRHVAMSH")
+
+	var (
+		events []*api.EventWrapper
+		hashes map[string][]byte
+		info   *api.TopicInfo
+	)
+
+	// Mock an in-memory event store that returns copies of the events like the database
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		out := make([]*api.EventWrapper, 0, len(events))
+		for _, event := range events {
+			out = append(out, proto.Clone(event).(*api.EventWrapper))
+		}
+		return store.NewEventIterator(out)
+	}
+
+	retrieve := func(eventID []byte) (*api.EventWrapper, error) {
+		for _, event := range events {
+			if bytes.Equal(event.Id, eventID) {
+				return proto.Clone(event).(*api.EventWrapper), nil
+			}
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	s.store.OnRetrieve = func(_ ulid.ULID, eventID rlid.RLID) (*api.EventWrapper, error) {
+		return retrieve(eventID.Bytes())
+	}
+
+	s.store.OnInsert = func(in *api.EventWrapper) error {
+		for i, event := range events {
+			if bytes.Equal(event.Id, in.Id) {
+				events[i] = proto.Clone(in).(*api.EventWrapper)
+				return nil
+			}
+		}
+		return errors.ErrNotFound
+	}
+
+	s.store.OnClearIndash = func(ulid.ULID) error {
+		hashes = make(map[string][]byte)
+		return nil
+	}
+
+	s.store.OnIndash = func(_ ulid.ULID, hash []byte, eventID rlid.RLID) error {
+		hashes[string(hash)] = eventID.Bytes()
+		return nil
+	}
+
+	s.store.OnUnhash = func(_ ulid.ULID, hash []byte) (*api.EventWrapper, error) {
+		if eventID, ok := hashes[string(hash)]; ok {
+			return retrieve(eventID)
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	s.store.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) {
+		return &api.TopicInfo{TopicId: topicID.Bytes(), Events: uint64(len(events))}, nil
+	}
+
+	s.store.OnUpdateTopicInfo = func(in *api.TopicInfo) error {
+		info = in
+		return nil
+	}
+
+	// Returns the data of the stored event, dereferencing duplicates
+	data := func(idx int) []byte {
+		event := proto.Clone(events[idx]).(*api.EventWrapper)
+		if event.IsDuplicate {
+			target, err := retrieve(event.DuplicateId)
+			require.NoError(err, "could not retrieve original of event %d", idx)
+			require.False(target.IsDuplicate, "expected event %d to reference an original event", idx)
+			require.NoError(event.DuplicateFrom(target))
+		}
+
+		e, err := event.Unwrap()
+		require.NoError(err, "could not unwrap event %d", idx)
+		return e.Data
+	}
+
+	// Requires that the events are duplicates of the original event in the map and
+	// that all other events are originals.
+	requireDuplicates := func(originals map[int]int) {
+		for idx, event := range events {
+			if orig, ok := originals[idx]; ok {
+				require.True(event.IsDuplicate, "expected event %d to be a duplicate", idx)
+				require.Equal(events[orig].Id, event.DuplicateId, "expected event %d to be a duplicate of event %d", idx, orig)
+			} else {
+				require.False(event.IsDuplicate, "expected event %d to be an original", idx)
+				require.Empty(event.DuplicateId, "expected event %d to be an original", idx)
+				require.NotEmpty(event.Event, "expected event %d to have data", idx)
+			}
+		}
+		require.Equal(uint64(len(originals)), info.Duplicates)
+	}
+
+	s.Run("Albums", func() {
+		fixtures, err := loadEventFixtures("testdata/albums.json", topicID, "pid")
+		require.NoError(err, "could not load albums fixtures")
+		expected := eventsData(fixtures)
+		events = fixtures
+
+		// The albums are not strict duplicates since the album was updated
+		policy := &api.Deduplication{Strategy: api.Deduplication_STRICT}
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(nil)
+
+		// With the earliest offset the updated album references the first album but
+		// keeps its own data since duplicates are not overwritten.
+		policy = &api.Deduplication{Strategy: api.Deduplication_UNIQUE_KEY, Keys: []string{"pid"}, Offset: api.Deduplication_OFFSET_EARLIEST}
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(map[int]int{4: 0})
+		require.Equal(expected[4], data(4))
+
+		// With the latest offset the updated album is the original
+		policy.Offset = api.Deduplication_OFFSET_LATEST
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(map[int]int{0: 4})
+		require.Equal(expected[0], data(0))
+		require.Equal(expected[4], data(4))
+
+		// If duplicates are overwritten then the updated album is returned for both
+		policy.OverwriteDuplicate = true
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(map[int]int{0: 4})
+		require.Empty(events[0].Event, "expected overwritten duplicate to have no data")
+		require.Equal(expected[4], data(0))
+
+		// If the topic is no longer deduplicated the events are restored, though the
+		// overwritten data cannot be recovered.
+		policy = &api.Deduplication{Strategy: api.Deduplication_NONE}
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(nil)
+		require.Equal(expected[4], data(0))
+		require.Equal(expected[4], data(4))
+	})
+
+	s.Run("ListensEarliest", func() {
+		fixtures, err := loadEventFixtures("testdata/listens.json", topicID, "user")
+		require.NoError(err, "could not load listens fixtures")
+		expected := eventsData(fixtures)
+		events = fixtures
+
+		// The first listen of each user is the original; the duplicates keep their
+		// own data since the listens are unique by user and are not overwritten.
+		policy := &api.Deduplication{Strategy: api.Deduplication_UNIQUE_KEY, Keys: []string{"user"}}
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(map[int]int{4: 0, 7: 0, 2: 1, 10: 1, 11: 1, 12: 1, 13: 1, 5: 3, 6: 3, 8: 3, 9: 3, 14: 3, 15: 3})
+
+		for idx := range events {
+			require.Equal(expected[idx], data(idx), "expected event %d to keep its data", idx)
+		}
+	})
+
+	s.Run("ListensLatestOverwrite", func() {
+		fixtures, err := loadEventFixtures("testdata/listens.json", topicID, "user")
+		require.NoError(err, "could not load listens fixtures")
+		expected := eventsData(fixtures)
+		events = fixtures
+
+		// The last listen of each user is the original and is returned for every
+		// listen by the user since the duplicates are overwritten (last write wins).
+		policy := &api.Deduplication{Strategy: api.Deduplication_UNIQUE_KEY, Keys: []string{"user"}, Offset: api.Deduplication_OFFSET_LATEST, OverwriteDuplicate: true}
+		originals := map[int]int{0: 7, 4: 7, 1: 13, 2: 13, 10: 13, 11: 13, 12: 13, 3: 15, 5: 15, 6: 15, 8: 15, 9: 15, 14: 15}
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(originals)
+
+		for idx, orig := range originals {
+			require.Empty(events[idx].Event, "expected overwritten duplicate %d to have no data", idx)
+			require.Equal(expected[orig], data(idx), "expected event %d to be overwritten by event %d", idx, orig)
+		}
+	})
+
+	s.Run("Chain", func() {
+		fixtures, err := loadEventFixtures("testdata/listens.json", topicID, "user")
+		require.NoError(err, "could not load listens fixtures")
+		expected := eventsData(fixtures)
+		events = fixtures
+
+		// Publishing with the latest offset policy can create chains of references
+		policy := &api.Deduplication{Strategy: api.Deduplication_UNIQUE_KEY, Keys: []string{"user"}, Offset: api.Deduplication_OFFSET_LATEST}
+		require.NoError(events[4].DuplicateOf(events[7], policy))
+		require.NoError(events[0].DuplicateOf(events[4], policy))
+
+		// Rehashing restores the events from the chain of references
+		policy.Strategy = api.Deduplication_NONE
+		require.NoError(s.srv.Rehash(context.Background(), topicID, policy))
+		requireDuplicates(nil)
+
+		for idx := range events {
+			require.Equal(expected[idx], data(idx), "expected event %d to be restored", idx)
+		}
+
+		// Cyclic references cannot be restored
+		events[7].IsDuplicate, events[7].DuplicateId = true, events[0].Id
+		events[0].IsDuplicate, events[0].DuplicateId = true, events[7].Id
+		require.Error(s.srv.Rehash(context.Background(), topicID, policy))
+	})
+}
+
+// Load the JSON fixtures as events in the topic, adding the values of the specified
+// fields of each fixture to the event metadata.
+func loadEventFixtures(path string, topicID ulid.ULID, keys ...string) (_ []*api.EventWrapper, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+
+	var fixtures []map[string]any
+	if err = json.Unmarshal(data, &fixtures); err != nil {
+		return nil, err
+	}
+
+	var seq rlid.Sequence
+	ts := time.Date(2023, 10, 16, 12, 0, 0, 0, time.UTC)
+	events := make([]*api.EventWrapper, 0, len(fixtures))
+	for i, fixture := range fixtures {
+		event := &api.Event{
+			Metadata: make(map[string]string),
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Fixture", MajorVersion: 1},
+			Created:  timestamppb.New(ts.Add(time.Duration(i) * time.Minute)),
+		}
+
+		if event.Data, err = json.Marshal(fixture); err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			event.Metadata[key] = fmt.Sprint(fixture[key])
+		}
+
+		wrapper := &api.EventWrapper{Id: seq.Next().Bytes(), TopicId: topicID.Bytes(), Committed: event.Created}
+		if err = wrapper.Wrap(event); err != nil {
+			return nil, err
+		}
+		events = append(events, wrapper)
+	}
+	return events, nil
+}
+
+// Returns the data of each of the events in order.
+func eventsData(events []*api.EventWrapper) [][]byte {
+	data := make([][]byte, 0, len(events))
+	for _, event := range events {
+		e, err := event.Unwrap()
+		if err != nil {
+			panic(err)
+		}
+		data = append(data, e.Data)
+	}
+	return data
+}
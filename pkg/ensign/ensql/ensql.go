@@ -4,6 +4,8 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 )
 
 // Parse an EnSQL statement to create a Query object for an Ensign SQL execution. An
@@ -80,7 +82,12 @@ func (p *parser) exec() error {
 
 		case stepSelectField:
 			// After a SELECT statement we expect a comma separated list of fields or *
-			field := p.peek()
+			field := p.peekField()
+			if field.Type == Function {
+				p.step = stepSelectAggregate
+				continue
+			}
+
 			if field.Type != Identifier && field.Type != Asterisk {
 				if field.Type == ReservedWord {
 					return ErrNoFieldsSelected
@@ -92,7 +99,7 @@ func (p *parser) exec() error {
 			p.query.Fields = append(p.query.Fields, field)
 
 			// Advance the index so that we can peek ahead to determine the next state
-			p.pop()
+			p.popField()
 
 			next := p.peek()
 			switch next.Token {
@@ -110,6 +117,63 @@ func (p *parser) exec() error {
 				return Error(p.idx, next.Token, "invalid select fields statement")
 			}
 
+		case stepSelectAggregate:
+			// Pop the function and ensure that the step is correct
+			function := p.pop()
+			if function.Type != Function {
+				panic(InvalidState("aggregate function", function.Token))
+			}
+
+			aggregate := Aggregate{}
+			var err error
+			if aggregate.Function, err = function.ParseAggregate(); err != nil {
+				return Error(p.idx, function.Token, err.Error())
+			}
+
+			// The function is only identified if it is followed by parens
+			if lp := p.pop(); lp.Token != LP {
+				panic(InvalidState(LP, lp.Token))
+			}
+
+			// The aggregate is applied to a field or * if the function is count
+			aggregate.Field = p.popField()
+			switch aggregate.Field.Type {
+			case Identifier:
+			case Asterisk:
+				if aggregate.Function != Count {
+					return Errorf(p.idx, aggregate.Field.Token, "cannot apply %s to *", aggregate.Function)
+				}
+			default:
+				return Error(p.idx, aggregate.Field.Token, "invalid aggregate field identifier")
+			}
+
+			if rp := p.pop(); rp.Token != RP {
+				return Error(p.idx, rp.Token, "aggregate function missing closing parens")
+			}
+
+			// Add the aggregate to the fields using its string representation so that it
+			// can be aliased and so that the results are ordered by the select fields.
+			token := aggregate.String()
+			p.query.Fields = append(p.query.Fields, Token{token, Function, len(token)})
+
+			if p.query.Aggregates == nil {
+				p.query.Aggregates = make(map[string]Aggregate)
+			}
+			p.query.Aggregates[token] = aggregate
+
+			next := p.peek()
+			switch next.Token {
+			case COMMA:
+				p.pop()
+				p.step = stepSelectField
+			case AS:
+				p.step = stepSelectFieldAlias
+			case FROM:
+				p.step = stepSelectFrom
+			default:
+				return Error(p.idx, next.Token, "invalid select fields statement")
+			}
+
 		case stepSelectFieldAlias:
 			// Pop the aliasing reserved word and ensure that the step is correct
 			if rword := p.pop(); rword.Token != AS {
@@ -180,6 +244,8 @@ func (p *parser) exec() error {
 				p.step = stepTerm
 			case WHERE:
 				p.step = stepWhere
+			case GROUPBY:
+				p.step = stepGroupBy
 			case OFFSET:
 				p.step = stepOffset
 			case LIMIT:
@@ -218,6 +284,8 @@ func (p *parser) exec() error {
 				p.step = stepTerm
 			case WHERE:
 				p.step = stepWhere
+			case GROUPBY:
+				p.step = stepGroupBy
 			case OFFSET:
 				p.step = stepOffset
 			case LIMIT:
@@ -253,6 +321,8 @@ func (p *parser) exec() error {
 				p.step = stepTerm
 			case WHERE:
 				p.step = stepWhere
+			case GROUPBY:
+				p.step = stepGroupBy
 			case OFFSET:
 				p.step = stepOffset
 			case LIMIT:
@@ -290,7 +360,7 @@ func (p *parser) exec() error {
 
 		case stepWhereField:
 			// Pop the field and ensure that it is an identifier
-			field := p.pop()
+			field := p.popField()
 			if field.Type != Identifier {
 				return Error(p.idx, field.Token, "where clause predicates expressions must start with an identifier")
 			}
@@ -305,7 +375,49 @@ func (p *parser) exec() error {
 			if next.Type != OperatorToken {
 				return Error(p.idx, next.Token, "invalid where clause")
 			}
-			p.step = stepWhereOperator
+
+			if next.Token != BETWEEN {
+				p.step = stepWhereOperator
+				continue
+			}
+
+			// BETWEEN is rewritten as two inclusive conditions on the field joined by AND;
+			// since AND binds more tightly than OR no parentheses are required.
+			p.pop()
+			low := p.pop()
+			if !isValue(low) {
+				return Error(p.idx, low.Token, "invalid between clause")
+			}
+
+			if err := p.query.Conditions.ConditionOperator(Token{GTE, OperatorToken, len(GTE)}); err != nil {
+				return Error(p.idx, low.Token, err.Error())
+			}
+
+			if err := p.query.Conditions.ConditionRight(low); err != nil {
+				return Error(p.idx, low.Token, err.Error())
+			}
+
+			if and := p.pop(); and.Token != AND {
+				return Error(p.idx, and.Token, "between clause requires AND")
+			}
+
+			if err := p.query.Conditions.LogicalOperator(And); err != nil {
+				return Error(p.idx, AND, err.Error())
+			}
+
+			if err := p.query.Conditions.ConditionLeft(field); err != nil {
+				return Error(p.idx, field.Token, err.Error())
+			}
+
+			if err := p.query.Conditions.ConditionOperator(Token{LTE, OperatorToken, len(LTE)}); err != nil {
+				return Error(p.idx, field.Token, err.Error())
+			}
+
+			// The upper bound is added to the condition by the where value step
+			if high := p.peek(); !isValue(high) {
+				return Error(p.idx, high.Token, "invalid between clause")
+			}
+			p.step = stepWhereValue
 
 		case stepWhereOperator:
 			// Pop the operator and ensure that it is a comparison operator
@@ -344,6 +456,8 @@ func (p *parser) exec() error {
 				p.step = stepWhereCloseParens
 			case AND, OR:
 				p.step = stepWhereLogical
+			case GROUPBY:
+				p.step = stepGroupBy
 			case OFFSET:
 				p.step = stepOffset
 			case LIMIT:
@@ -366,6 +480,8 @@ func (p *parser) exec() error {
 				p.step = stepTerm
 			case AND, OR:
 				p.step = stepWhereLogical
+			case GROUPBY:
+				p.step = stepGroupBy
 			case OFFSET:
 				p.step = stepOffset
 			case LIMIT:
@@ -404,15 +520,47 @@ func (p *parser) exec() error {
 				return Error(p.idx, next.Token, "invalid where clause")
 			}
 
+		case stepGroupBy:
+			// Pop the GROUP BY keyword and ensure that the step is correct
+			if rword := p.pop(); rword.Token != GROUPBY {
+				panic(InvalidState(GROUPBY, rword.Token))
+			} else if len(p.query.GroupBy) > 0 {
+				return Error(p.idx, rword.Token, "group by clause has already been identified")
+			}
+			p.step = stepGroupByField
+
+		case stepGroupByField:
+			// After GROUP BY we expect a comma separated list of fields
+			field := p.popField()
+			if field.Type != Identifier {
+				return Error(p.idx, field.Token, "invalid group by field identifier")
+			}
+			p.query.GroupBy = append(p.query.GroupBy, field)
+
+			next := p.peek()
+			switch next.Token {
+			case COMMA:
+				p.pop()
+				p.step = stepGroupByField
+			case SC, Empty.Token:
+				p.step = stepTerm
+			case OFFSET:
+				p.step = stepOffset
+			case LIMIT:
+				p.step = stepLimit
+			default:
+				return Error(p.idx, next.Token, "invalid group by clause")
+			}
+
 		case stepOffset:
 			// Pop the OFFSET reserved word and ensure that the step is correct
 			if rword := p.pop(); rword.Token != OFFSET {
 				panic(InvalidState(OFFSET, rword.Token))
 			}
 
-			// After OFFSET we expect a numeric identifier
+			// After OFFSET we expect a numeric identifier or a quoted event id
 			offset := p.peek()
-			if offset.Type != Numeric {
+			if offset.Type != Numeric && offset.Type != QuotedString {
 				return Error(p.idx, offset.Token, "invalid offset")
 			}
 
@@ -422,9 +570,16 @@ func (p *parser) exec() error {
 			}
 
 			// Set the offset on the query
-			var err error
-			if p.query.Offset, err = offset.ParseUint(10, 64); err != nil {
-				return Error(p.idx, offset.Token, "could not parse offset")
+			if offset.Type == QuotedString {
+				var err error
+				if p.query.OffsetID, err = rlid.ParseStrict(offset.Token); err != nil {
+					return Error(p.idx, offset.Token, "invalid offset")
+				}
+			} else {
+				var err error
+				if p.query.Offset, err = offset.ParseUint(10, 64); err != nil {
+					return Error(p.idx, offset.Token, "could not parse offset")
+				}
 			}
 			p.query.HasOffset = true
 
@@ -534,6 +689,37 @@ func (p *parser) validateSelectQuery() error {
 		}
 	}
 
+	// The where clause must be a complete predicate that can be evaluated
+	if p.query.Conditions != nil {
+		if _, err := p.query.Conditions.Predicate(); err != nil {
+			return err
+		}
+	}
+
+	// If parsing stopped while expecting a group by field then the clause is incomplete
+	if p.step == stepGroupByField {
+		return ErrGroupByFields
+	}
+
+	// In an aggregate query all fields that are not aggregated must be grouped
+	if p.query.IsAggregate() {
+		grouped := make(map[string]struct{}, len(p.query.GroupBy))
+		for _, field := range p.query.GroupBy {
+			grouped[field.Token] = struct{}{}
+		}
+
+		for _, field := range p.query.Fields {
+			switch field.Type {
+			case Asterisk:
+				return ErrAggregateAllFields
+			case Identifier:
+				if _, ok := grouped[field.Token]; !ok {
+					return ErrNonAggregateField
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -546,12 +732,36 @@ func (p *parser) pop() Token {
 	return peeked
 }
 
+// PopField is like pop but advances the index past a field path such as a.b.c rather
+// than stopping at the first dot.
+func (p *parser) popField() Token {
+	peeked := p.peekField()
+	p.idx += peeked.Length
+	p.strip()
+	return peeked
+}
+
 // Peek returns the next token without modifying the underlying state of the parser.
 func (p *parser) peek() Token {
 	if p.idx >= len(p.sql) {
 		return Empty
 	}
 
+	// Check to see if the next token is a contextual keyword; these are checked before
+	// the reserved words since they must be followed by specific tokens.
+	if loc := groupbyre.FindStringIndex(p.sql[p.idx:]); loc != nil {
+		return Token{GROUPBY, ReservedWord, loc[1]}
+	}
+
+	if loc := betweenre.FindStringIndex(p.sql[p.idx:]); loc != nil {
+		return Token{BETWEEN, OperatorToken, loc[1]}
+	}
+
+	if loc := functionre.FindStringSubmatchIndex(p.sql[p.idx:]); loc != nil {
+		token := p.sql[p.idx+loc[2] : p.idx+loc[3]]
+		return Token{strings.ToUpper(token), Function, len(token)}
+	}
+
 	// Check to see if the next token is any of our reserved words.
 	for _, rWord := range ReservedWords {
 		token := strings.ToUpper(p.sql[p.idx:min(len(p.sql), p.idx+len(rWord))])
@@ -583,6 +793,16 @@ func (p *parser) peek() Token {
 	return identifier
 }
 
+// Returns true if the token can be used as a value on the right side of a condition.
+func isValue(token Token) bool {
+	switch token.Type {
+	case QuotedString, Numeric, Boolean:
+		return true
+	default:
+		return false
+	}
+}
+
 // Returns the token that is inside a pair of single quotes e.g. 'token' ensuring that
 // any escaped quotes are included, e.g. 'token\'s' should return token's. Note that the
 // enclosing quotes are removed from the token but the length includes the quotes to
@@ -611,7 +831,39 @@ func (p *parser) peekQuotedString() Token {
 	return Token{"", EmptyToken, len(p.sql) - p.idx}
 }
 
-var numre = regexp.MustCompile(`[-\.0-9]`)
+// Returns the next token, joining identifiers separated by dots into a single field
+// identifier so that nested fields can be referenced, e.g. user.address.city. There
+// cannot be whitespace around the dots in a field path.
+func (p *parser) peekField() Token {
+	field := p.peek()
+	if field.Type != Identifier {
+		return field
+	}
+
+	for i := p.idx + field.Length; i+1 < len(p.sql) && p.sql[i] == DOT[0]; {
+		j := i + 1
+		for j < len(p.sql) && identre.MatchString(string(p.sql[j])) {
+			j++
+		}
+
+		// A trailing dot is not part of the field
+		if j == i+1 {
+			break
+		}
+
+		field.Token = p.sql[p.idx:j]
+		field.Length = len(field.Token)
+		i = j
+	}
+	return field
+}
+
+var (
+	numre      = regexp.MustCompile(`[-\.0-9]`)
+	groupbyre  = regexp.MustCompile(`^(?i)GROUP\s+BY\b`)
+	betweenre  = regexp.MustCompile(`^(?i)BETWEEN\b`)
+	functionre = regexp.MustCompile(`^(?i)(COUNT|SUM|AVG|MIN|MAX)\s*\(`)
+)
 
 func (p *parser) peekNumeric() Token {
 	// Numeric matches any positive or negative decimal number (base10) including both
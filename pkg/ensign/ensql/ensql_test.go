@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	. "github.com/rotationalio/ensign/pkg/ensign/ensql"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -112,6 +113,18 @@ func TestParse(t *testing.T) {
 			Expected: nil,
 			Err:      Error(12, "age", "invalid select fields statement").Error(),
 		},
+		{
+			Name:     "select nested fields",
+			SQL:      "SELECT user.name AS name, user.address.city FROM topic",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"user.name", Identifier, 9}, {"user.address.city", Identifier, 17}}, Topic: Topic{Topic: "topic"}, Aliases: map[string]string{"user.name": "name"}},
+			Err:      "",
+		},
+		{
+			Name:     "select nested field trailing dot",
+			SQL:      "SELECT user. FROM topic",
+			Expected: nil,
+			Err:      Error(11, ".", "invalid select fields statement").Error(),
+		},
 		{
 			Name:     "invalid alias",
 			SQL:      "SELECT name AS 1234 FROM topic",
@@ -442,6 +455,146 @@ func TestParse(t *testing.T) {
 			Expected: nil,
 			Err:      "syntax error at position 32 near \"red\": invalid where clause",
 		},
+		{
+			Name:     "where unclosed parens",
+			SQL:      "SELECT * FROM topic WHERE (color = 'red' OR color = 'blue'",
+			Expected: nil,
+			Err:      ErrCloseParens.Error(),
+		},
+		{
+			Name:     "where nested field trailing dot",
+			SQL:      "SELECT * FROM topic WHERE user. = 'red'",
+			Expected: nil,
+			Err:      Error(30, ".", "invalid where clause").Error(),
+		},
+		{
+			Name: "aggregates with group by",
+			SQL:  "SELECT type_name, COUNT(*) AS events, avg(reading) FROM topic WHERE region = 'us-east-1' GROUP BY type_name LIMIT 10",
+			Expected: &Query{
+				Type:       SelectQuery,
+				Fields:     []Token{{"type_name", Identifier, 9}, {"COUNT(*)", Function, 8}, {"AVG(reading)", Function, 12}},
+				Topic:      Topic{Topic: "topic"},
+				Conditions: MakeConditionGroup("region = 'us-east-1'"),
+				Aliases:    map[string]string{"COUNT(*)": "events"},
+				Aggregates: map[string]Aggregate{"COUNT(*)": {Count, Token{"*", Asterisk, 1}}, "AVG(reading)": {Avg, Token{"reading", Identifier, 7}}},
+				GroupBy:    []Token{{"type_name", Identifier, 9}},
+				Limit:      10,
+				HasLimit:   true,
+			},
+			Err: "",
+		},
+		{
+			Name: "aggregates without group by",
+			SQL:  "SELECT COUNT (*), SUM(cart.total), MIN(created), MAX(created) FROM topic",
+			Expected: &Query{
+				Type:   SelectQuery,
+				Fields: []Token{{"COUNT(*)", Function, 8}, {"SUM(cart.total)", Function, 15}, {"MIN(created)", Function, 12}, {"MAX(created)", Function, 12}},
+				Topic:  Topic{Topic: "topic"},
+				Aggregates: map[string]Aggregate{
+					"COUNT(*)":        {Count, Token{"*", Asterisk, 1}},
+					"SUM(cart.total)": {Sum, Token{"cart.total", Identifier, 10}},
+					"MIN(created)":    {Min, Token{"created", Identifier, 7}},
+					"MAX(created)":    {Max, Token{"created", Identifier, 7}},
+				},
+			},
+			Err: "",
+		},
+		{
+			Name: "group by multiple fields",
+			SQL:  "SELECT type_name, region, count(*) FROM topic.Reading GROUP BY type_name, region;",
+			Expected: &Query{
+				Type:       SelectQuery,
+				Fields:     []Token{{"type_name", Identifier, 9}, {"region", Identifier, 6}, {"COUNT(*)", Function, 8}},
+				Topic:      Topic{Topic: "topic", Schema: "Reading"},
+				Aggregates: map[string]Aggregate{"COUNT(*)": {Count, Token{"*", Asterisk, 1}}},
+				GroupBy:    []Token{{"type_name", Identifier, 9}, {"region", Identifier, 6}},
+			},
+			Err: "",
+		},
+		{
+			Name:     "group by without aggregates",
+			SQL:      "SELECT type_name FROM topic GROUP BY type_name",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"type_name", Identifier, 9}}, Topic: Topic{Topic: "topic"}, GroupBy: []Token{{"type_name", Identifier, 9}}},
+			Err:      "",
+		},
+		{
+			Name:     "fields named like aggregate functions",
+			SQL:      "SELECT count, summary, grouped FROM topic",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"count", Identifier, 5}, {"summary", Identifier, 7}, {"grouped", Identifier, 7}}, Topic: Topic{Topic: "topic"}},
+			Err:      "",
+		},
+		{
+			Name:     "cannot sum all fields",
+			SQL:      "SELECT SUM(*) FROM topic",
+			Expected: nil,
+			Err:      Error(12, "*", "cannot apply SUM to *").Error(),
+		},
+		{
+			Name:     "aggregate missing closing parens",
+			SQL:      "SELECT COUNT(name FROM topic",
+			Expected: nil,
+			Err:      Error(23, "FROM", "aggregate function missing closing parens").Error(),
+		},
+		{
+			Name:     "non aggregate field not grouped",
+			SQL:      "SELECT name, COUNT(*) FROM topic",
+			Expected: nil,
+			Err:      ErrNonAggregateField.Error(),
+		},
+		{
+			Name:     "select all fields with group by",
+			SQL:      "SELECT * FROM topic GROUP BY name",
+			Expected: nil,
+			Err:      ErrAggregateAllFields.Error(),
+		},
+		{
+			Name:     "group by trailing comma",
+			SQL:      "SELECT name, COUNT(*) FROM topic GROUP BY name,",
+			Expected: nil,
+			Err:      ErrGroupByFields.Error(),
+		},
+		{
+			Name:     "double group by",
+			SQL:      "SELECT name FROM topic GROUP BY name GROUP BY name",
+			Expected: nil,
+			Err:      Error(37, "GROUP BY", "invalid group by clause").Error(),
+		},
+		{
+			Name:     "where between",
+			SQL:      "SELECT * FROM topic WHERE reading BETWEEN 10 AND 20 OR region = 'us-east-1'",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"*", Asterisk, 1}}, Topic: Topic{Topic: "topic"}, Conditions: MakeConditionGroup("reading >= 10 AND reading <= 20 OR region = 'us-east-1'")},
+			Err:      "",
+		},
+		{
+			Name:     "where between timestamps",
+			SQL:      "SELECT * FROM topic WHERE region = 'us-east-1' AND created between '2023-07-29' and '2023-07-30' LIMIT 10",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"*", Asterisk, 1}}, Topic: Topic{Topic: "topic"}, Conditions: MakeConditionGroup("region = 'us-east-1' AND created >= '2023-07-29' AND created <= '2023-07-30'"), Limit: 10, HasLimit: true},
+			Err:      "",
+		},
+		{
+			Name:     "where field prefixed by between",
+			SQL:      "SELECT * FROM topic WHERE betweenness = 1",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"*", Asterisk, 1}}, Topic: Topic{Topic: "topic"}, Conditions: MakeConditionGroup("betweenness = 1")},
+			Err:      "",
+		},
+		{
+			Name:     "where between missing and",
+			SQL:      "SELECT * FROM topic WHERE reading BETWEEN 10 OR 20",
+			Expected: nil,
+			Err:      Error(48, "OR", "between clause requires AND").Error(),
+		},
+		{
+			Name:     "where between missing value",
+			SQL:      "SELECT * FROM topic WHERE reading BETWEEN 10 AND",
+			Expected: nil,
+			Err:      Error(48, "", "invalid between clause").Error(),
+		},
+		{
+			Name:     "offset event id",
+			SQL:      "SELECT * FROM topic OFFSET '064yrcthc000000d' LIMIT 10",
+			Expected: &Query{Type: SelectQuery, Fields: []Token{{"*", Asterisk, 1}}, Topic: Topic{Topic: "topic"}, OffsetID: rlid.MustParse("064yrcthc000000d"), HasOffset: true, Limit: 10, HasLimit: true},
+			Err:      "",
+		},
 		{
 			Name:     "topic with dash",
 			SQL:      "SELECT * FROM dashed-topic",
@@ -485,3 +638,19 @@ func TestParse(t *testing.T) {
 	}
 
 }
+
+func TestParseFieldPaths(t *testing.T) {
+	query, err := Parse("SELECT user.name FROM topic.Person.2 WHERE user.address.city = 'Boston' AND user.age > 21")
+	require.NoError(t, err, "could not parse query with nested fields")
+	require.Equal(t, []Token{{"user.name", Identifier, 9}}, query.Fields)
+	require.Equal(t, Topic{Topic: "topic", Schema: "Person", Version: 2}, query.Topic)
+
+	where, err := query.Conditions.Predicate()
+	require.NoError(t, err, "could not create predicate from nested fields")
+	require.Equal(t, "user.address.city = 'Boston' AND user.age > 21", where.String())
+
+	conjuncts := where.Conjuncts()
+	require.Len(t, conjuncts, 2)
+	require.Equal(t, "user.address.city", conjuncts[0].Identifier())
+	require.Equal(t, "user.age", conjuncts[1].Identifier())
+}
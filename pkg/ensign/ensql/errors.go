@@ -13,6 +13,8 @@ var (
 	ErrInvalidSelectAllFields = errors.New("cannot select * and specify fields")
 	ErrNonNumeric             = errors.New("cannot parse non-numeric token as a number")
 	ErrNonBoolean             = errors.New("cannot parse non-boolean token as a bool")
+	ErrNonString              = errors.New("cannot parse non-quoted token as a string")
+	ErrNonTimestamp           = errors.New("cannot parse token as a timestamp")
 	ErrNotAnOperator          = errors.New("cannot parse token as an operator")
 	ErrUnknownOperator        = errors.New("unknown operator token specified")
 	ErrPredicateType          = errors.New("unknown or unhandled operator in predicate")
@@ -21,6 +23,12 @@ var (
 	ErrCloseParens            = errors.New("cannot close expression parentheses")
 	ErrAppendOperator         = errors.New("cannot append operator to condition group")
 	ErrAppendCondition        = errors.New("cannot append or update condition in group")
+	ErrUnhandledVariables     = errors.New("cannot lookup identifiers in unhandled variables type")
+	ErrNotAFunction           = errors.New("cannot parse token as an aggregate function")
+	ErrUnknownFunction        = errors.New("unknown aggregate function specified")
+	ErrNonAggregateField      = errors.New("selected fields must be aggregated or in the GROUP BY clause")
+	ErrAggregateAllFields     = errors.New("cannot select * in an aggregate query")
+	ErrGroupByFields          = errors.New("GROUP BY requires one or more fields")
 )
 
 type SyntaxError struct {
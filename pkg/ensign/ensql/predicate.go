@@ -1,6 +1,13 @@
 package ensql
 
-import "errors"
+import (
+	"cmp"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 // Predicate implements a binary abstract syntax tree that can be used to evaluate
 // complex predicate expressions that are created from where clauses. Predicates should
@@ -70,7 +77,18 @@ func (p Predicate) Validate() error {
 	}
 }
 
-// Evaluate the predicate tree for the specified variables.
+// Variables resolve the identifiers in a predicate to the values that the predicate is
+// evaluated against, e.g. the fields of an event. If the identifier cannot be resolved
+// then ok should be false.
+type Variables interface {
+	Lookup(identifier string) (value any, ok bool)
+}
+
+// Evaluate the predicate tree for the specified variables. Variables can be either a
+// Variables interface or a map[string]any; identifiers are resolved from the first
+// variables that contain the identifier. If an identifier is not found or the value
+// cannot be compared to the value in the predicate then the comparison is false (as
+// with NULL values in SQL), errors are only returned if the predicate is invalid.
 // TODO: from the predicate tree, extract a comparable representation that doesn't have
 // to parse numbers/bools/etc every time and can be applied more efficiently to a large
 // number of events.
@@ -90,16 +108,107 @@ func (p Predicate) Evaluate(vars ...any) (bool, error) {
 // Compare requires the left value to be an identifier token and the right value to be
 // either a numeric, quoted string, or boolean value to ensure the comparison operation
 // happens correctly with the specified variables.
-func (p Predicate) compare(vars ...any) (bool, error) {
-	// TODO: implement
-	return false, errors.New("not implemented yet")
+func (p Predicate) compare(vars ...any) (_ bool, err error) {
+	var (
+		ok    bool
+		left  Token
+		right Token
+		value any
+	)
+
+	if left, ok = p.Left.(Token); !ok || left.Type != Identifier {
+		return false, ErrInvalidPredicate
+	}
+
+	if right, ok = p.Right.(Token); !ok {
+		return false, ErrInvalidPredicate
+	}
+
+	if value, ok, err = lookup(left.Token, vars); err != nil || !ok {
+		return false, err
+	}
+
+	var order int
+	switch right.Type {
+	case Numeric:
+		order, ok, err = compareNumeric(value, right)
+	case QuotedString:
+		order, ok, err = compareString(value, right)
+	case Boolean:
+		order, ok, err = compareBool(value, right)
+	default:
+		return false, ErrInvalidPredicate
+	}
+
+	if err != nil || !ok {
+		return false, err
+	}
+
+	switch p.Operator {
+	case Eq:
+		return order == 0, nil
+	case Ne:
+		return order != 0, nil
+	case Gt:
+		return order > 0, nil
+	case Lt:
+		return order < 0, nil
+	case Gte:
+		return order >= 0, nil
+	case Lte:
+		return order <= 0, nil
+	default:
+		return false, ErrInvalidPredicate
+	}
 }
 
 // Search implements the like and ilike operators. The left value should be an
-// identifier and the right token should be a quoted string.
-func (p Predicate) search(vars ...any) (bool, error) {
-	// TODO: implement
-	return false, errors.New("not implemented yet")
+// identifier and the right token should be a quoted string. The pattern uses % to
+// match any sequence of zero or more characters and _ to match any single character;
+// either can be escaped with a backslash to match the literal character.
+func (p Predicate) search(vars ...any) (_ bool, err error) {
+	var (
+		ok      bool
+		left    Token
+		right   Token
+		value   any
+		pattern string
+	)
+
+	if left, ok = p.Left.(Token); !ok || left.Type != Identifier {
+		return false, ErrInvalidPredicate
+	}
+
+	if right, ok = p.Right.(Token); !ok {
+		return false, ErrInvalidPredicate
+	}
+
+	if pattern, err = right.ParseString(); err != nil {
+		return false, ErrInvalidPredicate
+	}
+
+	if value, ok, err = lookup(left.Token, vars); err != nil || !ok {
+		return false, err
+	}
+
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return false, nil
+	}
+
+	switch p.Operator {
+	case Like:
+		return like(s, pattern), nil
+	case ILike:
+		return like(strings.ToLower(s), strings.ToLower(pattern)), nil
+	default:
+		return false, ErrInvalidPredicate
+	}
 }
 
 // Boolean implements logical operations. The left and right values should be predicates
@@ -139,6 +248,70 @@ func (p Predicate) boolean(vars ...any) (_ bool, err error) {
 	}
 }
 
+// Conjuncts returns the predicates that must all be true for the predicate to be true,
+// e.g. the leaves of a chain of AND operators. If the predicate is not an AND then the
+// predicate itself is the only conjunct.
+func (p Predicate) Conjuncts() []Predicate {
+	if p.Operator != And {
+		return []Predicate{p}
+	}
+
+	conjuncts := make([]Predicate, 0, 2)
+	for _, child := range []any{p.Left, p.Right} {
+		if pred, ok := child.(Predicate); ok {
+			conjuncts = append(conjuncts, pred.Conjuncts()...)
+		}
+	}
+	return conjuncts
+}
+
+// Identifier returns the identifier on the left side of a comparison or search
+// predicate or an empty string if the predicate is not a leaf node.
+func (p Predicate) Identifier() string {
+	if token, ok := p.Left.(Token); ok && token.Type == Identifier {
+		return token.Token
+	}
+	return ""
+}
+
+// String returns the where clause that the predicate evaluates. Parentheses are added
+// to logical predicates whose operator differs from their parent so that the order of
+// evaluation of the predicate is explicit.
+func (p Predicate) String() string {
+	switch p.Type() {
+	case LogicalPredicate:
+		var sb strings.Builder
+		for i, child := range []any{p.Left, p.Right} {
+			if i > 0 {
+				sb.WriteString(" " + p.Operator.String() + " ")
+			}
+
+			if pred, ok := child.(Predicate); ok && pred.Type() == LogicalPredicate && pred.Operator != p.Operator {
+				sb.WriteString("(" + pred.String() + ")")
+			} else {
+				sb.WriteString(operandString(child))
+			}
+		}
+		return sb.String()
+	default:
+		return operandString(p.Left) + " " + p.Operator.String() + " " + operandString(p.Right)
+	}
+}
+
+func operandString(operand any) string {
+	switch o := operand.(type) {
+	case Predicate:
+		return o.String()
+	case Token:
+		if o.Type == QuotedString {
+			return string(SQUOTE) + o.Token + string(SQUOTE)
+		}
+		return o.Token
+	default:
+		return "?"
+	}
+}
+
 func (p Predicate) Type() PredicateType {
 	switch p.Operator {
 	case And, Or:
@@ -163,3 +336,179 @@ const (
 	ComparisonPredicate
 	SearchPredicate
 )
+
+// Lookup the identifier in the first variables that contain it. Nil values are treated
+// as if they were not found so that they are not comparable to any other value.
+func lookup(identifier string, vars []any) (value any, ok bool, err error) {
+	for _, v := range vars {
+		switch v := v.(type) {
+		case Variables:
+			value, ok = v.Lookup(identifier)
+		case map[string]any:
+			value, ok = v[identifier]
+		default:
+			return nil, false, ErrUnhandledVariables
+		}
+
+		if ok {
+			return value, value != nil, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Compares a value to a numeric token, returning -1 if the value is less than the
+// token, 0 if they are equal, and +1 if the value is greater than the token. If the
+// value is not a number (or a string that can be parsed as a number) then ok is false.
+func compareNumeric(value any, token Token) (order int, ok bool, err error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := token.ParseInt(10, 64); err == nil {
+			return cmp.Compare(rv.Int(), i), true, nil
+		}
+		return compareFloat(float64(rv.Int()), token)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := token.ParseUint(10, 64); err == nil {
+			return cmp.Compare(rv.Uint(), u), true, nil
+		}
+		return compareFloat(float64(rv.Uint()), token)
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(rv.Float(), token)
+	case reflect.String:
+		var f float64
+		if f, err = strconv.ParseFloat(rv.String(), 64); err != nil {
+			return 0, false, nil
+		}
+		return compareFloat(f, token)
+	default:
+		return 0, false, nil
+	}
+}
+
+func compareFloat(value float64, token Token) (int, bool, error) {
+	f, err := token.ParseFloat(64)
+	if err != nil {
+		return 0, false, ErrInvalidPredicate
+	}
+	return cmp.Compare(value, f), true, nil
+}
+
+// Compares a value to a quoted string token. Strings are compared lexicographically,
+// timestamps are compared by parsing the token as a timestamp, and numbers and bools
+// are compared if the token can be parsed as a number or a bool respectively.
+func compareString(value any, token Token) (order int, ok bool, err error) {
+	var s string
+	if s, err = token.ParseString(); err != nil {
+		return 0, false, ErrInvalidPredicate
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Compare(v, s), true, nil
+	case []byte:
+		return strings.Compare(string(v), s), true, nil
+	case time.Time:
+		var ts time.Time
+		if ts, err = token.ParseTime(); err != nil {
+			return 0, false, err
+		}
+		return v.Compare(ts), true, nil
+	case bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err != nil {
+			return 0, false, nil
+		}
+		return compareBools(v, b), true, nil
+	default:
+		if _, err = strconv.ParseFloat(s, 64); err != nil {
+			return 0, false, nil
+		}
+		return compareNumeric(value, Token{Token: s, Type: Numeric, Length: len(s)})
+	}
+}
+
+// Compares a value to a boolean token; only bools and strings that can be parsed as
+// bools are comparable to a boolean token, where false is less than true.
+func compareBool(value any, token Token) (order int, ok bool, err error) {
+	var b bool
+	if b, err = token.ParseBool(); err != nil {
+		return 0, false, ErrInvalidPredicate
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return compareBools(v, b), true, nil
+	case string:
+		var vb bool
+		if vb, err = strconv.ParseBool(v); err != nil {
+			return 0, false, nil
+		}
+		return compareBools(vb, b), true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Like returns true if the entire string matches the pattern, where % matches zero or
+// more characters, _ matches exactly one character and \ escapes the next character in
+// the pattern. Matching backtracks to the most recent % when a character mismatches.
+func like(s, pattern string) bool {
+	var (
+		si, pi     int
+		star, mark = -1, 0
+	)
+
+	for si < len(s) {
+		if pi < len(pattern) {
+			switch pattern[pi] {
+			case '%':
+				star, mark = pi, si
+				pi++
+				continue
+			case '_':
+				_, size := utf8.DecodeRuneInString(s[si:])
+				si += size
+				pi++
+				continue
+			case ESCAPE:
+				if pi+1 < len(pattern) && pattern[pi+1] == s[si] {
+					si++
+					pi += 2
+					continue
+				}
+			default:
+				if pattern[pi] == s[si] {
+					si++
+					pi++
+					continue
+				}
+			}
+		}
+
+		// Mismatch: backtrack to the last % and have it consume one more character.
+		if star < 0 {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[mark:])
+		mark += size
+		si, pi = mark, star+1
+	}
+
+	// Any remaining % in the pattern can match the empty string
+	for pi < len(pattern) && pattern[pi] == '%' {
+		pi++
+	}
+	return pi == len(pattern)
+}
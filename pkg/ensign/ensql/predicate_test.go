@@ -2,6 +2,7 @@ package ensql_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/rotationalio/ensign/pkg/ensign/ensql"
 	"github.com/stretchr/testify/require"
@@ -105,3 +106,210 @@ func TestNestedPredicate(t *testing.T) {
 
 	require.NoError(t, pred.Validate(), "expected nested predicate to be valid")
 }
+
+func TestEvaluatePredicate(t *testing.T) {
+	vars := map[string]any{
+		"color":    "red",
+		"country":  "fr",
+		"age":      int64(42),
+		"count":    uint8(7),
+		"score":    3.14,
+		"level":    "12",
+		"active":   true,
+		"flag":     "false",
+		"created":  time.Date(2023, 7, 29, 12, 22, 42, 0, time.UTC),
+		"nickname": nil,
+	}
+
+	testCases := []struct {
+		clause   string
+		expected bool
+	}{
+		{"color = 'red'", true},
+		{"color != 'red'", false},
+		{"color <> 'blue'", true},
+		{"color > 'blue'", true},
+		{"color < 'blue'", false},
+		{"age = 42", true},
+		{"age > 18", true},
+		{"age >= 42", true},
+		{"age < 42", false},
+		{"age <= 41.5", false},
+		{"age = '42'", true},
+		{"count > 6", true},
+		{"count != 7", false},
+		{"score > 3", true},
+		{"score = 3.14", true},
+		{"score < -1", false},
+		{"level > 9", true},
+		{"active = true", true},
+		{"active = f", false},
+		{"active != False", true},
+		{"flag = FALSE", true},
+		{"active = 'true'", true},
+		{"created > '2023-07-29'", true},
+		{"created < '2023-07-29T12:22:43Z'", true},
+		{"created = '2023-07-29T07:22:42-05:00'", true},
+		{"created >= '2023-07-30 00:00:00'", false},
+		{"color = 42", false},
+		{"age = 'red'", false},
+		{"active > 1", false},
+		{"missing = 'red'", false},
+		{"missing != 'red'", false},
+		{"nickname = 'red'", false},
+		{"color = 'red' AND age > 18", true},
+		{"color = 'blue' OR age > 18", true},
+		{"color = 'blue' OR color = 'red' AND country = 'de'", false},
+		{"color = 'red' OR color = 'blue' AND country = 'de'", true},
+		{"(color = 'red' OR color = 'blue') AND country = 'de'", false},
+		{"(color = 'red' OR color = 'blue') AND (age > 21 AND age <= 65)", true},
+	}
+
+	for i, tc := range testCases {
+		pred, err := MakeConditionGroup(tc.clause).Predicate()
+		require.NoError(t, err, "could not create predicate for test case %d: %s", i, tc.clause)
+
+		actual, err := pred.Evaluate(vars)
+		require.NoError(t, err, "could not evaluate predicate for test case %d: %s", i, tc.clause)
+		require.Equal(t, tc.expected, actual, "unexpected result for test case %d: %s", i, tc.clause)
+	}
+
+	// Errors are returned for predicates that cannot be evaluated
+	pred, err := MakeConditionGroup("created > 'yesterday'").Predicate()
+	require.NoError(t, err, "could not create predicate")
+	_, err = pred.Evaluate(vars)
+	require.ErrorIs(t, err, ErrNonTimestamp)
+
+	_, err = Predicate{Token{"age", Identifier, 3}, Gt, Token{"1.2.3", Numeric, 5}}.Evaluate(vars)
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+
+	_, err = Predicate{Token{"age", Identifier, 3}, Gt, Token{"18", Numeric, 2}}.Evaluate("age")
+	require.ErrorIs(t, err, ErrUnhandledVariables)
+
+	_, err = Predicate{Token{"age", Identifier, 3}, UnknownOperator, Token{"18", Numeric, 2}}.Evaluate(vars)
+	require.ErrorIs(t, err, ErrPredicateType)
+}
+
+func TestEvaluateVariables(t *testing.T) {
+	// Identifiers should be resolved from the first variables that contain them.
+	pred, err := MakeConditionGroup("color = 'red' AND age > 18").Predicate()
+	require.NoError(t, err, "could not create predicate")
+
+	ok, err := pred.Evaluate(variables{"color": "red"}, map[string]any{"color": "blue", "age": 21})
+	require.NoError(t, err, "could not evaluate predicate")
+	require.True(t, ok, "expected identifiers to be resolved from the first variables")
+
+	ok, err = pred.Evaluate(map[string]any{"color": "blue", "age": 21}, variables{"color": "red"})
+	require.NoError(t, err, "could not evaluate predicate")
+	require.False(t, ok, "expected identifiers to be resolved from the first variables")
+
+	ok, err = pred.Evaluate()
+	require.NoError(t, err, "could not evaluate predicate")
+	require.False(t, ok, "expected no match when there are no variables")
+}
+
+func TestSearchPredicate(t *testing.T) {
+	testCases := []struct {
+		value    any
+		op       Operator
+		pattern  string
+		expected bool
+	}{
+		{"hello world", Like, "hello world", true},
+		{"hello world", Like, "hello", false},
+		{"hello world", Like, "hello%", true},
+		{"hello world", Like, "%world", true},
+		{"hello world", Like, "%lo wo%", true},
+		{"hello world", Like, "%", true},
+		{"", Like, "%", true},
+		{"", Like, "_", false},
+		{"hello world", Like, "h%o%d", true},
+		{"hello world", Like, "h%o%x", false},
+		{"hello world", Like, "hell_ world", true},
+		{"hello world", Like, "_____ _____", true},
+		{"hello world", Like, "____ _____", false},
+		{"hello world", Like, "HELLO%", false},
+		{"hello world", ILike, "HELLO%", true},
+		{"Hello World", ILike, "%o w%", true},
+		{"100%", Like, "100\\%", true},
+		{"1000", Like, "100\\%", false},
+		{"a_b", Like, "a\\_b", true},
+		{"acb", Like, "a\\_b", false},
+		{"it's", Like, "it\\'s", true},
+		{"héllo", Like, "h_llo", true},
+		{"héllo wörld", ILike, "HÉLLO W%", true},
+		{[]byte("hello world"), Like, "%world", true},
+		{42, Like, "42", false},
+		{nil, Like, "%", false},
+	}
+
+	for i, tc := range testCases {
+		pred := Predicate{Token{"greeting", Identifier, 8}, tc.op, Token{tc.pattern, QuotedString, len(tc.pattern) + 2}}
+		actual, err := pred.Evaluate(map[string]any{"greeting": tc.value})
+		require.NoError(t, err, "could not evaluate search predicate for test case %d", i)
+		require.Equal(t, tc.expected, actual, "unexpected search result for test case %d: %v %s %q", i, tc.value, tc.op, tc.pattern)
+	}
+
+	// Search patterns must be quoted strings
+	_, err := Predicate{Token{"greeting", Identifier, 8}, Like, Token{"42", Numeric, 2}}.Evaluate(map[string]any{"greeting": "42"})
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+}
+
+type variables map[string]any
+
+func (v variables) Lookup(identifier string) (any, bool) {
+	val, ok := v[identifier]
+	return val, ok
+}
+
+func TestPredicateString(t *testing.T) {
+	testCases := []struct {
+		clause   string
+		expected string
+	}{
+		{"color = 'red'", "color = 'red'"},
+		{"age >= 21", "age >= 21"},
+		{"active != true", "active != true"},
+		{"name ILIKE 'it\\'s%'", "name ILIKE 'it\\'s%'"},
+		{"color = 'red' AND age > 21 AND active = true", "color = 'red' AND age > 21 AND active = true"},
+		{"color = 'red' OR color = 'blue' AND age > 21", "color = 'red' OR (color = 'blue' AND age > 21)"},
+		{"(color = 'red' OR color = 'blue') AND age > 21", "(color = 'red' OR color = 'blue') AND age > 21"},
+		{"((color = 'red'))", "color = 'red'"},
+	}
+
+	for i, tc := range testCases {
+		pred, err := MakeConditionGroup(tc.clause).Predicate()
+		require.NoError(t, err, "could not create predicate for test case %d", i)
+		require.Equal(t, tc.expected, pred.String(), "unexpected string for test case %d", i)
+
+		// The string representation should be parseable into the same predicate
+		reparsed, err := MakeConditionGroup(pred.String()).Predicate()
+		require.NoError(t, err, "could not reparse predicate for test case %d", i)
+		require.Equal(t, pred, reparsed, "expected reparsed predicate to match for test case %d", i)
+	}
+}
+
+func TestPredicateConjuncts(t *testing.T) {
+	testCases := []struct {
+		clause      string
+		identifiers []string
+	}{
+		{"color = 'red'", []string{"color"}},
+		{"color = 'red' AND age > 21", []string{"color", "age"}},
+		{"color = 'red' AND (age > 21 AND active = true)", []string{"color", "age", "active"}},
+		{"color = 'red' OR age > 21", []string{""}},
+		{"color = 'red' AND (age > 21 OR active = true)", []string{"color", ""}},
+		{"color = 'red' OR color = 'blue' AND age > 21", []string{""}},
+	}
+
+	for i, tc := range testCases {
+		pred, err := MakeConditionGroup(tc.clause).Predicate()
+		require.NoError(t, err, "could not create predicate for test case %d", i)
+
+		conjuncts := pred.Conjuncts()
+		require.Len(t, conjuncts, len(tc.identifiers), "unexpected number of conjuncts for test case %d", i)
+		for j, conjunct := range conjuncts {
+			require.Equal(t, tc.identifiers[j], conjunct.Identifier(), "unexpected conjunct %d for test case %d", j, i)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package ensql
 import (
 	"fmt"
 	"strings"
+
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 )
 
 // Query is a parsed representation of an EnSQL statement that can be used to process
@@ -15,7 +17,10 @@ type Query struct {
 	Conditions *ConditionGroup
 	Fields     []Token
 	Aliases    map[string]string
+	Aggregates map[string]Aggregate
+	GroupBy    []Token
 	Offset     uint64
+	OffsetID   rlid.RLID
 	HasOffset  bool
 	Limit      uint64
 	HasLimit   bool
@@ -27,6 +32,12 @@ func (q Query) String() string {
 	return q.Raw
 }
 
+// Returns true if the query aggregates events into groups rather than returning a
+// result for each event, e.g. if it has aggregate functions or a GROUP BY clause.
+func (q Query) IsAggregate() bool {
+	return len(q.Aggregates) > 0 || len(q.GroupBy) > 0
+}
+
 // The type of the EnSQL query (e.g. SELECT)
 type QueryType uint8
 
@@ -51,6 +62,18 @@ type Topic struct {
 	Version uint32
 }
 
+// Aggregate is a function that is applied to a field (or * for COUNT) over all of the
+// events in a group. Aggregates are selected by the token of their string
+// representation, e.g. COUNT(*) or AVG(reading), which is also used for aliasing.
+type Aggregate struct {
+	Function AggregateFunction
+	Field    Token
+}
+
+func (a Aggregate) String() string {
+	return fmt.Sprintf("%s(%s)", a.Function, a.Field.Token)
+}
+
 // Condition represents a basic expression in a where clause.
 type Condition struct {
 	Left     Token
@@ -66,6 +89,23 @@ func (c Condition) IsPartial() bool {
 	return c.Left == Empty || c.Operator == Empty || c.Right == Empty
 }
 
+// Predicate returns the comparison or search predicate for the condition.
+func (c Condition) Predicate() (_ Predicate, err error) {
+	if c.IsPartial() {
+		return Predicate{}, ErrInvalidPredicate
+	}
+
+	pred := Predicate{Left: c.Left, Right: c.Right}
+	if pred.Operator, err = c.Operator.ParseOperator(); err != nil {
+		return Predicate{}, err
+	}
+
+	if err = pred.Validate(); err != nil {
+		return Predicate{}, err
+	}
+	return pred, nil
+}
+
 func (c Condition) String() string {
 	return fmt.Sprintf("%s %s %s", c.Left.Token, c.Operator.Token, c.Right.Token)
 }
@@ -266,6 +306,81 @@ func (g *ConditionGroup) CloseParens() error {
 	return nil
 }
 
+// Predicate builds the predicate tree that is used to evaluate the condition group.
+// Logical operators are applied with AND taking precedence over OR, e.g. the group
+// a OR b AND c is evaluated as a OR (b AND c); operators of the same precedence are
+// evaluated from left to right. Nested groups are built as subtrees so that the
+// parentheses take precedence over any of the operators in the group. An error is
+// returned if the group is empty, has unclosed parentheses, or is otherwise incomplete.
+func (g *ConditionGroup) Predicate() (_ Predicate, err error) {
+	if g.current != nil && g.current != g {
+		return Predicate{}, ErrCloseParens
+	}
+
+	// Split the children into terms that are joined by OR where each term is a list of
+	// predicates that are joined by AND.
+	terms := [][]Predicate{nil}
+	expectOperand := true
+
+	for _, child := range g.children {
+		var operand Predicate
+		switch c := child.(type) {
+		case Operator:
+			if expectOperand {
+				return Predicate{}, ErrInvalidPredicate
+			}
+
+			switch c {
+			case And:
+			case Or:
+				terms = append(terms, nil)
+			default:
+				return Predicate{}, ErrInvalidPredicate
+			}
+
+			expectOperand = true
+			continue
+		case *Condition:
+			operand, err = c.Predicate()
+		case *ConditionGroup:
+			operand, err = c.Predicate()
+		default:
+			return Predicate{}, ErrInvalidPredicate
+		}
+
+		if err != nil {
+			return Predicate{}, err
+		}
+
+		if !expectOperand {
+			return Predicate{}, ErrInvalidPredicate
+		}
+
+		terms[len(terms)-1] = append(terms[len(terms)-1], operand)
+		expectOperand = false
+	}
+
+	// Empty groups and groups that end with an operator are incomplete.
+	if expectOperand {
+		return Predicate{}, ErrInvalidPredicate
+	}
+
+	var pred Predicate
+	for i, term := range terms {
+		and := term[0]
+		for _, right := range term[1:] {
+			and = Predicate{Left: and, Operator: And, Right: right}
+		}
+
+		if i == 0 {
+			pred = and
+		} else {
+			pred = Predicate{Left: pred, Operator: Or, Right: and}
+		}
+	}
+	return pred, nil
+}
+
 func (g *ConditionGroup) String() string {
 	var sb strings.Builder
 	for _, child := range g.children {
@@ -97,3 +97,58 @@ func TestConditionIsPartial(t *testing.T) {
 		require.False(t, condition.IsPartial(), "expected test case %d to not be partial", i)
 	}
 }
+
+func TestConditionGroupPredicate(t *testing.T) {
+	red := Predicate{Token{"color", Identifier, 5}, Eq, Token{"red", QuotedString, 5}}
+	blue := Predicate{Token{"color", Identifier, 5}, Eq, Token{"blue", QuotedString, 6}}
+	fr := Predicate{Token{"country", Identifier, 7}, Eq, Token{"fr", QuotedString, 4}}
+	adult := Predicate{Token{"age", Identifier, 3}, Gt, Token{"18", Numeric, 2}}
+
+	testCases := []struct {
+		clause   string
+		expected Predicate
+	}{
+		{"color = 'red'", red},
+		{"(color = 'red')", red},
+		{"color = 'red' AND country = 'fr'", Predicate{red, And, fr}},
+		{"color = 'red' OR color = 'blue' AND country = 'fr'", Predicate{red, Or, Predicate{blue, And, fr}}},
+		{"color = 'red' AND country = 'fr' OR color = 'blue'", Predicate{Predicate{red, And, fr}, Or, blue}},
+		{"(color = 'red' OR color = 'blue') AND country = 'fr'", Predicate{Predicate{red, Or, blue}, And, fr}},
+		{"color = 'red' AND country = 'fr' AND age > 18", Predicate{Predicate{red, And, fr}, And, adult}},
+		{"color = 'red' OR color = 'blue' OR age > 18", Predicate{Predicate{red, Or, blue}, Or, adult}},
+		{
+			"color = 'red' AND country = 'fr' OR color = 'blue' AND age > 18",
+			Predicate{Predicate{red, And, fr}, Or, Predicate{blue, And, adult}},
+		},
+		{
+			"(color = 'red' OR color = 'blue') AND (country = 'fr' OR age > 18)",
+			Predicate{Predicate{red, Or, blue}, And, Predicate{fr, Or, adult}},
+		},
+	}
+
+	for i, tc := range testCases {
+		actual, err := MakeConditionGroup(tc.clause).Predicate()
+		require.NoError(t, err, "could not create predicate for test case %d", i)
+		require.Equal(t, tc.expected, actual, "unexpected predicate for test case %d: %s", i, tc.clause)
+		require.NoError(t, actual.Validate(), "expected valid predicate for test case %d", i)
+	}
+
+	// Incomplete condition groups cannot be converted into predicates
+	_, err := NewConditionGroup().Predicate()
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+
+	group := NewConditionGroup()
+	require.NoError(t, group.ConditionLeft(Token{"color", Identifier, 5}))
+	_, err = group.Predicate()
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+
+	group = MakeConditionGroup("color = 'red'")
+	require.NoError(t, group.LogicalOperator(And))
+	_, err = group.Predicate()
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+
+	group = MakeConditionGroup("color = 'red' AND")
+	require.NoError(t, group.OpenParens())
+	_, err = group.Predicate()
+	require.ErrorIs(t, err, ErrCloseParens)
+}
@@ -21,4 +21,7 @@ const (
 	stepOffsetValue
 	stepLimit
 	stepLimitValue
+	stepSelectAggregate
+	stepGroupBy
+	stepGroupByField
 )
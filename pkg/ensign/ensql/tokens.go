@@ -3,6 +3,7 @@ package ensql
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Reserved Words constants
@@ -35,6 +36,19 @@ const (
 	ESCAPE   = '\\'
 )
 
+// Keywords that are only recognized in the context of the query where they are expected
+// rather than being reserved, so that field names such as count or summary can still
+// be used as identifiers. Aggregate functions must be followed by an open parens.
+const (
+	GROUPBY = "GROUP BY"
+	BETWEEN = "BETWEEN"
+	COUNT   = "COUNT"
+	SUM     = "SUM"
+	AVG     = "AVG"
+	MIN     = "MIN"
+	MAX     = "MAX"
+)
+
 var (
 	Empty = Token{"", EmptyToken, 0}
 )
@@ -117,6 +131,35 @@ func (o Operator) String() string {
 	}
 }
 
+// Aggregate functions that can be applied to fields over a group of events.
+type AggregateFunction uint8
+
+const (
+	UnknownAggregate AggregateFunction = iota
+	Count                              // COUNT
+	Sum                                // SUM
+	Avg                                // AVG
+	Min                                // MIN
+	Max                                // MAX
+)
+
+func (f AggregateFunction) String() string {
+	switch f {
+	case Count:
+		return COUNT
+	case Sum:
+		return SUM
+	case Avg:
+		return AVG
+	case Min:
+		return MIN
+	case Max:
+		return MAX
+	default:
+		return "UnknownAggregate"
+	}
+}
+
 // A token represents a parsed element from the SQL and is returned from peek. The
 // token string may not match the original string in the query (for example it might be
 // uppercased or have quotations or whitespace stripped). When evaluating tokens, both
@@ -142,6 +185,7 @@ const (
 	QuotedString
 	Numeric
 	Boolean
+	Function
 )
 
 // Tokenize returns the tokens parsed from the input string with no validation or FSM.
@@ -159,6 +203,28 @@ func Tokenize(sql string) []Token {
 	return tokens
 }
 
+// Parse a function token as an aggregate function.
+func (t Token) ParseAggregate() (AggregateFunction, error) {
+	if t.Type != Function {
+		return UnknownAggregate, ErrNotAFunction
+	}
+
+	switch strings.ToUpper(t.Token) {
+	case COUNT:
+		return Count, nil
+	case SUM:
+		return Sum, nil
+	case AVG:
+		return Avg, nil
+	case MIN:
+		return Min, nil
+	case MAX:
+		return Max, nil
+	default:
+		return UnknownAggregate, ErrUnknownFunction
+	}
+}
+
 // Parse a numeric token as a signed integer using strconv.ParseInt. Generally, the base
 // should be 10 and the bitSize should be 64 unless otherwise defined by the schema.
 func (t Token) ParseInt(base, bitSize int) (int64, error) {
@@ -194,6 +260,40 @@ func (t Token) ParseBool() (bool, error) {
 	return strconv.ParseBool(t.Token)
 }
 
+// Parse a quoted string token, replacing any escaped single quotes in the string.
+func (t Token) ParseString() (string, error) {
+	if t.Type != QuotedString {
+		return "", ErrNonString
+	}
+	return strings.ReplaceAll(t.Token, `\'`, "'"), nil
+}
+
+// Layouts that are accepted when parsing a quoted string token as a timestamp, from
+// the most to the least specific. Timestamps without a zone are parsed as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+}
+
+// Parse a quoted string token as a timestamp; RFC3339 timestamps are expected but
+// dates and timestamps without a timezone are also accepted.
+func (t Token) ParseTime() (time.Time, error) {
+	s, err := t.ParseString()
+	if err != nil {
+		return time.Time{}, ErrNonTimestamp
+	}
+
+	for _, layout := range timeLayouts {
+		var ts time.Time
+		if ts, err = time.Parse(layout, s); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, ErrNonTimestamp
+}
+
 func (t Token) ParseOperator() (Operator, error) {
 	if t.Type != OperatorToken {
 		return UnknownOperator, ErrNotAnOperator
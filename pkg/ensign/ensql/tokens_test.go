@@ -2,7 +2,9 @@ package ensql_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/rotationalio/ensign/pkg/ensign/ensql"
 	"github.com/stretchr/testify/require"
@@ -244,6 +246,35 @@ LIMIT 100;`
 	}
 }
 
+func TestTokenizeAggregates(t *testing.T) {
+	sql := "SELECT region, count, Count(*), max (reading) FROM sensors GROUP  BY region, grouping"
+	expected := []Token{
+		{SELECT, ReservedWord, 6},
+		{"region", Identifier, 6},
+		{COMMA, Punctuation, 1},
+		{"count", Identifier, 5},
+		{COMMA, Punctuation, 1},
+		{COUNT, Function, 5},
+		{LP, Punctuation, 1},
+		{ASTERISK, Asterisk, 1},
+		{RP, Punctuation, 1},
+		{COMMA, Punctuation, 1},
+		{MAX, Function, 3},
+		{LP, Punctuation, 1},
+		{"reading", Identifier, 7},
+		{RP, Punctuation, 1},
+		{FROM, ReservedWord, 4},
+		{"sensors", Identifier, 7},
+		{GROUPBY, ReservedWord, 9},
+		{"region", Identifier, 6},
+		{COMMA, Punctuation, 1},
+		{"grouping", Identifier, 8},
+	}
+
+	actual := Tokenize(sql)
+	require.Equal(t, expected, actual)
+}
+
 func TestParseInt(t *testing.T) {
 	testCases := []struct {
 		token    Token
@@ -357,6 +388,50 @@ func TestParseBool(t *testing.T) {
 	}
 }
 
+func TestParseString(t *testing.T) {
+	testCases := []struct {
+		token    Token
+		expected string
+		err      error
+	}{
+		{Token{"abc", QuotedString, 5}, "abc", nil},
+		{Token{"", QuotedString, 2}, "", nil},
+		{Token{`it\'s`, QuotedString, 7}, "it's", nil},
+		{Token{`100\%`, QuotedString, 7}, `100\%`, nil},
+		{Token{"abc", Identifier, 3}, "", ErrNonString},
+		{Token{"42", Numeric, 2}, "", ErrNonString},
+	}
+
+	for _, tc := range testCases {
+		actual, err := tc.token.ParseString()
+		require.Equal(t, tc.expected, actual)
+		require.ErrorIs(t, err, tc.err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	testCases := []struct {
+		token    Token
+		expected time.Time
+		err      error
+	}{
+		{Token{"2023-07-29T12:22:42Z", QuotedString, 22}, time.Date(2023, 7, 29, 12, 22, 42, 0, time.UTC), nil},
+		{Token{"2023-07-29T12:22:42.123456789Z", QuotedString, 32}, time.Date(2023, 7, 29, 12, 22, 42, 123456789, time.UTC), nil},
+		{Token{"2023-07-29T12:22:42-05:00", QuotedString, 27}, time.Date(2023, 7, 29, 17, 22, 42, 0, time.UTC), nil},
+		{Token{"2023-07-29T12:22:42", QuotedString, 21}, time.Date(2023, 7, 29, 12, 22, 42, 0, time.UTC), nil},
+		{Token{"2023-07-29 12:22:42", QuotedString, 21}, time.Date(2023, 7, 29, 12, 22, 42, 0, time.UTC), nil},
+		{Token{"2023-07-29", QuotedString, 12}, time.Date(2023, 7, 29, 0, 0, 0, 0, time.UTC), nil},
+		{Token{"yesterday", QuotedString, 11}, time.Time{}, ErrNonTimestamp},
+		{Token{"1690633362", Numeric, 10}, time.Time{}, ErrNonTimestamp},
+	}
+
+	for _, tc := range testCases {
+		actual, err := tc.token.ParseTime()
+		require.True(t, tc.expected.Equal(actual), "expected %s got %s", tc.expected, actual)
+		require.ErrorIs(t, err, tc.err)
+	}
+}
+
 func TestParseOperator(t *testing.T) {
 	for rword, ttype := range ReservedWordType {
 		token := Token{rword, ttype, len(rword)}
@@ -380,3 +455,18 @@ func TestParseOperator(t *testing.T) {
 	_, err := bad.ParseOperator()
 	require.ErrorIs(t, err, ErrUnknownOperator)
 }
+
+func TestParseAggregate(t *testing.T) {
+	for _, fn := range []AggregateFunction{Count, Sum, Avg, Min, Max} {
+		token := Token{strings.ToLower(fn.String()), Function, len(fn.String())}
+		actual, err := token.ParseAggregate()
+		require.NoError(t, err, "could not parse %s", fn)
+		require.Equal(t, fn, actual)
+	}
+
+	_, err := Token{"count", Identifier, 5}.ParseAggregate()
+	require.ErrorIs(t, err, ErrNotAFunction)
+
+	_, err = Token{"MEDIAN", Function, 6}.ParseAggregate()
+	require.ErrorIs(t, err, ErrUnknownFunction)
+}
@@ -4,14 +4,18 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/broker"
 	"github.com/rotationalio/ensign/pkg/ensign/contexts"
+	"github.com/rotationalio/ensign/pkg/ensign/groups"
 	"github.com/rotationalio/ensign/pkg/ensign/o11y"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/ensign/topics"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/permissions"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/tokens"
@@ -27,7 +31,14 @@ import (
 )
 
 // Cannot publish events > 5MiB long
-const EventMaxDataSize int = 5.243e6
+const EventMaxDataSize = api.EventMaxDataSize
+
+// Sent to subscribers with a DISCONNECT overflow policy when their queue overflows.
+const ReasonOverflow = "subscriber is not receiving events as quickly as they are published"
+
+// Sent to subscribers when the broker closes the subscription, e.g. because the topics
+// of the subscription have been destroyed.
+const ReasonClosed = "subscription was closed by the server"
 
 // Publish implements a streaming endpoint that allows users to publish events into a
 // topic or topics that are managed by the current broker.
@@ -42,8 +53,10 @@ const EventMaxDataSize int = 5.243e6
 // The second phase initializes two go routines: the primary go routine receives events
 // from the client and extracts them. It then sends them via a channel to a second go
 // routine that handles pre-broker processing and any acks/nacks received from the
-// broker. The handler go routine waits for these routines to complete before returning
-// any error from the recv routine.
+// broker. The second routine also sends topic updates to the client when topics in the
+// project are created, archived, or destroyed so that events can be published to new
+// topics without reopening the stream. The handler go routine waits for these routines
+// to complete before returning any error from the recv routine.
 //
 // Permissions: publisher
 func (s *Server) Publish(stream api.Ensign_PublishServer) (err error) {
@@ -61,6 +74,15 @@ func (s *Server) Publish(stream api.Ensign_PublishServer) (err error) {
 		return err
 	}
 
+	// Watch for topic updates before the allowed topics are loaded so that topics that
+	// are created while the stream is being opened are not missed.
+	var watcher *topics.Watcher
+	if watcher, err = handler.Watch(s.watch); err != nil {
+		// NOTE: Watch() returns a status error that can be returned directly.
+		return err
+	}
+	defer s.watch.Unwatch(watcher)
+
 	// Get the allowed topics based on the claims
 	var allowedTopics *topics.NameGroup
 	if allowedTopics, err = handler.AllowedTopics(); err != nil {
@@ -226,9 +248,6 @@ func (s *Server) Publish(stream api.Ensign_PublishServer) (err error) {
 				}
 
 				// Verify the event is in a topic that the user is allowed to publish to
-				// TODO: this won't allow topics that were created after the stream was
-				// created but are still valid. Need to unify the allowed mechanism into
-				// a global topic handler check rather than in a per-stream check.
 				var topicID ulid.ULID
 				if topicID, err = event.ParseTopicID(); err != nil {
 					sentry.Debug(ctx).Err(err).Msg("could not parse topic id from user")
@@ -255,8 +274,11 @@ func (s *Server) Publish(stream api.Ensign_PublishServer) (err error) {
 					continue
 				}
 
-				// Push event on to the primary buffer
+				// Push event on to the primary buffer; the storage compression and
+				// encryption are managed by the server so they cannot be set by the publisher.
 				event.Publisher = publisher
+				event.StorageCompression = nil
+				event.StorageEncryption = nil
 				s.broker.Publish(streamID, event)
 
 				// Increment counters for sending back closed stream message
@@ -268,6 +290,19 @@ func (s *Server) Publish(stream api.Ensign_PublishServer) (err error) {
 			// Handle acks/nacks coming from the broker
 			case result := <-results:
 				handler.Reply(result)
+
+			// Update the allowed topics when topics are created or deleted so that the
+			// publisher can publish to new topics without reopening the stream.
+			case update := <-watcher.Updates():
+				var ok bool
+				if ok, err = allowedTopics.Apply(update, open.Topics...); err != nil {
+					sentry.Warn(ctx).Err(err).Str("topic", update.Name).Msg("could not apply topic update to publisher stream")
+					continue
+				}
+
+				if ok {
+					handler.TopicUpdate(update)
+				}
 			}
 		}
 	}(events, results)
@@ -375,6 +410,20 @@ func (p PublisherHandler) CloseStream(publisherID string, events, topics uint64)
 	return err
 }
 
+// Sends a topic update to the publisher, logging any send errors that occur.
+func (p *PublisherHandler) TopicUpdate(update *api.TopicUpdate) error {
+	err := p.stream.Send(&api.PublisherReply{
+		Embed: &api.PublisherReply_TopicUpdate{
+			TopicUpdate: update,
+		},
+	})
+
+	if err != nil {
+		log.Warn().Err(err).Str("topic", update.Name).Msg("could not send topic update")
+	}
+	return err
+}
+
 // Handles the publisher reply from the broker
 func (p *PublisherHandler) Reply(msg broker.PublishResult) error {
 	if msg.IsNack() {
@@ -427,6 +476,15 @@ func (s *Server) Subscribe(stream api.Ensign_SubscribeServer) (err error) {
 		return err
 	}
 
+	// Watch for topic updates before the allowed topics are loaded so that topics that
+	// are created while the stream is being opened are not missed.
+	var watcher *topics.Watcher
+	if watcher, err = handler.Watch(s.watch); err != nil {
+		// NOTE: Watch() returns a status error that can be returned directly.
+		return err
+	}
+	defer s.watch.Unwatch(watcher)
+
 	// Get the allowed topics based on the claims
 	var allowedTopics *topics.NameGroup
 	if allowedTopics, err = handler.AllowedTopics(); err != nil {
@@ -451,7 +509,6 @@ func (s *Server) Subscribe(stream api.Ensign_SubscribeServer) (err error) {
 	}
 
 	// Handle the subscription stream initialization
-	// TODO: handle the consumer group
 	if len(sub.Topics) > 0 {
 		allowedTopics = allowedTopics.Filter(sub.Topics...)
 		if allowedTopics.Length() == 0 {
@@ -460,6 +517,17 @@ func (s *Server) Subscribe(stream api.Ensign_SubscribeServer) (err error) {
 		}
 	}
 
+	// If a consumer group is specified, join the group so that the subscriber resumes
+	// from the offsets that have been committed by the group.
+	var consumer *groups.Consumer
+	if sub.Group != nil {
+		if consumer, err = handler.JoinGroup(s.groups, sub.Group); err != nil {
+			// NOTE: JoinGroup() returns a status error that can be returned directly.
+			return err
+		}
+		defer s.groups.Leave(consumer)
+	}
+
 	// Send back topic mapping
 	ready := &api.StreamReady{
 		ClientId: sub.ClientId,
@@ -477,60 +545,321 @@ func (s *Server) Subscribe(stream api.Ensign_SubscribeServer) (err error) {
 	}
 
 	// Setup the stream handlers
+	// NOTE: the subscription must be registered with the broker before any events are
+	// replayed for the consumer group to ensure no events are missed in the handoff.
+	// Members of a consumer group share the events from the broker between them.
 	var nEvents, nAcks, nNacks uint64
-	streamID, events, err := s.broker.Subscribe(allowedTopics.TopicIDs()...)
+	opts := broker.Options{Overflow: sub.Overflow}
+	if consumer != nil {
+		key := consumer.Group().Key()
+		opts.Group = key[:]
+	}
+
+	var subscriber *broker.Subscriber
+	if subscriber, err = s.broker.SubscribeWith(opts, allowedTopics.TopicIDs()...); err != nil {
+		sentry.Warn(ctx).Err(err).Msg("could not register subscriber with broker")
+		return status.Error(codes.Unavailable, "ensign broker is not available")
+	}
+
+	streamID := subscriber.ID
 	defer s.broker.Close(streamID)
 
 	// Now that we're all set up, log the fact that we're ready to go.
 	log.Info().
 		Str("client_id", sub.ClientId).Str("stream_id", streamID.String()).
-		Int("n_topics", allowedTopics.Length()).
+		Int("n_topics", allowedTopics.Length()).Bool("consumer_group", consumer != nil).
+		Str("overflow", sub.Overflow.String()).
 		Msg("subscriber stream opened")
 
 	// Begin handling events from the broker.
 	var wg sync.WaitGroup
 	wg.Add(2)
 
+	// Closed when the ack routine stops to signal the event routine to stop.
+	done := make(chan struct{})
+
+	// Receives the error to return when the event routine disconnects the subscriber,
+	// e.g. because it cannot keep up or the broker closed the subscription.
+	disconnected := make(chan error, 1)
+
 	// Execute the event sending loop
-	go func(events <-chan *api.EventWrapper) {
+	// If the subscriber is part of a consumer group, events that were committed after the
+	// group's offsets are replayed from the event store before events from the broker and
+	// any events that the group needs to redeliver are sent alongside broker events.
+	// This routine only logs errors; the error returned to the user is set by the ack
+	// routine, which will stop when the stream is closed.
+	// NOTE: this go routine cannot recv messages since it calls send!
+	go func(events <-chan *api.EventWrapper, overflows <-chan rlid.RLID) {
+		var err error
 		defer wg.Done()
+
+		var (
+			cursor       *Cursor
+			redeliveries <-chan *api.EventWrapper
+		)
+
+		topicIDs := allowedTopics.TopicIDs()
+
+		send := func(event *api.EventWrapper, topicID ulid.ULID, offset uint64) error {
+			// Stop replaying events if the client has stopped the stream.
+			select {
+			case <-done:
+				return io.EOF
+			default:
+			}
+
+			// Duplicates are not sent if the subscriber asked to skip them, but they
+			// are consumed by the group so that its offsets continue to advance.
+			if sub.SkipDuplicates && event.IsDuplicate {
+				if consumer != nil {
+					if _, err := consumer.Skip(event, topicID, offset); err != nil {
+						sentry.Warn(ctx).Err(err).Bytes("event_id", event.Id).Msg("could not skip consumer group delivery")
+					}
+				}
+				return nil
+			}
+
+			// The delivery must be recorded before the event is sent so that the ack
+			// from the client cannot arrive before the group is tracking the event.
+			// Events that another consumer in the group has already claimed are skipped.
+			if consumer != nil {
+				deliver, err := consumer.Deliver(event, topicID, offset)
+				if err != nil {
+					sentry.Warn(ctx).Err(err).Bytes("event_id", event.Id).Msg("could not track consumer group delivery")
+				} else if !deliver {
+					return nil
+				}
+			}
+
+			if err := handler.Send(event); err != nil {
+				return err
+			}
+
+			nEvents++
+			return nil
+		}
+
+		// Send an event from the broker, skipping events that have already been
+		// replayed from the event store.
+		handle := func(event *api.EventWrapper) error {
+			topicID, err := event.ParseTopicID()
+			if err != nil {
+				sentry.Warn(ctx).Err(err).Bytes("topicID", event.TopicId).Bytes("event", event.Id).Msg("could not parse topic id on event in log")
+				return nil
+			}
+
+			// Filter events based on the topic ID
+			if ok := allowedTopics.ContainsTopicID(topicID); !ok {
+				return nil
+			}
+
+			var offset uint64
+			if cursor != nil {
+				var ok bool
+				if offset, ok = cursor.Advance(topicID, event); !ok {
+					return nil
+				}
+			}
+			return send(event, topicID, offset)
+		}
+
+		// Catch up on the events that the broker spilled because the subscriber fell
+		// behind. The events queued before the spill are sent first, then the spilled
+		// events are read from the event store. The store is read a second time after
+		// the broker resumes so that events committed during the first read are sent.
+		spill := func(from rlid.RLID) error {
+		drain:
+			for {
+				select {
+				case event, open := <-events:
+					if !open {
+						return io.EOF
+					}
+
+					if err := handle(event); err != nil {
+						return err
+					}
+				default:
+					break drain
+				}
+			}
+
+			for _, topicID := range topicIDs {
+				cursor.Seek(topicID, from)
+			}
+
+			for _, topicID := range topicIDs {
+				if _, err := cursor.Replay(topicID, send); err != nil {
+					return err
+				}
+			}
+
+			if err := s.broker.Resume(streamID); err != nil {
+				return err
+			}
+
+			for _, topicID := range topicIDs {
+				if _, err := cursor.Replay(topicID, send); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// Update the topics of the subscription when topics are created or deleted. If
+		// the subscriber did not filter topics or the new topic matches its filter, the
+		// topic is added to the broker subscription and any events committed to it
+		// before it was added are replayed if the subscriber has a cursor. Topics that
+		// are deleted are removed from the subscription by the broker.
+		updateTopics := func(update *api.TopicUpdate) error {
+			ok, err := allowedTopics.Apply(update, sub.Topics...)
+			if err != nil {
+				sentry.Warn(ctx).Err(err).Str("topic", update.Name).Msg("could not apply topic update to subscriber stream")
+				return nil
+			}
+
+			if !ok {
+				return nil
+			}
+
+			topicIDs = allowedTopics.TopicIDs()
+			if err = handler.TopicUpdate(update); err != nil {
+				return err
+			}
+
+			if update.State == api.TopicState_READY {
+				var topicID ulid.ULID
+				copy(topicID[:], update.TopicId)
+
+				if err = s.broker.AddTopic(streamID, topicID); err != nil {
+					return err
+				}
+
+				if cursor != nil {
+					cursor.Seek(topicID, rlid.Null)
+					if _, err = cursor.Replay(topicID, send); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		if consumer != nil {
+			cursor = NewCursor(s.data, consumer.Group())
+			redeliveries = consumer.Redeliveries()
+
+			// Keep replaying events until the cursor has caught up with the event store.
+			// Events on the broker channel are discarded before each pass since they
+			// have already been committed and will be replayed; this also ensures that
+			// the broker does not drop events if the replay takes a long time.
+		replay:
+			for {
+			drain:
+				for {
+					select {
+					case _, open := <-events:
+						if !open {
+							return
+						}
+					default:
+						break drain
+					}
+				}
+
+				var nSent int
+				for _, topicID := range topicIDs {
+					var n int
+					if n, err = cursor.Replay(topicID, send); err != nil {
+						if streamClosed(err) {
+							log.Debug().Msg("subscribe stream closed by client")
+							return
+						}
+						sentry.Warn(ctx).Err(err).Str("topic_id", topicID.String()).Msg("could not replay events for consumer group")
+						return
+					}
+					nSent += n
+				}
+
+				if nSent == 0 {
+					break replay
+				}
+			}
+		} else if sub.Overflow == api.Subscription_SPILL {
+			cursor = NewCursor(s.data, nil)
+		}
+
 		for {
 			select {
 			case <-ctx.Done():
 				if err := ctx.Err(); err != nil {
 					log.Debug().Err(err).Msg("context closed in subscribe event routine")
+				}
+				return
+			case <-done:
+				return
+			case event := <-redeliveries:
+				// Redelivered events are already being tracked by the consumer group
+				if err = handler.Send(event); err != nil {
+					if streamClosed(err) {
+						log.Debug().Msg("subscribe stream closed by client")
+						return
+					}
+					sentry.Warn(ctx).Err(err).Msg("subscribe stream crashed")
 					return
 				}
-			case event := <-events:
-
-				var topicID ulid.ULID
-				if topicID, err = event.ParseTopicID(); err != nil {
-					sentry.Warn(ctx).Err(err).Bytes("topicID", event.TopicId).Bytes("event", event.Id).Msg("could not parse topic id on event in log")
-					continue
+				nEvents++
+			case from := <-overflows:
+				if sub.Overflow == api.Subscription_DISCONNECT {
+					log.Warn().Str("stream_id", streamID.String()).Msg("disconnecting subscriber that cannot keep up with events")
+					handler.CloseStream(nEvents, uint64(allowedTopics.Length()), atomic.LoadUint64(&nAcks), atomic.LoadUint64(&nNacks), ReasonOverflow)
+					disconnected <- status.Error(codes.ResourceExhausted, ReasonOverflow)
+					return
 				}
 
-				// Filter events based on the topic ID
-				if ok := allowedTopics.ContainsTopicID(topicID); !ok {
-					continue
+				log.Debug().Str("stream_id", streamID.String()).Str("from", from.String()).Msg("subscriber spilled events")
+				if err = spill(from); err != nil {
+					if streamClosed(err) {
+						log.Debug().Msg("subscribe stream closed by client")
+						return
+					}
+					sentry.Warn(ctx).Err(err).Msg("could not catch up on spilled events")
+					return
+				}
+			case update := <-watcher.Updates():
+				if err = updateTopics(update); err != nil {
+					if streamClosed(err) {
+						log.Debug().Msg("subscribe stream closed by client")
+						return
+					}
+					sentry.Warn(ctx).Err(err).Msg("could not update subscriber topics")
+					return
+				}
+			case event, open := <-events:
+				// If the events channel has closed, the broker is no longer sending events
+				if !open {
+					handler.CloseStream(nEvents, uint64(allowedTopics.Length()), atomic.LoadUint64(&nAcks), atomic.LoadUint64(&nNacks), ReasonClosed)
+					disconnected <- status.Error(codes.Unavailable, ReasonClosed)
+					return
 				}
 
-				if err = handler.Send(event); err != nil {
+				if err = handle(event); err != nil {
 					if streamClosed(err) {
 						log.Debug().Msg("subscribe stream closed by client")
-						err = nil
 						return
 					}
 					sentry.Warn(ctx).Err(err).Msg("subscribe stream crashed")
 					return
 				}
-				nEvents++
 			}
 		}
-	}(events)
+	}(subscriber.Events, subscriber.Overflows)
 
 	// Receive acks from the clients
+	var recvErr error
 	go func() {
 		defer wg.Done()
+		defer close(done)
 		for {
 			select {
 			case <-ctx.Done():
@@ -542,27 +871,44 @@ func (s *Server) Subscribe(stream api.Ensign_SubscribeServer) (err error) {
 			}
 
 			var in *api.SubscribeRequest
-			if in, err = stream.Recv(); err != nil {
-				if streamClosed(err) {
+			if in, recvErr = stream.Recv(); recvErr != nil {
+				if streamClosed(recvErr) {
 					log.Debug().Msg("subscribe stream closed by client")
-					err = nil
+					recvErr = nil
 					return
 				}
-				sentry.Warn(ctx).Err(err).Msg("subscribe stream crashed")
+				sentry.Warn(ctx).Err(recvErr).Msg("subscribe stream crashed")
 				return
 			}
 
-			// TODO: handle acks and nacks
 			if ack := in.GetAck(); ack != nil {
-				nAcks++
+				atomic.AddUint64(&nAcks, 1)
+				handler.Ack(consumer, ack)
 			} else if nack := in.GetNack(); nack != nil {
-				nNacks++
+				atomic.AddUint64(&nNacks, 1)
+				handler.Nack(consumer, nack)
 			}
 		}
 	}()
-	wg.Wait()
+
+	// If the subscriber is disconnected the handler returns without waiting for the
+	// ack routine, which is blocked on Recv until the stream is closed by returning. The
+	// routine may still handle acks after the consumer has left its group, which the
+	// group ignores since the consumer's events have been handed off to other consumers.
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case err := <-disconnected:
+		return err
+	}
+
 	log.Info().Uint64("nEvents", nEvents).Uint64("acks", nAcks).Uint64("nacks", nNacks).Msg("subscribe stream terminated")
-	return err
+	return recvErr
 }
 
 type SubscriberHandler struct {
@@ -589,6 +935,103 @@ func (s SubscriberHandler) Send(event *api.EventWrapper) error {
 	})
 }
 
+// Sends a topic update to the subscriber so that it can map the topics of new events.
+func (s SubscriberHandler) TopicUpdate(update *api.TopicUpdate) error {
+	return s.stream.Send(&api.SubscribeReply{
+		Embed: &api.SubscribeReply_TopicUpdate{
+			TopicUpdate: update,
+		},
+	})
+}
+
+// Sends a close stream message to a subscriber that the server is disconnecting.
+func (s SubscriberHandler) CloseStream(events, topics, acks, nacks uint64, reason string) error {
+	err := s.stream.Send(&api.SubscribeReply{
+		Embed: &api.SubscribeReply_CloseStream{
+			CloseStream: &api.CloseStream{
+				Events: events,
+				Topics: topics,
+				Acks:   acks,
+				Nacks:  nacks,
+				Reason: reason,
+			},
+		},
+	})
+
+	if err != nil {
+		log.Debug().Err(err).Msg("could not send close stream message")
+	}
+	return err
+}
+
+// JoinGroup joins the consumer group specified by the subscriber, creating the group if
+// it does not exist. The project ID of the group is set from the claims so Authorize
+// must be called first. Returns a status error if the group cannot be joined.
+func (s *SubscriberHandler) JoinGroup(registry *groups.Registry, in *api.ConsumerGroup) (consumer *groups.Consumer, err error) {
+	var projectID ulid.ULID
+	if projectID, err = s.ProjectID(); err != nil {
+		return nil, err
+	}
+
+	if consumer, err = registry.Join(projectID, in); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrInvalidGroup):
+			log.Debug().Err(err).Msg("invalid consumer group specified by subscriber")
+			return nil, status.Error(codes.InvalidArgument, "invalid consumer group")
+		case errors.Is(err, groups.ErrUnsupportedSemantic):
+			return nil, status.Error(codes.Unimplemented, "exactly once delivery is not supported")
+		default:
+			sentry.Error(s.stream.Context()).Err(err).Msg("could not get or create consumer group")
+			return nil, status.Error(codes.Internal, "could not open subscriber stream")
+		}
+	}
+	return consumer, nil
+}
+
+// Ack marks the acked event as consumed by the consumer group. If the subscriber is
+// not part of a consumer group then acks are simply counted.
+func (s *SubscriberHandler) Ack(consumer *groups.Consumer, ack *api.Ack) {
+	if consumer == nil {
+		return
+	}
+
+	eventID := &rlid.RLID{}
+	if err := eventID.UnmarshalBinary(ack.Id); err != nil {
+		log.Debug().Err(err).Msg("could not parse event id in subscriber ack")
+		return
+	}
+
+	if err := consumer.Ack(*eventID); err != nil {
+		if errors.Is(err, groups.ErrNotDelivered) {
+			log.Debug().Str("event_id", eventID.String()).Msg("subscriber acked event that is not in flight")
+			return
+		}
+		sentry.Error(s.stream.Context()).Err(err).Msg("could not commit consumer group offset")
+	}
+}
+
+// Nack requests that the consumer group redelivers the nacked event. If the subscriber
+// is not part of a consumer group then nacks are simply counted.
+func (s *SubscriberHandler) Nack(consumer *groups.Consumer, nack *api.Nack) {
+	if consumer == nil {
+		return
+	}
+
+	eventID := &rlid.RLID{}
+	if err := eventID.UnmarshalBinary(nack.Id); err != nil {
+		log.Debug().Err(err).Msg("could not parse event id in subscriber nack")
+		return
+	}
+
+	if err := consumer.Nack(*eventID, nack.Code); err != nil {
+		if errors.Is(err, groups.ErrNotDelivered) {
+			log.Debug().Str("event_id", eventID.String()).Msg("subscriber nacked event that is not in flight")
+			return
+		}
+		sentry.Error(s.stream.Context()).Err(err).Msg("could not redeliver nacked event")
+	}
+}
+
 // StreamHandler provides some common functionality to both the Publisher and Subscriber
 // stream handlers, for example providing authentication and collecting allowed topics.
 type StreamHandler struct {
@@ -642,6 +1085,17 @@ func (s *StreamHandler) ProjectID() (ulid.ULID, error) {
 	return s.projectID, nil
 }
 
+// Watch registers a watcher for updates to the topics in the project of the claims.
+// Authorize must be called first or this method will return a status error. Every
+// watcher must be unwatched when the stream closes.
+func (s *StreamHandler) Watch(watchers *topics.Watchers) (_ *topics.Watcher, err error) {
+	var projectID ulid.ULID
+	if projectID, err = s.ProjectID(); err != nil {
+		return nil, err
+	}
+	return watchers.Watch(projectID), nil
+}
+
 // AllowedTopics returns a set of topic IDs and hashed topic names that are allowed to
 // be accessed by the given claims. This set can be filtered to further restrict the
 // stream based on user input. A specialized data structure is used to make it easy to
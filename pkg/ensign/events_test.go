@@ -19,6 +19,9 @@ import (
 	"github.com/rotationalio/ensign/pkg/ensign/contexts"
 	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/mock"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	storeerrors "github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	store "github.com/rotationalio/ensign/pkg/ensign/store/mock"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/permissions"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/tokens"
@@ -26,6 +29,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -307,6 +311,76 @@ func (s *serverTestSuite) TestPublisherNackEvents() {
 	require.Equal(api.CodeMaxEventSizeExceeded, nack.Error)
 }
 
+func (s *serverTestSuite) TestPublisherTopicUpdates() {
+	// Should be able to publish to a topic created after the stream was opened
+	require := s.Require()
+	stream := s.setupValidPublisher()
+	s.store.UseError(store.Insert, nil)
+
+	topicID := ulid.MustParse("01H6XTBKRV2E5S2N6YZWN3J6WQ")
+	s.store.OnCreateTopic = func(topic *api.Topic) error {
+		topic.Id = topicID.Bytes()
+		return nil
+	}
+	s.store.OnRetrieveTopic = func(tid ulid.ULID) (*api.Topic, error) {
+		if tid.Compare(topicID) == 0 {
+			return &api.Topic{Id: topicID.Bytes(), Name: "example-topic-5", Status: api.TopicState_READY}, nil
+		}
+		return MockRetrieveTopic(tid)
+	}
+
+	requests := make(chan *api.PublisherRequest, 1)
+	stream.OnRecv = func() (*api.PublisherRequest, error) {
+		if msg, ok := <-requests; ok {
+			return msg, nil
+		}
+		return nil, io.EOF
+	}
+
+	replies := make(chan *api.PublisherReply, 8)
+	stream.Capture(replies)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.Publish(stream)
+	}()
+
+	requests <- &api.PublisherRequest{Embed: &api.PublisherRequest_OpenStream{OpenStream: &api.OpenStream{ClientId: "tester"}}}
+	ready := (<-replies).GetReady()
+	require.NotNil(ready, "expected stream ready reply")
+	require.NotContains(ready.Topics, "example-topic-5")
+
+	// Creating a topic in the project sends a topic update to the publisher
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.CreateTopics},
+	}
+
+	ctx := contexts.WithClaims(context.Background(), claims)
+	_, err := s.srv.CreateTopic(ctx, &api.Topic{Name: "example-topic-5"})
+	require.NoError(err, "could not create topic")
+
+	update := (<-replies).GetTopicUpdate()
+	require.NotNil(update, "expected topic update reply")
+	require.Equal("example-topic-5", update.Name)
+	require.Equal(topicID.Bytes(), update.TopicId)
+	require.Equal(api.TopicState_READY, update.State)
+
+	// Events published to the new topic should be accepted
+	event := MakeEmpty(topicID.String())
+	requests <- &api.PublisherRequest{Embed: &api.PublisherRequest_Event{Event: event}}
+	ack := (<-replies).GetAck()
+	require.NotNil(ack, "expected event to be acked")
+	require.Equal(event.LocalId, ack.Id)
+
+	close(requests)
+	require.NoError(<-errc, "expected no error when the client closes the stream")
+}
+
 func TestPublisherHandler(t *testing.T) {
 	store := &store.Store{}
 	stream := &mock.PublisherServer{}
@@ -501,6 +575,35 @@ func TestPublisherHandler(t *testing.T) {
 
 }
 
+func TestSubscriberHandler(t *testing.T) {
+	stream := &mock.SubscribeServer{}
+	meta, err := store.Open(config.StorageConfig{ReadOnly: false, Testing: true})
+	require.NoError(t, err, "could not open mock store for testing")
+	handler := ensign.NewSubscribeHandler(stream, meta)
+
+	t.Run("CloseStream", func(t *testing.T) {
+		defer stream.Reset()
+
+		// Handle error checking
+		stream.WithError(mock.StreamSend, io.EOF)
+		err := handler.CloseStream(12, 2, 10, 1, ensign.ReasonOverflow)
+		require.ErrorIs(t, err, io.EOF, "close stream should return a send error")
+
+		var closed *api.CloseStream
+		stream.OnSend = func(in *api.SubscribeReply) error {
+			closed = in.GetCloseStream()
+			return nil
+		}
+
+		require.NoError(t, handler.CloseStream(12, 2, 10, 1, ensign.ReasonOverflow))
+		require.Equal(t, uint64(12), closed.Events)
+		require.Equal(t, uint64(2), closed.Topics)
+		require.Equal(t, uint64(10), closed.Acks)
+		require.Equal(t, uint64(1), closed.Nacks)
+		require.Equal(t, ensign.ReasonOverflow, closed.Reason)
+	})
+}
+
 func (s *serverTestSuite) TestSubscriberStreamInitialization() {
 	require := s.Require()
 
@@ -557,17 +660,425 @@ func (s *serverTestSuite) TestSubscriberStreamInitialization() {
 	err = s.srv.Subscribe(stream)
 	s.GRPCErrorIs(err, codes.FailedPrecondition, "must send subscription to initialize stream")
 
-	// TODO: happy path is timing out; need way to cancel subscribe stream.
-	// subscription := &api.Subscription{ClientId: "tester", Topics: nil}
-	// sub := stream.WithSubscription(subscription)
+	// Happy path: the subscriber is sent a ready message and then closes the stream
+	sub := stream.WithSubscription(&api.Subscription{ClientId: "tester"})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.Subscribe(stream)
+	}()
+
+	ready := sub.Ready()
+	require.NotNil(ready, "did not get a ready response from server")
+	require.Equal("tester", ready.ClientId)
+	s.CheckTopicMap(claims.ProjectID, ready.Topics)
+
+	sub.Close()
+	require.NoError(<-errc, "expected no error when the client closes the stream")
+}
+
+func (s *serverTestSuite) TestSubscribeConsumerGroup() {
+	require := s.Require()
+	stream := &mock.SubscribeServer{}
+	s.store.OnAllowedTopics = MockAllowedTopics
+	s.store.OnTopicName = MockTopicName
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+	stream.WithPeer(claims, MakePeer("172.92.121.6:10820"))
 
-	// err = s.srv.Subscribe(stream)
-	// require.NoError(err, "error happened?")
+	// Create events in the topic, the group has already consumed the first two events
+	topicID := "01H6XTAVNM21F6JXNGAJF1SJ4S"
+	var seq rlid.Sequence
+	events := make([]*api.EventWrapper, 0, 5)
+	for i := 0; i < 5; i++ {
+		event := MakeEmpty(topicID)
+		event.Id = seq.Next().Bytes()
+		events = append(events, event)
+	}
 
-	// ready := sub.Ready()
-	// require.NotNil(ready, "did not get a ready response from server")
-	// sub.Close()
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	s.store.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		require.Equal(ulid.MustParse(claims.ProjectID).Bytes(), in.ProjectId, "expected project ID to be set from claims")
+		key, _ := in.Key()
+		in.Id = key[:]
+		in.Created = timestamppb.Now()
+		in.Modified = in.Created
+		in.TopicOffsets = map[string]uint64{topicID: 2}
+		return false, nil
+	}
+
+	// The group is also saved when consumers join or leave so only report new offsets.
+	commits := make(chan uint64, 8)
+	committed := uint64(2)
+	s.store.OnUpdateGroup = func(in *api.ConsumerGroup) error {
+		if offset := in.TopicOffsets[topicID]; offset != committed {
+			committed = offset
+			commits <- offset
+		}
+		return nil
+	}
+
+	sub := stream.WithSubscription(&api.Subscription{
+		ClientId: "tester",
+		Topics:   []string{"example-topic-2"},
+		Group:    &api.ConsumerGroup{Name: "testing.group"},
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.Subscribe(stream)
+	}()
+
+	ready := sub.Ready()
+	require.NotNil(ready, "did not get a ready response from server")
+
+	// The subscriber should receive the events after the committed offset
+	for i := 2; i < 5; i++ {
+		event := sub.Next()
+		require.NotNil(event, "expected event to be replayed")
+		require.Equal(events[i].Id, event.Id, "expected events to be replayed in order")
+	}
+
+	// Acking the events should commit the offset of the consumer group
+	for i := 2; i < 5; i++ {
+		sub.Ack(events[i].Id)
+		select {
+		case offset := <-commits:
+			require.Equal(uint64(i+1), offset, "expected the group offset to be committed")
+		case <-time.After(5 * time.Second):
+			require.Fail("timed out waiting for the group offset to be committed")
+		}
+	}
 
+	sub.Close()
+	require.NoError(<-errc, "expected no error when the client closes the stream")
+	require.Equal(1, s.store.Calls(store.GetOrCreateGroup))
+}
+
+func (s *serverTestSuite) TestSubscribeRedelivery() {
+	require := s.Require()
+	stream := &mock.SubscribeServer{}
+	s.store.OnAllowedTopics = MockAllowedTopics
+	s.store.OnTopicName = MockTopicName
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+	stream.WithPeer(claims, MakePeer("172.92.121.6:10820"))
+
+	topicID := "01H6XTAVNM21F6JXNGAJF1SJ4S"
+	var seq rlid.Sequence
+	events := make([]*api.EventWrapper, 0, 3)
+	for i := 0; i < 3; i++ {
+		event := MakeEmpty(topicID)
+		event.Id = seq.Next().Bytes()
+		events = append(events, event)
+	}
+
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	s.store.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		key, _ := in.Key()
+		in.Id = key[:]
+		in.Created = timestamppb.Now()
+		in.Modified = in.Created
+		in.TopicOffsets = map[string]uint64{topicID: 0}
+		return false, nil
+	}
+
+	// The group is also saved when consumers join or leave so only report new offsets.
+	commits := make(chan uint64, 8)
+	committed := uint64(0)
+	s.store.OnUpdateGroup = func(in *api.ConsumerGroup) error {
+		if offset := in.TopicOffsets[topicID]; offset != committed {
+			committed = offset
+			commits <- offset
+		}
+		return nil
+	}
+
+	sub := stream.WithSubscription(&api.Subscription{
+		ClientId: "tester",
+		Topics:   []string{"example-topic-2"},
+		Group: &api.ConsumerGroup{
+			Name:            "testing.redelivery",
+			Delivery:        api.DeliverySemantic_AT_LEAST_ONCE,
+			DeliveryTimeout: durationpb.New(100 * time.Millisecond),
+		},
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.Subscribe(stream)
+	}()
+
+	require.NotNil(sub.Ready(), "did not get a ready response from server")
+	for i := 0; i < 3; i++ {
+		require.Equal(events[i].Id, sub.Next().Id, "expected events to be replayed in order")
+	}
+
+	// A nacked event should be redelivered
+	sub.Nack(events[0].Id, api.Nack_DELIVER_AGAIN_ANY, "")
+	require.Equal(events[0].Id, sub.Next().Id, "expected nacked event to be redelivered")
+
+	// An event that is not acked within the delivery timeout should be redelivered
+	sub.Ack(events[0].Id)
+	sub.Ack(events[2].Id)
+	require.Equal(events[1].Id, sub.Next().Id, "expected timed out event to be redelivered")
+	require.Equal(uint64(1), <-commits, "expected offset to be committed for the first event")
+
+	sub.Ack(events[1].Id)
+	require.Equal(uint64(3), <-commits, "expected offset to be committed for all events")
+
+	sub.Close()
+	require.NoError(<-errc, "expected no error when the client closes the stream")
+}
+
+func (s *serverTestSuite) TestSubscribeConsumerGroupMembers() {
+	require := s.Require()
+	s.store.OnAllowedTopics = MockAllowedTopics
+	s.store.OnTopicName = MockTopicName
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	topicID := "01H6XTAVNM21F6JXNGAJF1SJ4S"
+	var seq rlid.Sequence
+	events := make([]*api.EventWrapper, 0, 3)
+	for i := 0; i < 3; i++ {
+		event := MakeEmpty(topicID)
+		event.Id = seq.Next().Bytes()
+		events = append(events, event)
+	}
+
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	s.store.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		key, _ := in.Key()
+		in.Id = key[:]
+		in.Created = timestamppb.Now()
+		in.Modified = in.Created
+		in.TopicOffsets = map[string]uint64{topicID: 0}
+		return false, nil
+	}
+
+	// Report the number of consumers whenever a consumer joins or leaves the group.
+	consumers := make(chan int, 8)
+	members := 0
+	s.store.OnUpdateGroup = func(in *api.ConsumerGroup) error {
+		if len(in.Consumers) != members {
+			members = len(in.Consumers)
+			consumers <- members
+		}
+		return nil
+	}
+
+	// Open a subscriber stream for a member of the group and read its events.
+	subscribe := func() (*mock.Subscription, <-chan *api.EventWrapper, <-chan error) {
+		stream := &mock.SubscribeServer{}
+		stream.WithPeer(claims, MakePeer("172.92.121.6:10820"))
+		sub := stream.WithSubscription(&api.Subscription{
+			ClientId: "tester",
+			Topics:   []string{"example-topic-2"},
+			Group:    &api.ConsumerGroup{Name: "testing.members", Delivery: api.DeliverySemantic_AT_LEAST_ONCE},
+		})
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- s.srv.Subscribe(stream)
+		}()
+
+		require.NotNil(sub.Ready(), "did not get a ready response from server")
+		recv := make(chan *api.EventWrapper, 8)
+		go func() {
+			defer close(recv)
+			for event := sub.Next(); event != nil; event = sub.Next() {
+				recv <- event
+			}
+		}()
+		return sub, recv, errc
+	}
+
+	alpha, alphaEvents, alphaErr := subscribe()
+	require.Equal(1, <-consumers, "expected the consumer to be recorded on the group")
+	for i := 0; i < 3; i++ {
+		require.Equal(events[i].Id, (<-alphaEvents).Id, "expected events to be replayed in order")
+	}
+	alpha.Ack(events[0].Id)
+
+	// The second member should not receive the events that are in flight to the first
+	bravo, bravoEvents, bravoErr := subscribe()
+	require.Equal(2, <-consumers, "expected the consumer to be recorded on the group")
+
+	select {
+	case event := <-bravoEvents:
+		require.Fail("expected no events to be sent to the second member", "received event %x", event.Id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// When the first member disconnects its unacked events are sent to the second
+	alpha.Close()
+	require.NoError(<-alphaErr, "expected no error when the client closes the stream")
+
+	require.Equal(1, <-consumers, "expected the consumer to be removed from the group")
+
+	redelivered := [][]byte{(<-bravoEvents).Id, (<-bravoEvents).Id}
+	require.ElementsMatch([][]byte{events[1].Id, events[2].Id}, redelivered, "expected unacked events to be redelivered")
+
+	bravo.Close()
+	require.NoError(<-bravoErr, "expected no error when the client closes the stream")
+	require.Equal(0, <-consumers, "expected the consumer to be removed from the group")
+}
+
+func (s *serverTestSuite) TestSubscribeConsumerGroupErrors() {
+	stream := &mock.SubscribeServer{}
+	s.store.OnAllowedTopics = MockAllowedTopics
+	s.store.OnTopicName = MockTopicName
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+	stream.WithPeer(claims, MakePeer("172.92.121.6:10820"))
+
+	// A consumer group must have an ID or a name
+	stream.WithSubscription(&api.Subscription{ClientId: "tester", Group: &api.ConsumerGroup{}})
+	err := s.srv.Subscribe(stream)
+	s.GRPCErrorIs(err, codes.InvalidArgument, "invalid consumer group")
+
+	// Handle database errors when loading the consumer group
+	s.store.UseError(store.GetOrCreateGroup, errors.New("something bad happened"))
+	stream.WithSubscription(&api.Subscription{ClientId: "tester", Group: &api.ConsumerGroup{Name: "testing.group"}})
+	err = s.srv.Subscribe(stream)
+	s.GRPCErrorIs(err, codes.Internal, "could not open subscriber stream")
+
+	// Exactly once delivery semantics are not supported
+	s.store.OnGetOrCreateGroup = func(*api.ConsumerGroup) (bool, error) { return true, nil }
+	stream.WithSubscription(&api.Subscription{ClientId: "tester", Group: &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_EXACTLY_ONCE}})
+	err = s.srv.Subscribe(stream)
+	s.GRPCErrorIs(err, codes.Unimplemented, "exactly once delivery is not supported")
+}
+
+func (s *serverTestSuite) TestSubscribeTopicUpdates() {
+	// Should receive events from topics created after the stream was opened
+	require := s.Require()
+	stream := &mock.SubscribeServer{}
+	s.store.OnAllowedTopics = MockAllowedTopics
+	s.store.OnTopicName = MockTopicName
+	s.store.UseError(store.Insert, nil)
+	s.store.UseError(store.UpdateOffset, nil)
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(nil)
+	}
+
+	topicID := ulid.MustParse("01H6XTBKRV2E5S2N6YZWN3J6WQ")
+	s.store.OnCreateTopic = func(topic *api.Topic) error {
+		topic.Id = topicID.Bytes()
+		return nil
+	}
+	s.store.OnRetrieveTopic = func(tid ulid.ULID) (*api.Topic, error) {
+		if tid.Compare(topicID) == 0 {
+			return &api.Topic{Id: topicID.Bytes(), Name: "example-topic-5", Status: api.TopicState_READY}, nil
+		}
+		return MockRetrieveTopic(tid)
+	}
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber, permissions.CreateTopics},
+	}
+	stream.WithPeer(claims, MakePeer("172.92.121.6:10820"))
+
+	sub := stream.WithSubscription(&api.Subscription{ClientId: "tester"})
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.Subscribe(stream)
+	}()
+
+	ready := sub.Ready()
+	require.NotNil(ready, "did not get a ready response from server")
+	require.NotContains(ready.Topics, "example-topic-5")
+
+	// Creating a topic in the project sends a topic update to the subscriber
+	ctx := contexts.WithClaims(context.Background(), claims)
+	_, err := s.srv.CreateTopic(ctx, &api.Topic{Name: "example-topic-5"})
+	require.NoError(err, "could not create topic")
+
+	update := sub.TopicUpdate()
+	require.NotNil(update, "expected topic update reply")
+	require.Equal("example-topic-5", update.Name)
+	require.Equal(api.TopicState_READY, update.State)
+
+	// Events published to the new topic should be sent to the subscriber; the topic
+	// update is received before the subscription is updated so wait for the topic to
+	// be added by publishing until an event is received.
+	publisher := s.setupValidPublisher()
+	s.store.OnAllowedTopics = func(projectID ulid.ULID) ([]ulid.ULID, error) {
+		topics, err := MockAllowedTopics(projectID)
+		return append(topics, topicID), err
+	}
+	s.store.OnTopicName = func(tid ulid.ULID) (string, error) {
+		if tid.Compare(topicID) == 0 {
+			return "example-topic-5", nil
+		}
+		return MockTopicName(tid)
+	}
+
+	events := make(chan *api.EventWrapper, 1)
+	go func() {
+		events <- sub.Next()
+	}()
+
+	for i := 0; i < 50; i++ {
+		event := MakeEmpty(topicID.String())
+		results := publisher.WithEventResults(&api.OpenStream{ClientId: "publisher"}, event)
+		require.NoError(s.srv.Publish(publisher))
+		require.NotNil(results.Ack(event), "expected event to be published to the new topic")
+
+		select {
+		case event := <-events:
+			require.NotNil(event, "expected event from new topic")
+			require.Equal(topicID.Bytes(), event.TopicId)
+			sub.Close()
+			require.NoError(<-errc, "expected no error when the client closes the stream")
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	require.Fail("timed out waiting for event from new topic")
 }
 
 func TestStreamHandler(t *testing.T) {
@@ -670,6 +1181,15 @@ func (s *serverTestSuite) setupValidPublisher() *mock.PublisherServer {
 	s.store.OnAllowedTopics = MockAllowedTopics
 	s.store.OnTopicName = MockTopicName
 
+	// The broker sequences the events it commits using the topic offset
+	s.store.OnRetrieveTopic = MockRetrieveTopic
+	s.store.UseError(store.UpdateOffset, nil)
+	if s.store.OnList == nil {
+		s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+			return store.NewEventIterator(nil)
+		}
+	}
+
 	// Create base claims to add to the stream context for authentication
 	// These claims are valid but will have no topics associated with them.
 	claims := &tokens.Claims{
@@ -724,6 +1244,21 @@ func MockTopicName(topicID ulid.ULID) (string, error) {
 
 }
 
+func MockRetrieveTopic(topicID ulid.ULID) (*api.Topic, error) {
+	tids := topicID.String()
+	for projectID, tmap := range projectTopics {
+		if name, ok := tmap[tids]; ok {
+			return &api.Topic{
+				Id:        topicID.Bytes(),
+				ProjectId: ulid.MustParse(projectID).Bytes(),
+				Name:      name,
+				Status:    api.TopicState_READY,
+			}, nil
+		}
+	}
+	return nil, storeerrors.ErrNotFound
+}
+
 func MakePeer(ipaddr string) *peer.Peer {
 	return &peer.Peer{
 		Addr:     net.TCPAddrFromAddrPort(netip.MustParseAddrPort(ipaddr)),
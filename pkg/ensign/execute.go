@@ -0,0 +1,238 @@
+package ensign
+
+import (
+	"context"
+
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/broker"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/utils/sentry"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// An execution tracks the state of a query plan as events are read so that events can
+// be read from both the event store and the broker without sending an event twice.
+// Events are read in the order of their ids so the id of the last event that was read
+// is used to skip events that have already been evaluated.
+type execution struct {
+	plan              *queryPlan
+	includeDuplicates bool
+	send              func(*api.EventWrapper, *EventFields) error
+	paginate          bool
+	started           bool
+	last              rlid.RLID
+	nSkipped          uint64
+	nSent             uint64
+}
+
+// Execute the query plan, reading events from the topic and calling send for each
+// event that matches the query. The fields are only created if they are required to
+// evaluate the where clause so they may be nil. If send returns an error the query
+// stops executing; all errors returned are status errors. The offset and limit are not
+// applied to the events of aggregate queries since they apply to the grouped rows.
+//
+// If the query is continuous then once the events in the event store have been read
+// the query continues to send events from the broker as they are committed until the
+// client closes the stream or the limit of the query is reached.
+func (s *Server) execute(ctx context.Context, plan *queryPlan, includeDuplicates bool, send func(*api.EventWrapper, *EventFields) error) (err error) {
+	exec := &execution{
+		plan:              plan,
+		includeDuplicates: includeDuplicates,
+		send:              send,
+		paginate:          !plan.query.IsAggregate(),
+	}
+
+	log.Debug().Str("query", plan.query.Raw).Str("topic", plan.topicID.String()).Bool("continuous", plan.continuous).Msg("starting ensql query execution")
+	if exec.limited() {
+		return nil
+	}
+
+	if plan.continuous {
+		return s.follow(ctx, exec)
+	}
+
+	_, err = s.read(ctx, exec)
+	return err
+}
+
+// Read the events in the event store that are after the last event read by the
+// execution. If no events have been read yet, the iterator seeks to the first event
+// that can match the created time range or event id offset of the query rather than
+// scanning the topic from the beginning. Returns true if the query is done.
+func (s *Server) read(ctx context.Context, exec *execution) (done bool, err error) {
+	events := s.data.List(exec.plan.topicID)
+	defer events.Release()
+
+	var ok bool
+	switch start, seek := exec.plan.start(); {
+	case exec.started:
+		ok = events.Seek(exec.last)
+	case seek:
+		log.Debug().Str("start", start.String()).Msg("seeking ensql query to start event")
+		ok = events.Seek(start)
+	default:
+		ok = events.Next()
+	}
+
+	for ; ok; ok = events.Next() {
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			sentry.Error(ctx).Bytes("key", events.Key()).Err(err).Msg("could not parse event")
+			continue
+		}
+
+		if done, err = s.evaluate(ctx, exec, event); err != nil || done {
+			return done, err
+		}
+	}
+
+	if err = events.Error(); err != nil {
+		sentry.Error(ctx).Err(err).Msg("could not retrieve events from database")
+		return true, status.Error(codes.Internal, "could not execute query")
+	}
+	return false, nil
+}
+
+// Follow executes a continuous query by reading the events in the event store and then
+// evaluating events from the broker as they are committed. The query subscribes to the
+// broker before the event store is read so that no events are missed in the hand off;
+// the events that are queued by the broker while the store is read are skipped by the
+// execution if they have already been read. If the query cannot keep up with the
+// broker, the events that spilled are read from the event store.
+func (s *Server) follow(ctx context.Context, exec *execution) (err error) {
+	var sub *broker.Subscriber
+	if sub, err = s.broker.SubscribeWith(broker.Options{Overflow: api.Subscription_SPILL}, exec.plan.topicID); err != nil {
+		sentry.Warn(ctx).Err(err).Msg("could not register continuous query with broker")
+		return status.Error(codes.Unavailable, "ensign broker is not available")
+	}
+	defer s.broker.Close(sub.ID)
+
+	var done bool
+	if done, err = s.read(ctx, exec); err != nil || done {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("continuous query stream closed by client")
+			return nil
+		case <-sub.Overflows:
+			// Evaluate the events queued before the spill, then read the spilled events
+			// from the event store. The store is read again once the broker resumes so
+			// that the events committed during the first read are not missed.
+		drain:
+			for {
+				select {
+				case event, open := <-sub.Events:
+					if !open {
+						return status.Error(codes.Unavailable, "ensign broker is not available")
+					}
+
+					if done, err = s.evaluate(ctx, exec, event); err != nil || done {
+						return err
+					}
+				default:
+					break drain
+				}
+			}
+
+			if done, err = s.read(ctx, exec); err != nil || done {
+				return err
+			}
+
+			if err = s.broker.Resume(sub.ID); err != nil {
+				sentry.Warn(ctx).Err(err).Msg("could not resume continuous query")
+				return status.Error(codes.Unavailable, "ensign broker is not available")
+			}
+
+			if done, err = s.read(ctx, exec); err != nil || done {
+				return err
+			}
+		case event, open := <-sub.Events:
+			// If the events channel has closed, the broker is no longer sending events
+			if !open {
+				return status.Error(codes.Unavailable, "ensign broker is not available")
+			}
+
+			if done, err = s.evaluate(ctx, exec, event); err != nil || done {
+				return err
+			}
+		}
+	}
+}
+
+// Evaluate the event against the query and send it if it matches, returning true if the
+// query is done because the limit has been reached or the client closed the stream.
+// Events that are not after the last event evaluated by the execution are skipped.
+func (s *Server) evaluate(ctx context.Context, exec *execution, event *api.EventWrapper) (done bool, err error) {
+	var eventID rlid.RLID
+	if eventID, err = event.ParseEventID(); err != nil {
+		sentry.Error(ctx).Bytes("event_id", event.Id).Err(err).Msg("could not parse event id")
+		return false, nil
+	}
+
+	if exec.started && eventID.Compare(exec.last) <= 0 {
+		return false, nil
+	}
+	exec.started, exec.last = true, eventID
+
+	// Skip over duplicates unless specified by the query
+	if !exec.includeDuplicates && event.IsDuplicate {
+		return false, nil
+	}
+
+	// If we're including duplicates, and the event is a duplicate, then dereference
+	// the duplicate from the database so there is correct event information.
+	topicID := exec.plan.topicID
+	if event.IsDuplicate {
+		if err = dereference(s.data, topicID, event); err != nil {
+			sentry.Error(ctx).Err(err).Bytes("duplicate_id", event.DuplicateId).Str("topic_id", topicID.String()).Msg("could not dereference duplicate event")
+			return false, nil
+		}
+	}
+
+	// Skip over events that do not match the where clause
+	var fields *EventFields
+	if exec.plan.where != nil {
+		if fields, err = NewEventFields(event, exec.plan.types); err != nil {
+			sentry.Error(ctx).Bytes("event_id", event.Id).Err(err).Msg("could not unwrap event")
+			return false, nil
+		}
+
+		var match bool
+		if match, err = exec.plan.where.Evaluate(fields); err != nil {
+			log.Debug().Err(err).Str("query", exec.plan.query.Raw).Msg("could not evaluate where clause")
+			return true, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if !match {
+			return false, nil
+		}
+	}
+
+	// Skip over the number of matching events specified by a numeric offset
+	if exec.paginate && exec.nSkipped < exec.plan.query.Offset {
+		exec.nSkipped++
+		return false, nil
+	}
+
+	if err = exec.send(event, fields); err != nil {
+		if streamClosed(err) {
+			log.Debug().Msg("query stream closed by client")
+			return true, nil
+		}
+		sentry.Warn(ctx).Err(err).Msg("ensql query stream crashed")
+		return true, status.Error(codes.Aborted, "query stream aborted")
+	}
+
+	exec.nSent++
+	return exec.limited(), nil
+}
+
+// Returns true if the limit of the query has been reached.
+func (e *execution) limited() bool {
+	return e.paginate && e.plan.query.HasLimit && e.nSent >= e.plan.query.Limit
+}
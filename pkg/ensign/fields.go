@@ -0,0 +1,233 @@
+package ensign
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Reserved identifiers that resolve to the fields of an event rather than to metadata
+// or payload fields when evaluating EnSQL queries.
+const (
+	FieldMimetype    = "mimetype"
+	FieldTypeName    = "type_name"
+	FieldTypeVersion = "type_version"
+	FieldCreated     = "created"
+)
+
+// EventFields implements ensql.Variables to resolve identifiers in EnSQL queries to
+// values from an event. Identifiers are resolved first from the reserved event fields,
+// then from the event metadata, and finally from the fields of the event payload if
+// the payload can be decoded (e.g. JSON, msgpack, or protobuf data). Nested payload
+// fields are referenced by a path of keys separated by dots, e.g. user.address.city.
+// The payload is only decoded when a lookup requires it and is decoded at most once.
+type EventFields struct {
+	event   *api.Event
+	types   *protoregistry.Files
+	payload map[string]any
+	decoded bool
+}
+
+// Create the fields for the event in the wrapper so that it can be queried. Protobuf
+// payloads can only be decoded if the types registry contains a message descriptor
+// whose full name is the name of the event type; types may be nil.
+func NewEventFields(wrapper *api.EventWrapper, types *protoregistry.Files) (_ *EventFields, err error) {
+	fields := &EventFields{types: types}
+	if fields.event, err = wrapper.Unwrap(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Lookup the value of the identifier in the event.
+func (f *EventFields) Lookup(identifier string) (any, bool) {
+	switch identifier {
+	case FieldMimetype:
+		return f.event.Mimetype.MimeType(), true
+	case FieldTypeName:
+		return f.event.ResolveType().Name, true
+	case FieldTypeVersion:
+		return f.event.ResolveType().Semver(), true
+	case FieldCreated:
+		if f.event.Created == nil {
+			return nil, false
+		}
+		return f.event.Created.AsTime(), true
+	}
+
+	if value, ok := f.event.Metadata[identifier]; ok {
+		return value, true
+	}
+
+	if !f.decoded {
+		f.decode()
+	}
+
+	if value, ok := f.payload[identifier]; ok {
+		return value, true
+	}
+
+	if !strings.Contains(identifier, ".") {
+		return nil, false
+	}
+
+	var value any = f.payload
+	for _, key := range strings.Split(identifier, ".") {
+		switch container := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = container[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(container) {
+				return nil, false
+			}
+			value = container[idx]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Payload returns the decoded top level fields of the event payload, which is nil if
+// the payload could not be decoded.
+func (f *EventFields) Payload() map[string]any {
+	if !f.decoded {
+		f.decode()
+	}
+	return f.payload
+}
+
+// Decode the event payload into a map of fields. If the mimetype is not one that can
+// be decoded or the payload is not an object then there are no payload fields.
+func (f *EventFields) decode() {
+	f.decoded = true
+	switch f.event.Mimetype {
+	case mimetype.ApplicationJSON, mimetype.ApplicationJSONLD:
+		// Use numbers so that integers are not converted into floats
+		decoder := json.NewDecoder(bytes.NewReader(f.event.Data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&f.payload); err != nil {
+			f.payload = nil
+			return
+		}
+		f.payload = jsonNumbers(f.payload).(map[string]any)
+	case mimetype.ApplicationMsgPack:
+		if err := msgpack.Unmarshal(f.event.Data, &f.payload); err != nil {
+			f.payload = nil
+		}
+	case mimetype.ApplicationProtobuf:
+		if f.types == nil {
+			return
+		}
+
+		desc, err := f.types.FindDescriptorByName(protoreflect.FullName(f.event.ResolveType().Name))
+		if err != nil {
+			return
+		}
+
+		mdesc, ok := desc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return
+		}
+
+		msg := dynamicpb.NewMessage(mdesc)
+		if err := proto.Unmarshal(f.event.Data, msg); err != nil {
+			return
+		}
+		f.payload = protoFields(msg)
+	}
+}
+
+// Replace json.Number values with int64 values if they are integers, otherwise with
+// float64 values so that numbers in JSON payloads can be compared and typed.
+func jsonNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = jsonNumbers(item)
+		}
+	}
+	return value
+}
+
+// Convert a protocol buffer message into a map of fields keyed by the field names
+// defined in the message descriptor. Fields that track presence are omitted if they
+// are not set, otherwise the default value of the field is used.
+func protoFields(msg protoreflect.Message) map[string]any {
+	fields := make(map[string]any)
+	descs := msg.Descriptor().Fields()
+	for i := 0; i < descs.Len(); i++ {
+		fd := descs.Get(i)
+		if fd.HasPresence() && !msg.Has(fd) {
+			continue
+		}
+
+		value := msg.Get(fd)
+		switch {
+		case fd.IsList():
+			list := value.List()
+			items := make([]any, 0, list.Len())
+			for j := 0; j < list.Len(); j++ {
+				items = append(items, protoValue(fd, list.Get(j)))
+			}
+			fields[string(fd.Name())] = items
+		case fd.IsMap():
+			items := make(map[string]any, value.Map().Len())
+			value.Map().Range(func(key protoreflect.MapKey, val protoreflect.Value) bool {
+				items[key.String()] = protoValue(fd.MapValue(), val)
+				return true
+			})
+			fields[string(fd.Name())] = items
+		default:
+			fields[string(fd.Name())] = protoValue(fd, value)
+		}
+	}
+	return fields
+}
+
+// Convert a singular protocol buffer value into a Go value; enums are converted into
+// their names, timestamps into times, and messages into maps of fields.
+func protoValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if enum := fd.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name())
+		}
+		return int64(value.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := value.Message()
+		if msg.Descriptor().FullName() == "google.protobuf.Timestamp" {
+			fields := msg.Descriptor().Fields()
+			seconds := msg.Get(fields.ByName("seconds")).Int()
+			nanos := msg.Get(fields.ByName("nanos")).Int()
+			return time.Unix(seconds, nanos).UTC()
+		}
+		return protoFields(msg)
+	default:
+		return value.Interface()
+	}
+}
@@ -0,0 +1,62 @@
+package groups
+
+import (
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+)
+
+// The number of redelivered events that can be queued for a consumer before the group
+// must find another consumer to redeliver the event to.
+const RedeliveryBuffer = 1024
+
+// Consumer represents a single subscriber stream that has joined a consumer group.
+// Events must be recorded with Deliver before they are sent to the subscriber so that
+// the group can track them until they are consumed and so that events are not sent to
+// more than one consumer in the group. The subscriber must also send any events
+// received on the Redeliveries channel, which are events whose delivery to a consumer
+// in the group timed out or was nacked. Once the consumer has left the group, its acks
+// and nacks are ignored since its events have been handed off to the rest of the group.
+type Consumer struct {
+	ID           ulid.ULID
+	group        *Group
+	redeliveries chan *api.EventWrapper
+	left         bool
+}
+
+// Group returns the consumer group that the consumer belongs to.
+func (c *Consumer) Group() *Group {
+	return c.group
+}
+
+// Redeliveries returns a channel of events that must be resent to the subscriber.
+func (c *Consumer) Redeliveries() <-chan *api.EventWrapper {
+	return c.redeliveries
+}
+
+// Deliver records that the event at the specified offset of the topic is being sent
+// to the consumer. This must be called before the event is sent to the subscriber so
+// that an ack cannot be received before the delivery is tracked. If false is returned
+// then the event has already been delivered to the group and must not be sent.
+func (c *Consumer) Deliver(event *api.EventWrapper, topicID ulid.ULID, offset uint64) (bool, error) {
+	return c.group.deliver(c, event, topicID, offset)
+}
+
+// Skip marks the event at the specified offset of the topic as consumed by the group
+// without delivering it, e.g. because the subscriber does not want to receive it. If
+// false is returned the event has already been delivered to or consumed by the group.
+func (c *Consumer) Skip(event *api.EventWrapper, topicID ulid.ULID, offset uint64) (bool, error) {
+	return c.group.skip(event, topicID, offset)
+}
+
+// Ack marks the event as consumed by the group, possibly advancing the group offset.
+// ErrNotDelivered is returned if the consumer has already left the group.
+func (c *Consumer) Ack(eventID rlid.RLID) error {
+	return c.group.ack(c, eventID)
+}
+
+// Nack requests that the event be redelivered according to the nack code.
+// ErrNotDelivered is returned if the consumer has already left the group.
+func (c *Consumer) Nack(eventID rlid.RLID, code api.Nack_Code) error {
+	return c.group.nack(c, eventID, code)
+}
@@ -0,0 +1,656 @@
+/*
+Package groups manages the server-side state of consumer groups. Subscribers that
+specify a consumer group share the offsets that the group has committed for each topic
+so that when a subscriber reconnects it can resume from where the group left off rather
+than only receiving events that are published after the stream is opened.
+*/
+package groups
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/meta"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// The delivery timeout used if the consumer group does not specify one.
+	DefaultDeliveryTimeout = 20 * time.Second
+
+	// The minimum interval between checks for events whose delivery has timed out.
+	minSweepInterval = 10 * time.Millisecond
+)
+
+var (
+	ErrNotDelivered        = errors.New("event was not delivered to the consumer group")
+	ErrUnsupportedSemantic = errors.New("exactly once delivery is not supported")
+)
+
+// Registry ensures that all of the subscriber streams on the server that belong to the
+// same consumer group share a single in-memory Group. The meta store does not guard the
+// order of writes to a group, so only one Group should ever update a group's offsets.
+type Registry struct {
+	sync.Mutex
+	db     store.GroupStore
+	groups map[meta.ObjectKey]*Group
+}
+
+// NewRegistry creates a consumer group registry backed by the specified group store.
+func NewRegistry(db store.GroupStore) *Registry {
+	return &Registry{
+		db:     db,
+		groups: make(map[meta.ObjectKey]*Group),
+	}
+}
+
+// Join fetches the consumer group from the registry or loads it from the store if it
+// is not already in memory, creating the group in the store if it does not exist, then
+// adds a new consumer to the group. The projectID of the group is always set from the
+// specified projectID, which should be taken from the claims of the subscriber rather
+// than from user input. Every call to Join must be paired with a call to Leave when the
+// subscriber stream closes.
+func (r *Registry) Join(projectID ulid.ULID, in *api.ConsumerGroup) (consumer *Consumer, err error) {
+	// Create a copy of the group so that user input is not modified.
+	cg := proto.Clone(in).(*api.ConsumerGroup)
+	cg.ProjectId = projectID[:]
+	if err = meta.ValidateGroup(cg, true); err != nil {
+		return nil, err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	key := meta.GroupKey(cg)
+	group := r.groups[key]
+	if group == nil {
+		if _, err = r.db.GetOrCreateGroup(cg); err != nil {
+			return nil, err
+		}
+
+		if group, err = newGroup(r.db, key, cg); err != nil {
+			return nil, err
+		}
+		r.groups[key] = group
+	}
+
+	if consumer, err = group.join(); err != nil {
+		if len(group.consumers) == 0 {
+			group.close()
+			delete(r.groups, key)
+		}
+		return nil, err
+	}
+	return consumer, nil
+}
+
+// Leave removes the consumer from its group; any events that were delivered to the
+// consumer but not acked are redelivered to the other consumers in the group. When
+// there are no more consumers connected to the group it is removed from memory.
+func (r *Registry) Leave(consumer *Consumer) {
+	r.Lock()
+	defer r.Unlock()
+
+	group := consumer.group
+	if remaining := group.leave(consumer); remaining == 0 {
+		group.close()
+		delete(r.groups, group.key)
+	}
+}
+
+// DeleteTopic removes the offsets of the topic from all of the consumer groups in the
+// project, including any deliveries of the topic's events that groups in memory are
+// tracking. This must be called when a topic is destroyed so that the offsets are not
+// left behind in the meta store. The registry is locked while the groups are updated
+// so that groups cannot be loaded with the topic's offsets while they are deleted.
+func (r *Registry) DeleteTopic(projectID, topicID ulid.ULID) (err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	iter := r.db.ListGroups(projectID)
+	defer iter.Release()
+
+	for iter.Next() {
+		var cg *api.ConsumerGroup
+		if cg, err = iter.Group(); err != nil {
+			return err
+		}
+
+		if group, ok := r.groups[meta.GroupKey(cg)]; ok {
+			if err = group.deleteTopic(topicID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := cg.TopicOffsets[topicID.String()]; !ok {
+			continue
+		}
+
+		delete(cg.TopicOffsets, topicID.String())
+		delete(cg.TopicEventIds, topicID.String())
+		if err = r.db.UpdateGroup(cg); err != nil {
+			return err
+		}
+	}
+
+	return iter.Error()
+}
+
+// Len returns the number of consumer groups currently held in memory.
+func (r *Registry) Len() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.groups)
+}
+
+// Group wraps a ConsumerGroup that has been loaded from the meta store and manages
+// updates to the group's topic offsets. The offset of a topic is the offset of the
+// last event in that topic that has been consumed by the group; e.g. an offset of 0
+// means that no events in the topic have been consumed.
+//
+// Events that are delivered to consumers are tracked by the group until they are
+// consumed. With at least once delivery, an event is only consumed when it is acked; if
+// it is not acked within the delivery timeout or it is nacked, it is redelivered to a
+// consumer in the group. With at most once delivery, events are not redelivered when
+// they are nacked or time out; a nacked event is consumed, but an event whose delivery
+// times out is dropped without being consumed so that it is replayed the next time the
+// group is loaded. With either semantic, events in flight to a consumer that leaves the
+// group are redelivered to the remaining consumers. The committed offset only advances
+// once every event at or before it has been consumed. Groups that do not specify a
+// delivery semantic use at least once delivery.
+//
+// Multiple consumers in the group may be replaying the same events from the event store
+// at the same time, so the group ensures that each event is only delivered to one of
+// its consumers; redeliveries aside, an event that is in flight or has been consumed
+// will not be delivered again.
+type Group struct {
+	sync.RWMutex
+	db        store.GroupStore
+	key       meta.ObjectKey
+	group     *api.ConsumerGroup
+	semantic  api.DeliverySemantic
+	timeout   time.Duration
+	consumers []*Consumer
+	next      int
+	inflight  map[rlid.RLID]*delivery
+	consumed  map[ulid.ULID]map[uint64]rlid.RLID
+	parked    []*delivery
+	done      chan struct{}
+}
+
+// A delivery references an event that has been delivered but has not been consumed.
+type delivery struct {
+	event    *api.EventWrapper
+	eventID  rlid.RLID
+	topicID  ulid.ULID
+	offset   uint64
+	consumer *Consumer
+	exclude  *Consumer
+	deadline time.Time
+}
+
+func newGroup(db store.GroupStore, key meta.ObjectKey, cg *api.ConsumerGroup) (_ *Group, err error) {
+	if cg.TopicOffsets == nil {
+		cg.TopicOffsets = make(map[string]uint64)
+	}
+
+	if cg.TopicEventIds == nil {
+		cg.TopicEventIds = make(map[string][]byte)
+	}
+
+	// Consumers recorded by a previous server process are no longer connected.
+	cg.Consumers = nil
+
+	group := &Group{
+		db:       db,
+		key:      key,
+		group:    cg,
+		semantic: cg.Delivery,
+		timeout:  DefaultDeliveryTimeout,
+		inflight: make(map[rlid.RLID]*delivery),
+		consumed: make(map[ulid.ULID]map[uint64]rlid.RLID),
+		done:     make(chan struct{}),
+	}
+
+	switch group.semantic {
+	case api.DeliverySemantic_UNSPECIFIED:
+		group.semantic = api.DeliverySemantic_AT_LEAST_ONCE
+	case api.DeliverySemantic_EXACTLY_ONCE:
+		return nil, ErrUnsupportedSemantic
+	}
+
+	if timeout := cg.DeliveryTimeout.AsDuration(); timeout > 0 {
+		group.timeout = timeout
+	}
+
+	go group.sweep()
+	return group, nil
+}
+
+// Proto returns a copy of the underlying consumer group.
+func (g *Group) Proto() *api.ConsumerGroup {
+	g.RLock()
+	defer g.RUnlock()
+	return proto.Clone(g.group).(*api.ConsumerGroup)
+}
+
+// Key returns the key of the consumer group in the meta store, which uniquely identifies
+// the group across all projects.
+func (g *Group) Key() meta.ObjectKey {
+	return g.key
+}
+
+// Semantic returns the delivery semantic of the group.
+func (g *Group) Semantic() api.DeliverySemantic {
+	return g.semantic
+}
+
+// Offset returns the committed offset of the group for the specified topic. If the
+// group has never committed an offset for the topic, false is returned.
+func (g *Group) Offset(topicID ulid.ULID) (offset uint64, ok bool) {
+	g.RLock()
+	defer g.RUnlock()
+	offset, ok = g.group.TopicOffsets[topicID.String()]
+	return offset, ok
+}
+
+// EventID returns the ID of the event at the committed offset of the topic. If the
+// group has not committed an event for the topic, e.g. because the offset was committed
+// before event IDs were recorded or the topic was empty, false is returned.
+func (g *Group) EventID(topicID ulid.ULID) (eventID rlid.RLID, ok bool) {
+	g.RLock()
+	defer g.RUnlock()
+
+	var id []byte
+	if id, ok = g.group.TopicEventIds[topicID.String()]; !ok || len(id) != len(eventID) {
+		return eventID, false
+	}
+
+	copy(eventID[:], id)
+	return eventID, !rlid.IsZero(eventID)
+}
+
+// Commit the offset for the specified topic along with the ID of the event at that
+// offset and persist the group to the meta store. Offsets only move forward, if the
+// offset is not greater than the current offset of the topic then nothing happens.
+// Committing an offset for a topic that the group does not have an offset for yet
+// starts tracking the topic at that offset.
+func (g *Group) Commit(topicID ulid.ULID, offset uint64, eventID rlid.RLID) (err error) {
+	g.Lock()
+	defer g.Unlock()
+	return g.commit(topicID, offset, eventID)
+}
+
+func (g *Group) commit(topicID ulid.ULID, offset uint64, eventID rlid.RLID) (err error) {
+	key := topicID.String()
+	if current, ok := g.group.TopicOffsets[key]; ok && offset <= current {
+		return nil
+	}
+
+	// Only modify the in-memory group if the update succeeds.
+	cg := proto.Clone(g.group).(*api.ConsumerGroup)
+	if cg.TopicOffsets == nil {
+		cg.TopicOffsets = make(map[string]uint64)
+	}
+	cg.TopicOffsets[key] = offset
+
+	if cg.TopicEventIds == nil {
+		cg.TopicEventIds = make(map[string][]byte)
+	}
+	cg.TopicEventIds[key] = eventID.Bytes()
+
+	if err = g.db.UpdateGroup(cg); err != nil {
+		return err
+	}
+
+	g.group = cg
+	return nil
+}
+
+// deleteTopic stops tracking the deliveries of events in the topic and removes the offset
+// of the topic from the group, persisting the group if it had an offset for the topic.
+func (g *Group) deleteTopic(topicID ulid.ULID) (err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	for eventID, d := range g.inflight {
+		if d.topicID == topicID {
+			delete(g.inflight, eventID)
+		}
+	}
+
+	parked := g.parked[:0]
+	for _, d := range g.parked {
+		if d.topicID != topicID {
+			parked = append(parked, d)
+		}
+	}
+	g.parked = parked
+	delete(g.consumed, topicID)
+
+	key := topicID.String()
+	if _, ok := g.group.TopicOffsets[key]; !ok {
+		return nil
+	}
+
+	cg := proto.Clone(g.group).(*api.ConsumerGroup)
+	delete(cg.TopicOffsets, key)
+	delete(cg.TopicEventIds, key)
+	if err = g.db.UpdateGroup(cg); err != nil {
+		return err
+	}
+
+	g.group = cg
+	return nil
+}
+
+// deliver records that the event at the specified offset of the topic is being sent to
+// the consumer. If the event is already in flight or has been consumed by the group then
+// the delivery is not recorded and false is returned; the event must not be sent.
+func (g *Group) deliver(c *Consumer, event *api.EventWrapper, topicID ulid.ULID, offset uint64) (_ bool, err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	var eventID rlid.RLID
+	if eventID, err = event.ParseEventID(); err != nil {
+		return false, err
+	}
+
+	if _, ok := g.inflight[eventID]; ok {
+		return false, nil
+	}
+
+	if committed, ok := g.group.TopicOffsets[topicID.String()]; ok && offset <= committed {
+		return false, nil
+	}
+
+	if _, ok := g.consumed[topicID][offset]; ok {
+		return false, nil
+	}
+
+	g.inflight[eventID] = &delivery{
+		event:    event,
+		eventID:  eventID,
+		topicID:  topicID,
+		offset:   offset,
+		consumer: c,
+		deadline: time.Now().Add(g.timeout),
+	}
+	return true, nil
+}
+
+// skip consumes the event at the specified offset of the topic without delivering it so
+// that the group offset is not held back by events that are never sent. Events that are
+// in flight or that have already been consumed are not skipped and false is returned.
+func (g *Group) skip(event *api.EventWrapper, topicID ulid.ULID, offset uint64) (_ bool, err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	var eventID rlid.RLID
+	if eventID, err = event.ParseEventID(); err != nil {
+		return false, err
+	}
+
+	if _, ok := g.inflight[eventID]; ok {
+		return false, nil
+	}
+
+	if committed, ok := g.group.TopicOffsets[topicID.String()]; ok && offset <= committed {
+		return false, nil
+	}
+
+	if _, ok := g.consumed[topicID][offset]; ok {
+		return false, nil
+	}
+
+	if err = g.consume(&delivery{event: event, eventID: eventID, topicID: topicID, offset: offset}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ack marks the event as consumed by the group and commits the offset of the event's
+// topic if all of the events delivered before it have also been consumed. If the event
+// is not in flight or the consumer has left the group then ErrNotDelivered is returned.
+func (g *Group) ack(c *Consumer, eventID rlid.RLID) (err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	d, ok := g.inflight[eventID]
+	if !ok || c.left {
+		return ErrNotDelivered
+	}
+	return g.consume(d)
+}
+
+// nack requests that the event is redelivered. Events that the consumer cannot handle
+// or that it asked not to receive again are redelivered to a different consumer in the
+// group, otherwise the event is redelivered to any consumer in the group. If the group
+// has at most once delivery semantics the nacked event is consumed instead.
+func (g *Group) nack(c *Consumer, eventID rlid.RLID, code api.Nack_Code) (err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	d, ok := g.inflight[eventID]
+	if !ok || c.left {
+		return ErrNotDelivered
+	}
+
+	if g.semantic != api.DeliverySemantic_AT_LEAST_ONCE {
+		return g.consume(d)
+	}
+
+	d.exclude = nil
+	switch code {
+	case api.Nack_DELIVER_AGAIN_NOT_ME, api.Nack_UNHANDLED_MIMETYPE, api.Nack_UNKNOWN_TYPE:
+		d.exclude = c
+	}
+
+	g.redeliver(d)
+	return nil
+}
+
+// consume removes the delivery from the in-flight events and commits the offset of the
+// topic up to the last of the contiguous consumed events. Events may be consumed out of
+// order, so offsets past the committed offset are held until the gap before them is
+// filled. Must be called while the group is locked.
+func (g *Group) consume(d *delivery) error {
+	delete(g.inflight, d.eventID)
+
+	committed := g.group.TopicOffsets[d.topicID.String()]
+	if d.offset <= committed {
+		return nil
+	}
+
+	consumed, ok := g.consumed[d.topicID]
+	if !ok {
+		consumed = make(map[uint64]rlid.RLID)
+		g.consumed[d.topicID] = consumed
+	}
+	consumed[d.offset] = d.eventID
+
+	var eventID rlid.RLID
+	offset := committed
+	for {
+		next, ok := consumed[offset+1]
+		if !ok {
+			break
+		}
+		offset++
+		eventID = next
+	}
+
+	if offset == committed {
+		return nil
+	}
+
+	if err := g.commit(d.topicID, offset, eventID); err != nil {
+		return err
+	}
+
+	for o := committed + 1; o <= offset; o++ {
+		delete(consumed, o)
+	}
+	return nil
+}
+
+// redeliver hands the delivery off to the next available consumer in round robin order
+// that is not excluded by the delivery. If no consumer can accept the event, the
+// delivery is parked until the next sweep or until a new consumer joins the group.
+// Must be called while the group is locked.
+func (g *Group) redeliver(d *delivery) {
+	for i := 0; i < len(g.consumers); i++ {
+		c := g.consumers[(g.next+i)%len(g.consumers)]
+		if c == d.exclude {
+			continue
+		}
+
+		select {
+		case c.redeliveries <- d.event:
+			g.next = (g.next + i + 1) % len(g.consumers)
+			d.consumer = c
+			d.deadline = time.Now().Add(g.timeout)
+			return
+		default:
+		}
+	}
+
+	d.consumer = nil
+	g.parked = append(g.parked, d)
+}
+
+// sweep periodically handles events whose delivery timeout has passed and retries
+// parked deliveries until the group is closed.
+func (g *Group) sweep() {
+	interval := g.timeout / 4
+	if interval < minSweepInterval {
+		interval = minSweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case now := <-ticker.C:
+			g.Lock()
+			g.unpark()
+			for _, d := range g.inflight {
+				if d.consumer != nil && now.After(d.deadline) {
+					g.expire(d)
+				}
+			}
+			g.Unlock()
+		}
+	}
+}
+
+// expire handles a delivery that timed out: with at least once delivery the event is
+// redelivered, otherwise it is dropped. A dropped event is not consumed, so the offset
+// is not committed past it and the event is replayed when the group is next loaded.
+// Must be called while the group is locked.
+func (g *Group) expire(d *delivery) {
+	if g.semantic == api.DeliverySemantic_AT_LEAST_ONCE {
+		d.exclude = nil
+		g.redeliver(d)
+		return
+	}
+	delete(g.inflight, d.eventID)
+}
+
+// unpark attempts to redeliver all parked deliveries. Must be called while locked.
+func (g *Group) unpark() {
+	parked := g.parked
+	g.parked = nil
+	for _, d := range parked {
+		if _, ok := g.inflight[d.eventID]; ok {
+			g.redeliver(d)
+		}
+	}
+}
+
+// join adds a new consumer to the group and records it in the meta store.
+func (g *Group) join() (_ *Consumer, err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	c := &Consumer{
+		ID:           ulid.Make(),
+		group:        g,
+		redeliveries: make(chan *api.EventWrapper, RedeliveryBuffer),
+	}
+
+	g.consumers = append(g.consumers, c)
+	if err = g.saveConsumers(); err != nil {
+		g.consumers = g.consumers[:len(g.consumers)-1]
+		g.group.Consumers = g.group.Consumers[:len(g.group.Consumers)-1]
+		return nil, err
+	}
+
+	g.unpark()
+	return c, nil
+}
+
+// leave removes the consumer from the group and redelivers any events that were sent to
+// the consumer but not consumed; if no consumers remain the deliveries are parked and
+// are replayed from the committed offset when the group is next loaded. Returns the
+// number of remaining consumers.
+func (g *Group) leave(c *Consumer) int {
+	g.Lock()
+	defer g.Unlock()
+
+	c.left = true
+	for i, consumer := range g.consumers {
+		if consumer == c {
+			g.consumers = append(g.consumers[:i], g.consumers[i+1:]...)
+			break
+		}
+	}
+
+	if g.next >= len(g.consumers) {
+		g.next = 0
+	}
+
+	if err := g.saveConsumers(); err != nil {
+		log.Warn().Err(err).Str("consumer_id", c.ID.String()).Msg("could not remove consumer from consumer group")
+	}
+
+	for _, d := range g.inflight {
+		if d.exclude == c {
+			d.exclude = nil
+		}
+
+		if d.consumer == c {
+			d.exclude = nil
+			g.redeliver(d)
+		}
+	}
+
+	g.unpark()
+	return len(g.consumers)
+}
+
+// saveConsumers updates the consumers of the group from the connected consumers and
+// persists the group to the meta store. The in-memory group is updated even if the
+// store cannot be written to so that the consumers are saved with the next commit.
+// Must be called while the group is locked.
+func (g *Group) saveConsumers() error {
+	g.group.Consumers = make([][]byte, 0, len(g.consumers))
+	for _, c := range g.consumers {
+		g.group.Consumers = append(g.group.Consumers, c.ID.Bytes())
+	}
+	return g.db.UpdateGroup(g.group)
+}
+
+func (g *Group) close() {
+	close(g.done)
+}
@@ -0,0 +1,633 @@
+package groups_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/groups"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	serr "github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
+	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	projectID = ulid.MustParse("01GTSMMC152Q95RD4TNYDFJGHT")
+	topicID   = ulid.MustParse("01GTSMQ3V8ASAPNCFEN378T8RD")
+)
+
+func TestRegistry(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+
+	// Cannot join a group without a name or an ID
+	_, err := registry.Join(projectID, &api.ConsumerGroup{})
+	require.ErrorIs(t, err, serr.ErrGroupMissingKeyField)
+
+	// Exactly once delivery is not supported
+	_, err = registry.Join(projectID, &api.ConsumerGroup{Name: "exactly.once", Delivery: api.DeliverySemantic_EXACTLY_ONCE})
+	require.ErrorIs(t, err, groups.ErrUnsupportedSemantic)
+	require.Equal(t, 0, registry.Len())
+
+	// Subscribers in the same group should share the group
+	alpha, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	require.Equal(t, projectID[:], alpha.Group().Proto().ProjectId, "expected project ID to be set")
+	require.Equal(t, api.DeliverySemantic_AT_LEAST_ONCE, alpha.Group().Semantic(), "expected default delivery semantic")
+
+	calls := db.Calls(mock.GetOrCreateGroup)
+	bravo, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	require.Same(t, alpha.Group(), bravo.Group(), "expected the same group to be returned")
+	require.NotEqual(t, alpha.ID, bravo.ID, "expected consumers to have unique IDs")
+	require.Equal(t, calls, db.Calls(mock.GetOrCreateGroup), "expected the group to only be loaded once")
+
+	// The same group name in a different project is a different group
+	charlie, err := registry.Join(ulid.MustParse("01GTSMZNRYXNAZQF5R8NHQ14NM"), &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	require.NotSame(t, alpha.Group(), charlie.Group(), "expected a different group to be returned")
+	require.Equal(t, 2, registry.Len())
+
+	// Group is removed from memory when all subscribers leave
+	registry.Leave(alpha)
+	require.Equal(t, 2, registry.Len())
+	registry.Leave(bravo)
+	registry.Leave(charlie)
+	require.Equal(t, 0, registry.Len())
+
+	// Handle database errors
+	db.UseError(mock.GetOrCreateGroup, errors.New("something bad happened"))
+	_, err = registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.EqualError(t, err, "something bad happened")
+	require.Equal(t, 0, registry.Len())
+}
+
+func TestRegistryDeleteTopic(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+
+	otherID := ulid.MustParse("01GTSMSX1M9G2Z45VGG4M12WC0")
+	consumer, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	group := consumer.Group()
+	require.NoError(t, group.Commit(topicID, 4, rlid.Null))
+	require.NoError(t, group.Commit(otherID, 2, rlid.Null))
+
+	// Events of the topic that are in flight are no longer tracked by the group
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 1)
+	deliver(t, consumer, events[0], 5)
+
+	// A group that is not in memory also has an offset for the topic
+	stored := &api.ConsumerGroup{Name: "stored.group", ProjectId: projectID[:], TopicOffsets: map[string]uint64{topicID.String(): 8}}
+	unrelated := &api.ConsumerGroup{Name: "unrelated.group", ProjectId: projectID[:], TopicOffsets: map[string]uint64{otherID.String(): 3}}
+
+	db.OnListGroups = func(ulid.ULID) iterator.GroupIterator {
+		return mock.NewGroupIterator([]*api.ConsumerGroup{group.Proto(), stored, unrelated})
+	}
+
+	updated := make(map[string]*api.ConsumerGroup)
+	db.OnUpdateGroup = func(in *api.ConsumerGroup) error {
+		updated[in.Name] = in
+		return nil
+	}
+
+	require.NoError(t, registry.DeleteTopic(projectID, topicID))
+	require.Len(t, updated, 2, "expected only groups with an offset for the topic to be updated")
+	require.NotContains(t, updated["testing.group"].TopicOffsets, topicID.String())
+	require.NotContains(t, updated["stored.group"].TopicOffsets, topicID.String())
+
+	_, ok := group.Offset(topicID)
+	require.False(t, ok, "expected the offset of the topic to be removed from the group")
+
+	offset, ok := group.Offset(otherID)
+	require.True(t, ok, "expected other topic offsets to be kept")
+	require.Equal(t, uint64(2), offset)
+
+	err = consumer.Ack(eventID(events[0]))
+	require.ErrorIs(t, err, groups.ErrNotDelivered, "expected deliveries of the topic to be removed")
+
+	// Handle database errors
+	db.OnListGroups = func(ulid.ULID) iterator.GroupIterator {
+		return mock.NewGroupErrorIterator(errors.New("something bad happened"))
+	}
+	require.EqualError(t, registry.DeleteTopic(projectID, topicID), "something bad happened")
+}
+
+func TestGroupCommit(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	consumer, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+	group := consumer.Group()
+
+	_, ok := group.Offset(topicID)
+	require.False(t, ok, "expected no offset for a new group")
+
+	// Offsets can be initialized to any value
+	calls := db.Calls(mock.UpdateGroup)
+	require.NoError(t, group.Commit(topicID, 0, rlid.Null))
+	offset, ok := group.Offset(topicID)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), offset)
+	require.Equal(t, calls+1, db.Calls(mock.UpdateGroup))
+
+	_, ok = group.EventID(topicID)
+	require.False(t, ok, "expected no event id when a null id is committed")
+
+	// Offsets can only move forward
+	var seq rlid.Sequence
+	committedID := seq.Next()
+	require.NoError(t, group.Commit(topicID, 42, committedID))
+	require.NoError(t, group.Commit(topicID, 12, seq.Next()))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(42), offset)
+
+	eventID, ok := group.EventID(topicID)
+	require.True(t, ok, "expected the event id to be committed with the offset")
+	require.Equal(t, committedID, eventID)
+	require.Equal(t, calls+2, db.Calls(mock.UpdateGroup), "expected no update when offset does not advance")
+
+	// Offset is not modified if the group cannot be saved
+	db.UseError(mock.UpdateGroup, errors.New("something bad happened"))
+	require.Error(t, group.Commit(topicID, 43, rlid.Null))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(42), offset)
+}
+
+func TestGroupAck(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	consumer, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_AT_LEAST_ONCE})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	group := consumer.Group()
+	require.NoError(t, group.Commit(topicID, 10, rlid.Null))
+
+	// Deliver some events to the group
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 5)
+	for i, event := range events {
+		deliver(t, consumer, event, uint64(i+11))
+	}
+
+	// Cannot ack an event that was not delivered
+	require.ErrorIs(t, consumer.Ack(seq.Next()), groups.ErrNotDelivered)
+
+	// Acking events out of order should not advance the offset past unacked events
+	require.NoError(t, consumer.Ack(eventID(events[1])))
+	offset, _ := group.Offset(topicID)
+	require.Equal(t, uint64(10), offset)
+
+	require.NoError(t, consumer.Ack(eventID(events[0])))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(12), offset)
+
+	committedID, _ := group.EventID(topicID)
+	require.Equal(t, eventID(events[1]), committedID, "expected the id of the event at the offset to be committed")
+
+	require.NoError(t, consumer.Ack(eventID(events[4])))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(12), offset)
+
+	require.NoError(t, consumer.Ack(eventID(events[2])))
+	require.NoError(t, consumer.Ack(eventID(events[3])))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(15), offset)
+
+	// Cannot ack an event twice
+	require.ErrorIs(t, consumer.Ack(eventID(events[3])), groups.ErrNotDelivered)
+}
+
+func TestGroupSkip(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	consumer, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_AT_LEAST_ONCE})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	group := consumer.Group()
+	require.NoError(t, group.Commit(topicID, 10, rlid.Null))
+
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 4)
+	deliver(t, consumer, events[0], 11)
+
+	// Skipped events are consumed without being delivered
+	ok, err := consumer.Skip(events[1], topicID, 12)
+	require.NoError(t, err, "could not skip event")
+	require.True(t, ok, "expected event to be skipped")
+
+	offset, _ := group.Offset(topicID)
+	require.Equal(t, uint64(10), offset, "expected offset to be held by the in-flight event")
+
+	// Events that are in flight or have already been consumed cannot be skipped
+	ok, err = consumer.Skip(events[0], topicID, 11)
+	require.NoError(t, err)
+	require.False(t, ok, "expected in-flight event not to be skipped")
+
+	ok, err = consumer.Skip(events[1], topicID, 12)
+	require.NoError(t, err)
+	require.False(t, ok, "expected consumed event not to be skipped")
+
+	ok, _ = consumer.Deliver(events[1], topicID, 12)
+	require.False(t, ok, "expected skipped event not to be delivered")
+
+	// Acking the in-flight event commits the offset past the skipped event
+	require.NoError(t, consumer.Ack(eventID(events[0])))
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(12), offset)
+
+	ok, err = consumer.Skip(events[2], topicID, 13)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	offset, _ = group.Offset(topicID)
+	require.Equal(t, uint64(13), offset)
+
+	ok, err = consumer.Skip(events[2], topicID, 13)
+	require.NoError(t, err)
+	require.False(t, ok, "expected committed event not to be skipped")
+}
+
+func TestGroupConsumers(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+
+	// Consumers recorded on the stored group are no longer connected
+	db.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		in.Consumers = [][]byte{{1, 2, 3, 4}}
+		return false, nil
+	}
+
+	alpha, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	bravo, err := registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.NoError(t, err, "could not join group")
+	require.Equal(t, [][]byte{alpha.ID.Bytes(), bravo.ID.Bytes()}, alpha.Group().Proto().Consumers)
+	require.Equal(t, 2, db.Calls(mock.UpdateGroup), "expected consumers to be saved on join")
+
+	registry.Leave(alpha)
+	require.Equal(t, [][]byte{bravo.ID.Bytes()}, bravo.Group().Proto().Consumers)
+	require.Equal(t, 3, db.Calls(mock.UpdateGroup), "expected consumers to be saved on leave")
+
+	// A consumer cannot join if the group cannot be saved
+	db.UseError(mock.UpdateGroup, errors.New("something bad happened"))
+	_, err = registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.EqualError(t, err, "something bad happened")
+	require.Equal(t, [][]byte{bravo.ID.Bytes()}, bravo.Group().Proto().Consumers)
+
+	// The last consumer can still leave the group
+	registry.Leave(bravo)
+	require.Equal(t, 0, registry.Len())
+
+	// A new group is not kept in memory if the first consumer cannot join
+	_, err = registry.Join(projectID, &api.ConsumerGroup{Name: "testing.group"})
+	require.EqualError(t, err, "something bad happened")
+	require.Equal(t, 0, registry.Len())
+}
+
+func TestDeliverOnce(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	cg := &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_AT_LEAST_ONCE}
+
+	alpha, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(alpha)
+
+	bravo, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(bravo)
+	require.NoError(t, alpha.Group().Commit(topicID, 2, rlid.Null))
+
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 5)
+
+	// Events at or before the committed offset are not delivered
+	for i, event := range events[:2] {
+		ok, err := alpha.Deliver(event, topicID, uint64(i+1))
+		require.NoError(t, err)
+		require.False(t, ok, "expected committed event not to be delivered")
+	}
+
+	// An event can only be delivered to one consumer in the group
+	deliver(t, alpha, events[2], 3)
+	deliver(t, bravo, events[3], 4)
+	deliver(t, alpha, events[4], 5)
+
+	ok, err := bravo.Deliver(events[2], topicID, 3)
+	require.NoError(t, err)
+	require.False(t, ok, "expected in flight event not to be delivered")
+
+	// Consumed events are not delivered again even if the offset has not advanced
+	require.NoError(t, alpha.Ack(eventID(events[4])))
+	ok, err = bravo.Deliver(events[4], topicID, 5)
+	require.NoError(t, err)
+	require.False(t, ok, "expected consumed event not to be delivered")
+
+	offset, _ := alpha.Group().Offset(topicID)
+	require.Equal(t, uint64(2), offset)
+
+	// Filling the gap commits all of the consumed events
+	require.NoError(t, bravo.Ack(eventID(events[3])))
+	require.NoError(t, bravo.Ack(eventID(events[2])))
+	offset, _ = alpha.Group().Offset(topicID)
+	require.Equal(t, uint64(5), offset)
+
+	// Errors are returned for events that cannot be parsed
+	_, err = alpha.Deliver(&api.EventWrapper{Id: []byte{1, 2}}, topicID, 6)
+	require.Error(t, err)
+}
+
+func TestRedeliverTimeout(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	cg := &api.ConsumerGroup{
+		Name:            "testing.group",
+		Delivery:        api.DeliverySemantic_AT_LEAST_ONCE,
+		DeliveryTimeout: durationpb.New(50 * time.Millisecond),
+	}
+
+	consumer, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 2)
+	for i, event := range events {
+		deliver(t, consumer, event, uint64(i+1))
+	}
+
+	// Ack the second event, the first event should be redelivered after the timeout
+	require.NoError(t, consumer.Ack(eventID(events[1])))
+	require.Same(t, events[0], redelivered(t, consumer), "expected the unacked event to be redelivered")
+
+	offset, _ := consumer.Group().Offset(topicID)
+	require.Equal(t, uint64(0), offset, "expected offset not to advance before ack")
+
+	// Once the redelivered event is acked the offset should advance
+	require.NoError(t, consumer.Ack(eventID(events[0])))
+	offset, _ = consumer.Group().Offset(topicID)
+	require.Equal(t, uint64(2), offset)
+}
+
+func TestRedeliverNack(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	cg := &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_AT_LEAST_ONCE}
+
+	alpha, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 4)
+	for i, event := range events {
+		deliver(t, alpha, event, uint64(i+1))
+	}
+
+	// Cannot nack an event that was not delivered
+	require.ErrorIs(t, alpha.Nack(seq.Next(), api.Nack_DELIVER_AGAIN_ANY), groups.ErrNotDelivered)
+
+	// With only one consumer, any nack except not me redelivers to the same consumer
+	for i, code := range []api.Nack_Code{api.Nack_DELIVER_AGAIN_ANY, api.Nack_TIMEOUT, api.Nack_UNPROCESSED} {
+		require.NoError(t, alpha.Nack(eventID(events[i]), code))
+		require.Same(t, events[i], redelivered(t, alpha), "expected event to be redelivered for %s", code)
+	}
+
+	// A not me nack should be held until another consumer joins the group
+	require.NoError(t, alpha.Nack(eventID(events[3]), api.Nack_DELIVER_AGAIN_NOT_ME))
+	require.Len(t, alpha.Redeliveries(), 0, "expected event not to be redelivered to the nacking consumer")
+
+	bravo, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	require.Same(t, events[3], redelivered(t, bravo), "expected event to be redelivered to a new consumer")
+
+	// When a consumer leaves the group its unacked events are redelivered
+	registry.Leave(alpha)
+	for i := 0; i < 3; i++ {
+		redelivered(t, bravo)
+	}
+
+	// Offsets only advance on ack
+	offset, _ := bravo.Group().Offset(topicID)
+	require.Equal(t, uint64(0), offset)
+
+	for _, event := range events {
+		require.NoError(t, bravo.Ack(eventID(event)))
+	}
+
+	offset, _ = bravo.Group().Offset(topicID)
+	require.Equal(t, uint64(4), offset)
+	registry.Leave(bravo)
+}
+
+func TestAtMostOnce(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	cg := &api.ConsumerGroup{
+		Name:            "testing.group",
+		Delivery:        api.DeliverySemantic_AT_MOST_ONCE,
+		DeliveryTimeout: durationpb.New(50 * time.Millisecond),
+	}
+
+	consumer, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	var seq rlid.Sequence
+	events := makeEvents(&seq, 3)
+	for i, event := range events {
+		deliver(t, consumer, event, uint64(i+1))
+	}
+
+	// Acked and nacked events are consumed and not redelivered
+	require.NoError(t, consumer.Ack(eventID(events[0])))
+	require.NoError(t, consumer.Nack(eventID(events[1]), api.Nack_DELIVER_AGAIN_ANY))
+	offset, _ := consumer.Group().Offset(topicID)
+	require.Equal(t, uint64(2), offset)
+
+	// Events that time out are dropped without being redelivered or consumed
+	require.Eventually(t, func() bool {
+		ok, err := consumer.Deliver(events[2], topicID, 3)
+		return err == nil && ok
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, consumer.Redeliveries(), 0)
+
+	offset, _ = consumer.Group().Offset(topicID)
+	require.Equal(t, uint64(2), offset, "expected the offset not to advance past the expired event")
+}
+
+func TestLeaveReplay(t *testing.T) {
+	semantics := []api.DeliverySemantic{
+		api.DeliverySemantic_UNSPECIFIED,
+		api.DeliverySemantic_AT_LEAST_ONCE,
+		api.DeliverySemantic_AT_MOST_ONCE,
+	}
+
+	for _, semantic := range semantics {
+		t.Run(semantic.String(), func(t *testing.T) {
+			db := newPersistentStore(t)
+			registry := groups.NewRegistry(db)
+			cg := &api.ConsumerGroup{Name: "testing.group", Delivery: semantic}
+
+			consumer, err := registry.Join(projectID, cg)
+			require.NoError(t, err, "could not join group")
+
+			var seq rlid.Sequence
+			events := makeEvents(&seq, 2)
+			deliver(t, consumer, events[0], 1)
+			deliver(t, consumer, events[1], 2)
+			require.NoError(t, consumer.Ack(eventID(events[0])))
+
+			// The consumer leaves without acking the second event
+			registry.Leave(consumer)
+			require.Equal(t, 0, registry.Len())
+
+			// When the group is rejoined the unacked event is replayed
+			consumer, err = registry.Join(projectID, cg)
+			require.NoError(t, err, "could not rejoin group")
+			defer registry.Leave(consumer)
+
+			offset, ok := consumer.Group().Offset(topicID)
+			require.True(t, ok, "expected the acked event to be committed")
+			require.Equal(t, uint64(1), offset, "expected the offset not to advance past the unacked event")
+
+			ok, err = consumer.Deliver(events[0], topicID, 1)
+			require.NoError(t, err)
+			require.False(t, ok, "expected the acked event not to be replayed")
+			deliver(t, consumer, events[1], 2)
+		})
+	}
+}
+
+func TestLeaveRedeliver(t *testing.T) {
+	db := newStore(t)
+	registry := groups.NewRegistry(db)
+	cg := &api.ConsumerGroup{Name: "testing.group", Delivery: api.DeliverySemantic_AT_MOST_ONCE}
+
+	alpha, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+
+	bravo, err := registry.Join(projectID, cg)
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(bravo)
+
+	var seq rlid.Sequence
+	event := makeEvents(&seq, 1)[0]
+	deliver(t, alpha, event, 1)
+
+	// Events in flight to a consumer that leaves are redelivered to the group
+	registry.Leave(alpha)
+	require.Equal(t, eventID(event), eventID(redelivered(t, bravo)))
+
+	// Acks and nacks from a consumer that has left the group are ignored
+	require.ErrorIs(t, alpha.Ack(eventID(event)), groups.ErrNotDelivered)
+	require.ErrorIs(t, alpha.Nack(eventID(event), api.Nack_DELIVER_AGAIN_ANY), groups.ErrNotDelivered)
+
+	_, ok := bravo.Group().Offset(topicID)
+	require.False(t, ok, "expected no offset to be committed")
+
+	require.NoError(t, bravo.Ack(eventID(event)))
+	offset, _ := bravo.Group().Offset(topicID)
+	require.Equal(t, uint64(1), offset)
+}
+
+// Creates a mock store that behaves like the meta store for group operations.
+func newStore(t *testing.T) *mock.Store {
+	db, err := mock.Open(config.StorageConfig{Testing: true})
+	require.NoError(t, err, "could not open mock store")
+
+	db.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		key, err := in.Key()
+		if err != nil {
+			return false, err
+		}
+
+		in.Id = key[:]
+		in.Created = timestamppb.Now()
+		in.Modified = in.Created
+		return true, nil
+	}
+
+	db.OnUpdateGroup = func(*api.ConsumerGroup) error {
+		return nil
+	}
+	return db
+}
+
+// Creates a mock store that persists groups so that a group's offsets are loaded when
+// the group is removed from the registry and joined again.
+func newPersistentStore(t *testing.T) *mock.Store {
+	db := newStore(t)
+	saved := make(map[string]*api.ConsumerGroup)
+
+	db.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+		key, err := in.Key()
+		if err != nil {
+			return false, err
+		}
+
+		if group, ok := saved[string(key[:])]; ok {
+			proto.Reset(in)
+			proto.Merge(in, group)
+			return false, nil
+		}
+
+		in.Id = key[:]
+		in.Created = timestamppb.Now()
+		in.Modified = in.Created
+		saved[string(key[:])] = proto.Clone(in).(*api.ConsumerGroup)
+		return true, nil
+	}
+
+	db.OnUpdateGroup = func(in *api.ConsumerGroup) error {
+		saved[string(in.Id)] = proto.Clone(in).(*api.ConsumerGroup)
+		return nil
+	}
+	return db
+}
+
+func deliver(t *testing.T, consumer *groups.Consumer, event *api.EventWrapper, offset uint64) {
+	ok, err := consumer.Deliver(event, topicID, offset)
+	require.NoError(t, err, "could not deliver event")
+	require.True(t, ok, "expected event to be delivered")
+}
+
+func makeEvents(seq *rlid.Sequence, n int) []*api.EventWrapper {
+	events := make([]*api.EventWrapper, 0, n)
+	for i := 0; i < n; i++ {
+		events = append(events, &api.EventWrapper{Id: seq.Next().Bytes(), TopicId: topicID[:]})
+	}
+	return events
+}
+
+func eventID(event *api.EventWrapper) rlid.RLID {
+	id, err := event.ParseEventID()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func redelivered(t *testing.T, consumer *groups.Consumer) *api.EventWrapper {
+	select {
+	case event := <-consumer.Redeliveries():
+		return event
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for redelivery")
+		return nil
+	}
+}
@@ -0,0 +1,183 @@
+package info
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rs/zerolog/log"
+)
+
+// The state of a key in a compacted topic: the newest event with the key and whether or
+// not that event is a tombstone that deletes the key.
+type keyState struct {
+	eventID   rlid.RLID
+	tombstone bool
+}
+
+// A duplicate in a compacted topic and the event that it refers to. Events referenced
+// by a retained duplicate cannot be compacted or the duplicate could not be resolved.
+type reference struct {
+	key    string
+	target rlid.RLID
+}
+
+// Compact the topic so that only the newest event for each key remains. Events without
+// a key are never compacted; tombstones are retained until their grace period has
+// passed, then they are removed along with the key. Events referenced by duplicates
+// that are retained are also kept so that the duplicates can still be resolved. The
+// topic is compacted in two passes: the first pass finds the newest event for each key
+// and the second pass deletes every other keyed event. Deleting events in place
+// preserves the RLID ordering of the retained events. Only events that have been
+// counted by the topic info are compacted so that the counts remain consistent.
+func (t *TopicInfoGatherer) compact(topicID ulid.ULID, policy *api.Compaction, info *api.TopicInfo) (err error) {
+	var offset rlid.RLID
+	if offset, err = info.ParseEventOffsetID(); err != nil {
+		return fmt.Errorf("could not unmarshal event offset id: %w", err)
+	}
+
+	// First pass: find the newest event for each key and the duplicate references.
+	latest := make(map[string]keyState)
+	references := make(map[rlid.RLID]reference)
+
+	events := t.events.List(topicID)
+	for events.Next() {
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			events.Release()
+			return fmt.Errorf("could not unmarshal event: %w", err)
+		}
+
+		var eventID rlid.RLID
+		if eventID, err = event.ParseEventID(); err != nil {
+			events.Release()
+			return fmt.Errorf("could not parse event id: %w", err)
+		}
+
+		if eventID.Compare(offset) > 0 {
+			break
+		}
+
+		if event.IsDuplicate {
+			ref := reference{key: string(event.Key)}
+			if err = ref.target.UnmarshalBinary(event.DuplicateId); err != nil {
+				events.Release()
+				return fmt.Errorf("could not parse duplicate id: %w", err)
+			}
+			references[eventID] = ref
+		}
+
+		if len(event.Key) > 0 {
+			latest[string(event.Key)] = keyState{eventID: eventID, tombstone: event.IsTombstone()}
+		}
+	}
+
+	events.Release()
+	if err = events.Error(); err != nil {
+		return fmt.Errorf("could not fetch events: %w", err)
+	}
+
+	now := time.Now()
+	grace := policy.GracePeriod()
+	retain := func(eventID rlid.RLID, key string) bool {
+		if key == "" {
+			return true
+		}
+
+		state := latest[key]
+		if state.eventID.Compare(eventID) != 0 {
+			return false
+		}
+		return !state.tombstone || now.Sub(rlid.Time(eventID.Time())) <= grace
+	}
+
+	// Keep the events referenced by retained duplicates, following chains of references.
+	referenced := make(map[rlid.RLID]struct{})
+	for eventID, ref := range references {
+		if !retain(eventID, ref.key) {
+			continue
+		}
+
+		for target := ref.target; ; {
+			if _, seen := referenced[target]; seen {
+				break
+			}
+			referenced[target] = struct{}{}
+
+			next, ok := references[target]
+			if !ok {
+				break
+			}
+			target = next.target
+		}
+	}
+
+	// Second pass: collect the events that are compacted and count them so that they
+	// can be removed from the topic info once they have been deleted.
+	compacted := make([]rlid.RLID, 0)
+	removed := &api.TopicInfo{}
+
+	events = t.events.List(topicID)
+	defer events.Release()
+
+	for events.Next() {
+		data := events.Value()
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			return fmt.Errorf("could not unmarshal event: %w", err)
+		}
+
+		var eventID rlid.RLID
+		if eventID, err = event.ParseEventID(); err != nil {
+			return fmt.Errorf("could not parse event id: %w", err)
+		}
+
+		if eventID.Compare(offset) > 0 {
+			break
+		}
+
+		if retain(eventID, string(event.Key)) {
+			continue
+		}
+
+		if _, ok := referenced[eventID]; ok {
+			continue
+		}
+
+		compacted = append(compacted, eventID)
+		t.tally(removed, topicID, event, uint64(len(data)))
+	}
+
+	if err = events.Error(); err != nil {
+		return fmt.Errorf("could not fetch events: %w", err)
+	}
+
+	if len(compacted) == 0 {
+		return nil
+	}
+
+	if err = t.events.DeleteEvents(topicID, compacted...); err != nil {
+		return err
+	}
+
+	// Remove the compacted events from the topic info counts
+	deduct(info, removed)
+	log.Debug().Str("topic_id", topicID.String()).Uint64("events", removed.Events).Uint64("data_size_bytes", removed.DataSizeBytes).Msg("compacted topic")
+	return nil
+}
+
+// Returns true if the topic has not been compacted within the compaction interval, in
+// which case the topic is marked as compacted so that other runs do not compact it.
+func (t *TopicInfoGatherer) compactionDue(topicID ulid.ULID) bool {
+	t.cmu.Lock()
+	defer t.cmu.Unlock()
+
+	if last, ok := t.compacted[topicID]; ok && time.Since(last) < CompactionInterval {
+		return false
+	}
+
+	t.compacted[topicID] = time.Now()
+	return true
+}
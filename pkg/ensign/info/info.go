@@ -15,7 +15,6 @@ import (
 	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/utils/sentry"
 	"github.com/rs/zerolog/log"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -30,6 +29,11 @@ const (
 	// gathering process, but to minimize the amount of CPU needed to perform topic info
 	// gathering in favor of publisher and subscriber routines.
 	InfoWorkers = 4
+
+	// CompactionInterval specifies the minimum delay between compactions of a topic
+	// with a compaction policy. Compaction reads every event in the topic so it is
+	// performed less frequently than topic info gathering.
+	CompactionInterval = 1 * time.Hour
 )
 
 // TopicInfoGatherer runs a go routine that periodically lists all of the topics on the
@@ -43,21 +47,33 @@ const (
 // -- no other go routine should write to the topic info, only read from it.
 type TopicInfoGatherer struct {
 	sync.Mutex
-	events  store.EventStore
-	topics  store.TopicInfoStore
-	done    chan struct{}
-	running bool
+	events    store.EventStore
+	topics    store.TopicInfoStore
+	done      chan struct{}
+	running   bool
+	cmu       sync.Mutex
+	compacted map[ulid.ULID]time.Time
+	skip      bool
 }
 
 func New(events store.EventStore, topics store.TopicInfoStore) *TopicInfoGatherer {
 	return &TopicInfoGatherer{
-		events:  events,
-		topics:  topics,
-		done:    make(chan struct{}),
-		running: false,
+		events:    events,
+		topics:    topics,
+		done:      make(chan struct{}),
+		running:   false,
+		compacted: make(map[ulid.ULID]time.Time),
 	}
 }
 
+// SkipPolicies stops the gatherer from enforcing the retention and compaction policies
+// of topics so that events are only counted, e.g. when the events are replicated to a
+// quorum and cannot be removed by each node independently. This must be called before
+// the gatherer is run.
+func (t *TopicInfoGatherer) SkipPolicies() {
+	t.skip = true
+}
+
 // Run the background go routine that collects topic info from each topic.
 // NOTE: this should not be run in maintenance mode.
 // WARNING: Do not call this method more than once per process!
@@ -191,9 +207,10 @@ eventLoop:
 		info.Events++
 		info.DataSizeBytes += dataSize
 
-		// Parse the event wrapper from the data
-		event := &api.EventWrapper{}
-		if err = proto.Unmarshal(data, event); err != nil {
+		// Parse the event wrapper, which unseals and decompresses the event if required;
+		// the data size is the size of the event as it is stored on disk.
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
 			sentry.Warn(nil).Err(err).Bytes("eventKey", events.Key()).Msg("could not unmarshal event")
 			continue eventLoop
 		}
@@ -204,26 +221,13 @@ eventLoop:
 		// Check if the event is a duplicate
 		if event.IsDuplicate {
 			info.Duplicates++
-
-			// Rehydrate the event from the original to ensure mime and event type
-			// correctly compute the duplication if needed.
-			var target *api.EventWrapper
-			if target, err = t.events.Retrieve(topicID, rlid.RLID(event.DuplicateId)); err != nil {
-				sentry.Warn(nil).Err(err).Bytes("targetKey", event.DuplicateId).Msg("could not retreive target of duplicate event")
-				continue eventLoop
-			}
-
-			if err = event.DuplicateFrom(target); err != nil {
-				sentry.Warn(nil).Err(err).Bytes("targetKey", event.DuplicateId).Msg("could not dereferecnce duplicate")
-				continue eventLoop
-			}
 		}
 
 		// Unwrap the event to perform type checking.
 		var e *api.Event
-		if e, err = event.Unwrap(); err != nil {
+		if e, err = t.unwrap(topicID, event); err != nil {
 			sentry.Warn(nil).Err(err).Bytes("eventKey", events.Key()).Msg("could not unwrap event from wrapper")
-			continue
+			continue eventLoop
 		}
 
 		// Update the event type info on the info
@@ -240,9 +244,234 @@ eventLoop:
 		return fmt.Errorf("could not fetch events: %w", err)
 	}
 
+	// Enforce the retention and compaction policies of the topic now that all of its
+	// events are counted. Policies are only enforced on ready topics so that events are
+	// not removed from topics that are being rehashed or destroyed.
+	if !t.skip && topic.Status == api.TopicState_READY && len(info.EventOffsetId) != 0 {
+		if topic.Retention.Enabled() {
+			if err = t.expire(topicID, topic.Retention, info); err != nil {
+				return fmt.Errorf("could not enforce retention policy: %w", err)
+			}
+		}
+
+		if topic.Compaction.GetEnabled() && t.compactionDue(topicID) {
+			if err = t.compact(topicID, topic.Compaction, info); err != nil {
+				return fmt.Errorf("could not compact topic: %w", err)
+			}
+		}
+	}
+
 	// Save the topic info back to disk
 	if err = t.topics.UpdateTopicInfo(info); err != nil {
 		return err
 	}
 	return nil
 }
+
+// Expire the oldest events in the topic that are not kept by the retention policy and
+// remove them from the topic info. Because RLIDs are time ordered, the events are
+// checked in the order they were committed until the first event that is retained; all
+// events up to that event are then deleted as a single range. Only events that have
+// been counted by the topic info are expired so that the counts remain consistent.
+//
+// Events referenced by duplicates that are retained cannot be expired or the duplicates
+// could not be resolved, so an event referenced by a later duplicate is only expired if
+// that duplicate is expired with it; otherwise the range stops before the event.
+func (t *TopicInfoGatherer) expire(topicID ulid.ULID, policy *api.Retention, info *api.TopicInfo) (err error) {
+	var offset rlid.RLID
+	if offset, err = info.ParseEventOffsetID(); err != nil {
+		return fmt.Errorf("could not unmarshal event offset id: %w", err)
+	}
+
+	var references map[rlid.RLID]rlid.RLID
+	if references, err = t.references(topicID); err != nil {
+		return err
+	}
+
+	// Events are added to the pending range until every duplicate that refers to an
+	// event in the range is also in the range, then the range can be expired.
+	var (
+		through rlid.RLID
+		until   rlid.RLID
+		now     = time.Now()
+		expired = &api.TopicInfo{}
+		pending = &api.TopicInfo{}
+	)
+
+	events := t.events.List(topicID)
+	defer events.Release()
+
+	for events.Next() {
+		data := events.Value()
+		dataSize := uint64(len(data))
+
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			return fmt.Errorf("could not unmarshal event: %w", err)
+		}
+
+		var eventID rlid.RLID
+		if err = eventID.UnmarshalBinary(event.Id); err != nil {
+			return fmt.Errorf("could not parse event id: %w", err)
+		}
+
+		// Do not expire events that have not been counted by the topic info yet.
+		if eventID.Compare(offset) > 0 {
+			break
+		}
+
+		// Events are expired if they are too old or if the topic is too large; since
+		// the oldest events are expired first the topic shrinks as events are expired.
+		remainingEvents := subtract(info.Events, expired.Events+pending.Events)
+		remainingBytes := subtract(info.DataSizeBytes, expired.DataSizeBytes+pending.DataSizeBytes)
+		if !policy.Expires(rlid.Time(eventID.Time()), now) &&
+			(policy.MaxEvents == 0 || remainingEvents <= policy.MaxEvents) &&
+			(policy.MaxBytes == 0 || remainingBytes <= policy.MaxBytes) {
+			break
+		}
+
+		t.tally(pending, topicID, event, dataSize)
+		if ref, ok := references[eventID]; ok && ref.Compare(until) > 0 {
+			until = ref
+		}
+
+		if eventID.Compare(until) >= 0 {
+			through = eventID
+			accumulate(expired, pending)
+			pending = &api.TopicInfo{}
+		}
+	}
+
+	if err = events.Error(); err != nil {
+		return fmt.Errorf("could not fetch events: %w", err)
+	}
+
+	// Nothing to do if all of the events are retained
+	if rlid.IsZero(through) {
+		return nil
+	}
+
+	if err = t.events.Expire(topicID, through); err != nil {
+		return err
+	}
+
+	// Remove the expired events from the topic info counts
+	deduct(info, expired)
+	log.Debug().Str("topic_id", topicID.String()).Uint64("events", expired.Events).Uint64("data_size_bytes", expired.DataSizeBytes).Msg("expired events from topic")
+	return nil
+}
+
+// Returns the newest duplicate in the topic that refers to each event that is the
+// target of a duplicate. All of the events in the topic are checked, including those
+// that have not been counted by the topic info yet, since they may refer to events
+// that have been counted.
+func (t *TopicInfoGatherer) references(topicID ulid.ULID) (_ map[rlid.RLID]rlid.RLID, err error) {
+	references := make(map[rlid.RLID]rlid.RLID)
+	events := t.events.List(topicID)
+	defer events.Release()
+
+	for events.Next() {
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			return nil, fmt.Errorf("could not unmarshal event: %w", err)
+		}
+
+		if !event.IsDuplicate {
+			continue
+		}
+
+		var eventID, target rlid.RLID
+		if eventID, err = event.ParseEventID(); err != nil {
+			return nil, fmt.Errorf("could not parse event id: %w", err)
+		}
+
+		if err = target.UnmarshalBinary(event.DuplicateId); err != nil {
+			return nil, fmt.Errorf("could not parse duplicate id: %w", err)
+		}
+
+		if eventID.Compare(references[target]) > 0 {
+			references[target] = eventID
+		}
+	}
+
+	if err = events.Error(); err != nil {
+		return nil, fmt.Errorf("could not fetch events: %w", err)
+	}
+	return references, nil
+}
+
+// Unwrap the event to determine its type and mimetype. Duplicates are rehydrated from
+// the original event to ensure the mime and event type are correctly computed.
+func (t *TopicInfoGatherer) unwrap(topicID ulid.ULID, event *api.EventWrapper) (_ *api.Event, err error) {
+	if event.IsDuplicate {
+		var target *api.EventWrapper
+		if target, err = t.events.Retrieve(topicID, rlid.RLID(event.DuplicateId)); err != nil {
+			return nil, fmt.Errorf("could not retrieve target of duplicate event: %w", err)
+		}
+
+		if err = event.DuplicateFrom(target); err != nil {
+			return nil, fmt.Errorf("could not dereference duplicate: %w", err)
+		}
+	}
+	return event.Unwrap()
+}
+
+// Add the event to the counts of the removed topic info so that the removed events can
+// be deducted from the topic info once they have been deleted. If the event cannot be
+// unwrapped then it was not counted in the event type info when it was gathered.
+func (t *TopicInfoGatherer) tally(removed *api.TopicInfo, topicID ulid.ULID, event *api.EventWrapper, dataSize uint64) {
+	removed.Events++
+	removed.DataSizeBytes += dataSize
+	if event.IsDuplicate {
+		removed.Duplicates++
+	}
+
+	e, err := t.unwrap(topicID, event)
+	if err != nil {
+		log.Debug().Err(err).Str("topic_id", topicID.String()).Msg("could not unwrap removed event")
+		return
+	}
+
+	etypeinfo := removed.FindEventTypeInfo(e.ResolveType(), e.Mimetype)
+	etypeinfo.Events++
+	etypeinfo.DataSizeBytes += dataSize
+	if event.IsDuplicate {
+		etypeinfo.Duplicates++
+	}
+}
+
+// Deduct the counts of the removed events from the topic info.
+func deduct(info, removed *api.TopicInfo) {
+	info.Events = subtract(info.Events, removed.Events)
+	info.Duplicates = subtract(info.Duplicates, removed.Duplicates)
+	info.DataSizeBytes = subtract(info.DataSizeBytes, removed.DataSizeBytes)
+
+	for _, removedType := range removed.Types {
+		etypeinfo := info.FindEventTypeInfo(removedType.Type, removedType.Mimetype)
+		etypeinfo.Events = subtract(etypeinfo.Events, removedType.Events)
+		etypeinfo.Duplicates = subtract(etypeinfo.Duplicates, removedType.Duplicates)
+		etypeinfo.DataSizeBytes = subtract(etypeinfo.DataSizeBytes, removedType.DataSizeBytes)
+	}
+}
+
+// Add the counts of the removed events to the counts of the other removed events.
+func accumulate(info, removed *api.TopicInfo) {
+	info.Events += removed.Events
+	info.Duplicates += removed.Duplicates
+	info.DataSizeBytes += removed.DataSizeBytes
+
+	for _, removedType := range removed.Types {
+		etypeinfo := info.FindEventTypeInfo(removedType.Type, removedType.Mimetype)
+		etypeinfo.Events += removedType.Events
+		etypeinfo.Duplicates += removedType.Duplicates
+		etypeinfo.DataSizeBytes += removedType.DataSizeBytes
+	}
+}
+
+// Subtract b from a without underflowing if the counts are inconsistent.
+func subtract(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
@@ -3,19 +3,25 @@ package info_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/config"
 	"github.com/rotationalio/ensign/pkg/ensign/info"
 	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store"
 	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestInfoGather(t *testing.T) {
@@ -53,6 +59,346 @@ func TestInfoGather(t *testing.T) {
 	checkPhase2(t, topics)
 }
 
+func TestInfoGatherRetention(t *testing.T) {
+	events, topics := createDatabase(t)
+	gatherer := info.New(events, topics)
+
+	topic := &api.Topic{
+		ProjectId: ulid.MustParse("01GTSMMC152Q95RD4TNYDFJGHT").Bytes(),
+		Name:      "testing.testapp.telemetry",
+		Status:    api.TopicState_READY,
+		Retention: &api.Retention{MaxAge: durationpb.New(30 * 24 * time.Hour)},
+	}
+	require.NoError(t, topics.CreateTopic(topic), "could not create topic")
+
+	topicID, err := topic.ParseTopicID()
+	require.NoError(t, err, "could not parse topic id")
+
+	// Create 10 events, the first 4 of which are older than the max age.
+	now := time.Now()
+	eventIDs := make([]rlid.RLID, 0, 10)
+	for i := 0; i < 10; i++ {
+		committed := now.Add(-time.Duration(10-i) * time.Hour)
+		if i < 4 {
+			committed = now.Add(-time.Duration(40-i) * 24 * time.Hour)
+		}
+
+		eventID := rlid.RLID{}
+		require.NoError(t, eventID.SetTime(rlid.Timestamp(committed)))
+		require.NoError(t, eventID.SetSequence(uint32(i+1)))
+		eventIDs = append(eventIDs, eventID)
+
+		event := &api.EventWrapper{
+			Id:        eventID.Bytes(),
+			TopicId:   topicID.Bytes(),
+			Offset:    uint64(i + 1),
+			Committed: timestamppb.New(committed),
+		}
+
+		etype := &api.Type{Name: "Reading", MajorVersion: 1}
+		if i%2 == 1 {
+			etype = &api.Type{Name: "Alert", MajorVersion: 1}
+		}
+
+		require.NoError(t, event.Wrap(&api.Event{
+			Data:     []byte(strings.Repeat("a", 16*(i+1))),
+			Mimetype: mimetype.ApplicationOctetStream,
+			Type:     etype,
+			Created:  timestamppb.New(committed),
+		}))
+		require.NoError(t, events.Insert(event), "could not insert event")
+	}
+
+	gather := func() *api.TopicInfo {
+		wg := &sync.WaitGroup{}
+		require.NoError(t, gatherer.Gather(wg), "could not gather topic info")
+		wg.Wait()
+
+		info, err := topics.TopicInfo(topicID)
+		require.NoError(t, err, "could not fetch topic info")
+		return info
+	}
+
+	checkRetained := func(info *api.TopicInfo, expected []rlid.RLID) {
+		actual := make([]rlid.RLID, 0, len(expected))
+		iter := events.List(topicID)
+		defer iter.Release()
+
+		var size, alerts uint64
+		for iter.Next() {
+			event, err := iter.Event()
+			require.NoError(t, err, "could not unmarshal event")
+			actual = append(actual, rlid.RLID(event.Id))
+			size += uint64(len(iter.Value()))
+
+			e, err := event.Unwrap()
+			require.NoError(t, err, "could not unwrap event")
+			if e.Type.Name == "Alert" {
+				alerts++
+			}
+		}
+		require.NoError(t, iter.Error(), "could not iterate over events")
+
+		require.Equal(t, expected, actual, "unexpected events retained in topic")
+		require.Equal(t, uint64(len(expected)), info.Events, "event count mismatch")
+		require.Equal(t, size, info.DataSizeBytes, "data size mismatch")
+
+		alertInfo := info.FindEventTypeInfo(&api.Type{Name: "Alert", MajorVersion: 1}, mimetype.ApplicationOctetStream)
+		readingInfo := info.FindEventTypeInfo(&api.Type{Name: "Reading", MajorVersion: 1}, mimetype.ApplicationOctetStream)
+		require.Equal(t, alerts, alertInfo.Events, "alert type count mismatch")
+		require.Equal(t, uint64(len(expected))-alerts, readingInfo.Events, "reading type count mismatch")
+		require.Equal(t, size, alertInfo.DataSizeBytes+readingInfo.DataSizeBytes, "type data size mismatch")
+	}
+
+	// Events older than the max age should be expired
+	info := gather()
+	checkRetained(info, eventIDs[4:])
+
+	// Gathering again should not change anything
+	info = gather()
+	checkRetained(info, eventIDs[4:])
+
+	// Limiting the number of events should expire the oldest events
+	topic.Retention = &api.Retention{MaxEvents: 3}
+	require.NoError(t, topics.UpdateTopic(topic), "could not update topic")
+	info = gather()
+	checkRetained(info, eventIDs[7:])
+
+	// Limiting the size of the topic should expire the oldest events
+	topic.Retention = &api.Retention{MaxBytes: info.DataSizeBytes - 1}
+	require.NoError(t, topics.UpdateTopic(topic), "could not update topic")
+	info = gather()
+	checkRetained(info, eventIDs[8:])
+
+	// Retention should not be enforced on topics that are not ready
+	topic.Status = api.TopicState_READONLY
+	topic.Retention = &api.Retention{MaxEvents: 1}
+	require.NoError(t, topics.UpdateTopic(topic), "could not update topic")
+	info = gather()
+	checkRetained(info, eventIDs[8:])
+
+	// Retention should not be enforced if the gatherer skips policies
+	gatherer.SkipPolicies()
+	topic.Status = api.TopicState_READY
+	require.NoError(t, topics.UpdateTopic(topic), "could not update topic")
+	info = gather()
+	checkRetained(info, eventIDs[8:])
+}
+
+func TestInfoGatherRetentionDuplicates(t *testing.T) {
+	events, topics := createDatabase(t)
+	gatherer := info.New(events, topics)
+
+	topic := &api.Topic{
+		ProjectId:     ulid.MustParse("01GTSMMC152Q95RD4TNYDFJGHT").Bytes(),
+		Name:          "testing.testapp.duplicates",
+		Status:        api.TopicState_READY,
+		Deduplication: &api.Deduplication{Strategy: api.Deduplication_STRICT},
+		Retention:     &api.Retention{MaxAge: durationpb.New(30 * 24 * time.Hour)},
+	}
+	require.NoError(t, topics.CreateTopic(topic), "could not create topic")
+
+	topicID, err := topic.ParseTopicID()
+	require.NoError(t, err, "could not parse topic id")
+
+	// Create 6 events, the first 4 of which are older than the max age. The second event
+	// is a duplicate of the first and the fifth event is a duplicate of the third.
+	now := time.Now()
+	duplicates := map[int]int{1: 0, 4: 2}
+	stored := make([]*api.EventWrapper, 0, 6)
+	for i := 0; i < 6; i++ {
+		committed := now.Add(-time.Duration(6-i) * time.Hour)
+		if i < 4 {
+			committed = now.Add(-time.Duration(40-i) * 24 * time.Hour)
+		}
+
+		eventID := rlid.RLID{}
+		require.NoError(t, eventID.SetTime(rlid.Timestamp(committed)))
+		require.NoError(t, eventID.SetSequence(uint32(i+1)))
+
+		event := &api.EventWrapper{
+			Id:        eventID.Bytes(),
+			TopicId:   topicID.Bytes(),
+			Offset:    uint64(i + 1),
+			Committed: timestamppb.New(committed),
+		}
+
+		require.NoError(t, event.Wrap(&api.Event{
+			Data:     []byte(strings.Repeat("a", 16*(i+1))),
+			Mimetype: mimetype.ApplicationOctetStream,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1},
+			Created:  timestamppb.New(committed),
+		}))
+
+		if original, ok := duplicates[i]; ok {
+			require.NoError(t, event.DuplicateOf(stored[original], topic.Deduplication))
+		}
+
+		require.NoError(t, events.Insert(event), "could not insert event")
+		stored = append(stored, event)
+	}
+
+	wg := &sync.WaitGroup{}
+	require.NoError(t, gatherer.Gather(wg), "could not gather topic info")
+	wg.Wait()
+
+	info, err := topics.TopicInfo(topicID)
+	require.NoError(t, err, "could not fetch topic info")
+
+	// The first two events are expired together but the third event is retained since
+	// it is referenced by a duplicate that is retained, along with the events after it.
+	iter := events.List(topicID)
+	defer iter.Release()
+
+	var retained []uint64
+	for iter.Next() {
+		event, err := iter.Event()
+		require.NoError(t, err, "could not unmarshal event")
+		retained = append(retained, event.Offset)
+	}
+	require.NoError(t, iter.Error(), "could not iterate over events")
+	require.Equal(t, []uint64{3, 4, 5, 6}, retained, "unexpected events retained in topic")
+	require.Equal(t, uint64(4), info.Events, "event count mismatch")
+	require.Equal(t, uint64(1), info.Duplicates, "duplicate count mismatch")
+
+	// The retained duplicate can still be resolved
+	duplicate, err := events.Retrieve(topicID, rlid.RLID(stored[4].Id))
+	require.NoError(t, err, "could not retrieve duplicate")
+	_, err = events.Retrieve(topicID, rlid.RLID(duplicate.DuplicateId))
+	require.NoError(t, err, "could not retrieve the original of the duplicate")
+}
+
+func TestInfoGatherCompaction(t *testing.T) {
+	events, topics := createDatabase(t)
+
+	topic := &api.Topic{
+		ProjectId:  ulid.MustParse("01GTSMMC152Q95RD4TNYDFJGHT").Bytes(),
+		Name:       "testing.testapp.accounts",
+		Status:     api.TopicState_READY,
+		Compaction: &api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(time.Hour)},
+	}
+	require.NoError(t, topics.CreateTopic(topic), "could not create topic")
+
+	topicID, err := topic.ParseTopicID()
+	require.NoError(t, err, "could not parse topic id")
+
+	// Events are described by their key, whether or not they are tombstones, and
+	// the event they duplicate (if any), ordered from oldest to newest.
+	fixtures := []struct {
+		key       string
+		tombstone bool
+		duplicate int
+		age       time.Duration
+		retained  bool
+	}{
+		{"account:1", false, -1, 10 * time.Hour, false},
+		{"account:2", false, -1, 9 * time.Hour, false},
+		{"", false, -1, 8 * time.Hour, true},
+		{"account:3", false, -1, 7 * time.Hour, false},
+		{"account:1", false, -1, 6 * time.Hour, false},
+		{"account:3", true, -1, 5 * time.Hour, false}, // tombstone past the grace period
+		{"account:4", false, -1, 4 * time.Hour, true}, // referenced by a retained duplicate
+		{"account:5", false, 6, 3*time.Hour + time.Minute, true},
+		{"account:4", false, -1, 3 * time.Hour, true},
+		{"account:1", false, -1, 2 * time.Hour, true},
+		{"account:2", true, -1, 30 * time.Minute, true}, // tombstone in the grace period
+		{"", false, -1, 10 * time.Minute, true},
+	}
+
+	now := time.Now()
+	wrappers := make([]*api.EventWrapper, 0, len(fixtures))
+	expected := make([]rlid.RLID, 0, len(fixtures))
+	for i, fixture := range fixtures {
+		committed := now.Add(-fixture.age)
+		eventID := rlid.RLID{}
+		require.NoError(t, eventID.SetTime(rlid.Timestamp(committed)))
+		require.NoError(t, eventID.SetSequence(uint32(i+1)))
+
+		event := &api.EventWrapper{
+			Id:        eventID.Bytes(),
+			TopicId:   topicID.Bytes(),
+			Offset:    uint64(i + 1),
+			Key:       []byte(fixture.key),
+			Committed: timestamppb.New(committed),
+		}
+
+		e := &api.Event{
+			Data:     []byte(fmt.Sprintf(`{"balance": %d}`, i*100)),
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Account", MajorVersion: 1},
+			Created:  timestamppb.New(committed),
+		}
+
+		if fixture.tombstone {
+			e.Data = nil
+		}
+
+		if fixture.duplicate >= 0 {
+			e.Data = []byte(fmt.Sprintf(`{"balance": %d}`, fixture.duplicate*100))
+		}
+		require.NoError(t, event.Wrap(e))
+
+		if fixture.duplicate >= 0 {
+			policy := &api.Deduplication{Strategy: api.Deduplication_DATAGRAM}
+			require.NoError(t, event.DuplicateOf(wrappers[fixture.duplicate], policy))
+		}
+
+		require.NoError(t, events.Insert(event), "could not insert event")
+		wrappers = append(wrappers, event)
+		if fixture.retained {
+			expected = append(expected, eventID)
+		}
+	}
+
+	gather := func() *api.TopicInfo {
+		// A new gatherer is used so that the compaction interval has not elapsed
+		gatherer := info.New(events, topics)
+		wg := &sync.WaitGroup{}
+		require.NoError(t, gatherer.Gather(wg), "could not gather topic info")
+		wg.Wait()
+
+		info, err := topics.TopicInfo(topicID)
+		require.NoError(t, err, "could not fetch topic info")
+		return info
+	}
+
+	checkCompacted := func(info *api.TopicInfo) {
+		actual := make([]rlid.RLID, 0, len(expected))
+		iter := events.List(topicID)
+		defer iter.Release()
+
+		var size, duplicates uint64
+		for iter.Next() {
+			event, err := iter.Event()
+			require.NoError(t, err, "could not unmarshal event")
+			actual = append(actual, rlid.RLID(event.Id))
+			size += uint64(len(iter.Value()))
+			if event.IsDuplicate {
+				duplicates++
+			}
+		}
+		require.NoError(t, iter.Error(), "could not iterate over events")
+
+		require.Equal(t, expected, actual, "unexpected events retained in topic")
+		require.Equal(t, uint64(len(expected)), info.Events, "event count mismatch")
+		require.Equal(t, duplicates, info.Duplicates, "duplicates count mismatch")
+		require.Equal(t, size, info.DataSizeBytes, "data size mismatch")
+
+		etypeinfo := info.FindEventTypeInfo(&api.Type{Name: "Account", MajorVersion: 1}, mimetype.ApplicationJSON)
+		require.Equal(t, uint64(len(expected)), etypeinfo.Events, "type count mismatch")
+		require.Equal(t, duplicates, etypeinfo.Duplicates, "type duplicates mismatch")
+		require.Equal(t, size, etypeinfo.DataSizeBytes, "type data size mismatch")
+	}
+
+	// Only the newest event for each key should remain after compaction
+	info := gather()
+	checkCompacted(info)
+
+	// Compacting the topic again should not change anything
+	info = gather()
+	checkCompacted(info)
+}
+
 func TestInfoGatherFatal(t *testing.T) {
 	store := &mock.Store{}
 	store.UseError(mock.ListAllTopics, errors.New("this should be a fatal error"))
@@ -0,0 +1,13 @@
+package keys
+
+import "errors"
+
+var (
+	ErrNoMasterKeys      = errors.New("no master keys found in the key file")
+	ErrInvalidMasterKey  = errors.New("master keys must be specified as a key id and a base64 encoded 32 byte key")
+	ErrDuplicateKeyID    = errors.New("master key ids must be unique in the key file")
+	ErrUnknownMasterKey  = errors.New("data key is wrapped by a master key that is not in the key file")
+	ErrUnknownDataKey    = errors.New("event is sealed with a data key that is not in the topic keys")
+	ErrNoDataKeys        = errors.New("topic does not have any data keys")
+	ErrInvalidCiphertext = errors.New("ciphertext is too short to contain a nonce")
+)
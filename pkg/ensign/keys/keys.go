@@ -0,0 +1,282 @@
+/*
+Package keys implements envelope encryption at rest for the events in a topic. Each
+encrypted topic has one or more data keys that event payloads are sealed with before
+they are written to disk; data keys are wrapped by a master key loaded from a key file
+so that they are never stored in plaintext.
+*/
+package keys
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// New loads the master keys from the key file in the configuration and creates a
+// keyring that stores the wrapped data keys of each topic in the meta store.
+func New(conf config.EncryptionConfig, meta store.TopicKeyStore) (_ *Keyring, err error) {
+	var master *MasterKeys
+	if master, err = LoadMasterKeys(conf.KeyFile); err != nil {
+		return nil, err
+	}
+	return NewKeyring(master, meta), nil
+}
+
+// NewKeyring creates a keyring with the specified master keys.
+func NewKeyring(master *MasterKeys, meta store.TopicKeyStore) *Keyring {
+	return &Keyring{
+		master: master,
+		meta:   meta,
+		topics: make(map[ulid.ULID]*dataKeys),
+	}
+}
+
+// The Keyring seals and unseals events with the data keys of their topic. The data
+// keys of a topic are unwrapped when the topic is first used and are cached so that
+// the master key is only needed when keys are loaded, created, or rotated. A data key
+// is created for a topic when the first event is sealed.
+type Keyring struct {
+	sync.RWMutex
+	master *MasterKeys
+	meta   store.TopicKeyStore
+	topics map[ulid.ULID]*dataKeys
+}
+
+// The unwrapped data keys of a topic and the id of the active key.
+type dataKeys struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// Seal the event bytes with the active data key of the event's topic and record the
+// data key on the event's storage encryption so that it can be unsealed. A data key is
+// created if the topic does not have one yet.
+func (k *Keyring) Seal(event *api.EventWrapper) (err error) {
+	var topicID ulid.ULID
+	if topicID, err = event.ParseTopicID(); err != nil {
+		return err
+	}
+
+	var keys *dataKeys
+	if keys, err = k.load(topicID, true); err != nil {
+		return err
+	}
+
+	if event.Event, err = seal(keys.keys[keys.active], event.Event, topicID[:]); err != nil {
+		return err
+	}
+
+	event.StorageEncryption = &api.Encryption{
+		PublicKeyId:         keys.active,
+		EncryptionAlgorithm: api.Encryption_AES256_GCM,
+	}
+	return nil
+}
+
+// Unseal an event that was sealed by the keyring, removing the storage encryption from
+// the event. Events that are not sealed at rest are not modified; the encryption of
+// events that were encrypted by the publisher is not removed.
+func (k *Keyring) Unseal(event *api.EventWrapper) (err error) {
+	if !Sealed(event) {
+		return nil
+	}
+
+	var topicID ulid.ULID
+	if topicID, err = event.ParseTopicID(); err != nil {
+		return err
+	}
+
+	var keys *dataKeys
+	if keys, err = k.load(topicID, false); err != nil {
+		return err
+	}
+
+	aead, ok := keys.keys[event.StorageEncryption.PublicKeyId]
+	if !ok {
+		return ErrUnknownDataKey
+	}
+
+	if event.Event, err = open(aead, event.Event, topicID[:]); err != nil {
+		return err
+	}
+
+	event.StorageEncryption = nil
+	return nil
+}
+
+// Rotate creates a new active data key for the topic; events that are sealed after the
+// rotation use the new key and the previous keys are kept to unseal earlier events.
+func (k *Keyring) Rotate(topicID ulid.ULID) (err error) {
+	k.Lock()
+	defer k.Unlock()
+
+	var stored *api.TopicKeys
+	if stored, err = k.meta.TopicKeys(topicID); err != nil {
+		return err
+	}
+
+	// Refresh the cache from the stored keys in case another keyring modified them.
+	var keys *dataKeys
+	if keys, err = k.unwrap(topicID, stored); err != nil {
+		return err
+	}
+
+	if err = k.generate(topicID, stored, keys); err != nil {
+		return err
+	}
+
+	k.topics[topicID] = keys
+	return nil
+}
+
+// Rewrap the data keys of the topic that are wrapped by a master key other than the
+// active master key, e.g. after the master key has been rotated. Once every topic has
+// been rewrapped, the earlier master keys can be removed from the key file.
+func (k *Keyring) Rewrap(topicID ulid.ULID) (rewrapped int, err error) {
+	k.Lock()
+	defer k.Unlock()
+
+	var stored *api.TopicKeys
+	if stored, err = k.meta.TopicKeys(topicID); err != nil {
+		return 0, err
+	}
+
+	for _, dataKey := range stored.Keys {
+		if dataKey.MasterKeyId == k.master.Active() {
+			continue
+		}
+
+		var key []byte
+		if key, err = k.master.Unwrap(dataKey.MasterKeyId, dataKey.WrappedKey, wrapData(topicID, dataKey.KeyId)); err != nil {
+			return 0, err
+		}
+
+		if dataKey.MasterKeyId, dataKey.WrappedKey, err = k.master.Wrap(key, wrapData(topicID, dataKey.KeyId)); err != nil {
+			return 0, err
+		}
+		rewrapped++
+	}
+
+	if rewrapped > 0 {
+		if err = k.meta.UpdateTopicKeys(stored); err != nil {
+			return 0, err
+		}
+	}
+	return rewrapped, nil
+}
+
+// Forget removes the cached data keys of the topic, e.g. when the topic is deleted.
+func (k *Keyring) Forget(topicID ulid.ULID) {
+	k.Lock()
+	defer k.Unlock()
+	delete(k.topics, topicID)
+}
+
+// Load the data keys of the topic from the cache or from the meta store, creating the
+// first data key of the topic if create is true and the topic has no data keys.
+func (k *Keyring) load(topicID ulid.ULID, create bool) (keys *dataKeys, err error) {
+	k.RLock()
+	keys, ok := k.topics[topicID]
+	k.RUnlock()
+
+	if ok {
+		return keys, nil
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	// Check if the keys were loaded while waiting for the lock
+	if keys, ok = k.topics[topicID]; ok {
+		return keys, nil
+	}
+
+	var stored *api.TopicKeys
+	if stored, err = k.meta.TopicKeys(topicID); err != nil {
+		return nil, err
+	}
+
+	if keys, err = k.unwrap(topicID, stored); err != nil {
+		return nil, err
+	}
+
+	if keys.active == "" {
+		if !create {
+			return nil, ErrNoDataKeys
+		}
+
+		if err = k.generate(topicID, stored, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	k.topics[topicID] = keys
+	return keys, nil
+}
+
+// Unwrap all of the stored data keys of the topic; the last key is the active key.
+func (k *Keyring) unwrap(topicID ulid.ULID, stored *api.TopicKeys) (keys *dataKeys, err error) {
+	keys = &dataKeys{keys: make(map[string]cipher.AEAD, len(stored.Keys))}
+	for _, dataKey := range stored.Keys {
+		var key []byte
+		if key, err = k.master.Unwrap(dataKey.MasterKeyId, dataKey.WrappedKey, wrapData(topicID, dataKey.KeyId)); err != nil {
+			return nil, err
+		}
+
+		if keys.keys[dataKey.KeyId], err = newAEAD(key); err != nil {
+			return nil, err
+		}
+		keys.active = dataKey.KeyId
+	}
+	return keys, nil
+}
+
+// Generate a new data key, store it wrapped with the active master key, and add it to
+// the unwrapped keys as the active key. Must be called while the keyring is locked.
+func (k *Keyring) generate(topicID ulid.ULID, stored *api.TopicKeys, keys *dataKeys) (err error) {
+	key := make([]byte, KeySize)
+	if _, err = rand.Read(key); err != nil {
+		return err
+	}
+
+	dataKey := &api.DataKey{
+		KeyId:   ulid.Make().String(),
+		Created: timestamppb.Now(),
+	}
+
+	if dataKey.MasterKeyId, dataKey.WrappedKey, err = k.master.Wrap(key, wrapData(topicID, dataKey.KeyId)); err != nil {
+		return err
+	}
+
+	var aead cipher.AEAD
+	if aead, err = newAEAD(key); err != nil {
+		return err
+	}
+
+	// The data key must be stored before it is used or events could not be unsealed.
+	stored.Keys = append(stored.Keys, dataKey)
+	if err = k.meta.UpdateTopicKeys(stored); err != nil {
+		return err
+	}
+
+	keys.keys[dataKey.KeyId] = aead
+	keys.active = dataKey.KeyId
+	return nil
+}
+
+// Sealed returns true if the event was sealed at rest by a keyring. The encryption of
+// events by the publisher is not considered since it is not managed by the server.
+func Sealed(event *api.EventWrapper) bool {
+	return event.GetStorageEncryption() != nil
+}
+
+// The additional data that binds a wrapped data key to its topic and key id.
+func wrapData(topicID ulid.ULID, keyID string) []byte {
+	return append(topicID.Bytes(), keyID...)
+}
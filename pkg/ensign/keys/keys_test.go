@@ -0,0 +1,154 @@
+package keys_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/keys"
+	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestKeyring(t *testing.T) {
+	db := newKeyStore()
+	keyring, err := keys.New(config.EncryptionConfig{Enabled: true, KeyFile: writeKeyFile(t, "alpha")}, db.Store)
+	require.NoError(t, err, "could not create keyring")
+
+	topicID := ulids.New()
+	event := mkevent(t, topicID, "the quick brown fox jumps over the lazy dog")
+	plaintext := event.Event
+
+	// Sealing the first event creates a data key for the topic
+	require.NoError(t, keyring.Seal(event), "could not seal event")
+	require.True(t, keys.Sealed(event))
+	require.NotEqual(t, plaintext, event.Event)
+	require.Nil(t, event.Encryption, "expected the publisher encryption to be unchanged")
+	require.Equal(t, api.Encryption_AES256_GCM, event.StorageEncryption.EncryptionAlgorithm)
+	require.Len(t, db.keys[topicID].Keys, 1)
+	require.Equal(t, "alpha", db.keys[topicID].Keys[0].MasterKeyId)
+	require.Equal(t, db.keys[topicID].Keys[0].KeyId, event.StorageEncryption.PublicKeyId)
+
+	sealed := proto.Clone(event).(*api.EventWrapper)
+	require.NoError(t, keyring.Unseal(event), "could not unseal event")
+	require.Nil(t, event.StorageEncryption)
+	require.Equal(t, plaintext, event.Event)
+
+	// Events that are not sealed are not modified
+	require.NoError(t, keyring.Unseal(event), "could not unseal plaintext event")
+	require.Equal(t, plaintext, event.Event)
+
+	e2e := mkevent(t, topicID, "encrypted by the publisher")
+	e2e.Encryption = &api.Encryption{EncryptionKey: []byte("sealed"), EncryptionAlgorithm: api.Encryption_AES256_GCM, SealingAlgorithm: api.Encryption_RSA_OAEP_SHA512}
+	require.False(t, keys.Sealed(e2e))
+	require.NoError(t, keyring.Unseal(e2e))
+	require.Equal(t, []byte("sealed"), e2e.Encryption.EncryptionKey)
+
+	// Events encrypted by the publisher with a key id are not mistaken for sealed events
+	published := mkevent(t, ulids.New(), "encrypted by the publisher with a key id")
+	published.Encryption = &api.Encryption{PublicKeyId: "publisher", EncryptionAlgorithm: api.Encryption_AES256_GCM}
+	ciphertext := published.Event
+	require.False(t, keys.Sealed(published))
+	require.NoError(t, keyring.Unseal(published))
+	require.Equal(t, ciphertext, published.Event)
+	require.Equal(t, "publisher", published.Encryption.PublicKeyId)
+
+	// Sealed events are bound to their topic
+	moved := proto.Clone(sealed).(*api.EventWrapper)
+	moved.TopicId = ulids.New().Bytes()
+	require.ErrorIs(t, keyring.Unseal(moved), keys.ErrNoDataKeys)
+
+	// Rotating the key seals new events with the new key but old events can be unsealed
+	require.NoError(t, keyring.Rotate(topicID), "could not rotate data key")
+	require.Len(t, db.keys[topicID].Keys, 2)
+
+	rotated := mkevent(t, topicID, "sealed with the rotated key")
+	require.NoError(t, keyring.Seal(rotated))
+	require.NotEqual(t, sealed.StorageEncryption.PublicKeyId, rotated.StorageEncryption.PublicKeyId)
+	require.Equal(t, db.keys[topicID].Keys[1].KeyId, rotated.StorageEncryption.PublicKeyId)
+
+	for _, event := range []*api.EventWrapper{proto.Clone(sealed).(*api.EventWrapper), rotated} {
+		require.NoError(t, keyring.Unseal(event), "could not unseal event")
+		require.Nil(t, event.StorageEncryption)
+	}
+
+	// Data keys are removed with the topic
+	keyring.Forget(topicID)
+	delete(db.keys, topicID)
+	require.ErrorIs(t, keyring.Unseal(proto.Clone(sealed).(*api.EventWrapper)), keys.ErrNoDataKeys)
+}
+
+func TestKeyringRewrap(t *testing.T) {
+	alpha, err := keys.GenerateMasterKey("alpha")
+	require.NoError(t, err)
+	bravo, err := keys.GenerateMasterKey("bravo")
+	require.NoError(t, err)
+
+	master := func(lines ...string) *keys.MasterKeys {
+		keys, err := keys.ParseMasterKeys(strings.NewReader(strings.Join(lines, "\n")))
+		require.NoError(t, err, "could not parse master keys")
+		return keys
+	}
+
+	// Seal an event with a data key wrapped by the alpha master key
+	db := newKeyStore()
+	topicID := ulids.New()
+	event := mkevent(t, topicID, "sealed before the master key was rotated")
+	plaintext := event.Event
+	require.NoError(t, keys.NewKeyring(master(alpha), db.Store).Seal(event))
+
+	// The bravo master key cannot unwrap the data key until it has been rewrapped
+	require.ErrorIs(t, keys.NewKeyring(master(bravo), db.Store).Unseal(proto.Clone(event).(*api.EventWrapper)), keys.ErrUnknownMasterKey)
+
+	// Rotate the master key by adding bravo to the key file and rewrap the data keys
+	keyring := keys.NewKeyring(master(alpha, bravo), db.Store)
+	rewrapped, err := keyring.Rewrap(topicID)
+	require.NoError(t, err, "could not rewrap data keys")
+	require.Equal(t, 1, rewrapped)
+	require.Equal(t, "bravo", db.keys[topicID].Keys[0].MasterKeyId)
+
+	rewrapped, err = keyring.Rewrap(topicID)
+	require.NoError(t, err, "could not rewrap data keys")
+	require.Zero(t, rewrapped, "expected no keys to be rewrapped with the active master key")
+
+	// The alpha master key can now be removed from the key file
+	require.NoError(t, keys.NewKeyring(master(bravo), db.Store).Unseal(event))
+	require.Equal(t, plaintext, event.Event)
+}
+
+// A mock topic key store that keeps the topic keys in memory.
+type keyStore struct {
+	*mock.Store
+	sync.Mutex
+	keys map[ulid.ULID]*api.TopicKeys
+}
+
+func newKeyStore() *keyStore {
+	db := &keyStore{Store: &mock.Store{}, keys: make(map[ulid.ULID]*api.TopicKeys)}
+	db.OnTopicKeys = func(topicID ulid.ULID) (*api.TopicKeys, error) {
+		db.Lock()
+		defer db.Unlock()
+		if keys, ok := db.keys[topicID]; ok {
+			return proto.Clone(keys).(*api.TopicKeys), nil
+		}
+		return &api.TopicKeys{TopicId: topicID.Bytes()}, nil
+	}
+	db.OnUpdateTopicKeys = func(keys *api.TopicKeys) error {
+		db.Lock()
+		defer db.Unlock()
+		db.keys[ulid.ULID(keys.TopicId)] = proto.Clone(keys).(*api.TopicKeys)
+		return nil
+	}
+	return db
+}
+
+func mkevent(t *testing.T, topicID ulid.ULID, data string) *api.EventWrapper {
+	event := &api.EventWrapper{TopicId: topicID.Bytes()}
+	require.NoError(t, event.Wrap(&api.Event{Data: []byte(data)}), "could not wrap event")
+	return event
+}
@@ -0,0 +1,139 @@
+package keys
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeySize is the length of master and data keys in bytes; all keys are AES-256 keys.
+const KeySize = 32
+
+// MasterKeys are the keys that wrap the data keys of each topic so that data keys are
+// never stored in plaintext. Master keys are loaded from a key file where each line is
+// a key id and a base64 encoded key separated by a colon; blank lines and lines that
+// start with # are ignored. The last key in the file is the active key that new data
+// keys are wrapped with. To rotate the master key, append a new key to the file and
+// restart the server; earlier keys must be kept until the data keys they wrapped have
+// been rewrapped with the active key.
+type MasterKeys struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// LoadMasterKeys reads the master keys from the key file at the specified path.
+func LoadMasterKeys(path string) (_ *MasterKeys, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMasterKeys(f)
+}
+
+// ParseMasterKeys reads master keys in the key file format from the reader.
+func ParseMasterKeys(r io.Reader) (_ *MasterKeys, err error) {
+	master := &MasterKeys{keys: make(map[string]cipher.AEAD)}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyID, encoded, ok := strings.Cut(line, ":")
+		if keyID = strings.TrimSpace(keyID); !ok || keyID == "" {
+			return nil, ErrInvalidMasterKey
+		}
+
+		if _, ok := master.keys[keyID]; ok {
+			return nil, ErrDuplicateKeyID
+		}
+
+		var key []byte
+		if key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(encoded)); err != nil || len(key) != KeySize {
+			return nil, ErrInvalidMasterKey
+		}
+
+		if master.keys[keyID], err = newAEAD(key); err != nil {
+			return nil, err
+		}
+		master.active = keyID
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(master.keys) == 0 {
+		return nil, ErrNoMasterKeys
+	}
+	return master, nil
+}
+
+// GenerateMasterKey returns a line for the key file with a new random master key.
+func GenerateMasterKey(keyID string) (_ string, err error) {
+	key := make([]byte, KeySize)
+	if _, err = rand.Read(key); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s", keyID, base64.StdEncoding.EncodeToString(key)), nil
+}
+
+// Active returns the id of the master key that new data keys are wrapped with.
+func (m *MasterKeys) Active() string {
+	return m.active
+}
+
+// Wrap the data key with the active master key, returning the id of the master key and
+// the wrapped key. The additional data binds the wrapped key to its topic.
+func (m *MasterKeys) Wrap(dataKey, additionalData []byte) (keyID string, wrapped []byte, err error) {
+	if wrapped, err = seal(m.keys[m.active], dataKey, additionalData); err != nil {
+		return "", nil, err
+	}
+	return m.active, wrapped, nil
+}
+
+// Unwrap a data key that was wrapped by the specified master key.
+func (m *MasterKeys) Unwrap(keyID string, wrapped, additionalData []byte) ([]byte, error) {
+	aead, ok := m.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownMasterKey
+	}
+	return open(aead, wrapped, additionalData)
+}
+
+// Creates an AES-GCM cipher from the key.
+func newAEAD(key []byte) (_ cipher.AEAD, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt the plaintext with a random nonce that is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) (_ []byte, err error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt ciphertext that was encrypted by seal, using the prepended nonce.
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
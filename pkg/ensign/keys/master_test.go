@@ -0,0 +1,84 @@
+package keys_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rotationalio/ensign/pkg/ensign/keys"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMasterKeys(t *testing.T) {
+	alpha, err := keys.GenerateMasterKey("alpha")
+	require.NoError(t, err, "could not generate master key")
+
+	bravo, err := keys.GenerateMasterKey("bravo")
+	require.NoError(t, err, "could not generate master key")
+
+	// The last key in the file is the active key; comments and blank lines are ignored
+	master, err := keys.ParseMasterKeys(strings.NewReader("# master keys\n" + alpha + "\n\n" + bravo + "\n"))
+	require.NoError(t, err, "could not parse master keys")
+	require.Equal(t, "bravo", master.Active())
+
+	// Data keys are wrapped with the active key and can be unwrapped by key id
+	keyID, wrapped, err := master.Wrap([]byte("supersecretdatakey"), []byte("topic"))
+	require.NoError(t, err, "could not wrap data key")
+	require.Equal(t, "bravo", keyID)
+
+	unwrapped, err := master.Unwrap(keyID, wrapped, []byte("topic"))
+	require.NoError(t, err, "could not unwrap data key")
+	require.Equal(t, []byte("supersecretdatakey"), unwrapped)
+
+	_, err = master.Unwrap(keyID, wrapped, []byte("other"))
+	require.Error(t, err, "expected the additional data to be authenticated")
+
+	_, err = master.Unwrap("charlie", wrapped, []byte("topic"))
+	require.ErrorIs(t, err, keys.ErrUnknownMasterKey)
+
+	_, err = master.Unwrap(keyID, []byte{1, 2}, []byte("topic"))
+	require.ErrorIs(t, err, keys.ErrInvalidCiphertext)
+
+	testCases := []struct {
+		in  string
+		err error
+	}{
+		{"", keys.ErrNoMasterKeys},
+		{"# no keys here\n", keys.ErrNoMasterKeys},
+		{"alpha", keys.ErrInvalidMasterKey},
+		{":" + strings.SplitN(alpha, ":", 2)[1], keys.ErrInvalidMasterKey},
+		{"alpha:notbase64!", keys.ErrInvalidMasterKey},
+		{"alpha:c2hvcnQ=", keys.ErrInvalidMasterKey},
+		{alpha + "\n" + alpha, keys.ErrDuplicateKeyID},
+	}
+
+	for i, tc := range testCases {
+		_, err := keys.ParseMasterKeys(strings.NewReader(tc.in))
+		require.ErrorIs(t, err, tc.err, "test case %d failed", i)
+	}
+}
+
+func TestLoadMasterKeys(t *testing.T) {
+	path := writeKeyFile(t, "alpha")
+	master, err := keys.LoadMasterKeys(path)
+	require.NoError(t, err, "could not load master keys")
+	require.Equal(t, "alpha", master.Active())
+
+	_, err = keys.LoadMasterKeys(filepath.Join(t.TempDir(), "missing.keys"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// Write a key file with new master keys with the specified ids to a temporary directory.
+func writeKeyFile(t *testing.T, keyIDs ...string) string {
+	lines := make([]string, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		line, err := keys.GenerateMasterKey(keyID)
+		require.NoError(t, err, "could not generate master key")
+		lines = append(lines, line)
+	}
+
+	path := filepath.Join(t.TempDir(), "master.keys")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+	return path
+}
@@ -230,7 +230,7 @@ func (s *SubscribeServer) Reset() {
 // acks and nacks on the specified subscription channel.
 func (s *SubscribeServer) WithSubscription(subscription *api.Subscription) *Subscription {
 	sub := &Subscription{
-		closed:   false,
+		done:     make(chan struct{}),
 		requests: make(chan *api.SubscribeRequest, 1),
 		replies:  make(chan *api.SubscribeReply, 1),
 	}
@@ -243,70 +243,82 @@ func (s *SubscribeServer) WithSubscription(subscription *api.Subscription) *Subs
 	}
 
 	s.OnRecv = func() (*api.SubscribeRequest, error) {
-		sub.RLock()
-		defer sub.RUnlock()
-
-		if sub.closed {
+		select {
+		case msg := <-sub.requests:
+			return msg, nil
+		case <-sub.done:
 			return nil, io.EOF
 		}
-
-		msg := <-sub.requests
-		return msg, nil
 	}
 
 	s.OnSend = func(msg *api.SubscribeReply) error {
-		sub.RLock()
-		defer sub.RUnlock()
-
-		if sub.closed {
+		select {
+		case sub.replies <- msg:
+			return nil
+		case <-sub.done:
 			return io.EOF
 		}
-
-		sub.replies <- msg
-		return nil
 	}
 
 	return sub
 }
 
+// Subscription allows tests to interact with a subscribe stream from the client side.
+// Once the subscription is closed, the server will receive io.EOF on both send and recv.
 type Subscription struct {
-	sync.RWMutex
-	closed   bool
+	once     sync.Once
+	done     chan struct{}
 	requests chan *api.SubscribeRequest
 	replies  chan *api.SubscribeReply
 }
 
 func (s *Subscription) Close() {
-	s.Lock()
-	defer s.Unlock()
-	s.closed = true
-	close(s.replies)
-	close(s.requests)
+	s.once.Do(func() { close(s.done) })
 }
 
 func (s *Subscription) Ready() *api.StreamReady {
-	msg := <-s.replies
-	return msg.GetReady()
+	if msg := s.next(); msg != nil {
+		return msg.GetReady()
+	}
+	return nil
 }
 
 func (s *Subscription) Next() *api.EventWrapper {
-	msg := <-s.replies
-	return msg.GetEvent()
+	if msg := s.next(); msg != nil {
+		return msg.GetEvent()
+	}
+	return nil
+}
+
+func (s *Subscription) TopicUpdate() *api.TopicUpdate {
+	if msg := s.next(); msg != nil {
+		return msg.GetTopicUpdate()
+	}
+	return nil
+}
+
+func (s *Subscription) next() *api.SubscribeReply {
+	select {
+	case msg := <-s.replies:
+		return msg
+	case <-s.done:
+		return nil
+	}
 }
 
 func (s *Subscription) Ack(id []byte) {
-	s.requests <- &api.SubscribeRequest{
+	s.request(&api.SubscribeRequest{
 		Embed: &api.SubscribeRequest_Ack{
 			Ack: &api.Ack{
 				Id:        id,
 				Committed: timestamppb.Now(),
 			},
 		},
-	}
+	})
 }
 
 func (s *Subscription) Nack(id []byte, code api.Nack_Code, msg string) {
-	s.requests <- &api.SubscribeRequest{
+	s.request(&api.SubscribeRequest{
 		Embed: &api.SubscribeRequest_Nack{
 			Nack: &api.Nack{
 				Id:    id,
@@ -314,6 +326,13 @@ func (s *Subscription) Nack(id []byte, code api.Nack_Code, msg string) {
 				Error: msg,
 			},
 		},
+	})
+}
+
+func (s *Subscription) request(msg *api.SubscribeRequest) {
+	select {
+	case s.requests <- msg:
+	case <-s.done:
 	}
 }
 
@@ -36,6 +36,7 @@ const (
 var (
 	// All Ensign specific collectors for observability are defined here.
 	Events            *prometheus.CounterVec
+	DroppedEvents     *prometheus.CounterVec
 	OnlinePublishers  prometheus.Gauge
 	OnlineSubscribers prometheus.Gauge
 
@@ -181,7 +182,7 @@ func Shutdown(ctx context.Context) error {
 func registerCollectors() (err error) {
 	// Track all collectors to make it easier to register them at the end of this
 	// function. When adding new collectors make sure to increase the capacity.
-	collectors := make([]prometheus.Collector, 0, 8)
+	collectors := make([]prometheus.Collector, 0, 9)
 
 	// Ensign Collectors
 	Events = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -191,6 +192,13 @@ func registerCollectors() (err error) {
 	}, []string{"node", "region"})
 	collectors = append(collectors, Events)
 
+	DroppedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NamespaceEnsign,
+		Name:      "dropped_events",
+		Help:      "count the number of events not sent to subscribers because their queue was full",
+	}, []string{"policy"})
+	collectors = append(collectors, DroppedEvents)
+
 	OnlinePublishers = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: NamespaceEnsign,
 		Name:      "online_publishers",
@@ -27,6 +27,7 @@ func TestO11yServer(t *testing.T) {
 
 	// Collect some metrics
 	o11y.Events.WithLabelValues(conf.NodeID, "test").Inc()
+	o11y.DroppedEvents.WithLabelValues("DROP").Inc()
 	o11y.OnlinePublishers.Add(1)
 	o11y.OnlineSubscribers.Add(3)
 
@@ -0,0 +1,284 @@
+package ensign
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/ensql"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The maximum amount of time that an event's created timestamp is expected to be ahead
+// of the time it is committed, e.g. because the publisher's clock is ahead of the
+// server's clock. Events committed before the created time range minus this allowance
+// are skipped by seeking.
+const createdClockSkew = 5 * time.Minute
+
+// A queryPlan describes how an EnSQL query is executed against a topic. The same plan
+// is used to execute the query and to explain it so that explanations describe how
+// the query is actually executed.
+type queryPlan struct {
+	query      ensql.Query
+	topicID    ulid.ULID
+	where      *ensql.Predicate
+	strategy   api.QueryExplanation_ScanStrategy
+	since      time.Time
+	until      time.Time
+	types      *protoregistry.Files
+	continuous bool
+	warnings   []string
+}
+
+// Create a plan for the parsed query on the specified topic. An error is returned if
+// the where clause cannot be converted into a predicate. The created timestamp bounds
+// are inclusive and conservative, e.g. created > '2023-07-29' sets since to midnight;
+// events in the time range must still be evaluated against the where clause.
+func newQueryPlan(query ensql.Query, topicID ulid.ULID) (plan *queryPlan, err error) {
+	plan = &queryPlan{
+		query:    query,
+		topicID:  topicID,
+		strategy: api.QueryExplanation_FULL_SCAN,
+	}
+
+	if query.Conditions != nil {
+		var where ensql.Predicate
+		if where, err = query.Conditions.Predicate(); err != nil {
+			return nil, err
+		}
+		plan.where = &where
+	}
+
+	if plan.where != nil {
+		for _, cond := range plan.where.Conjuncts() {
+			if cond.Identifier() != FieldCreated {
+				continue
+			}
+
+			if cond.Type() == ensql.SearchPredicate {
+				plan.warn("created cannot be searched with %s", cond.Operator)
+				continue
+			}
+
+			ts, perr := cond.Right.(ensql.Token).ParseTime()
+			if perr != nil {
+				plan.warn("created must be compared to a quoted timestamp in %q", cond.String())
+				continue
+			}
+
+			switch cond.Operator {
+			case ensql.Gt, ensql.Gte:
+				if plan.since.IsZero() || ts.After(plan.since) {
+					plan.since = ts
+				}
+			case ensql.Lt, ensql.Lte:
+				if plan.until.IsZero() || ts.Before(plan.until) {
+					plan.until = ts
+				}
+			case ensql.Eq:
+				if plan.since.IsZero() || ts.After(plan.since) {
+					plan.since = ts
+				}
+				if plan.until.IsZero() || ts.Before(plan.until) {
+					plan.until = ts
+				}
+			}
+		}
+	}
+
+	if !plan.since.IsZero() && !plan.until.IsZero() && plan.since.After(plan.until) {
+		plan.warn("the created timestamp range is empty so no events will be returned")
+	}
+
+	// Only the lower bound of the time range can be used to seek since events that are
+	// created before the upper bound may be committed at any time after it.
+	switch {
+	case !plan.since.IsZero():
+		plan.strategy = api.QueryExplanation_TIME_RANGE
+	case query.HasOffset && !rlid.IsZero(query.OffsetID):
+		plan.strategy = api.QueryExplanation_OFFSET_SEEK
+	}
+
+	switch {
+	case query.IsAggregate():
+		plan.warn("aggregate queries can only be executed with QueryRows")
+	case len(query.Fields) > 0 && query.Fields[0].Type != ensql.Asterisk:
+		plan.warn("EnSQL returns entire events, use QueryRows to return only the selected fields")
+	}
+
+	if query.Topic.Schema != "" {
+		plan.warn("event types in the FROM clause do not filter events, use WHERE %s = '%s' instead", FieldTypeName, query.Topic.Schema)
+	}
+
+	if query.HasLimit && query.Limit == 0 {
+		plan.warn("the query has a limit of 0 so no events will be returned")
+	}
+
+	return plan, nil
+}
+
+// Explain the plan, estimating the number of results from the topic info. Because the
+// topic info only counts events by type and mimetype, the estimate is an upper bound
+// that only takes into account the conditions on the type name, version, and mimetype
+// that all returned events must match. Aggregate queries without a GROUP BY clause
+// always return a single row; otherwise the number of events bounds the groups.
+func (p *queryPlan) explain(info *api.TopicInfo, includeDuplicates bool) *api.QueryExplanation {
+	out := &api.QueryExplanation{
+		Query:    p.parsedQuery(),
+		Strategy: p.strategy,
+		Warnings: append([]string(nil), p.warnings...),
+	}
+
+	if !p.since.IsZero() {
+		out.Since = timestamppb.New(p.since)
+	}
+
+	if !p.until.IsZero() {
+		out.Until = timestamppb.New(p.until)
+	}
+
+	if info.Events == 0 {
+		out.Warnings = append(out.Warnings, "the topic has no events")
+		if p.query.IsAggregate() && len(p.query.GroupBy) == 0 {
+			out.EstimatedResults = p.paginate(1)
+		}
+		return out
+	}
+
+	// Separate the conditions that can be estimated from the event type info
+	var filters []ensql.Predicate
+	var unestimated bool
+	if p.where != nil {
+		for _, cond := range p.where.Conjuncts() {
+			switch cond.Identifier() {
+			case FieldTypeName, FieldTypeVersion, FieldMimetype:
+				filters = append(filters, cond)
+			default:
+				unestimated = true
+			}
+		}
+	}
+
+	events, duplicates := info.Events, info.Duplicates
+	if len(filters) > 0 && len(info.Types) > 0 {
+		events, duplicates = 0, 0
+		for _, etype := range info.Types {
+			if matchEventType(etype, filters) {
+				events += etype.Events
+				duplicates += etype.Duplicates
+			}
+		}
+	}
+
+	if !includeDuplicates {
+		events = subsat(events, duplicates)
+	}
+
+	if p.query.IsAggregate() && len(p.query.GroupBy) == 0 {
+		events = 1
+	}
+	out.EstimatedResults = p.paginate(events)
+
+	if unestimated {
+		out.Warnings = append(out.Warnings, "the estimated results do not account for all of the conditions in the where clause")
+	}
+	return out
+}
+
+// Returns the event id to seek to before reading events and true if the iterator should
+// seek rather than reading from the first event in the topic. Events are assumed to be
+// committed after they are created, so events are read from the first event committed
+// at the lower bound of the created time range less an allowance for clock skew. If the
+// query has an event id offset then events are read from the later of the two ids.
+func (p *queryPlan) start() (start rlid.RLID, seek bool) {
+	if !p.since.IsZero() {
+		if ts := p.since.Add(-createdClockSkew); ts.UnixMilli() > 0 {
+			if err := start.SetTime(rlid.Timestamp(ts)); err == nil {
+				seek = true
+			}
+		}
+	}
+
+	if p.query.HasOffset && p.query.OffsetID.Compare(start) > 0 {
+		start, seek = p.query.OffsetID, true
+	}
+	return start, seek
+}
+
+// Apply the offset and limit of the query to the number of results.
+func (p *queryPlan) paginate(results uint64) uint64 {
+	if p.query.HasOffset {
+		results = subsat(results, p.query.Offset)
+	}
+
+	if p.query.HasLimit && p.query.Limit < results {
+		results = p.query.Limit
+	}
+	return results
+}
+
+func (p *queryPlan) parsedQuery() *api.ParsedQuery {
+	parsed := &api.ParsedQuery{
+		Type:      p.query.Type.String(),
+		Topic:     p.query.Topic.Topic,
+		TopicId:   p.topicID.Bytes(),
+		Schema:    p.query.Topic.Schema,
+		Version:   p.query.Topic.Version,
+		Fields:    make([]string, 0, len(p.query.Fields)),
+		Aliases:   p.query.Aliases,
+		Offset:    p.query.Offset,
+		OffsetId:  p.query.OffsetID.Bytes(),
+		HasOffset: p.query.HasOffset,
+		Limit:     p.query.Limit,
+		HasLimit:  p.query.HasLimit,
+	}
+
+	for _, field := range p.query.Fields {
+		parsed.Fields = append(parsed.Fields, field.Token)
+	}
+
+	for _, field := range p.query.GroupBy {
+		parsed.GroupBy = append(parsed.GroupBy, field.Token)
+	}
+
+	if p.where != nil {
+		parsed.Where = p.where.String()
+	}
+	return parsed
+}
+
+func (p *queryPlan) warn(format string, args ...any) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// Returns true if the event type info matches all of the event type filters. If the
+// filters cannot be evaluated then the type is assumed to match.
+func matchEventType(info *api.EventTypeInfo, filters []ensql.Predicate) bool {
+	etype := info.Type
+	if etype == nil || etype.IsZero() {
+		etype = api.UnspecifiedType
+	}
+
+	vars := map[string]any{
+		FieldTypeName:    etype.Name,
+		FieldTypeVersion: etype.Semver(),
+		FieldMimetype:    info.Mimetype.MimeType(),
+	}
+
+	for _, filter := range filters {
+		if match, err := filter.Evaluate(vars); err == nil && !match {
+			return false
+		}
+	}
+	return true
+}
+
+func subsat(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
@@ -7,7 +7,6 @@ import (
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/contexts"
 	"github.com/rotationalio/ensign/pkg/ensign/ensql"
-	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/quarterdeck/permissions"
 	"github.com/rotationalio/ensign/pkg/utils/sentry"
@@ -15,14 +14,17 @@ import (
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protodesc"
 )
 
 // EnSQL parses an incoming query and executes the query request, sending all results
-// onto the query stream. The EnSQL query is guaranteed to terminate, e.g. it is not a
-// long running query that waits for subscription events to come from publishers. Once
-// the query has been completed the stream will close. Errors are returned for standard
-// SQL operations errors - for example if the query cannot be parsed or no results would
-// be returned from the query.
+// onto the query stream. Unless the query is continuous, the EnSQL query is guaranteed
+// to terminate, e.g. it is not a long running query that waits for subscription events
+// to come from publishers. Once the query has been completed the stream will close. A
+// continuous query sends the results in the topic and then sends matching events as
+// they are published until the client closes the stream. Errors are returned for
+// standard SQL operations errors - for example if the query cannot be parsed or no
+// results would be returned from the query.
 //
 // Permissions: subscriber
 func (s *Server) EnSQL(in *api.Query, stream api.Ensign_EnSQLServer) (err error) {
@@ -46,97 +48,96 @@ func (s *Server) EnSQL(in *api.Query, stream api.Ensign_EnSQLServer) (err error)
 		return status.Error(codes.PermissionDenied, "not authorized to perform this action")
 	}
 
-	// Parse the incoming query
-	if in.Query == "" {
-		return status.Error(codes.InvalidArgument, "invalid query")
+	var plan *queryPlan
+	if plan, err = s.planQuery(ctx, in, projectID); err != nil {
+		return err
 	}
 
-	var query ensql.Query
-	if query, err = ensql.Parse(in.Query); err != nil {
-		log.Debug().Err(err).Str("query", in.Query).Msg("could not parse query")
-		return status.Error(codes.InvalidArgument, err.Error())
+	// Aggregate queries return rows rather than events
+	if plan.query.IsAggregate() {
+		return status.Error(codes.InvalidArgument, "aggregate queries must be executed with QueryRows")
 	}
 
-	// Identify the topic in the query
-	var topicID ulid.ULID
-	if topicID, err = s.meta.LookupTopicID(query.Topic.Topic, projectID); err != nil {
-		log.Debug().Err(err).Str("topic", query.Topic.Topic).Msg("could not lookup topic in query")
-		if errors.Is(err, errors.ErrNotFound) {
-			return status.Error(codes.InvalidArgument, "unknown topic in query")
-		}
+	return s.execute(ctx, plan, in.IncludeDuplicates, func(event *api.EventWrapper, _ *EventFields) error {
+		return stream.Send(event)
+	})
+}
 
-		sentry.Error(ctx).Err(err).Msg("could not lookup topic name")
-		return status.Error(codes.Internal, "could not execute query")
+// QueryRows executes a query in the same manner as EnSQL, but rather than returning
+// entire events it returns a row for each event containing only the selected fields,
+// extracted from the event metadata or the decoded event payload. Protocol buffer
+// payloads can only be decoded if the descriptors of the event types are included
+// with the query. Aggregate queries return a row for each group of events once all of
+// the events have been read.
+//
+// Permissions: subscriber
+func (s *Server) QueryRows(in *api.Query, stream api.Ensign_QueryRowsServer) (err error) {
+	ctx := stream.Context()
+	claims, ok := contexts.ClaimsFrom(ctx)
+	if !ok {
+		// This should never happen but the check prevents nil panics.
+		sentry.Error(ctx).Msg("could not get user claims from authenticated request")
+		return status.Error(codes.Unauthenticated, "missing credentials")
 	}
 
-	// Begin simple execution of query
-	log.Debug().Str("query", query.Raw).Str("topic", topicID.String()).Msg("starting ensql query execution")
-	events := s.data.List(topicID)
-	defer events.Release()
-
-	// Skip over events in the offset
-	// NOTE: offset will include duplicates when skipping over ...
-	// TODO: this is very slow, we need to do a binary search for the offset instead.
-	if query.HasOffset {
-		for i := uint64(0); i < query.Offset; i++ {
-			if !events.Next() {
-				break
-			}
-		}
+	// The user must have the subscriber permission to execute a query
+	// TODO: remove the read topics permission when we update Quarterdeck permissions.
+	if !claims.HasAnyPermission(permissions.Subscriber, permissions.ReadTopics) {
+		return status.Error(codes.PermissionDenied, "not authorized to perform this action")
 	}
 
-	nSent := uint64(0)
-	for events.Next() {
-		var event *api.EventWrapper
-		if event, err = events.Event(); err != nil {
-			sentry.Error(ctx).Bytes("key", events.Key()).Err(err).Msg("could not parse event")
-			continue
-		}
+	var projectID ulid.ULID
+	if projectID = claims.ParseProjectID(); ulids.IsZero(projectID) {
+		sentry.Warn(ctx).Msg("no project id specified in claims")
+		return status.Error(codes.PermissionDenied, "not authorized to perform this action")
+	}
 
-		// Skip over duplicates unless specified by the query
-		if !in.IncludeDuplicates && event.IsDuplicate {
-			continue
-		}
+	var plan *queryPlan
+	if plan, err = s.planQuery(ctx, in, projectID); err != nil {
+		return err
+	}
 
-		// If we're including duplicates, and the event is a duplicate, then dereference
-		// the duplicate from the database so there is correct event information.
-		if event.IsDuplicate {
-			var target *api.EventWrapper
-			if target, err = s.data.Retrieve(topicID, rlid.RLID(event.DuplicateId)); err != nil {
-				sentry.Error(ctx).Bytes("duplicate_id", event.DuplicateId).Str("topic_id", topicID.String()).Msg("could not fetch duplicate reference target")
-				continue
+	if !plan.query.IsAggregate() {
+		return s.execute(ctx, plan, in.IncludeDuplicates, func(event *api.EventWrapper, fields *EventFields) (err error) {
+			if fields == nil {
+				if fields, err = NewEventFields(event, plan.types); err != nil {
+					sentry.Error(ctx).Bytes("event_id", event.Id).Err(err).Msg("could not unwrap event")
+					return nil
+				}
 			}
+			return stream.Send(plan.row(event.Id, fields))
+		})
+	}
 
-			if err = event.DuplicateFrom(target); err != nil {
-				sentry.Error(ctx).Bytes("duplicate_id", event.DuplicateId).Str("topic_id", topicID.String()).Msg("could not dereference duplicate event")
-				continue
+	// Aggregates are only returned once all events have been read
+	if plan.continuous {
+		return status.Error(codes.InvalidArgument, "aggregate queries cannot be continuous")
+	}
+
+	aggregation := newAggregation(plan)
+	if err = s.execute(ctx, plan, in.IncludeDuplicates, func(event *api.EventWrapper, fields *EventFields) (err error) {
+		if fields == nil {
+			if fields, err = NewEventFields(event, plan.types); err != nil {
+				sentry.Error(ctx).Bytes("event_id", event.Id).Err(err).Msg("could not unwrap event")
+				return nil
 			}
 		}
+		aggregation.add(fields)
+		return nil
+	}); err != nil {
+		return err
+	}
 
-		// TODO: evaluate WHERE clause
-		if err = stream.Send(event); err != nil {
+	for _, row := range aggregation.rows() {
+		if err = stream.Send(row); err != nil {
 			if streamClosed(err) {
-				log.Debug().Msg("publish stream closed by client")
+				log.Debug().Msg("query stream closed by client")
 				return nil
 			}
 			sentry.Warn(ctx).Err(err).Msg("ensql query stream crashed")
 			return status.Error(codes.Aborted, "query stream aborted")
 		}
-
-		// Check the limit to return a fixed number of events
-		nSent++
-		if query.HasLimit {
-			if nSent >= query.Limit {
-				break
-			}
-		}
 	}
-
-	if err := events.Error(); err != nil {
-		sentry.Error(ctx).Err(err).Msg("could not retrieve events from database")
-		return status.Error(codes.Internal, "could not execute query")
-	}
-
 	return nil
 }
 
@@ -144,7 +145,6 @@ func (s *Server) EnSQL(in *api.Query, stream api.Ensign_EnSQLServer) (err error)
 // plan and approximate number of results any any possible errors.
 //
 // Permissions: subscriber
-// TODO: implement explanation
 func (s *Server) Explain(ctx context.Context, in *api.Query) (out *api.QueryExplanation, err error) {
 	claims, ok := contexts.ClaimsFrom(ctx)
 	if !ok {
@@ -153,8 +153,9 @@ func (s *Server) Explain(ctx context.Context, in *api.Query) (out *api.QueryExpl
 		return nil, status.Error(codes.Unauthenticated, "missing credentials")
 	}
 
-	// The user must have the subscriber permission to execute a query
-	if !claims.HasPermission(permissions.Subscriber) {
+	// The user must have the subscriber permission to explain a query
+	// TODO: remove the read topics permission when we update Quarterdeck permissions.
+	if !claims.HasAnyPermission(permissions.Subscriber, permissions.ReadTopics) {
 		return nil, status.Error(codes.PermissionDenied, "not authorized to perform this action")
 	}
 
@@ -164,5 +165,68 @@ func (s *Server) Explain(ctx context.Context, in *api.Query) (out *api.QueryExpl
 		return nil, status.Error(codes.PermissionDenied, "not authorized to perform this action")
 	}
 
-	return nil, status.Error(codes.Unimplemented, "explain query is not implemented yet")
+	var plan *queryPlan
+	if plan, err = s.planQuery(ctx, in, projectID); err != nil {
+		return nil, err
+	}
+
+	// The topic info may not have been computed yet if the topic was recently created
+	var info *api.TopicInfo
+	if info, err = s.meta.TopicInfo(plan.topicID); err != nil {
+		if !errors.Is(err, errors.ErrNotFound) {
+			sentry.Error(ctx).Err(err).Str("topic_id", plan.topicID.String()).Msg("could not retrieve topic info")
+			return nil, status.Error(codes.Internal, "could not explain query")
+		}
+
+		info = &api.TopicInfo{TopicId: plan.topicID.Bytes(), ProjectId: projectID.Bytes()}
+		plan.warn("topic info is not available so the estimated results may be inaccurate")
+	}
+
+	return plan.explain(info, in.IncludeDuplicates), nil
+}
+
+// Parse the incoming query, identify the topic in the query, and create a plan to
+// execute it. The errors returned are status errors that can be returned to the user.
+func (s *Server) planQuery(ctx context.Context, in *api.Query, projectID ulid.ULID) (_ *queryPlan, err error) {
+	if in.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid query")
+	}
+
+	var query ensql.Query
+	if query, err = ensql.Parse(in.Query); err != nil {
+		log.Debug().Err(err).Str("query", in.Query).Msg("could not parse query")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Identify the topic in the query
+	var topicID ulid.ULID
+	if topicID, err = s.meta.LookupTopicID(query.Topic.Topic, projectID); err != nil {
+		log.Debug().Err(err).Str("topic", query.Topic.Topic).Msg("could not lookup topic in query")
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, status.Error(codes.InvalidArgument, "unknown topic in query")
+		}
+
+		sentry.Error(ctx).Err(err).Msg("could not lookup topic name")
+		return nil, status.Error(codes.Internal, "could not execute query")
+	}
+
+	var plan *queryPlan
+	if plan, err = newQueryPlan(query, topicID); err != nil {
+		log.Debug().Err(err).Str("query", in.Query).Msg("could not plan query")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if in.Continuous {
+		plan.continuous = true
+		plan.warn("the estimated results do not include events published after the continuous query starts")
+	}
+
+	// Register the protocol buffer descriptors to decode protobuf event payloads
+	if in.Descriptors != nil {
+		if plan.types, err = protodesc.NewFiles(in.Descriptors); err != nil {
+			log.Debug().Err(err).Str("query", in.Query).Msg("could not register protobuf descriptors")
+			return nil, status.Error(codes.InvalidArgument, "could not parse protocol buffer descriptors")
+		}
+	}
+	return plan, nil
 }
@@ -0,0 +1,812 @@
+package ensign_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/mock"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
+	store "github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"github.com/rotationalio/ensign/pkg/quarterdeck/permissions"
+	"github.com/rotationalio/ensign/pkg/quarterdeck/tokens"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *serverTestSuite) TestEnSQLWhere() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		return topicID, nil
+	}
+
+	events := makeQueryEvents(topicID)
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	testCases := []struct {
+		query    string
+		expected []int
+	}{
+		{"SELECT * FROM sensors", []int{0, 1, 2, 3, 4}},
+		{"SELECT * FROM sensors WHERE mimetype = 'application/json'", []int{0, 1, 4}},
+		{"SELECT * FROM sensors WHERE type_name = 'Reading' AND type_version = '1.2.0'", []int{1}},
+		{"SELECT * FROM sensors WHERE type_name = 'Unspecified'", []int{3}},
+		{"SELECT * FROM sensors WHERE created >= '2023-07-29T12:00:00Z'", []int{2, 3, 4}},
+		{"SELECT * FROM sensors WHERE region = 'us-east-1'", []int{0, 2, 3}},
+		{"SELECT * FROM sensors WHERE sensor LIKE 'therm%'", []int{0, 2}},
+		{"SELECT * FROM sensors WHERE sensor ILIKE 'THERM%'", []int{0, 2, 4}},
+		{"SELECT * FROM sensors WHERE reading > 20", []int{1, 2}},
+		{"SELECT * FROM sensors WHERE reading > 20 OR region = 'us-west-2' AND calibrated = true", []int{1, 2, 4}},
+		{"SELECT * FROM sensors WHERE (reading > 20 OR region = 'us-west-2') AND calibrated = true", []int{2, 4}},
+		{"SELECT * FROM sensors WHERE reading > 20 LIMIT 1", []int{1}},
+		{"SELECT * FROM sensors WHERE nothere = 'foo'", []int{}},
+	}
+
+	for i, tc := range testCases {
+		results, err := s.collectQuery(&api.Query{Query: tc.query}, mock.PerRPCToken(token))
+		require.NoError(err, "could not execute query for test case %d: %s", i, tc.query)
+		require.Len(results, len(tc.expected), "unexpected number of results for test case %d: %s", i, tc.query)
+
+		for j, idx := range tc.expected {
+			require.Equal(events[idx].Id, results[j].Id, "unexpected result %d for test case %d: %s", j, i, tc.query)
+		}
+	}
+
+	// A where clause that cannot be evaluated should return an error
+	_, err = s.collectQuery(&api.Query{Query: "SELECT * FROM sensors WHERE created > 'yesterday'"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "cannot parse token as a timestamp")
+}
+
+func (s *serverTestSuite) TestEnSQLSeek() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		return topicID, nil
+	}
+
+	// Events are committed shortly after they are created except for a backfilled
+	// event that was committed long after it was created and an event that was created
+	// long after it was committed, which cannot be found by seeking on created.
+	ts := time.Date(2023, 7, 29, 10, 0, 0, 0, time.UTC)
+	fixtures := []struct {
+		region    string
+		created   time.Time
+		committed time.Time
+	}{
+		{"us-east-1", ts, ts.Add(time.Second)},
+		{"eu-central-1", ts.Add(1 * time.Hour), ts.Add(1*time.Hour + time.Second)},
+		{"us-east-1", ts.Add(2 * time.Hour), ts.Add(2*time.Hour + time.Second)},
+		{"us-east-1", ts.Add(3 * time.Hour), ts.Add(3*time.Hour + time.Second)},
+		{"us-west-2", ts.Add(4 * time.Hour), ts.Add(4*time.Hour + time.Second)},
+		{"us-east-1", ts.Add(-1 * time.Hour), ts.Add(5 * time.Hour)},
+		{"us-east-1", ts.Add(3*time.Hour + 30*time.Minute), ts.Add(30 * time.Minute)},
+	}
+
+	fixtureIDs := make([]rlid.RLID, 0, len(fixtures))
+	events := make([]*api.EventWrapper, 0, len(fixtures))
+	for i, fixture := range fixtures {
+		event := MakeEvent(topicID.String(), &api.Event{
+			Data:     []byte("{}"),
+			Metadata: map[string]string{"region": fixture.region},
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1},
+			Created:  timestamppb.New(fixture.created),
+		})
+
+		eventID := rlid.Make(uint32(i + 1))
+		require.NoError(eventID.SetTime(rlid.Timestamp(fixture.committed)), "could not set event id time")
+		event.Id = eventID.Bytes()
+
+		fixtureIDs = append(fixtureIDs, eventID)
+		events = append(events, event)
+	}
+
+	// Events are stored in the order of their ids
+	sort.Slice(events, func(i, j int) bool {
+		return bytes.Compare(events[i].Id, events[j].Id) < 0
+	})
+
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	tomorrow := rlid.Make(0)
+	require.NoError(tomorrow.SetTime(rlid.Timestamp(ts.Add(24*time.Hour))), "could not set event id time")
+
+	testCases := []struct {
+		query    string
+		expected []int
+	}{
+		{"SELECT * FROM sensors WHERE created >= '2023-07-29T12:00:00Z'", []int{2, 3, 4}},
+		{"SELECT * FROM sensors WHERE created < '2023-07-29T11:00:00Z'", []int{0, 5}},
+		{"SELECT * FROM sensors WHERE created BETWEEN '2023-07-29T11:00:00Z' AND '2023-07-29T12:00:00Z'", []int{1, 2}},
+		{fmt.Sprintf("SELECT * FROM sensors OFFSET '%s'", fixtureIDs[2]), []int{2, 3, 4, 5}},
+		{fmt.Sprintf("SELECT * FROM sensors WHERE created >= '2023-07-29T12:00:00Z' OFFSET '%s'", fixtureIDs[3]), []int{3, 4}},
+		{fmt.Sprintf("SELECT * FROM sensors WHERE created >= '2023-07-29T13:00:00Z' OFFSET '%s'", fixtureIDs[0]), []int{3, 4}},
+		{fmt.Sprintf("SELECT * FROM sensors OFFSET '%s'", tomorrow), []int{}},
+		{"SELECT * FROM sensors WHERE region = 'us-east-1' OFFSET 1 LIMIT 2", []int{6, 2}},
+		{"SELECT * FROM sensors OFFSET 5", []int{4, 5}},
+		{"SELECT * FROM sensors LIMIT 0", []int{}},
+	}
+
+	for i, tc := range testCases {
+		results, err := s.collectQuery(&api.Query{Query: tc.query}, mock.PerRPCToken(token))
+		require.NoError(err, "could not execute query for test case %d: %s", i, tc.query)
+		require.Len(results, len(tc.expected), "unexpected number of results for test case %d: %s", i, tc.query)
+
+		for j, idx := range tc.expected {
+			require.Equal(fixtureIDs[idx].Bytes(), results[j].Id, "unexpected result %d for test case %d: %s", j, i, tc.query)
+		}
+	}
+}
+
+func (s *serverTestSuite) TestEnSQLContinuous() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		return topicID, nil
+	}
+
+	makeEvent := func(region string) *api.EventWrapper {
+		return MakeEvent(topicID.String(), &api.Event{
+			Data:     []byte("{}"),
+			Metadata: map[string]string{"region": region},
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1},
+			Created:  timestamppb.Now(),
+		})
+	}
+
+	// The events committed by the broker are inserted into the store
+	var mu sync.Mutex
+	events := makeQueryEvents(topicID)
+	s.store.OnInsert = func(event *api.EventWrapper) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, proto.Clone(event).(*api.EventWrapper))
+		return nil
+	}
+
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		mu.Lock()
+		defer mu.Unlock()
+		return store.NewEventIterator(slices.Clone(events))
+	}
+
+	publish := func(regions ...string) {
+		pub := s.setupValidPublisher()
+		published := make([]*api.EventWrapper, 0, len(regions))
+		for _, region := range regions {
+			published = append(published, makeEvent(region))
+		}
+
+		pub.WithEventResults(&api.OpenStream{ClientId: "tester"}, published...)
+		require.NoError(s.srv.Publish(pub), "could not publish events")
+	}
+
+	recv := func(stream api.Ensign_EnSQLClient) *api.EventWrapper {
+		event, err := stream.Recv()
+		require.NoError(err, "could not receive event from continuous query")
+
+		data, err := event.Unwrap()
+		require.NoError(err, "could not unwrap event")
+		require.Equal("us-east-1", data.Metadata["region"], "expected only matching events to be returned")
+		return event
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Should return the matching events in the topic before the published events
+	query, err := s.client.EnSQL(ctx, &api.Query{Query: "SELECT * FROM sensors WHERE region = 'us-east-1'", Continuous: true}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute continuous query")
+
+	for _, idx := range []int{0, 2, 3} {
+		require.Equal(events[idx].Id, recv(query).Id, "expected events in the topic to be returned first")
+	}
+
+	publish("us-east-1", "eu-central-1", "us-east-1", "us-west-2")
+	first, second := recv(query), recv(query)
+	require.Equal(1, bytes.Compare(second.Id, first.Id), "expected published events to be returned in order")
+	require.Equal(1, bytes.Compare(first.Id, events[4].Id), "expected published events after the events in the topic")
+
+	// A continuous query with a limit should stop once the limit is reached
+	limited, err := s.client.EnSQL(ctx, &api.Query{Query: "SELECT * FROM sensors WHERE region = 'us-east-1' LIMIT 6", Continuous: true}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute continuous query")
+
+	for _, expected := range [][]byte{events[0].Id, events[2].Id, events[3].Id, first.Id, second.Id} {
+		require.Equal(expected, recv(limited).Id, "expected the events in the topic to be returned without duplicates")
+	}
+
+	publish("us-west-2", "us-east-1", "us-east-1")
+	third := recv(limited)
+	require.Equal(third.Id, recv(query).Id, "expected the published event to be returned by both queries")
+	_, err = limited.Recv()
+	require.ErrorIs(err, io.EOF, "expected the continuous query to stop at its limit")
+
+	// The continuous query should stop when the client closes the stream
+	recv(query)
+	cancel()
+	_, err = query.Recv()
+	s.GRPCErrorIs(err, codes.Canceled, "")
+
+	// Aggregate queries cannot be continuous
+	_, err = s.collectRows(&api.Query{Query: "SELECT COUNT(*) FROM sensors", Continuous: true}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "aggregate queries cannot be continuous")
+}
+
+func (s *serverTestSuite) TestExplain() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	ctx := context.Background()
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		if name != "sensors" {
+			return ulid.ULID{}, errors.ErrNotFound
+		}
+		return topicID, nil
+	}
+
+	s.store.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) {
+		return &api.TopicInfo{
+			TopicId:    topicID.Bytes(),
+			Events:     6,
+			Duplicates: 1,
+			Types: []*api.EventTypeInfo{
+				{Type: &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 1}, Mimetype: mimetype.ApplicationJSON, Events: 3, Duplicates: 1},
+				{Type: &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 2}, Mimetype: mimetype.ApplicationJSON, Events: 1},
+				{Type: &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 1}, Mimetype: mimetype.ApplicationMsgPack, Events: 1},
+				{Mimetype: mimetype.TextPlain, Events: 1},
+			},
+		}, nil
+	}
+
+	testCases := []struct {
+		query      *api.Query
+		strategy   api.QueryExplanation_ScanStrategy
+		estimated  uint64
+		warnings   []string
+		unestimate bool
+	}{
+		{&api.Query{Query: "SELECT * FROM sensors"}, api.QueryExplanation_FULL_SCAN, 5, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors", IncludeDuplicates: true}, api.QueryExplanation_FULL_SCAN, 6, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE type_name = 'Reading' AND mimetype = 'application/json'"}, api.QueryExplanation_FULL_SCAN, 3, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE type_version = '1.2.0'"}, api.QueryExplanation_FULL_SCAN, 1, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE type_name = 'Unspecified' OR mimetype = 'application/json'"}, api.QueryExplanation_FULL_SCAN, 5, nil, true},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE region = 'us-east-1' OFFSET 2"}, api.QueryExplanation_FULL_SCAN, 3, nil, true},
+		{&api.Query{Query: "SELECT * FROM sensors OFFSET '064yrcthc000000d'"}, api.QueryExplanation_OFFSET_SEEK, 5, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE created BETWEEN '2023-07-29' AND '2023-07-30'"}, api.QueryExplanation_TIME_RANGE, 5, nil, true},
+		{&api.Query{Query: "SELECT * FROM sensors WHERE created < '2023-07-30'"}, api.QueryExplanation_FULL_SCAN, 5, nil, true},
+		{&api.Query{Query: "SELECT * FROM sensors LIMIT 2"}, api.QueryExplanation_FULL_SCAN, 2, nil, false},
+		{&api.Query{Query: "SELECT * FROM sensors", Continuous: true}, api.QueryExplanation_FULL_SCAN, 5, []string{"the estimated results do not include events published after the continuous query starts"}, false},
+		{&api.Query{Query: "SELECT * FROM sensors LIMIT 0"}, api.QueryExplanation_FULL_SCAN, 0, []string{"the query has a limit of 0 so no events will be returned"}, false},
+		{
+			&api.Query{Query: "SELECT sensor FROM sensors.Reading"}, api.QueryExplanation_FULL_SCAN, 5,
+			[]string{"EnSQL returns entire events, use QueryRows to return only the selected fields", "event types in the FROM clause do not filter events, use WHERE type_name = 'Reading' instead"}, false,
+		},
+		{
+			&api.Query{Query: "SELECT COUNT(*) FROM sensors LIMIT 10"}, api.QueryExplanation_FULL_SCAN, 1,
+			[]string{"aggregate queries can only be executed with QueryRows"}, false,
+		},
+		{
+			&api.Query{Query: "SELECT type_name, COUNT(*) FROM sensors GROUP BY type_name"}, api.QueryExplanation_FULL_SCAN, 5,
+			[]string{"aggregate queries can only be executed with QueryRows"}, false,
+		},
+		{
+			&api.Query{Query: "SELECT * FROM sensors WHERE created > 'yesterday'"}, api.QueryExplanation_FULL_SCAN, 5,
+			[]string{"created must be compared to a quoted timestamp in \"created > 'yesterday'\""}, true,
+		},
+		{
+			&api.Query{Query: "SELECT * FROM sensors WHERE created > '2023-07-30' AND created < '2023-07-29'"}, api.QueryExplanation_TIME_RANGE, 5,
+			[]string{"the created timestamp range is empty so no events will be returned"}, true,
+		},
+	}
+
+	for i, tc := range testCases {
+		out, err := s.client.Explain(ctx, tc.query, mock.PerRPCToken(token))
+		require.NoError(err, "could not explain query for test case %d", i)
+		require.Equal(tc.strategy, out.Strategy, "unexpected strategy for test case %d", i)
+		require.Equal(tc.estimated, out.EstimatedResults, "unexpected estimate for test case %d", i)
+
+		warnings := tc.warnings
+		if tc.unestimate {
+			warnings = append(warnings, "the estimated results do not account for all of the conditions in the where clause")
+		}
+
+		if len(warnings) == 0 {
+			require.Empty(out.Warnings, "expected no warnings for test case %d", i)
+		} else {
+			require.Equal(warnings, out.Warnings, "unexpected warnings for test case %d", i)
+		}
+	}
+
+	// The parsed query and time range should be returned in the explanation
+	out, err := s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors WHERE (created >= '2023-07-29' AND created < '2023-07-30') OR reading > 20 AND created < '2023-07-31' LIMIT 2"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not explain query")
+	require.Equal(api.QueryExplanation_FULL_SCAN, out.Strategy, "expected a full scan when the time range is not required")
+	require.Equal("(created >= '2023-07-29' AND created < '2023-07-30') OR (reading > 20 AND created < '2023-07-31')", out.Query.Where)
+	require.Nil(out.Since, "expected no since timestamp when the time range is not required")
+
+	out, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors WHERE created <= '2023-07-30' OFFSET '064yrcthc000000d'"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not explain query")
+	require.Equal(api.QueryExplanation_OFFSET_SEEK, out.Strategy)
+	require.Nil(out.Since, "expected no since timestamp")
+	require.True(time.Date(2023, 7, 30, 0, 0, 0, 0, time.UTC).Equal(out.Until.AsTime()), "unexpected until timestamp")
+	require.True(out.Query.HasOffset)
+	require.Zero(out.Query.Offset)
+	require.Equal(rlid.MustParse("064yrcthc000000d").Bytes(), out.Query.OffsetId)
+
+	out, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors WHERE created >= '2023-07-29' AND region = 'us-east-1' AND created < '2023-07-30T12:00:00Z' LIMIT 2"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not explain query")
+	require.Equal(api.QueryExplanation_TIME_RANGE, out.Strategy)
+	require.Equal(uint64(2), out.EstimatedResults)
+	require.True(time.Date(2023, 7, 29, 0, 0, 0, 0, time.UTC).Equal(out.Since.AsTime()), "unexpected since timestamp")
+	require.True(time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC).Equal(out.Until.AsTime()), "unexpected until timestamp")
+	require.Equal("SELECT", out.Query.Type)
+	require.Equal("sensors", out.Query.Topic)
+	require.Equal(topicID.Bytes(), out.Query.TopicId)
+	require.Equal([]string{"*"}, out.Query.Fields)
+	require.Equal("created >= '2023-07-29' AND region = 'us-east-1' AND created < '2023-07-30T12:00:00Z'", out.Query.Where)
+	require.True(out.Query.HasLimit)
+	require.Equal(uint64(2), out.Query.Limit)
+	require.False(out.Query.HasOffset)
+
+	// Should warn when the topic info is not available
+	s.store.UseError(store.TopicInfo, errors.ErrNotFound)
+	out, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not explain query")
+	require.Zero(out.EstimatedResults)
+	require.Equal([]string{"topic info is not available so the estimated results may be inaccurate", "the topic has no events"}, out.Warnings)
+
+	// Should return an internal error if the topic info cannot be retrieved
+	s.store.UseError(store.TopicInfo, errors.ErrIterReleased)
+	_, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.Internal, "could not explain query")
+
+	// Should not be able to explain invalid queries
+	_, err = s.client.Explain(ctx, &api.Query{}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "invalid query")
+
+	_, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "")
+
+	_, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM unknown"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "unknown topic in query")
+
+	// Should not be able to explain without permissions
+	claims.Permissions = []string{permissions.Publisher}
+	token, err = s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	_, err = s.client.Explain(ctx, &api.Query{Query: "SELECT * FROM sensors"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.PermissionDenied, "not authorized to perform this action")
+}
+
+func (s *serverTestSuite) TestQueryRows() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		return topicID, nil
+	}
+
+	// Add events with nested JSON and protocol buffer payloads to the query events
+	created := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	topic := &api.Topic{
+		Name:          "readings",
+		Readonly:      true,
+		Shards:        3,
+		Status:        api.TopicState_READY,
+		Deduplication: &api.Deduplication{Strategy: api.Deduplication_STRICT},
+		Types:         []*api.Type{{Name: "Reading", MajorVersion: 1}},
+		Created:       timestamppb.New(created),
+	}
+
+	pbData, err := proto.Marshal(topic)
+	require.NoError(err, "could not marshal protobuf payload")
+
+	events := makeQueryEvents(topicID)
+	for _, fixture := range []*api.Event{
+		{
+			Data:     []byte(`{"location": {"building": "B1", "floor": 3}, "tags": ["indoor", "hvac"]}`),
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Location", MajorVersion: 1},
+			Created:  timestamppb.New(created),
+		},
+		{
+			Data:     pbData,
+			Mimetype: mimetype.ApplicationProtobuf,
+			Type:     &api.Type{Name: "ensign.v1beta1.Topic", MajorVersion: 1},
+			Created:  timestamppb.New(created),
+		},
+	} {
+		event := MakeEvent(topicID.String(), fixture)
+		event.Id = rlid.Make(uint32(len(events) + 1)).Bytes()
+		events = append(events, event)
+	}
+
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	// Should project fields from JSON payloads, metadata, and event fields
+	rows, err := s.collectRows(&api.Query{Query: "SELECT sensor, reading AS value, region, created FROM sensors WHERE mimetype = 'application/json' LIMIT 2"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute query")
+	require.Len(rows, 2)
+
+	require.Equal(events[0].Id, rows[0].EventId)
+	require.Len(rows[0].Columns, 4)
+	require.Equal("sensor", rows[0].Columns[0].Name)
+	require.Equal("thermometer", rows[0].Columns[0].GetS())
+	require.Equal("value", rows[0].Columns[1].Name)
+	require.Equal(18.5, rows[0].Columns[1].GetD())
+	require.Equal("us-east-1", rows[0].Columns[2].GetS())
+	require.True(time.Date(2023, 7, 29, 10, 0, 0, 0, time.UTC).Equal(rows[0].Columns[3].GetT().AsTime()))
+
+	require.Equal(events[1].Id, rows[1].EventId)
+	require.Equal(int64(1013), rows[1].Columns[1].GetI(), "expected integers in JSON to be returned as integers")
+
+	// Should project nested fields and return objects and lists as JSON
+	rows, err = s.collectRows(&api.Query{Query: "SELECT location.building, location.floor AS floor, location, tags, tags.1, nothere FROM sensors WHERE type_name = 'Location'"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute query")
+	require.Len(rows, 1)
+
+	cols := rows[0].Columns
+	require.Len(cols, 6)
+	require.Equal("location.building", cols[0].Name)
+	require.Equal("B1", cols[0].GetS())
+	require.Equal("floor", cols[1].Name)
+	require.Equal(int64(3), cols[1].GetI())
+	require.JSONEq(`{"building": "B1", "floor": 3}`, cols[2].GetJson())
+	require.JSONEq(`["indoor", "hvac"]`, cols[3].GetJson())
+	require.Equal("hvac", cols[4].GetS())
+	require.Equal("nothere", cols[5].Name)
+	require.Nil(cols[5].Value, "expected missing fields to be null")
+
+	// Should return all top level payload fields sorted by name when selecting *
+	rows, err = s.collectRows(&api.Query{Query: "SELECT * FROM sensors WHERE sensor = 'thermostat'"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute query")
+	require.Len(rows, 1)
+
+	cols = rows[0].Columns
+	require.Len(cols, 3)
+	require.Equal("calibrated", cols[0].Name)
+	require.True(cols[0].GetB())
+	require.Equal("reading", cols[1].Name)
+	require.Equal("sensor", cols[2].Name)
+	require.Equal("thermostat", cols[2].GetS())
+
+	// Should not be able to decode protocol buffers without descriptors
+	query := &api.Query{Query: "SELECT name, status, readonly, shards, deduplication.strategy, types, created FROM sensors WHERE type_name = 'ensign.v1beta1.Topic'"}
+	rows, err = s.collectRows(query, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute query")
+	require.Len(rows, 1)
+	require.Nil(rows[0].Columns[0].Value, "expected protobuf fields to be null without descriptors")
+
+	// Should decode protocol buffers with descriptors
+	query.Descriptors = fileDescriptorSet(api.File_api_v1beta1_topic_proto)
+	rows, err = s.collectRows(query, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute query")
+	require.Len(rows, 1)
+
+	cols = rows[0].Columns
+	require.Len(cols, 7)
+	require.Equal("readings", cols[0].GetS())
+	require.Equal("READY", cols[1].GetS())
+	require.True(cols[2].GetB())
+	require.Equal(uint64(3), cols[3].GetU())
+	require.Equal("STRICT", cols[4].GetS())
+	require.JSONEq(`[{"name": "Reading", "major_version": 1, "minor_version": 0, "patch_version": 0}]`, cols[5].GetJson())
+	require.True(created.Equal(cols[6].GetT().AsTime()))
+
+	// Should not be able to query with invalid descriptors
+	query.Descriptors = &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(api.File_api_v1beta1_topic_proto)}}
+	_, err = s.collectRows(query, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "could not parse protocol buffer descriptors")
+
+	// Should not be able to query without permissions
+	claims.Permissions = []string{permissions.Publisher}
+	token, err = s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	_, err = s.collectRows(&api.Query{Query: "SELECT * FROM sensors"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.PermissionDenied, "not authorized to perform this action")
+}
+
+func (s *serverTestSuite) TestQueryAggregates() {
+	require := s.Require()
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "01H784KEP6F5EMW9CBYAHFB3J3",
+		},
+		OrgID:       "01H784KNY3GN2GC8NHW4ZKC5A9",
+		ProjectID:   "01H6PGFTK2X53RGG2KMSGR2M61",
+		Permissions: []string{permissions.Subscriber},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create valid claims for the user")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	s.store.OnLookupTopicID = func(name string, _ ulid.ULID) (ulid.ULID, error) {
+		return topicID, nil
+	}
+
+	events := makeQueryEvents(topicID)
+	s.store.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	// Should compute each aggregate function for each group
+	rows, err := s.collectRows(&api.Query{Query: "SELECT type_name, COUNT(*) AS events, COUNT(reading) AS readings, SUM(reading), AVG(reading), MIN(created), MAX(reading) FROM sensors GROUP BY type_name"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute aggregate query")
+	require.Len(rows, 2)
+
+	cols := rows[0].Columns
+	require.Empty(rows[0].EventId, "aggregate rows should not have an event id")
+	require.Len(cols, 7)
+	require.Equal("type_name", cols[0].Name)
+	require.Equal("Reading", cols[0].GetS())
+	require.Equal("events", cols[1].Name)
+	require.Equal(int64(4), cols[1].GetI())
+	require.Equal("readings", cols[2].Name)
+	require.Equal(int64(4), cols[2].GetI())
+	require.Equal("SUM(reading)", cols[3].Name)
+	require.Equal(1064.5, cols[3].GetD())
+	require.Equal(266.125, cols[4].GetD())
+	require.True(time.Date(2023, 7, 29, 10, 0, 0, 0, time.UTC).Equal(cols[5].GetT().AsTime()))
+	require.Equal(int64(1013), cols[6].GetI())
+
+	cols = rows[1].Columns
+	require.Equal("Unspecified", cols[0].GetS())
+	require.Equal(int64(1), cols[1].GetI())
+	require.Equal(int64(0), cols[2].GetI())
+	require.True(time.Date(2023, 7, 29, 13, 0, 0, 0, time.UTC).Equal(cols[5].GetT().AsTime()))
+	for _, col := range []*api.Column{cols[3], cols[4], cols[6]} {
+		require.Nil(col.Value, "expected %s to be null when there are no values", col.Name)
+	}
+
+	// Should sum integers as integers and aggregate all events without a group by
+	rows, err = s.collectRows(&api.Query{Query: "SELECT COUNT(*), SUM(reading) FROM sensors WHERE calibrated = true"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute aggregate query")
+	require.Len(rows, 1)
+	require.Equal("COUNT(*)", rows[0].Columns[0].Name)
+	require.Equal(int64(2), rows[0].Columns[0].GetI())
+	require.Equal(int64(33), rows[0].Columns[1].GetI())
+
+	// Should return a single row even if there are no matching events
+	rows, err = s.collectRows(&api.Query{Query: "SELECT COUNT(*) FROM sensors WHERE region = 'nowhere'"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute aggregate query")
+	require.Len(rows, 1)
+	require.Equal(int64(0), rows[0].Columns[0].GetI())
+
+	// Should apply the offset and limit to the groups rather than the events
+	rows, err = s.collectRows(&api.Query{Query: "SELECT region, COUNT(*) FROM sensors GROUP BY region LIMIT 2 OFFSET 1"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute aggregate query")
+	require.Len(rows, 2)
+	require.Equal("us-east-1", rows[0].Columns[0].GetS())
+	require.Equal(int64(3), rows[0].Columns[1].GetI())
+	require.Equal("us-west-2", rows[1].Columns[0].GetS())
+	require.Equal(int64(1), rows[1].Columns[1].GetI())
+
+	// Should group events that are missing the group by field under null
+	rows, err = s.collectRows(&api.Query{Query: "SELECT calibrated, COUNT(*) FROM sensors GROUP BY calibrated"}, mock.PerRPCToken(token))
+	require.NoError(err, "could not execute aggregate query")
+	require.Len(rows, 3)
+	require.Nil(rows[0].Columns[0].Value)
+	require.Equal(int64(1), rows[0].Columns[1].GetI())
+	require.False(rows[1].Columns[0].GetB())
+	require.Equal(int64(2), rows[1].Columns[1].GetI())
+	require.True(rows[2].Columns[0].GetB())
+	require.Equal(int64(2), rows[2].Columns[1].GetI())
+
+	// Should not be able to execute aggregate queries with EnSQL
+	_, err = s.collectQuery(&api.Query{Query: "SELECT COUNT(*) FROM sensors"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "aggregate queries must be executed with QueryRows")
+
+	// Should not be able to execute invalid aggregate queries
+	_, err = s.collectRows(&api.Query{Query: "SELECT region, COUNT(*) FROM sensors"}, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, "selected fields must be aggregated or in the GROUP BY clause")
+}
+
+// Execute the query and collect all of the events returned on the query stream.
+func (s *serverTestSuite) collectQuery(in *api.Query, opts ...grpc.CallOption) (results []*api.EventWrapper, err error) {
+	var stream api.Ensign_EnSQLClient
+	if stream, err = s.client.EnSQL(context.Background(), in, opts...); err != nil {
+		return nil, err
+	}
+
+	for {
+		var event *api.EventWrapper
+		if event, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return results, nil
+			}
+			return nil, err
+		}
+		results = append(results, event)
+	}
+}
+
+func makeQueryEvents(topicID ulid.ULID) []*api.EventWrapper {
+	var seq rlid.Sequence
+	ts := time.Date(2023, 7, 29, 10, 0, 0, 0, time.UTC)
+
+	jsonData := func(v any) []byte {
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		return data
+	}
+
+	msgpackData := func(v any) []byte {
+		data, err := msgpack.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		return data
+	}
+
+	fixtures := []*api.Event{
+		{
+			Data:     jsonData(map[string]any{"sensor": "thermometer", "reading": 18.5, "calibrated": false}),
+			Metadata: map[string]string{"region": "us-east-1"},
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 1},
+		},
+		{
+			Data:     jsonData(map[string]any{"sensor": "barometer", "reading": 1013, "calibrated": false}),
+			Metadata: map[string]string{"region": "eu-central-1"},
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 2},
+		},
+		{
+			Data:     msgpackData(map[string]any{"sensor": "thermostat", "reading": int64(21), "calibrated": true}),
+			Metadata: map[string]string{"region": "us-east-1"},
+			Mimetype: mimetype.ApplicationMsgPack,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 1},
+		},
+		{
+			Data:     []byte("sensor=thermometer,reading=42"),
+			Metadata: map[string]string{"region": "us-east-1"},
+			Mimetype: mimetype.TextPlain,
+		},
+		{
+			Data:     jsonData(map[string]any{"sensor": "Thermocouple", "reading": 12, "calibrated": true}),
+			Metadata: map[string]string{"region": "us-west-2"},
+			Mimetype: mimetype.ApplicationJSON,
+			Type:     &api.Type{Name: "Reading", MajorVersion: 1, MinorVersion: 1},
+		},
+	}
+
+	events := make([]*api.EventWrapper, 0, len(fixtures))
+	for i, fixture := range fixtures {
+		fixture.Created = timestamppb.New(ts.Add(time.Duration(i) * time.Hour))
+		event := MakeEvent(topicID.String(), fixture)
+		event.Id = seq.Next().Bytes()
+		events = append(events, event)
+	}
+	return events
+}
+
+// Execute the query and collect all of the rows returned on the query rows stream.
+func (s *serverTestSuite) collectRows(in *api.Query, opts ...grpc.CallOption) (rows []*api.QueryRow, err error) {
+	var stream api.Ensign_QueryRowsClient
+	if stream, err = s.client.QueryRows(context.Background(), in, opts...); err != nil {
+		return nil, err
+	}
+
+	for {
+		var row *api.QueryRow
+		if row, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return rows, nil
+			}
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// Create a descriptor set that contains the file and all of its imports.
+func fileDescriptorSet(file protoreflect.FileDescriptor) *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]struct{})
+
+	var add func(protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	add(file)
+	return set
+}
@@ -0,0 +1,258 @@
+package ensign
+
+import (
+	"errors"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/groups"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+)
+
+var ErrCursorNotStarted = errors.New("cursor without a consumer group must seek before replaying a topic")
+
+// Cursor tracks the position of a consumer group subscriber in each of the topics it
+// is subscribed to. The cursor is used to replay events from the event store that were
+// committed after the group's offset and then to filter events from the broker so that
+// the subscriber does not receive events that have already been replayed.
+//
+// Subscribers that are not in a consumer group use a cursor without a group to catch up
+// on events that spilled because the subscriber could not keep up with the broker; the
+// topics of these cursors must be started with Seek before they are replayed.
+//
+// The offset of an event is the offset assigned by the broker when the event was
+// committed. Events committed before topics were sequenced do not have an offset; the
+// offset of these events is their 1-indexed position in the topic, including duplicates,
+// which is the same offset the broker recovers for them.
+type Cursor struct {
+	data    store.EventStore
+	group   *groups.Group
+	offsets map[ulid.ULID]uint64
+	last    map[ulid.ULID]rlid.RLID
+}
+
+// Sender is called for each event that is replayed along with the offset of the event.
+type Sender func(event *api.EventWrapper, topicID ulid.ULID, offset uint64) error
+
+// NewCursor creates a cursor to replay events from the event store; group may be nil.
+func NewCursor(data store.EventStore, group *groups.Group) *Cursor {
+	return &Cursor{
+		data:    data,
+		group:   group,
+		offsets: make(map[ulid.ULID]uint64),
+		last:    make(map[ulid.ULID]rlid.RLID),
+	}
+}
+
+// Replay sends every event in the topic that is after the cursor's current position. On
+// the first replay of a topic the cursor starts at the group's committed offset; if the
+// group has no offset for the topic, then the cursor is moved to the end of the topic
+// and the offset is committed so that the group resumes from this point in the future.
+// Replay can be called multiple times to catch up on events that were committed while
+// the previous replay was running; it returns the number of events that were sent.
+func (c *Cursor) Replay(topicID ulid.ULID, send Sender) (nSent int, err error) {
+	last, started := c.last[topicID]
+	if !started && c.group == nil {
+		return 0, ErrCursorNotStarted
+	}
+
+	var (
+		committed uint64
+		hasOffset bool
+	)
+
+	if c.group != nil {
+		committed, hasOffset = c.group.Offset(topicID)
+	}
+
+	// The end of the topic can usually be found without reading the whole topic.
+	if !started && !hasOffset {
+		var found bool
+		if found, err = c.end(topicID); err != nil {
+			return 0, err
+		}
+
+		if found {
+			return 0, c.group.Commit(topicID, c.offsets[topicID], c.last[topicID])
+		}
+	}
+
+	events := c.data.List(topicID)
+	defer events.Release()
+
+	// Seek to the position of the cursor rather than reading the topic from the start.
+	// Events without an offset are counted from the position, so the topic is only read
+	// from its first event if the position is not known, e.g. if the group's offset was
+	// committed without the ID of the event at the offset.
+	var (
+		ok     bool
+		offset uint64
+	)
+
+	switch {
+	case started && !rlid.IsZero(last):
+		offset = c.offsets[topicID]
+		ok = events.Seek(last)
+	case !started && hasOffset:
+		if eventID, found := c.group.EventID(topicID); found {
+			offset = committed - 1
+			ok = events.Seek(eventID)
+			break
+		}
+		fallthrough
+	default:
+		ok = events.Next()
+	}
+
+	for ; ok; ok = events.Next() {
+		var event *api.EventWrapper
+		if event, err = events.Event(); err != nil {
+			return nSent, err
+		}
+
+		var eventID rlid.RLID
+		if eventID, err = event.ParseEventID(); err != nil {
+			return nSent, err
+		}
+
+		// Skip events that have already been replayed; the offset of the cursor is the
+		// offset of the last replayed event so these events are not counted again.
+		if started && eventID.Compare(last) <= 0 {
+			continue
+		}
+
+		if event.Offset > 0 {
+			offset = event.Offset
+		} else {
+			offset++
+		}
+
+		// Skip events that have already been consumed by the group.
+		if !started && (!hasOffset || offset <= committed) {
+			c.offsets[topicID] = offset
+			c.last[topicID] = eventID
+			continue
+		}
+
+		// Dereference duplicates so that the subscriber receives the event data.
+		if event.IsDuplicate {
+			if err = dereference(c.data, topicID, event); err != nil {
+				return nSent, err
+			}
+		}
+
+		if err = send(event, topicID, offset); err != nil {
+			return nSent, err
+		}
+
+		nSent++
+		c.offsets[topicID] = offset
+		c.last[topicID] = eventID
+	}
+
+	if err = events.Error(); err != nil {
+		return nSent, err
+	}
+
+	if !started {
+		// Ensure the cursor is marked as started even if the topic is empty.
+		if _, ok := c.last[topicID]; !ok {
+			c.last[topicID] = rlid.Null
+		}
+
+		if !hasOffset {
+			if err = c.group.Commit(topicID, c.offsets[topicID], c.last[topicID]); err != nil {
+				return nSent, err
+			}
+		}
+	}
+	return nSent, nil
+}
+
+// The largest possible event ID, used to seek past the last event of a topic.
+var lastEventID = rlid.RLID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// Starts the topic at the last event in the topic without reading the events before it.
+// If the last event was committed before topics were sequenced its offset can only be
+// found by counting the events in the topic, so false is returned and the topic is not
+// started. An empty topic is started at offset 0.
+func (c *Cursor) end(topicID ulid.ULID) (_ bool, err error) {
+	events := c.data.List(topicID)
+	defer events.Release()
+
+	if events.Seek(lastEventID) || !events.Prev() {
+		if err = events.Error(); err != nil {
+			return false, err
+		}
+
+		c.offsets[topicID] = 0
+		c.last[topicID] = rlid.Null
+		return true, nil
+	}
+
+	var event *api.EventWrapper
+	if event, err = events.Event(); err != nil {
+		return false, err
+	}
+
+	if event.Offset == 0 {
+		return false, nil
+	}
+
+	var eventID rlid.RLID
+	if eventID, err = event.ParseEventID(); err != nil {
+		return false, err
+	}
+
+	c.offsets[topicID] = event.Offset
+	c.last[topicID] = eventID
+	return true, nil
+}
+
+// Seek starts a topic that the cursor has not sent any events for yet so that the next
+// replay of the topic begins with the specified event. Topics that have already been
+// started are not modified since all events after the cursor's position are replayed.
+func (c *Cursor) Seek(topicID ulid.ULID, eventID rlid.RLID) {
+	if _, started := c.last[topicID]; started {
+		return
+	}
+
+	// The position is set to the RLID just before the event since events after the
+	// position are replayed and RLIDs are compared as big-endian integers.
+	last := eventID
+	for i := len(last) - 1; i >= 0; i-- {
+		last[i]--
+		if last[i] != 0xff {
+			break
+		}
+	}
+
+	if rlid.IsZero(eventID) {
+		last = rlid.Null
+	}
+	c.last[topicID] = last
+}
+
+// Advance the cursor with an event from the broker, returning the offset of the event.
+// If the event has already been replayed by the cursor then false is returned and the
+// event should not be sent to the subscriber.
+//
+// The offset is always the offset that the broker assigned to the event when it was
+// committed. Offsets cannot be counted from the events the cursor receives since the
+// broker shares the events of a topic between the members of a consumer group, so each
+// member only receives some of the events in the topic.
+func (c *Cursor) Advance(topicID ulid.ULID, event *api.EventWrapper) (offset uint64, ok bool) {
+	eventID, err := event.ParseEventID()
+	if err != nil {
+		return 0, false
+	}
+
+	if last, started := c.last[topicID]; started && eventID.Compare(last) <= 0 {
+		return 0, false
+	}
+
+	c.offsets[topicID] = event.Offset
+	c.last[topicID] = eventID
+	return event.Offset, true
+}
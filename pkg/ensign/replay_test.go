@@ -0,0 +1,210 @@
+package ensign_test
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/rotationalio/ensign/pkg/ensign"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/groups"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
+	store "github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorSeek(t *testing.T) {
+	data, err := store.Open(config.StorageConfig{Testing: true})
+	require.NoError(t, err, "could not open mock store")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	var seq rlid.Sequence
+	events := make([]*api.EventWrapper, 0, 5)
+	for i := 0; i < 5; i++ {
+		event := MakeEmpty(topicID.String())
+		event.Id = seq.Next().Bytes()
+		events = append(events, event)
+	}
+
+	data.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	var sent [][]byte
+	send := func(event *api.EventWrapper, _ ulid.ULID, _ uint64) error {
+		sent = append(sent, event.Id)
+		return nil
+	}
+
+	// A cursor without a group cannot replay a topic until it has been started
+	cursor := ensign.NewCursor(data, nil)
+	_, err = cursor.Replay(topicID, send)
+	require.ErrorIs(t, err, ensign.ErrCursorNotStarted)
+
+	// Seeking replays events starting with the specified event
+	cursor.Seek(topicID, rlid.RLID(events[2].Id))
+	nSent, err := cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Equal(t, 3, nSent)
+	require.Equal(t, [][]byte{events[2].Id, events[3].Id, events[4].Id}, sent)
+
+	// Seeking a topic that has already started does not move the cursor
+	cursor.Seek(topicID, rlid.RLID(events[0].Id))
+	nSent, err = cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Zero(t, nSent)
+
+	// Events that have been replayed are skipped when received from the broker
+	_, ok := cursor.Advance(topicID, events[4])
+	require.False(t, ok, "expected replayed event to be skipped")
+
+	event := MakeEmpty(topicID.String())
+	event.Id = seq.Next().Bytes()
+	_, ok = cursor.Advance(topicID, event)
+	require.True(t, ok, "expected new event to be sent")
+
+	// Seeking to the null RLID replays the entire topic
+	sent = nil
+	cursor = ensign.NewCursor(data, nil)
+	cursor.Seek(topicID, rlid.Null)
+	nSent, err = cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Equal(t, 5, nSent)
+}
+
+func TestCursorOffsets(t *testing.T) {
+	data, err := store.Open(config.StorageConfig{Testing: true})
+	require.NoError(t, err, "could not open mock store")
+
+	// The first two events were committed before topics were sequenced and the event at
+	// offset 4 is no longer in the topic.
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	var seq rlid.Sequence
+	events := make([]*api.EventWrapper, 0, 4)
+	for _, offset := range []uint64{0, 0, 3, 5} {
+		event := MakeEmpty(topicID.String())
+		event.Id = seq.Next().Bytes()
+		event.Offset = offset
+		events = append(events, event)
+	}
+
+	data.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	var offsets []uint64
+	send := func(_ *api.EventWrapper, _ ulid.ULID, offset uint64) error {
+		offsets = append(offsets, offset)
+		return nil
+	}
+
+	// Events are replayed with their sequenced offset or their position in the topic
+	cursor := ensign.NewCursor(data, nil)
+	cursor.Seek(topicID, rlid.Null)
+	_, err = cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Equal(t, []uint64{1, 2, 3, 5}, offsets)
+
+	// Events from the broker are advanced to their sequenced offset
+	event := MakeEmpty(topicID.String())
+	event.Id = seq.Next().Bytes()
+	event.Offset = 6
+
+	offset, ok := cursor.Advance(topicID, event)
+	require.True(t, ok, "expected new event to be sent")
+	require.Equal(t, uint64(6), offset)
+}
+
+func TestCursorGroupSeek(t *testing.T) {
+	data, err := store.Open(config.StorageConfig{Testing: true})
+	require.NoError(t, err, "could not open mock store")
+
+	data.OnGetOrCreateGroup = func(*api.ConsumerGroup) (bool, error) { return true, nil }
+	data.OnUpdateGroup = func(*api.ConsumerGroup) error { return nil }
+
+	// The first event cannot be read, so the cursor fails if it reads from the start
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	var seq rlid.Sequence
+	events := []*api.EventWrapper{{Id: []byte("bad"), TopicId: topicID[:]}}
+	for i := 0; i < 4; i++ {
+		event := MakeEmpty(topicID.String())
+		event.Id = seq.Next().Bytes()
+		event.Offset = uint64(i + 2)
+		events = append(events, event)
+	}
+
+	data.OnList = func(ulid.ULID) iterator.EventIterator {
+		return store.NewEventIterator(events)
+	}
+
+	var offsets []uint64
+	send := func(_ *api.EventWrapper, _ ulid.ULID, offset uint64) error {
+		offsets = append(offsets, offset)
+		return nil
+	}
+
+	registry := groups.NewRegistry(data)
+	projectID := ulid.MustParse("01H6XTBMNK8KRJ4BNAEJ7XBJM3")
+
+	// A group without an offset starts at the end of the topic
+	consumer, err := registry.Join(projectID, &api.ConsumerGroup{Name: "new.group"})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+
+	cursor := ensign.NewCursor(data, consumer.Group())
+	nSent, err := cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Zero(t, nSent)
+
+	offset, _ := consumer.Group().Offset(topicID)
+	require.Equal(t, uint64(5), offset)
+
+	eventID, _ := consumer.Group().EventID(topicID)
+	require.Equal(t, rlid.RLID(events[4].Id), eventID)
+
+	// A group with an offset seeks to the committed event
+	consumer, err = registry.Join(projectID, &api.ConsumerGroup{Name: "committed.group"})
+	require.NoError(t, err, "could not join group")
+	defer registry.Leave(consumer)
+	require.NoError(t, consumer.Group().Commit(topicID, 3, rlid.RLID(events[2].Id)))
+
+	cursor = ensign.NewCursor(data, consumer.Group())
+	nSent, err = cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Equal(t, 2, nSent)
+	require.Equal(t, []uint64{4, 5}, offsets)
+
+	// Replaying the topic again seeks to the last replayed event
+	event := MakeEmpty(topicID.String())
+	event.Id = seq.Next().Bytes()
+	event.Offset = 6
+	events = append(events, event)
+
+	nSent, err = cursor.Replay(topicID, send)
+	require.NoError(t, err, "could not replay events")
+	require.Equal(t, 1, nSent)
+	require.Equal(t, []uint64{4, 5, 6}, offsets)
+}
+
+func TestCursorAdvance(t *testing.T) {
+	data, err := store.Open(config.StorageConfig{Testing: true})
+	require.NoError(t, err, "could not open mock store")
+
+	topicID := ulid.MustParse("01H6XTAVNM21F6JXNGAJF1SJ4S")
+	cursor := ensign.NewCursor(data, nil)
+	cursor.Seek(topicID, rlid.Null)
+
+	// A member of a consumer group only receives its share of the events in the topic,
+	// so the offsets of the events are not contiguous.
+	var seq rlid.Sequence
+	for _, expected := range []uint64{2, 5, 6, 9} {
+		event := MakeEmpty(topicID.String())
+		event.Id = seq.Next().Bytes()
+		event.Offset = expected
+
+		offset, ok := cursor.Advance(topicID, event)
+		require.True(t, ok, "expected new event to be sent")
+		require.Equal(t, expected, offset, "expected the offset assigned by the broker")
+	}
+}
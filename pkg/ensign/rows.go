@@ -0,0 +1,92 @@
+package ensign
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/ensql"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Project the selected fields of the event into a row of columns that are named by the
+// field alias if one is specified in the query. If all fields are selected then there
+// is a column for each top level field of the payload, sorted by name so that events
+// with the same schema have the same columns in the same order.
+func (p *queryPlan) row(eventID []byte, fields *EventFields) *api.QueryRow {
+	row := &api.QueryRow{EventId: eventID}
+	if len(p.query.Fields) == 1 && p.query.Fields[0].Type == ensql.Asterisk {
+		payload := fields.Payload()
+		names := make([]string, 0, len(payload))
+		for name := range payload {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		row.Columns = make([]*api.Column, 0, len(names))
+		for _, name := range names {
+			row.Columns = append(row.Columns, newColumn(name, payload[name]))
+		}
+		return row
+	}
+
+	row.Columns = make([]*api.Column, 0, len(p.query.Fields))
+	for _, field := range p.query.Fields {
+		name := field.Token
+		if alias, ok := p.query.Aliases[field.Token]; ok {
+			name = alias
+		}
+
+		value, _ := fields.Lookup(field.Token)
+		row.Columns = append(row.Columns, newColumn(name, value))
+	}
+	return row
+}
+
+// Create a column with the protocol buffer type that best matches the Go type of the
+// value. Values that are not primitive types (e.g. objects and lists) are marshaled
+// as JSON and the column is null if the value is nil or cannot be marshaled.
+func newColumn(name string, value any) *api.Column {
+	col := &api.Column{Name: name}
+	switch v := value.(type) {
+	case nil:
+	case int:
+		col.Value = &api.Column_I{I: int64(v)}
+	case int8:
+		col.Value = &api.Column_I{I: int64(v)}
+	case int16:
+		col.Value = &api.Column_I{I: int64(v)}
+	case int32:
+		col.Value = &api.Column_I{I: int64(v)}
+	case int64:
+		col.Value = &api.Column_I{I: v}
+	case uint:
+		col.Value = &api.Column_U{U: uint64(v)}
+	case uint8:
+		col.Value = &api.Column_U{U: uint64(v)}
+	case uint16:
+		col.Value = &api.Column_U{U: uint64(v)}
+	case uint32:
+		col.Value = &api.Column_U{U: uint64(v)}
+	case uint64:
+		col.Value = &api.Column_U{U: v}
+	case float32:
+		col.Value = &api.Column_D{D: float64(v)}
+	case float64:
+		col.Value = &api.Column_D{D: v}
+	case bool:
+		col.Value = &api.Column_B{B: v}
+	case string:
+		col.Value = &api.Column_S{S: v}
+	case []byte:
+		col.Value = &api.Column_Y{Y: v}
+	case time.Time:
+		col.Value = &api.Column_T{T: timestamppb.New(v)}
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			col.Value = &api.Column_Json{Json: string(data)}
+		}
+	}
+	return col
+}
@@ -16,12 +16,16 @@ import (
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/broker"
 	"github.com/rotationalio/ensign/pkg/ensign/config"
+	"github.com/rotationalio/ensign/pkg/ensign/groups"
 	"github.com/rotationalio/ensign/pkg/ensign/info"
 	"github.com/rotationalio/ensign/pkg/ensign/interceptors"
+	"github.com/rotationalio/ensign/pkg/ensign/keys"
 	"github.com/rotationalio/ensign/pkg/ensign/o11y"
 	"github.com/rotationalio/ensign/pkg/ensign/store"
 	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
+	"github.com/rotationalio/ensign/pkg/ensign/topics"
 	quarterdeck "github.com/rotationalio/ensign/pkg/quarterdeck/api/v1"
+	"github.com/rotationalio/ensign/pkg/raft"
 	"github.com/rotationalio/ensign/pkg/utils/logger"
 	health "github.com/rotationalio/ensign/pkg/utils/probez/grpc/v1"
 	"github.com/rotationalio/ensign/pkg/utils/radish"
@@ -58,6 +62,10 @@ type Server struct {
 	auth    *interceptors.Authenticator // Fetches public keys from Quarterdeck to authenticate token requests
 	broker  *broker.Broker              // Brokers all incoming events from publishers and queues them to subscribers
 	infog   *info.TopicInfoGatherer     // Gathers topic information in a background go routine
+	groups  *groups.Registry            // Shares consumer group state between subscribers in the same group
+	watch   *topics.Watchers            // Notifies open streams when topics in their project are created or deleted
+	keys    *keys.Keyring               // Seals and unseals events in topics that are encrypted at rest, nil if not enabled
+	replica *raft.Replica               // Replicates published events to the quorum before they are acked, nil if not enabled
 	data    store.EventStore            // Storage for event data - writing to this store must happen as fast as possible
 	meta    store.MetaStore             // Storage for metadata such as topics and placement
 	tasks   *radish.TaskManager         // Manager for performing background tasks
@@ -102,16 +110,55 @@ func New(conf config.Config) (s *Server, err error) {
 			return nil, err
 		}
 
+		// Load the master keys to encrypt topics at rest and unseal events on read
+		if conf.Encryption.Enabled {
+			if s.keys, err = keys.New(conf.Encryption, s.meta); err != nil {
+				return nil, err
+			}
+			s.data.UseUnsealer(s.keys)
+		}
+
 		// Create the authenticator
 		if s.auth, err = interceptors.NewAuthenticator(conf.Auth.AuthOptions()...); err != nil {
 			return nil, err
 		}
 
 		// Create the broker with access to the data stores
-		s.broker = broker.New(s.data)
+		s.broker = broker.New(s.data, s.meta)
+		if s.keys != nil {
+			s.broker.UseSealer(s.keys)
+		}
+
+		// Replicate events to the quorum with the broker as the state machine that
+		// committed events are written by
+		if conf.Consensus.Enabled {
+			var path string
+			if path, err = conf.Storage.RaftPath(); err != nil {
+				return nil, err
+			}
+
+			if s.replica, err = raft.New(conf.Consensus.Raft(path), raft.WithStateMachine(s.broker)); err != nil {
+				return nil, err
+			}
+			s.broker.UseConsensus(s.replica, conf.Consensus.CommitTimeout)
+
+			// Modify topics and consumer groups through the quorum so that every node
+			// has the topics and group offsets of the events that are replicated to it.
+			s.data, s.meta = s.broker.Stores()
+		}
 
-		// Create the topic info gatherer
+		// Create the topic info gatherer; with consensus, retention and compaction would
+		// remove events from each node independently so the policies are not enforced.
 		s.infog = info.New(s.data, s.meta)
+		if s.replica != nil {
+			s.infog.SkipPolicies()
+		}
+
+		// Create the consumer group registry
+		s.groups = groups.NewRegistry(s.meta)
+
+		// Create the topic watchers to notify streams of topic updates
+		s.watch = topics.NewWatchers()
 
 		// Create the background task manager
 		s.tasks = radish.New(s.conf.Radish)
@@ -154,6 +201,24 @@ func (s *Server) Serve() (err error) {
 		// Start the task manager
 		s.tasks.Start()
 
+		// Finish destroying any topics that were being destroyed when the server stopped
+		if err = s.resumeDestroyTopics(); err != nil {
+			sentry.Error(nil).Err(err).Msg("could not resume destroying topics")
+		}
+
+		// Rewrap the data keys of encrypted topics in case the master key was rotated
+		if s.keys != nil {
+			s.tasks.Queue(radish.TaskFunc(s.RewrapTopicKeys), radish.WithErrorf("could not rewrap topic data keys"))
+		}
+
+		// Join the quorum before the broker starts proposing events to it
+		if s.replica != nil {
+			if err = s.replica.Run(nil); err != nil {
+				sentry.Error(nil).Err(err).Msg("could not run raft replica")
+				return err
+			}
+		}
+
 		// Start the broker to handle publish and subscribe
 		s.broker.Run(s.echan)
 
@@ -225,6 +290,13 @@ func (s *Server) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 
+		// Leave the quorum once the broker is no longer proposing events
+		if s.replica != nil {
+			if err = s.replica.Shutdown(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
 		// Shutdown the topic info gatherer
 		if err = s.infog.Shutdown(); err != nil {
 			errs = append(errs, err)
@@ -347,3 +419,13 @@ func (s *Server) StoreMock() *mock.Store {
 func (s *Server) RunBroker() {
 	s.broker.Run(s.echan)
 }
+
+// RunReplica joins the quorum on the specified socket for testing purposes.
+func (s *Server) RunReplica(sock net.Listener) error {
+	return s.replica.Run(sock)
+}
+
+// RunTasks starts the task manager for testing purposes.
+func (s *Server) RunTasks() {
+	s.tasks.Start()
+}
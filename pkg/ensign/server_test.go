@@ -103,6 +103,9 @@ func (s *serverTestSuite) SetupSuite() {
 
 	// Run the broker for handling events
 	s.srv.RunBroker()
+
+	// Run the task manager for handling background tasks
+	s.srv.RunTasks()
 }
 
 func (s *serverTestSuite) TearDownSuite() {
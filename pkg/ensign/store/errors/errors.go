@@ -41,6 +41,12 @@ var (
 	ErrTopicInfoInvalidProjectId = &Error{"cannot parse project_id field", ErrInvalidTopicInfo}
 	ErrTopicInfoInvalidTopicId   = &Error{"cannot parse topic_id field", ErrInvalidTopicInfo}
 
+	ErrInvalidTopicKeys          = errors.New("invalid topic keys")
+	ErrTopicKeysMissingProjectId = &Error{"missing project_id field", ErrInvalidTopicKeys}
+	ErrTopicKeysMissingTopicId   = &Error{"missing topic_id field", ErrInvalidTopicKeys}
+	ErrTopicKeysInvalidProjectId = &Error{"cannot parse project_id field", ErrInvalidTopicKeys}
+	ErrTopicKeysInvalidTopicId   = &Error{"cannot parse topic_id field", ErrInvalidTopicKeys}
+
 	ErrInvalidGroup          = errors.New("invalid group")
 	ErrGroupMissingProjectId = &Error{"missing project_id field", ErrInvalidGroup}
 	ErrGroupInvalidProjectId = &Error{"cannot parse project_id field", ErrInvalidGroup}
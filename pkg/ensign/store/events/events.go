@@ -33,6 +33,20 @@ func Open(conf config.StorageConfig) (store *Store, err error) {
 type Store struct {
 	db       *leveldb.DB
 	readonly bool
+	unsealer Unsealer
+}
+
+// Unsealer decrypts events that were sealed with the data key of their topic before
+// they were stored, e.g. for topics that are encrypted at rest. Unseal must leave
+// events that are not sealed unmodified.
+type Unsealer interface {
+	Unseal(*api.EventWrapper) error
+}
+
+// UseUnsealer sets the unsealer that events are decrypted with when they are read from
+// the store. This must be called before the store is used since it is not guarded.
+func (s *Store) UseUnsealer(unsealer Unsealer) {
+	s.unsealer = unsealer
 }
 
 func (s *Store) Close() error {
@@ -85,7 +99,7 @@ func (s *Store) List(topicID ulid.ULID) iterator.EventIterator {
 	slice := util.BytesPrefix(prefix)
 
 	iter := s.db.NewIterator(slice, nil)
-	return &EventIterator{Iterator: iter, topicID: topicID}
+	return &EventIterator{Iterator: iter, topicID: topicID, unsealer: s.unsealer}
 }
 
 // Retrieve a specific event from the database by topic and eventID.
@@ -100,15 +114,37 @@ func (s *Store) Retrieve(topicId ulid.ULID, eventID rlid.RLID) (event *api.Event
 		return nil, errors.Wrap(err)
 	}
 
+	return decode(data, s.unsealer)
+}
+
+// Unmarshal an event as it is stored on disk, unsealing it if it was sealed at rest
+// and then decompressing it if it was compressed for storage so that readers receive
+// the event as it was published, including any compression applied by the publisher.
+func decode(data []byte, unsealer Unsealer) (event *api.EventWrapper, err error) {
 	event = &api.EventWrapper{}
 	if err = proto.Unmarshal(data, event); err != nil {
 		return nil, err
 	}
 
+	if unsealer != nil {
+		if err = unsealer.Unseal(event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = event.Decompress(); err != nil {
+		return nil, err
+	}
 	return event, nil
 }
 
-// Destroy all events, meta-events, and index hashes of the specified topic.
+// The maximum number of keys deleted in a single batch write when destroying a topic.
+const DestroyBatchSize = 10000
+
+// Destroy all events, meta-events, and index hashes of the specified topic. Keys are
+// deleted in batches so that large topics do not have to be held in memory, and the
+// key range of the topic is compacted afterward so that the disk space is reclaimed
+// rather than waiting for leveldb to compact the deleted keys in the background.
 // NOTE: this will destroy anything in the database that is prefixed with the topicID.
 func (s *Store) Destroy(topicID ulid.ULID) (err error) {
 	if s.readonly {
@@ -127,6 +163,12 @@ func (s *Store) Destroy(topicID ulid.ULID) (err error) {
 	batch := &leveldb.Batch{}
 	for iter.Next() {
 		batch.Delete(iter.Key())
+		if batch.Len() >= DestroyBatchSize {
+			if err = s.db.Write(batch, &opt.WriteOptions{Sync: false, NoWriteMerge: true}); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
 	}
 
 	if err = iter.Error(); err != nil {
@@ -137,6 +179,167 @@ func (s *Store) Destroy(topicID ulid.ULID) (err error) {
 		return err
 	}
 
+	if err = s.db.CompactRange(*prefix); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Expire deletes all of the events and meta-events in the topic whose ID is less than
+// or equal to the specified event ID, along with any index hashes that refer to them.
+// Because RLIDs are time ordered, this removes the oldest events in the topic, e.g. to
+// enforce a retention policy. Keys are deleted in batches and the expired key ranges
+// are compacted to reclaim the disk space.
+func (s *Store) Expire(topicID ulid.ULID, through rlid.RLID) (err error) {
+	if s.readonly {
+		return errors.ErrReadOnly
+	}
+
+	if ulids.IsZero(topicID) || rlid.IsZero(through) {
+		return errors.ErrKeyNull
+	}
+
+	// Events and meta-events are stored contiguously by ID in their own segments so
+	// the expired events are a range from the start of each segment to the event ID.
+	ranges := make([]*util.Range, 0, 2)
+	for _, segment := range []Segment{EventSegment, MetaEventSegment} {
+		start := make([]byte, 18)
+		topicID.MarshalBinaryTo(start[:16])
+		copy(start[16:18], segment[:])
+
+		var limit Key
+		if limit, err = CreateKey(topicID, through, segment); err != nil {
+			return err
+		}
+
+		// The limit of a leveldb range is exclusive so append a byte to the key of the
+		// through event to ensure that it is included in the range.
+		ranges = append(ranges, &util.Range{Start: start, Limit: append(limit[:], 0x00)})
+	}
+
+	batch := &leveldb.Batch{}
+	for _, slice := range ranges {
+		iter := s.db.NewIterator(slice, &opt.ReadOptions{DontFillCache: true})
+		for iter.Next() {
+			batch.Delete(iter.Key())
+			if batch.Len() >= DestroyBatchSize {
+				if err = s.writeBatch(batch); err != nil {
+					iter.Release()
+					return err
+				}
+			}
+		}
+
+		iter.Release()
+		if err = iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	// Remove index hashes that refer to expired events so that new events are not
+	// checked against events that no longer exist in the topic.
+	if err = s.deleteIndash(batch, topicID, func(eventID rlid.RLID) bool {
+		return eventID.Compare(through) <= 0
+	}); err != nil {
+		return err
+	}
+
+	if err = s.writeBatch(batch); err != nil {
+		return err
+	}
+
+	for _, slice := range ranges {
+		if err = s.db.CompactRange(*slice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteEvents deletes the specified events and meta-events from the topic along with
+// any index hashes that refer to them, e.g. to remove events that were superseded by
+// newer events with the same key when a topic is compacted. The key range of the topic
+// is compacted afterward so that the disk space is reclaimed.
+func (s *Store) DeleteEvents(topicID ulid.ULID, eventIDs ...rlid.RLID) (err error) {
+	if s.readonly {
+		return errors.ErrReadOnly
+	}
+
+	if ulids.IsZero(topicID) {
+		return errors.ErrKeyNull
+	}
+
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	batch := &leveldb.Batch{}
+	deleted := make(map[rlid.RLID]struct{}, len(eventIDs))
+	for _, eventID := range eventIDs {
+		for _, segment := range []Segment{EventSegment, MetaEventSegment} {
+			var key Key
+			if key, err = CreateKey(topicID, eventID, segment); err != nil {
+				return err
+			}
+			batch.Delete(key[:])
+		}
+
+		deleted[eventID] = struct{}{}
+		if batch.Len() >= DestroyBatchSize {
+			if err = s.writeBatch(batch); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = s.deleteIndash(batch, topicID, func(eventID rlid.RLID) bool {
+		_, ok := deleted[eventID]
+		return ok
+	}); err != nil {
+		return err
+	}
+
+	if err = s.writeBatch(batch); err != nil {
+		return err
+	}
+
+	if err = s.db.CompactRange(*util.BytesPrefix(topicID.Bytes())); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Adds the index hashes of the topic whose event ID matches the filter to the batch to
+// be deleted, writing the batch if it grows too large.
+func (s *Store) deleteIndash(batch *leveldb.Batch, topicID ulid.ULID, filter func(rlid.RLID) bool) (err error) {
+	hashes := s.LoadIndash(topicID)
+	defer hashes.Release()
+
+	for hashes.Next() {
+		var eventID rlid.RLID
+		if err = eventID.UnmarshalBinary(hashes.Value()); err != nil {
+			continue
+		}
+
+		if filter(eventID) {
+			batch.Delete(hashes.Key())
+			if batch.Len() >= DestroyBatchSize {
+				if err = s.writeBatch(batch); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return hashes.Error()
+}
+
+// Writes the batch of deletes to the database and resets the batch so it can be reused.
+func (s *Store) writeBatch(batch *leveldb.Batch) error {
+	if err := s.db.Write(batch, &opt.WriteOptions{Sync: false, NoWriteMerge: true}); err != nil {
+		return err
+	}
+	batch.Reset()
 	return nil
 }
 
@@ -1,10 +1,14 @@
 package events_test
 
 import (
+	"bytes"
+
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	mimetype "github.com/rotationalio/ensign/pkg/ensign/mimetype/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"google.golang.org/protobuf/proto"
 )
 
 func (s *eventsTestSuite) TestInsert() {
@@ -220,6 +224,132 @@ func (s *eventsTestSuite) TestRetrieve() {
 	require.ErrorIs(err, errors.ErrInvalidKey)
 }
 
+func (s *eventsTestSuite) TestCompressed() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+	defer s.ResetDatabase()
+
+	topicID, eventID := ulid.Make(), rlid.Make(100)
+	event := &api.Event{
+		Data:     bytes.Repeat([]byte(`{"color":"blue","size":12}`), 64),
+		Mimetype: mimetype.ApplicationJSON,
+	}
+
+	wrapper := &api.EventWrapper{Id: eventID.Bytes(), TopicId: topicID.Bytes()}
+	require.NoError(wrapper.Wrap(event), "could not wrap event")
+	raw := wrapper.Event
+
+	require.NoError(wrapper.Compress(&api.Compression{Algorithm: api.Compression_DEFLATE}), "could not compress event")
+	require.NoError(s.store.Insert(wrapper), "could not insert compressed event")
+
+	// The event should be decompressed when it is retrieved
+	stored, err := s.store.Retrieve(topicID, eventID)
+	require.NoError(err, "could not retrieve compressed event")
+	require.Nil(stored.StorageCompression, "expected compression to be removed from the event")
+	require.Equal(raw, stored.Event, "expected the event to be decompressed")
+
+	// The event should be decompressed when it is listed but remain compressed on disk
+	events := s.store.List(topicID)
+	defer events.Release()
+
+	require.True(events.Next(), "expected an event in the topic")
+	stored, err = events.Event()
+	require.NoError(err, "could not unmarshal compressed event")
+	require.Nil(stored.StorageCompression, "expected compression to be removed from the event")
+	require.Equal(raw, stored.Event, "expected the event to be decompressed")
+	require.Less(len(events.Value()), len(raw), "expected the event to be compressed on disk")
+	require.False(events.Next(), "expected only one event in the topic")
+
+	// Events compressed by the publisher are returned as they were published, even if
+	// the server does not support the compression algorithm.
+	published := &api.EventWrapper{
+		Id:          rlid.Make(101).Bytes(),
+		TopicId:     topicID.Bytes(),
+		Event:       []byte("compressed by the publisher"),
+		Compression: &api.Compression{Algorithm: api.Compression_BROTLI},
+	}
+	require.NoError(s.store.Insert(published), "could not insert published event")
+
+	stored, err = s.store.Retrieve(topicID, rlid.RLID(published.Id))
+	require.NoError(err, "could not retrieve event compressed by the publisher")
+	require.True(proto.Equal(published, stored), "expected the event to be returned as it was published")
+}
+
+func (s *eventsTestSuite) TestSealed() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+	defer s.ResetDatabase()
+
+	s.store.UseUnsealer(xorUnsealer{})
+	defer s.store.UseUnsealer(nil)
+
+	topicID, eventID := ulid.Make(), rlid.Make(100)
+	event := &api.Event{
+		Data:     bytes.Repeat([]byte(`{"color":"red","size":7}`), 64),
+		Mimetype: mimetype.ApplicationJSON,
+	}
+
+	wrapper := &api.EventWrapper{Id: eventID.Bytes(), TopicId: topicID.Bytes()}
+	require.NoError(wrapper.Wrap(event), "could not wrap event")
+	raw := wrapper.Event
+
+	// Events are compressed before they are sealed so they are unsealed first on read
+	require.NoError(wrapper.Compress(&api.Compression{Algorithm: api.Compression_GZIP}), "could not compress event")
+	require.NoError(xorUnsealer{}.Seal(wrapper))
+	require.NoError(s.store.Insert(wrapper), "could not insert sealed event")
+
+	stored, err := s.store.Retrieve(topicID, eventID)
+	require.NoError(err, "could not retrieve sealed event")
+	require.Nil(stored.StorageEncryption, "expected encryption to be removed from the event")
+	require.Nil(stored.StorageCompression, "expected compression to be removed from the event")
+	require.Equal(raw, stored.Event, "expected the event to be unsealed")
+
+	events := s.store.List(topicID)
+	defer events.Release()
+
+	require.True(events.Next(), "expected an event in the topic")
+	stored, err = events.Event()
+	require.NoError(err, "could not unseal listed event")
+	require.Equal(raw, stored.Event, "expected the event to be unsealed")
+	require.False(events.Next(), "expected only one event in the topic")
+
+	// Unseal errors are returned to the caller
+	s.store.UseUnsealer(xorUnsealer{err: errors.ErrInvalidKey})
+	_, err = s.store.Retrieve(topicID, eventID)
+	require.ErrorIs(err, errors.ErrInvalidKey)
+}
+
+// A test unsealer that "encrypts" events by flipping the bits of the event payload.
+type xorUnsealer struct {
+	err error
+}
+
+func (u xorUnsealer) Seal(event *api.EventWrapper) error {
+	event.Event = xor(event.Event)
+	event.StorageEncryption = &api.Encryption{PublicKeyId: "xor", EncryptionAlgorithm: api.Encryption_AES256_GCM}
+	return nil
+}
+
+func (u xorUnsealer) Unseal(event *api.EventWrapper) error {
+	if u.err != nil {
+		return u.err
+	}
+
+	if event.StorageEncryption.GetPublicKeyId() == "xor" {
+		event.Event = xor(event.Event)
+		event.StorageEncryption = nil
+	}
+	return nil
+}
+
+func xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0xff
+	}
+	return out
+}
+
 func (s *readonlyEventsTestSuite) TestRetrieve() {
 	require := s.Require()
 	require.True(s.store.ReadOnly())
@@ -283,6 +413,196 @@ func (s *eventsTestSuite) TestDestroy() {
 	require.Zero(nIndash, "expected no index hashes in the database")
 }
 
+func (s *eventsTestSuite) TestExpire() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+
+	_, err := s.LoadAllFixtures()
+	require.NoError(err, "could not load fixtures")
+	defer s.ResetDatabase()
+
+	total, err := s.store.Count(nil)
+	require.NoError(err, "could not count database")
+
+	// Collect the event IDs in the topic to determine which events should expire
+	topicID := ulid.MustParse("01GTSN1139JMK1PS5A524FXWAZ")
+	eventIDs := make([]rlid.RLID, 0)
+	events := s.store.List(topicID)
+	for events.Next() {
+		event, err := events.Event()
+		require.NoError(err, "could not unmarshal event")
+		eventIDs = append(eventIDs, rlid.RLID(event.Id))
+	}
+	require.NoError(events.Error(), "could not iterate over events")
+	events.Release()
+	require.Greater(len(eventIDs), 2, "expected multiple events in the fixtures")
+
+	// Count the index hashes that refer to the events that will be expired
+	through := eventIDs[len(eventIDs)/2]
+	nExpiredHashes := 0
+	hashes := s.store.LoadIndash(topicID)
+	for hashes.Next() {
+		eventID := rlid.RLID{}
+		require.NoError(eventID.UnmarshalBinary(hashes.Value()))
+		if eventID.Compare(through) <= 0 {
+			nExpiredHashes++
+		}
+	}
+	require.NoError(hashes.Error(), "could not iterate over hashes")
+	hashes.Release()
+
+	err = s.store.Expire(topicID, through)
+	require.NoError(err, "unable to expire events")
+
+	// Only the events after the through event should remain in the topic
+	remaining := make([]rlid.RLID, 0)
+	events = s.store.List(topicID)
+	defer events.Release()
+	for events.Next() {
+		event, err := events.Event()
+		require.NoError(err, "could not unmarshal event")
+		remaining = append(remaining, rlid.RLID(event.Id))
+	}
+	require.NoError(events.Error(), "could not iterate over events")
+	require.Equal(eventIDs[len(eventIDs)/2+1:], remaining, "expected only events after the through event to remain")
+
+	// No index hashes should refer to expired events
+	hashes = s.store.LoadIndash(topicID)
+	defer hashes.Release()
+	for hashes.Next() {
+		eventID := rlid.RLID{}
+		require.NoError(eventID.UnmarshalBinary(hashes.Value()))
+		require.Equal(1, eventID.Compare(through), "expected index hashes for expired events to be deleted")
+	}
+	require.NoError(hashes.Error(), "could not iterate over hashes")
+
+	// No other objects in the database should have been deleted
+	count, err := s.store.Count(nil)
+	require.NoError(err, "could not count database")
+	require.Equal(total-uint64(len(eventIDs)/2+1)-uint64(nExpiredHashes), count)
+
+	// Expiring the topic again should not delete anything else
+	err = s.store.Expire(topicID, through)
+	require.NoError(err, "unable to expire events")
+
+	count, err = s.store.Count(nil)
+	require.NoError(err, "could not count database")
+	require.Equal(total-uint64(len(eventIDs)/2+1)-uint64(nExpiredHashes), count)
+
+	// Cannot expire events without a topic or event ID
+	require.ErrorIs(s.store.Expire(ulid.ULID{}, through), errors.ErrKeyNull)
+	require.ErrorIs(s.store.Expire(topicID, rlid.RLID{}), errors.ErrKeyNull)
+}
+
+func (s *eventsTestSuite) TestDeleteEvents() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+
+	_, err := s.LoadAllFixtures()
+	require.NoError(err, "could not load fixtures")
+	defer s.ResetDatabase()
+
+	total, err := s.store.Count(nil)
+	require.NoError(err, "could not count database")
+
+	// Collect the event IDs in the topic to determine which events to delete
+	topicID := ulid.MustParse("01GTSN1139JMK1PS5A524FXWAZ")
+	eventIDs := make([]rlid.RLID, 0)
+	events := s.store.List(topicID)
+	for events.Next() {
+		event, err := events.Event()
+		require.NoError(err, "could not unmarshal event")
+		eventIDs = append(eventIDs, rlid.RLID(event.Id))
+	}
+	require.NoError(events.Error(), "could not iterate over events")
+	events.Release()
+	require.Greater(len(eventIDs), 2, "expected multiple events in the fixtures")
+
+	// Delete every other event in the topic
+	deleted := make(map[rlid.RLID]struct{})
+	retained := make([]rlid.RLID, 0)
+	for i, eventID := range eventIDs {
+		if i%2 == 0 {
+			deleted[eventID] = struct{}{}
+		} else {
+			retained = append(retained, eventID)
+		}
+	}
+
+	// Count the index hashes that refer to the events that will be deleted
+	nDeletedHashes := 0
+	hashes := s.store.LoadIndash(topicID)
+	for hashes.Next() {
+		eventID := rlid.RLID{}
+		require.NoError(eventID.UnmarshalBinary(hashes.Value()))
+		if _, ok := deleted[eventID]; ok {
+			nDeletedHashes++
+		}
+	}
+	require.NoError(hashes.Error(), "could not iterate over hashes")
+	hashes.Release()
+
+	toDelete := make([]rlid.RLID, 0, len(deleted))
+	for eventID := range deleted {
+		toDelete = append(toDelete, eventID)
+	}
+
+	err = s.store.DeleteEvents(topicID, toDelete...)
+	require.NoError(err, "unable to delete events")
+
+	// Only the retained events should remain in the topic
+	remaining := make([]rlid.RLID, 0)
+	events = s.store.List(topicID)
+	defer events.Release()
+	for events.Next() {
+		event, err := events.Event()
+		require.NoError(err, "could not unmarshal event")
+		remaining = append(remaining, rlid.RLID(event.Id))
+	}
+	require.NoError(events.Error(), "could not iterate over events")
+	require.Equal(retained, remaining, "expected only the retained events to remain")
+
+	// No index hashes should refer to deleted events
+	hashes = s.store.LoadIndash(topicID)
+	defer hashes.Release()
+	for hashes.Next() {
+		eventID := rlid.RLID{}
+		require.NoError(eventID.UnmarshalBinary(hashes.Value()))
+		require.NotContains(deleted, eventID, "expected index hashes for deleted events to be deleted")
+	}
+	require.NoError(hashes.Error(), "could not iterate over hashes")
+
+	// No other objects in the database should have been deleted
+	count, err := s.store.Count(nil)
+	require.NoError(err, "could not count database")
+	require.Equal(total-uint64(len(deleted))-uint64(nDeletedHashes), count)
+
+	// Deleting no events should be a no-op
+	require.NoError(s.store.DeleteEvents(topicID))
+
+	// Cannot delete events without a topic or event ID
+	require.ErrorIs(s.store.DeleteEvents(ulid.ULID{}, retained...), errors.ErrKeyNull)
+	require.ErrorIs(s.store.DeleteEvents(topicID, rlid.RLID{}), errors.ErrKeyNull)
+}
+
+func (s *readonlyEventsTestSuite) TestDeleteEvents() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+
+	topicID := ulid.MustParse("01GTSN1139JMK1PS5A524FXWAZ")
+	err := s.store.DeleteEvents(topicID, rlid.Make(1))
+	require.ErrorIs(err, errors.ErrReadOnly, "expected readonly error on delete events")
+}
+
+func (s *readonlyEventsTestSuite) TestExpire() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+
+	topicID := ulid.MustParse("01GTSN1139JMK1PS5A524FXWAZ")
+	err := s.store.Expire(topicID, rlid.Make(1))
+	require.ErrorIs(err, errors.ErrReadOnly, "expected readonly error on expire events")
+}
+
 func (s *readonlyEventsTestSuite) TestDestroy() {
 	require := s.Require()
 	require.True(s.store.ReadOnly())
@@ -6,21 +6,20 @@ import (
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	ldbiter "github.com/syndtr/goleveldb/leveldb/iterator"
-	"google.golang.org/protobuf/proto"
 )
 
 // Implements iterator.EventIterator to access to a sequence of events in a topic.
 type EventIterator struct {
 	ldbiter.Iterator
-	topicID ulid.ULID
+	topicID  ulid.ULID
+	unsealer Unsealer
 }
 
+// Event returns the current event in the iterator, unsealing and decompressing the
+// event if it was encrypted or compressed for storage. Use Value to access the event as
+// it is stored on disk.
 func (i *EventIterator) Event() (*api.EventWrapper, error) {
-	event := &api.EventWrapper{}
-	if err := proto.Unmarshal(i.Value(), event); err != nil {
-		return nil, err
-	}
-	return event, nil
+	return decode(i.Value(), i.unsealer)
 }
 
 func (t *EventIterator) Seek(eventID rlid.RLID) bool {
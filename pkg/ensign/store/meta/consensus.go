@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"encoding/binary"
+
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// The applied index is stored under a single key in the consensus segment; the project
+// and object IDs of the key are zero since the index belongs to the node.
+var appliedIndexKey = ObjectKey{16: ConsensusSegment[0], 17: ConsensusSegment[1]}
+
+// AppliedIndex returns the index of the last raft log entry that was applied to the
+// event store of the node, or zero if no entries have been applied.
+func (s *Store) AppliedIndex() (index uint64, err error) {
+	var data []byte
+	if data, err = s.db.Get(appliedIndexKey[:], nil); err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err)
+	}
+
+	if len(data) != 8 {
+		return 0, errors.ErrKeyWrongSize
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// UpdateAppliedIndex stores the index of the last raft log entry that was applied to
+// the event store. The index is updated as every entry is applied so it is only synced
+// to disk when requested, e.g. before the log is compacted; if an unsynced index is
+// lost then the entries after the last synced index are applied again.
+func (s *Store) UpdateAppliedIndex(index uint64, sync bool) (err error) {
+	if s.readonly {
+		return errors.ErrReadOnly
+	}
+
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, index)
+	if err = s.db.Put(appliedIndexKey[:], data, &opt.WriteOptions{Sync: sync}); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
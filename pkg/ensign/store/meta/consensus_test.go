@@ -0,0 +1,41 @@
+package meta_test
+
+import "github.com/rotationalio/ensign/pkg/ensign/store/errors"
+
+func (s *metaTestSuite) TestAppliedIndex() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+	defer s.ResetDatabase()
+
+	// The applied index is zero if no entries have been applied
+	index, err := s.store.AppliedIndex()
+	require.NoError(err, "could not fetch applied index")
+	require.Zero(index)
+
+	require.NoError(s.store.UpdateAppliedIndex(42, false), "could not update applied index")
+	index, err = s.store.AppliedIndex()
+	require.NoError(err, "could not fetch applied index")
+	require.Equal(uint64(42), index)
+
+	require.NoError(s.store.UpdateAppliedIndex(1024, true), "could not sync applied index")
+	index, err = s.store.AppliedIndex()
+	require.NoError(err, "could not fetch applied index")
+	require.Equal(uint64(1024), index)
+
+	// The applied index should not be stored with the objects of any project
+	count, err := s.store.Count(nil)
+	require.NoError(err, "could not count database")
+	require.Equal(uint64(1), count)
+}
+
+func (s *readonlyMetaTestSuite) TestAppliedIndex() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+
+	index, err := s.store.AppliedIndex()
+	require.NoError(err, "could not fetch applied index")
+	require.Zero(index)
+
+	err = s.store.UpdateAppliedIndex(42, true)
+	require.ErrorIs(err, errors.ErrReadOnly)
+}
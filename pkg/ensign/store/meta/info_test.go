@@ -60,6 +60,7 @@ func testTopicInfo(require *require.Assertions, store store.TopicInfoStore) {
 func (s *metaTestSuite) TestUpdateTopicInfo() {
 	require := s.Require()
 	require.False(s.store.ReadOnly())
+	defer s.ResetDatabase()
 
 	info := &api.TopicInfo{
 		ProjectId:     ulids.MustBytes("01H7V2HDHM6QH6CZ0KATPSQMF1"),
@@ -62,6 +62,7 @@ func TestObjectKeys(t *testing.T) {
 	t.Run("TopicSegment", makeSegmentTest(meta.TopicSegment))
 	t.Run("TopicNamesSegment", makeSegmentTest(meta.TopicNamesSegment))
 	t.Run("TopicInfoSegment", makeSegmentTest(meta.TopicInfoSegment))
+	t.Run("TopicKeysSegment", makeSegmentTest(meta.TopicKeysSegment))
 	t.Run("GroupSegment", makeSegmentTest(meta.GroupSegment))
 }
 
@@ -70,6 +71,7 @@ func TestObjectKeyConvert(t *testing.T) {
 		meta.TopicSegment,
 		meta.TopicNamesSegment,
 		meta.TopicInfoSegment,
+		meta.TopicKeysSegment,
 		meta.GroupSegment,
 	}
 
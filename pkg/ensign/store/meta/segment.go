@@ -14,7 +14,9 @@ var (
 	TopicSegment      = Segment{0x74, 0x70}
 	TopicNamesSegment = Segment{0x54, 0x6e}
 	TopicInfoSegment  = Segment{0x54, 0x69}
+	TopicKeysSegment  = Segment{0x54, 0x6b}
 	GroupSegment      = Segment{0x47, 0x50}
+	ConsensusSegment  = Segment{0x52, 0x61}
 )
 
 func (s Segment) String() string {
@@ -25,8 +27,12 @@ func (s Segment) String() string {
 		return "topic_name"
 	case TopicInfoSegment:
 		return "topic_info"
+	case TopicKeysSegment:
+		return "topic_keys"
 	case GroupSegment:
 		return "group"
+	case ConsensusSegment:
+		return "consensus"
 	default:
 		return "unknown"
 	}
@@ -12,12 +12,16 @@ func TestSegment(t *testing.T) {
 	require.Equal(t, []byte("tp"), meta.TopicSegment[:])
 	require.Equal(t, []byte("Tn"), meta.TopicNamesSegment[:])
 	require.Equal(t, []byte("Ti"), meta.TopicInfoSegment[:])
+	require.Equal(t, []byte("Tk"), meta.TopicKeysSegment[:])
 	require.Equal(t, []byte("GP"), meta.GroupSegment[:])
+	require.Equal(t, []byte("Ra"), meta.ConsensusSegment[:])
 
 	// Test Strings
 	require.Equal(t, "topic", meta.TopicSegment.String())
 	require.Equal(t, "topic_name", meta.TopicNamesSegment.String())
 	require.Equal(t, "topic_info", meta.TopicInfoSegment.String())
+	require.Equal(t, "topic_keys", meta.TopicKeysSegment.String())
 	require.Equal(t, "group", meta.GroupSegment.String())
+	require.Equal(t, "consensus", meta.ConsensusSegment.String())
 	require.Equal(t, "unknown", meta.Segment([2]byte{0x00, 0x42}).String())
 }
@@ -0,0 +1,111 @@
+package meta
+
+import (
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TopicKeys returns the wrapped data keys for the given topic by first checking if the
+// topic exists in the database, then returning either the keys stored in the database
+// or an empty set of keys if no data keys have been created for the topic. If the topic
+// does not exist then a not found error is returned.
+func (s *Store) TopicKeys(topicID ulid.ULID) (_ *api.TopicKeys, err error) {
+	// Retrieve the projectId from the topicID index key
+	var index IndexKey
+	if index, err = CreateIndex(topicID); err != nil {
+		return nil, err
+	}
+
+	var keyData []byte
+	if keyData, err = s.db.Get(index[:], nil); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	var topicKey ObjectKey
+	if err = topicKey.UnmarshalValue(keyData); err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	// Convert the topic key into a topic keys key
+	topicKey.Convert(TopicKeysSegment)
+
+	var data []byte
+	if data, err = s.db.Get(topicKey[:], nil); err != nil {
+		// If the error is not found, then return an empty set of topic keys
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return &api.TopicKeys{
+				TopicId:   topicKey[18:],
+				ProjectId: topicKey[:16],
+			}, nil
+		}
+		return nil, errors.Wrap(err)
+	}
+
+	keys := &api.TopicKeys{}
+	if err = proto.Unmarshal(data, keys); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return keys, nil
+}
+
+// Replaces the current topic keys in the database with the ones specified, updating the
+// modified timestamp as it does. Data keys must be wrapped before they are stored; the
+// keys should only be updated by the keyring, which serializes updates to a topic.
+func (s *Store) UpdateTopicKeys(keys *api.TopicKeys) (err error) {
+	if s.readonly {
+		return errors.ErrReadOnly
+	}
+
+	if err = ValidateTopicKeys(keys); err != nil {
+		return err
+	}
+
+	// Set the modified timestamp on the struct
+	keys.Modified = timestamppb.Now()
+	key := TopicKeysKey(keys)
+
+	var value []byte
+	if value, err = proto.Marshal(keys); err != nil {
+		return errors.Wrap(err)
+	}
+
+	// Write with fsync since events cannot be unsealed if their data key is lost
+	if err = s.db.Put(key[:], value, &opt.WriteOptions{Sync: true}); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func TopicKeysKey(keys *api.TopicKeys) ObjectKey {
+	var key ObjectKey
+	copy(key[0:16], keys.ProjectId)
+	copy(key[16:18], TopicKeysSegment[:])
+	copy(key[18:], keys.TopicId)
+	return key
+}
+
+func ValidateTopicKeys(keys *api.TopicKeys) error {
+	switch {
+	case keys == nil:
+		return errors.ErrTopicKeysInvalidTopicId
+	case len(keys.ProjectId) == 0:
+		return errors.ErrTopicKeysMissingProjectId
+	case len(keys.TopicId) == 0:
+		return errors.ErrTopicKeysMissingTopicId
+	}
+
+	if projectID, err := ulids.Parse(keys.ProjectId); err != nil || ulids.IsZero(projectID) {
+		return errors.ErrTopicKeysInvalidProjectId
+	}
+
+	if topicID, err := ulids.Parse(keys.TopicId); err != nil || ulids.IsZero(topicID) {
+		return errors.ErrTopicKeysInvalidTopicId
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package meta_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/store"
+	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
+	"github.com/rotationalio/ensign/pkg/ensign/store/meta"
+	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *metaTestSuite) TestTopicKeys() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+
+	_, err := s.LoadAllFixtures()
+	require.NoError(err, "could not load all fixtures")
+	defer s.ResetDatabase()
+
+	testTopicKeys(require, s.store)
+
+	// Should be able to fetch topic keys that have been stored
+	keys := &api.TopicKeys{
+		TopicId:   ulids.MustBytes("01GTSN2NQV61P2R4WFYF1NF1JG"),
+		ProjectId: ulids.MustBytes("01GTSMZNRYXNAZQF5R8NHQ14NM"),
+		Keys: []*api.DataKey{
+			{
+				KeyId:       "01HNBMGDX5EEF6YVRXHNA8RZDE",
+				MasterKeyId: "alpha",
+				WrappedKey:  []byte("wrappedkey"),
+				Created:     timestamppb.Now(),
+			},
+		},
+	}
+	require.NoError(s.store.UpdateTopicKeys(keys), "could not update topic keys")
+
+	stored, err := s.store.TopicKeys(ulid.MustParse("01GTSN2NQV61P2R4WFYF1NF1JG"))
+	require.NoError(err, "could not fetch topic keys")
+	require.Len(stored.Keys, 1)
+	require.Equal("alpha", stored.Keys[0].MasterKeyId)
+	require.Equal([]byte("wrappedkey"), stored.Keys[0].WrappedKey)
+	require.NotNil(stored.Modified)
+}
+
+func (s *readonlyMetaTestSuite) TestTopicKeys() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+	testTopicKeys(require, s.store)
+}
+
+func testTopicKeys(require *require.Assertions, store store.TopicKeyStore) {
+	// Should get empty topic keys if the topic exists but no data keys have been created
+	topicID := ulid.MustParse("01GTSN2NQV61P2R4WFYF1NF1JG")
+	keys, err := store.TopicKeys(topicID)
+	require.NoError(err, "expected topic keys to be created")
+	require.Equal(topicID[:], keys.TopicId)
+	require.Equal(ulids.MustBytes("01GTSMZNRYXNAZQF5R8NHQ14NM"), keys.ProjectId)
+	require.Empty(keys.Keys)
+	require.Zero(keys.Modified)
+
+	// Should get not found if the topic does not exist in the database
+	_, err = store.TopicKeys(ulid.MustParse("01H7V5R4EZ4NATD6DC5RXWJMBG"))
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+func (s *metaTestSuite) TestUpdateTopicKeys() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+	defer s.ResetDatabase()
+
+	keys := &api.TopicKeys{
+		ProjectId: ulids.MustBytes("01H7V2HDHM6QH6CZ0KATPSQMF1"),
+		TopicId:   ulids.MustBytes("01H7V2HMSR47TQVSFCNTD4D5EE"),
+		Keys: []*api.DataKey{
+			{KeyId: "01HNBMGDX5EEF6YVRXHNA8RZDE", MasterKeyId: "alpha", WrappedKey: []byte("wrappedkey")},
+		},
+	}
+
+	count, err := s.store.Count(nil)
+	require.NoError(err, "could not count db")
+	require.Equal(uint64(0), count, "expected nothing in the database")
+
+	err = s.store.UpdateTopicKeys(keys)
+	require.NoError(err, "expected to be able to update topic keys")
+	require.NotNil(keys.Modified, "expected modified timestamp to be set")
+
+	count, err = s.store.Count(nil)
+	require.NoError(err, "could not count db")
+	require.Equal(uint64(1), count, "expected the keys in the database")
+
+	keys.Keys = append(keys.Keys, &api.DataKey{KeyId: "01HNBMHK2XJ0QWB9HX5E8XSPRS", MasterKeyId: "alpha", WrappedKey: []byte("rotatedkey")})
+	err = s.store.UpdateTopicKeys(keys)
+	require.NoError(err, "expected to be able to update topic keys")
+
+	count, err = s.store.Count(nil)
+	require.NoError(err, "could not count db")
+	require.Equal(uint64(1), count, "expected the keys in the database")
+}
+
+func (s *readonlyMetaTestSuite) TestUpdateTopicKeys() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+
+	keys := &api.TopicKeys{
+		ProjectId: ulids.MustBytes("01H7V2HDHM6QH6CZ0KATPSQMF1"),
+		TopicId:   ulids.MustBytes("01H7V2HMSR47TQVSFCNTD4D5EE"),
+	}
+
+	err := s.store.UpdateTopicKeys(keys)
+	require.ErrorIs(err, errors.ErrReadOnly)
+}
+
+func TestValidateTopicKeys(t *testing.T) {
+	projectID := ulids.MustBytes("01H7V2HDHM6QH6CZ0KATPSQMF1")
+	topicID := ulids.MustBytes("01H7V2HMSR47TQVSFCNTD4D5EE")
+
+	testCases := []struct {
+		keys   *api.TopicKeys
+		target error
+	}{
+		{nil, errors.ErrTopicKeysInvalidTopicId},
+		{&api.TopicKeys{ProjectId: projectID}, errors.ErrTopicKeysMissingTopicId},
+		{&api.TopicKeys{TopicId: topicID}, errors.ErrTopicKeysMissingProjectId},
+		{&api.TopicKeys{TopicId: topicID, ProjectId: projectID[4:]}, errors.ErrTopicKeysInvalidProjectId},
+		{&api.TopicKeys{TopicId: topicID, ProjectId: ulids.Null[:]}, errors.ErrTopicKeysInvalidProjectId},
+		{&api.TopicKeys{ProjectId: projectID, TopicId: ulids.Null[:]}, errors.ErrTopicKeysInvalidTopicId},
+		{&api.TopicKeys{ProjectId: projectID, TopicId: topicID[7:]}, errors.ErrTopicKeysInvalidTopicId},
+		{&api.TopicKeys{ProjectId: projectID, TopicId: topicID}, nil},
+	}
+
+	for i, tc := range testCases {
+		err := meta.ValidateTopicKeys(tc.keys)
+		require.ErrorIs(t, err, tc.target, "test %d failed", i)
+	}
+}
+
+func TestTopicKeysKey(t *testing.T) {
+	keys := &api.TopicKeys{
+		ProjectId: ulids.MustBytes("01H7V2HDHM6QH6CZ0KATPSQMF1"),
+		TopicId:   ulids.MustBytes("01H7V2HMSR47TQVSFCNTD4D5EE"),
+	}
+
+	key := meta.TopicKeysKey(keys)
+	require.Len(t, key, 34)
+	require.True(t, bytes.HasPrefix(key[:], keys.ProjectId))
+	require.True(t, bytes.Equal(key[16:18], meta.TopicKeysSegment[:]))
+	require.True(t, bytes.HasSuffix(key[:], keys.TopicId))
+}
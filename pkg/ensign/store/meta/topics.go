@@ -6,6 +6,7 @@ import (
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/utils/pagination"
@@ -238,9 +239,63 @@ func (s *Store) UpdateTopic(topic *api.Topic) (err error) {
 	return nil
 }
 
+// UpdateOffset sets the offset of the topic and the ID of the event at that offset as
+// events are committed to the topic. The read and write of the topic are performed
+// under the keymu lock and the offset is only ever moved forward, so if the topic is
+// already at or past the specified offset then no update is made. The write is not
+// synced to disk since an offset that is lost is recovered from the event log.
+//
+// NOTE: UpdateTopic may still write a stale offset if the topic it is given was read
+// before events were committed. This is safe since the offset of the topic is only the
+// starting point for recovering the offset from the event log, which is authoritative.
+func (s *Store) UpdateOffset(topicID ulid.ULID, offset uint64, eventID rlid.RLID) (err error) {
+	if s.readonly {
+		return errors.ErrReadOnly
+	}
+
+	key := IndexKey(topicID)
+	mu := s.keymu.Lock(key)
+	defer mu.Unlock()
+
+	var data []byte
+	if data, err = s.db.Get(key[:], nil); err != nil {
+		return errors.Wrap(err)
+	}
+
+	var objectKey ObjectKey
+	if err = objectKey.UnmarshalValue(data); err != nil {
+		return errors.Wrap(err)
+	}
+
+	if data, err = s.db.Get(objectKey[:], nil); err != nil {
+		return errors.Wrap(err)
+	}
+
+	topic := &api.Topic{}
+	if err = proto.Unmarshal(data, topic); err != nil {
+		return errors.Wrap(err)
+	}
+
+	if offset <= topic.Offset {
+		return nil
+	}
+
+	topic.Offset = offset
+	topic.OffsetId = eventID.Bytes()
+
+	if data, err = proto.Marshal(topic); err != nil {
+		return errors.Wrap(err)
+	}
+
+	if err = s.db.Put(objectKey[:], data, nil); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 // Delete a topic from the database. If the topic does not exist, no error is returned.
 // This method uses the keymu lock to avoid concurrency issues and also cleans up any
-// indices associated with the topic.
+// indices and the topic info associated with the topic.
 func (s *Store) DeleteTopic(topicID ulid.ULID) (err error) {
 	// Lookup the topic name to get the unique constraints to also delete
 	var topic *api.Topic
@@ -252,10 +307,18 @@ func (s *Store) DeleteTopic(topicID ulid.ULID) (err error) {
 		return err
 	}
 
-	// Delete the topic as well as the topic name index.
+	// The topic info and keys are stored alongside the topic in their own segments.
+	infoKey := TopicKey(topic)
+	infoKey.Convert(TopicInfoSegment)
+
+	keysKey := TopicKey(topic)
+	keysKey.Convert(TopicKeysSegment)
+
+	// Delete the topic as well as the topic name index, the topic info, and the topic
+	// keys; once the keys are deleted any events encrypted at rest cannot be read.
 	// NOTE: because no error is returned if the topic exists, there shouldn't be a
 	// concurrency issue between the retrieve above and the delete below.
-	if err := s.Destroy(IndexKey(topicID), TopicNameKey(topic)); err != nil {
+	if err := s.Destroy(IndexKey(topicID), TopicNameKey(topic), infoKey, keysKey); err != nil {
 		return err
 	}
 	return nil
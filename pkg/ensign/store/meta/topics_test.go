@@ -8,6 +8,7 @@ import (
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/errors"
 	"github.com/rotationalio/ensign/pkg/ensign/store/meta"
 	"github.com/rotationalio/ensign/pkg/utils/ulids"
@@ -396,6 +397,55 @@ func (s *readonlyMetaTestSuite) TestUpdateTopic() {
 	require.ErrorIs(err, errors.ErrReadOnly, "expected readonly error on create topic")
 }
 
+func (s *metaTestSuite) TestUpdateOffset() {
+	require := s.Require()
+	require.False(s.store.ReadOnly())
+
+	_, err := s.LoadTopicFixtures()
+	require.NoError(err, "could not load topic fixtures")
+	defer s.ResetDatabase()
+
+	topicID := ulids.MustParse("01GTSMQ3V8ASAPNCFEN378T8RD")
+	original, err := s.store.RetrieveTopic(topicID)
+	require.NoError(err, "could not retrieve topic")
+	require.Equal(uint64(83123), original.Offset)
+
+	// Cannot update the offset of a topic that doesn't exist
+	err = s.store.UpdateOffset(ulids.MustParse("01GTSMMC152Q95RD4TNYDFJGHT"), 1, rlid.Make(1))
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	// Should be able to move the offset forward
+	eventID := rlid.Make(83200)
+	err = s.store.UpdateOffset(topicID, 83200, eventID)
+	require.NoError(err, "could not update topic offset")
+
+	topic, err := s.store.RetrieveTopic(topicID)
+	require.NoError(err, "could not retrieve topic")
+	require.Equal(uint64(83200), topic.Offset)
+	require.Equal(eventID.Bytes(), topic.OffsetId)
+
+	// The rest of the topic should be unchanged
+	topic.Offset, topic.OffsetId = original.Offset, original.OffsetId
+	require.True(proto.Equal(original, topic), "expected only the offset to be modified")
+
+	// Should not be able to move the offset backward
+	err = s.store.UpdateOffset(topicID, 83150, rlid.Make(83150))
+	require.NoError(err, "expected no error when offset is behind")
+
+	topic, err = s.store.RetrieveTopic(topicID)
+	require.NoError(err, "could not retrieve topic")
+	require.Equal(uint64(83200), topic.Offset)
+	require.Equal(eventID.Bytes(), topic.OffsetId)
+}
+
+func (s *readonlyMetaTestSuite) TestUpdateOffset() {
+	require := s.Require()
+	require.True(s.store.ReadOnly())
+
+	err := s.store.UpdateOffset(ulids.MustParse("01GTSMQ3V8ASAPNCFEN378T8RD"), 90221, rlid.Make(90221))
+	require.ErrorIs(err, errors.ErrReadOnly, "expected readonly error on update offset")
+}
+
 func (s *metaTestSuite) TestDeleteTopic() {
 	require := s.Require()
 	require.False(s.store.ReadOnly())
@@ -409,10 +459,20 @@ func (s *metaTestSuite) TestDeleteTopic() {
 	require.NoError(err, "could not count database")
 	require.Equal(nFixtures, count, "expected topic fixtures in the database")
 
+	// Add topic info for the topic that should be deleted with the topic
+	info, err := s.store.TopicInfo(ulids.MustParse("01GTSMSX1M9G2Z45VGG4M12WC0"))
+	require.NoError(err, "could not fetch topic info")
+	info.Events = 42
+	require.NoError(s.store.UpdateTopicInfo(info), "could not update topic info")
+
+	count, err = s.store.Count(nil)
+	require.NoError(err, "could not count database")
+	require.Equal(nFixtures+1, count, "expected topic info in the database")
+
 	err = s.store.DeleteTopic(ulids.MustParse("01GTSMSX1M9G2Z45VGG4M12WC0"))
 	require.NoError(err, "Could not delete topic")
 
-	// Index and topic should have been deleted
+	// Index, topic, and topic info should have been deleted
 	count, err = s.store.Count(nil)
 	require.NoError(err, "could not count database")
 	require.Equal(nFixtures-3, count, "expected one less topic fixture and two less indices in the database")
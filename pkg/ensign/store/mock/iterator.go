@@ -156,13 +156,26 @@ func (t *EventIterator) Event() (*api.EventWrapper, error) {
 	return value.(*api.EventWrapper), nil
 }
 
+// Seek moves the iterator to the first event whose id is greater than or equal to the
+// specified id, assuming that the events are sorted by id as they are in the database.
+// Keys are compared by their last 10 bytes so that either event ids or event keys can
+// be used. If there is no such event the iterator is exhausted and false is returned.
 func (t *EventIterator) Seek(eventID rlid.RLID) bool {
-	for t.index < len(t.keys) {
-		if bytes.HasSuffix(t.keys[t.index], eventID[:]) {
+	if t.index < -1 {
+		if t.err == nil {
+			t.err = leveldb.ErrIterReleased
+		}
+		return false
+	}
+
+	for idx, key := range t.keys {
+		if len(key) >= len(eventID) && bytes.Compare(key[len(key)-len(eventID):], eventID[:]) >= 0 {
+			t.index = idx
 			return true
 		}
-		t.index++
 	}
+
+	t.index = len(t.keys)
 	return false
 }
 
@@ -269,3 +282,32 @@ func (t *TopicNamesIterator) NextPage(in *api.PageInfo) (out *api.TopicNamesPage
 	}
 	return out, nil
 }
+
+type GroupIterator struct {
+	MockIterator
+}
+
+func NewGroupIterator(groups []*api.ConsumerGroup) *GroupIterator {
+	keys := make([][]byte, 0, len(groups))
+	values := make([]interface{}, 0, len(groups))
+
+	for _, group := range groups {
+		key := meta.GroupKey(group)
+		keys = append(keys, key[:])
+		values = append(values, group)
+	}
+
+	return &GroupIterator{MockIterator{keys: keys, values: values, index: -1}}
+}
+
+func NewGroupErrorIterator(err error) *GroupIterator {
+	return &GroupIterator{MockIterator{index: -2, err: err}}
+}
+
+func (t *GroupIterator) Group() (*api.ConsumerGroup, error) {
+	value, err := t.Object()
+	if err != nil {
+		return nil, err
+	}
+	return value.(*api.ConsumerGroup), nil
+}
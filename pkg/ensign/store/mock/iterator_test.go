@@ -7,6 +7,7 @@ import (
 
 	"github.com/oklog/ulid/v2"
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/rlid"
 	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/ensign/store/meta"
 	"github.com/rotationalio/ensign/pkg/ensign/store/mock"
@@ -41,6 +42,7 @@ func TestIterator(t *testing.T) {
 		t.Run("Event", emptyTest(func() Iterator { return makeEmptyEventIterator() }))
 		t.Run("Topic", emptyTest(func() Iterator { return makeEmptyTopicIterator() }))
 		t.Run("TopicName", emptyTest(func() Iterator { return makeEmptyTopicNamesIterator() }))
+		t.Run("Group", emptyTest(func() Iterator { return makeEmptyGroupIterator() }))
 	})
 
 	t.Run("Release", func(t *testing.T) {
@@ -80,6 +82,7 @@ func TestIterator(t *testing.T) {
 		t.Run("Event", releaseTest(func() Iterator { return makeEmptyEventIterator() }))
 		t.Run("Topic", releaseTest(func() Iterator { return makeEmptyTopicIterator() }))
 		t.Run("TopicName", releaseTest(func() Iterator { return makeEmptyTopicNamesIterator() }))
+		t.Run("Group", releaseTest(func() Iterator { return makeEmptyGroupIterator() }))
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -109,6 +112,7 @@ func TestIterator(t *testing.T) {
 		t.Run("Event", errorTest(func() Iterator { return makeEventErrorIterator() }))
 		t.Run("Topic", errorTest(func() Iterator { return makeTopicErrorIterator() }))
 		t.Run("TopicName", errorTest(func() Iterator { return makeTopicNamesErrorIterator() }))
+		t.Run("Group", errorTest(func() Iterator { return makeGroupErrorIterator() }))
 	})
 }
 
@@ -131,6 +135,51 @@ func TestEventIterator(t *testing.T) {
 
 }
 
+func TestEventIteratorSeek(t *testing.T) {
+	events := make([]*api.EventWrapper, 0, 5)
+	for i := 0; i < 5; i++ {
+		var id rlid.RLID
+		id.SetTime(uint64(1000 + i*10))
+		events = append(events, &api.EventWrapper{Id: id.Bytes()})
+	}
+
+	makeID := func(ms uint64) (id rlid.RLID) {
+		id.SetTime(ms)
+		return id
+	}
+
+	it := mock.NewEventIterator(events)
+
+	// Should be able to seek before Next has been called
+	require.True(t, it.Seek(rlid.RLID(events[2].Id)), "could not seek to an exact id")
+	event, err := it.Event()
+	require.NoError(t, err)
+	require.Equal(t, events[2].Id, event.Id)
+
+	require.True(t, it.Next())
+	event, _ = it.Event()
+	require.Equal(t, events[3].Id, event.Id)
+
+	// Should seek to the first event after an id that is not in the iterator
+	require.True(t, it.Seek(makeID(1005)), "could not seek between ids")
+	event, _ = it.Event()
+	require.Equal(t, events[1].Id, event.Id)
+
+	require.True(t, it.Seek(rlid.Null), "could not seek to the start")
+	event, _ = it.Event()
+	require.Equal(t, events[0].Id, event.Id)
+
+	// Should exhaust the iterator when seeking past the last event
+	require.False(t, it.Seek(makeID(2000)), "expected seek past the end to fail")
+	require.False(t, it.Next())
+	require.NoError(t, it.Error())
+
+	// Should not be able to seek a released iterator
+	it.Release()
+	require.False(t, it.Seek(rlid.Null))
+	require.ErrorIs(t, it.Error(), leveldb.ErrIterReleased)
+}
+
 func TestTopicIterator(t *testing.T) {
 	fixture, err := mock.TopicListFixture("testdata/topics.pb.json")
 	require.NoError(t, err, "could not load testdata/topics.pb.json")
@@ -252,6 +301,25 @@ type Iterator interface {
 
 var errTestIterator = errors.New("this is a test iterator error")
 
+func TestGroupIterator(t *testing.T) {
+	fixture, err := mock.GroupListFixture("testdata/groups.pb.json")
+	require.NoError(t, err, "could not load testdata/groups.pb.json")
+
+	it := mock.NewGroupIterator(fixture)
+
+	groups := make([]string, 0, len(fixture))
+	for it.Next() {
+		group, err := it.Group()
+		require.NoError(t, err)
+		groups = append(groups, group.Name)
+
+		key := it.Key()
+		expected := meta.GroupKey(group)
+		require.True(t, bytes.Equal(key, expected[:]))
+	}
+	require.Len(t, groups, len(fixture))
+}
+
 type makeIterator func() Iterator
 
 func makeEmptyEventIterator() *mock.EventIterator {
@@ -266,6 +334,10 @@ func makeEmptyTopicNamesIterator() *mock.TopicNamesIterator {
 	return mock.NewTopicNamesIterator(nil)
 }
 
+func makeEmptyGroupIterator() *mock.GroupIterator {
+	return mock.NewGroupIterator(nil)
+}
+
 func makeEventErrorIterator() *mock.EventIterator {
 	return mock.NewEventErrorIterator(errTestIterator)
 }
@@ -277,3 +349,7 @@ func makeTopicErrorIterator() *mock.TopicIterator {
 func makeTopicNamesErrorIterator() *mock.TopicNamesIterator {
 	return mock.NewTopicNamesErrorIterator(errTestIterator)
 }
+
+func makeGroupErrorIterator() *mock.GroupIterator {
+	return mock.NewGroupErrorIterator(errTestIterator)
+}
@@ -10,65 +10,91 @@ import (
 	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/ensign/config"
 	"github.com/rotationalio/ensign/pkg/ensign/rlid"
+	"github.com/rotationalio/ensign/pkg/ensign/store/events"
 	"github.com/rotationalio/ensign/pkg/ensign/store/iterator"
 	"github.com/rotationalio/ensign/pkg/utils/ulids"
+	"google.golang.org/protobuf/proto"
 )
 
 // Constants are used to reference store methods in mock code
 const (
-	Close           = "Close"
-	ReadOnly        = "ReadOnly"
-	Insert          = "Insert"
-	List            = "List"
-	Retrieve        = "Retrieve"
-	Destroy         = "Destroy"
-	Indash          = "Indash"
-	Unhash          = "Unhash"
-	LoadIndash      = "LoadIndash"
-	ClearIndash     = "ClearIndash"
-	AllowedTopics   = "AllowedTopics"
-	ListTopics      = "ListTopics"
-	CreateTopic     = "CreateTopic"
-	RetrieveTopic   = "RetrieveTopic"
-	UpdateTopic     = "UpdateTopic"
-	DeleteTopic     = "DeleteTopic"
-	ListTopicNames  = "ListTopicNames"
-	TopicExists     = "TopicExists"
-	TopicName       = "TopicName"
-	LookupTopicID   = "LookupTopicID"
-	ListAllTopics   = "ListAllTopics"
-	TopicInfo       = "TopicInfo"
-	UpdateTopicInfo = "UpdateTopicInfo"
+	Close              = "Close"
+	ReadOnly           = "ReadOnly"
+	Insert             = "Insert"
+	List               = "List"
+	Retrieve           = "Retrieve"
+	Expire             = "Expire"
+	DeleteEvents       = "DeleteEvents"
+	Destroy            = "Destroy"
+	Indash             = "Indash"
+	Unhash             = "Unhash"
+	LoadIndash         = "LoadIndash"
+	ClearIndash        = "ClearIndash"
+	AllowedTopics      = "AllowedTopics"
+	ListTopics         = "ListTopics"
+	CreateTopic        = "CreateTopic"
+	RetrieveTopic      = "RetrieveTopic"
+	UpdateTopic        = "UpdateTopic"
+	UpdateOffset       = "UpdateOffset"
+	DeleteTopic        = "DeleteTopic"
+	ListTopicNames     = "ListTopicNames"
+	TopicExists        = "TopicExists"
+	TopicName          = "TopicName"
+	LookupTopicID      = "LookupTopicID"
+	ListAllTopics      = "ListAllTopics"
+	TopicInfo          = "TopicInfo"
+	UpdateTopicInfo    = "UpdateTopicInfo"
+	TopicKeys          = "TopicKeys"
+	UpdateTopicKeys    = "UpdateTopicKeys"
+	UseUnsealer        = "UseUnsealer"
+	ListGroups         = "ListGroups"
+	GetOrCreateGroup   = "GetOrCreateGroup"
+	UpdateGroup        = "UpdateGroup"
+	DeleteGroup        = "DeleteGroup"
+	AppliedIndex       = "AppliedIndex"
+	UpdateAppliedIndex = "UpdateAppliedIndex"
 )
 
 // Implements both a store.EventStore and a store.MetaStore for testing purposes.
 type Store struct {
 	sync.RWMutex
-	readonly          bool
-	calls             map[string]int
-	OnClose           func() error
-	OnReadOnly        func() bool
-	OnAllowedTopics   func(ulid.ULID) ([]ulid.ULID, error)
-	OnInsert          func(*api.EventWrapper) error
-	OnList            func(ulid.ULID) iterator.EventIterator
-	OnRetrieve        func(ulid.ULID, rlid.RLID) (*api.EventWrapper, error)
-	OnDestroy         func(ulid.ULID) error
-	OnIndash          func(ulid.ULID, []byte, rlid.RLID) error
-	OnUnhash          func(ulid.ULID, []byte) (*api.EventWrapper, error)
-	OnLoadIndash      func(ulid.ULID) iterator.IndashIterator
-	OnClearIndash     func(ulid.ULID) error
-	OnListTopics      func(ulid.ULID) iterator.TopicIterator
-	OnCreateTopic     func(*api.Topic) error
-	OnRetrieveTopic   func(topicID ulid.ULID) (*api.Topic, error)
-	OnUpdateTopic     func(*api.Topic) error
-	OnDeleteTopic     func(topicID ulid.ULID) error
-	OnListTopicNames  func(ulid.ULID) iterator.TopicNamesIterator
-	OnTopicExists     func(*api.TopicName) (*api.TopicExistsInfo, error)
-	OnTopicName       func(ulid.ULID) (string, error)
-	OnLookupTopicID   func(string, ulid.ULID) (ulid.ULID, error)
-	OnListAllTopics   func() iterator.TopicIterator
-	OnTopicInfo       func(ulid.ULID) (*api.TopicInfo, error)
-	OnUpdateTopicInfo func(*api.TopicInfo) error
+	readonly             bool
+	calls                map[string]int
+	OnClose              func() error
+	OnReadOnly           func() bool
+	OnAllowedTopics      func(ulid.ULID) ([]ulid.ULID, error)
+	OnInsert             func(*api.EventWrapper) error
+	OnList               func(ulid.ULID) iterator.EventIterator
+	OnRetrieve           func(ulid.ULID, rlid.RLID) (*api.EventWrapper, error)
+	OnExpire             func(ulid.ULID, rlid.RLID) error
+	OnDeleteEvents       func(ulid.ULID, ...rlid.RLID) error
+	OnDestroy            func(ulid.ULID) error
+	OnIndash             func(ulid.ULID, []byte, rlid.RLID) error
+	OnUnhash             func(ulid.ULID, []byte) (*api.EventWrapper, error)
+	OnLoadIndash         func(ulid.ULID) iterator.IndashIterator
+	OnClearIndash        func(ulid.ULID) error
+	OnListTopics         func(ulid.ULID) iterator.TopicIterator
+	OnCreateTopic        func(*api.Topic) error
+	OnRetrieveTopic      func(topicID ulid.ULID) (*api.Topic, error)
+	OnUpdateTopic        func(*api.Topic) error
+	OnUpdateOffset       func(ulid.ULID, uint64, rlid.RLID) error
+	OnDeleteTopic        func(topicID ulid.ULID) error
+	OnListTopicNames     func(ulid.ULID) iterator.TopicNamesIterator
+	OnTopicExists        func(*api.TopicName) (*api.TopicExistsInfo, error)
+	OnTopicName          func(ulid.ULID) (string, error)
+	OnLookupTopicID      func(string, ulid.ULID) (ulid.ULID, error)
+	OnListAllTopics      func() iterator.TopicIterator
+	OnTopicInfo          func(ulid.ULID) (*api.TopicInfo, error)
+	OnUpdateTopicInfo    func(*api.TopicInfo) error
+	OnTopicKeys          func(ulid.ULID) (*api.TopicKeys, error)
+	OnUpdateTopicKeys    func(*api.TopicKeys) error
+	OnUseUnsealer        func(events.Unsealer)
+	OnListGroups         func(ulid.ULID) iterator.GroupIterator
+	OnGetOrCreateGroup   func(*api.ConsumerGroup) (bool, error)
+	OnUpdateGroup        func(*api.ConsumerGroup) error
+	OnDeleteGroup        func(*api.ConsumerGroup) error
+	OnAppliedIndex       func() (uint64, error)
+	OnUpdateAppliedIndex func(uint64, bool) error
 }
 
 func Open(conf config.StorageConfig) (*Store, error) {
@@ -93,6 +119,8 @@ func (s *Store) Reset() {
 	s.OnInsert = nil
 	s.OnList = nil
 	s.OnRetrieve = nil
+	s.OnExpire = nil
+	s.OnDeleteEvents = nil
 	s.OnDestroy = nil
 	s.OnIndash = nil
 	s.OnUnhash = nil
@@ -103,6 +131,7 @@ func (s *Store) Reset() {
 	s.OnCreateTopic = nil
 	s.OnRetrieveTopic = nil
 	s.OnUpdateTopic = nil
+	s.OnUpdateOffset = nil
 	s.OnDeleteTopic = nil
 	s.OnListTopicNames = nil
 	s.OnTopicExists = nil
@@ -111,6 +140,15 @@ func (s *Store) Reset() {
 	s.OnListAllTopics = nil
 	s.OnTopicInfo = nil
 	s.OnUpdateTopicInfo = nil
+	s.OnTopicKeys = nil
+	s.OnUpdateTopicKeys = nil
+	s.OnUseUnsealer = nil
+	s.OnListGroups = nil
+	s.OnGetOrCreateGroup = nil
+	s.OnUpdateGroup = nil
+	s.OnDeleteGroup = nil
+	s.OnAppliedIndex = nil
+	s.OnUpdateAppliedIndex = nil
 }
 
 func (s *Store) Calls(call string) int {
@@ -199,6 +237,23 @@ func (s *Store) UseFixture(call, path string) (err error) {
 		s.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) {
 			return out, nil
 		}
+	case ListGroups:
+		var out []*api.ConsumerGroup
+		if out, err = UnmarshalGroupList(data); err != nil {
+			return err
+		}
+		s.OnListGroups = func(ulid.ULID) iterator.GroupIterator {
+			return NewGroupIterator(out)
+		}
+	case GetOrCreateGroup:
+		out := &api.ConsumerGroup{}
+		if err = jsonpb.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("could not unmarshal json into %T: %v", out, err)
+		}
+		s.OnGetOrCreateGroup = func(in *api.ConsumerGroup) (bool, error) {
+			proto.Merge(in, out)
+			return false, nil
+		}
 	default:
 		return fmt.Errorf("unhandled call %q", call)
 	}
@@ -219,6 +274,10 @@ func (s *Store) UseError(call string, err error) error {
 		s.OnRetrieve = func(ulid.ULID, rlid.RLID) (*api.EventWrapper, error) {
 			return nil, err
 		}
+	case Expire:
+		s.OnExpire = func(ulid.ULID, rlid.RLID) error { return err }
+	case DeleteEvents:
+		s.OnDeleteEvents = func(ulid.ULID, ...rlid.RLID) error { return err }
 	case Destroy:
 		s.OnDestroy = func(ulid.ULID) error { return err }
 	case Indash:
@@ -247,6 +306,8 @@ func (s *Store) UseError(call string, err error) error {
 		s.OnRetrieveTopic = func(ulid.ULID) (*api.Topic, error) { return nil, err }
 	case UpdateTopic:
 		s.OnUpdateTopic = func(*api.Topic) error { return err }
+	case UpdateOffset:
+		s.OnUpdateOffset = func(ulid.ULID, uint64, rlid.RLID) error { return err }
 	case DeleteTopic:
 		s.OnDeleteTopic = func(ulid.ULID) error { return err }
 	case TopicName:
@@ -261,6 +322,24 @@ func (s *Store) UseError(call string, err error) error {
 		s.OnTopicInfo = func(ulid.ULID) (*api.TopicInfo, error) { return nil, err }
 	case UpdateTopicInfo:
 		s.OnUpdateTopicInfo = func(*api.TopicInfo) error { return err }
+	case TopicKeys:
+		s.OnTopicKeys = func(ulid.ULID) (*api.TopicKeys, error) { return nil, err }
+	case UpdateTopicKeys:
+		s.OnUpdateTopicKeys = func(*api.TopicKeys) error { return err }
+	case ListGroups:
+		s.OnListGroups = func(ulid.ULID) iterator.GroupIterator {
+			return NewGroupErrorIterator(err)
+		}
+	case GetOrCreateGroup:
+		s.OnGetOrCreateGroup = func(*api.ConsumerGroup) (bool, error) { return false, err }
+	case UpdateGroup:
+		s.OnUpdateGroup = func(*api.ConsumerGroup) error { return err }
+	case DeleteGroup:
+		s.OnDeleteGroup = func(*api.ConsumerGroup) error { return err }
+	case AppliedIndex:
+		s.OnAppliedIndex = func() (uint64, error) { return 0, err }
+	case UpdateAppliedIndex:
+		s.OnUpdateAppliedIndex = func(uint64, bool) error { return err }
 	default:
 		return fmt.Errorf("unhandled call %q", call)
 	}
@@ -304,6 +383,22 @@ func (s *Store) Retrieve(topicID ulid.ULID, eventID rlid.RLID) (*api.EventWrappe
 	return nil, errors.New("mock database cannot retrieve event")
 }
 
+func (s *Store) Expire(topicID ulid.ULID, through rlid.RLID) error {
+	s.incrCalls(Expire)
+	if s.OnExpire != nil {
+		return s.OnExpire(topicID, through)
+	}
+	return errors.New("mock database cannot expire events in topic")
+}
+
+func (s *Store) DeleteEvents(topicID ulid.ULID, eventIDs ...rlid.RLID) error {
+	s.incrCalls(DeleteEvents)
+	if s.OnDeleteEvents != nil {
+		return s.OnDeleteEvents(topicID, eventIDs...)
+	}
+	return errors.New("mock database cannot delete events in topic")
+}
+
 func (s *Store) Destroy(topicID ulid.ULID) error {
 	s.incrCalls(Destroy)
 	if s.OnDestroy != nil {
@@ -341,6 +436,13 @@ func (s *Store) ClearIndash(topicID ulid.ULID) error {
 	return errors.New("mock database cannot clear indash")
 }
 
+func (s *Store) UseUnsealer(unsealer events.Unsealer) {
+	s.incrCalls(UseUnsealer)
+	if s.OnUseUnsealer != nil {
+		s.OnUseUnsealer(unsealer)
+	}
+}
+
 func (s *Store) AllowedTopics(projectID ulid.ULID) ([]ulid.ULID, error) {
 	s.incrCalls(AllowedTopics)
 	if s.OnAllowedTopics != nil {
@@ -378,6 +480,14 @@ func (s *Store) UpdateTopic(topic *api.Topic) error {
 	return errors.New("mock database cannot update topic")
 }
 
+func (s *Store) UpdateOffset(topicID ulid.ULID, offset uint64, eventID rlid.RLID) error {
+	s.incrCalls(UpdateOffset)
+	if s.OnUpdateOffset != nil {
+		return s.OnUpdateOffset(topicID, offset, eventID)
+	}
+	return errors.New("mock database cannot update topic offset")
+}
+
 func (s *Store) DeleteTopic(topicID ulid.ULID) error {
 	s.incrCalls(DeleteTopic)
 	if s.OnDeleteTopic != nil {
@@ -436,6 +546,67 @@ func (s *Store) UpdateTopicInfo(info *api.TopicInfo) error {
 	return errors.New("mock database cannot update topic info")
 }
 
+func (s *Store) TopicKeys(topicID ulid.ULID) (*api.TopicKeys, error) {
+	s.incrCalls(TopicKeys)
+	if s.OnTopicKeys != nil {
+		return s.OnTopicKeys(topicID)
+	}
+	return nil, errors.New("mock database cannot lookup topic keys")
+}
+
+func (s *Store) UpdateTopicKeys(keys *api.TopicKeys) error {
+	s.incrCalls(UpdateTopicKeys)
+	if s.OnUpdateTopicKeys != nil {
+		return s.OnUpdateTopicKeys(keys)
+	}
+	return errors.New("mock database cannot update topic keys")
+}
+
+func (s *Store) ListGroups(projectID ulid.ULID) iterator.GroupIterator {
+	s.incrCalls(ListGroups)
+	return s.OnListGroups(projectID)
+}
+
+func (s *Store) GetOrCreateGroup(group *api.ConsumerGroup) (bool, error) {
+	s.incrCalls(GetOrCreateGroup)
+	if s.OnGetOrCreateGroup != nil {
+		return s.OnGetOrCreateGroup(group)
+	}
+	return false, errors.New("mock database cannot get or create group")
+}
+
+func (s *Store) UpdateGroup(group *api.ConsumerGroup) error {
+	s.incrCalls(UpdateGroup)
+	if s.OnUpdateGroup != nil {
+		return s.OnUpdateGroup(group)
+	}
+	return errors.New("mock database cannot update group")
+}
+
+func (s *Store) DeleteGroup(group *api.ConsumerGroup) error {
+	s.incrCalls(DeleteGroup)
+	if s.OnDeleteGroup != nil {
+		return s.OnDeleteGroup(group)
+	}
+	return errors.New("mock database cannot delete group")
+}
+
+func (s *Store) AppliedIndex() (uint64, error) {
+	s.incrCalls(AppliedIndex)
+	if s.OnAppliedIndex != nil {
+		return s.OnAppliedIndex()
+	}
+	return 0, errors.New("mock database cannot get applied index")
+}
+
+func (s *Store) UpdateAppliedIndex(index uint64, sync bool) error {
+	s.incrCalls(UpdateAppliedIndex)
+	if s.OnUpdateAppliedIndex != nil {
+		return s.OnUpdateAppliedIndex(index, sync)
+	}
+	return errors.New("mock database cannot update applied index")
+}
+
 func (s *Store) incrCalls(call string) {
 	s.Lock()
 	defer s.Unlock()
@@ -57,7 +57,10 @@ type EventStore interface {
 	Insert(*api.EventWrapper) error
 	List(topicID ulid.ULID) iterator.EventIterator
 	Retrieve(topicID ulid.ULID, eventID rlid.RLID) (*api.EventWrapper, error)
+	Expire(topicID ulid.ULID, through rlid.RLID) error
+	DeleteEvents(topicID ulid.ULID, eventIDs ...rlid.RLID) error
 	Destroy(topicID ulid.ULID) error
+	UseUnsealer(events.Unsealer)
 }
 
 type EventHashStore interface {
@@ -73,6 +76,9 @@ type MetaStore interface {
 	TopicStore
 	TopicNamesStore
 	TopicInfoStore
+	TopicKeyStore
+	GroupStore
+	ConsensusStore
 }
 
 type TopicStore interface {
@@ -81,6 +87,7 @@ type TopicStore interface {
 	CreateTopic(*api.Topic) error
 	RetrieveTopic(topicID ulid.ULID) (*api.Topic, error)
 	UpdateTopic(*api.Topic) error
+	UpdateOffset(topicID ulid.ULID, offset uint64, eventID rlid.RLID) error
 	DeleteTopic(topicID ulid.ULID) error
 }
 
@@ -97,6 +104,16 @@ type TopicInfoStore interface {
 	UpdateTopicInfo(*api.TopicInfo) error
 }
 
+type TopicKeyStore interface {
+	TopicKeys(topicID ulid.ULID) (*api.TopicKeys, error)
+	UpdateTopicKeys(*api.TopicKeys) error
+}
+
+type ConsensusStore interface {
+	AppliedIndex() (uint64, error)
+	UpdateAppliedIndex(index uint64, sync bool) error
+}
+
 type GroupStore interface {
 	ListGroups(projectID ulid.ULID) iterator.GroupIterator
 	GetOrCreateGroup(*api.ConsumerGroup) (bool, error)
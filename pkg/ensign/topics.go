@@ -2,7 +2,6 @@ package ensign
 
 import (
 	"context"
-	goerrs "errors"
 	"fmt"
 	"strings"
 	"time"
@@ -19,6 +18,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // ListTopics associated with the project ID in the claims of the request. This unary
@@ -107,6 +107,41 @@ func (s *Server) CreateTopic(ctx context.Context, in *api.Topic) (_ *api.Topic,
 	// NOTE: this will also convert a nil deduplication policy into the default one.
 	in.Deduplication = in.Deduplication.Normalize()
 
+	// Topics do not have retention or compaction policies unless specified on creation.
+	if in.Retention != nil {
+		if err = in.Retention.Validate(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		in.Retention = in.Retention.Normalize()
+	}
+
+	if in.Compaction != nil {
+		if err = in.Compaction.Validate(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		in.Compaction = in.Compaction.Normalize()
+	}
+
+	// Each node would expire and compact the events it has replicated independently,
+	// so the policies cannot be used when events are replicated through consensus.
+	if s.replica != nil && (in.Retention.Enabled() || in.Compaction.GetEnabled()) {
+		return nil, status.Error(codes.FailedPrecondition, "retention and compaction policies are not supported with consensus")
+	}
+
+	// Events in the topic are stored uncompressed unless a compression policy is set.
+	if in.Compression != nil {
+		if err = in.Compression.Validate(); err != nil {
+			return nil, compressionError(err)
+		}
+		in.Compression = in.Compression.Normalize()
+	}
+
+	// Topics can only be encrypted at rest if the server has master keys to wrap the
+	// data keys of the topic with.
+	if in.Encryption.GetEnabled() && s.keys == nil {
+		return nil, status.Error(codes.FailedPrecondition, "encryption at rest is not enabled on this server")
+	}
+
 	// HACK: temporarily setting the topic status to ready until we have placement
 	// TODO: set the topic status as pending
 	in.Status = api.TopicState_READY
@@ -127,6 +162,9 @@ func (s *Server) CreateTopic(ctx context.Context, in *api.Topic) (_ *api.Topic,
 
 	// TODO: send topic to placement service
 
+	// Notify open streams in the project that the topic is available
+	s.notifyTopic(in)
+
 	// The store method updates the in reference in place, preventing an allocation.
 	return in, nil
 }
@@ -268,12 +306,12 @@ func (s *Server) DeleteTopic(ctx context.Context, in *api.TopicMod) (out *api.To
 	}
 
 	// TODO: send topic deletion to the placement service
-	// TODO: update broker to prevent any additional writes.
 
 	out = &api.TopicStatus{Id: topicID.String()}
 	switch in.Operation {
 	case api.TopicMod_ARCHIVE:
 		topic.Readonly = true
+		topic.Status = api.TopicState_READONLY
 		out.State = api.TopicState_READONLY
 
 		if err = s.meta.UpdateTopic(topic); err != nil {
@@ -281,31 +319,29 @@ func (s *Server) DeleteTopic(ctx context.Context, in *api.TopicMod) (out *api.To
 			return nil, status.Error(codes.Internal, "could not process delete topic request")
 		}
 
+		// Stop any additional writes from publishers that are already connected
+		if err = s.broker.UpdateTopic(topic); err != nil {
+			sentry.Warn(ctx).Err(err).ULID("topic_id", topicID).Msg("could not update topic state in the broker")
+		}
+		s.notifyTopic(topic)
+
 	case api.TopicMod_DESTROY:
 		// Update topic with the deleting state
+		topic.Status = api.TopicState_DELETING
 		out.State = api.TopicState_DELETING
 		if err = s.meta.UpdateTopic(topic); err != nil {
 			sentry.Error(ctx).Err(err).Msg("could not update topic as deleting")
 			return nil, status.Error(codes.Internal, "could not process delete topic request")
 		}
 
-		// Queue a job to delete all events associated with the topic then the topic.
-		s.tasks.Queue(radish.TaskFunc(func(ctx context.Context) error {
-			var errs error
-			if err = s.data.Destroy(topicID); err != nil {
-				errs = goerrs.Join(errs, err)
-			}
-
-			if err = s.meta.DeleteTopic(topicID); err != nil {
-				errs = goerrs.Join(errs, err)
-			}
+		// Stop any additional writes and close the subscriptions to the topic
+		if err = s.broker.UpdateTopic(topic); err != nil {
+			sentry.Warn(ctx).Err(err).ULID("topic_id", topicID).Msg("could not update topic state in the broker")
+		}
+		s.notifyTopic(topic)
 
-			return errs
-		}), radish.WithErrorf("could not destroy topic %s", topicID),
-			radish.WithRetries(3),
-			radish.WithBackoff(backoff.NewConstantBackOff(10*time.Minute)),
-			radish.WithTimeout(30*time.Minute),
-		)
+		// Queue a job to delete all of the data associated with the topic then the topic.
+		s.DestroyTopic(topic)
 	}
 
 	return out, nil
@@ -339,7 +375,7 @@ func (s *Server) SetTopicPolicy(ctx context.Context, in *api.TopicPolicy) (out *
 	}
 
 	// If no policy change has been specified, return invalid argument
-	if in.DeduplicationPolicy.Strategy == api.Deduplication_UNKNOWN && in.ShardingStrategy == api.ShardingStrategy_UNKNOWN {
+	if in.DeduplicationPolicy.GetStrategy() == api.Deduplication_UNKNOWN && in.ShardingStrategy == api.ShardingStrategy_UNKNOWN && in.RetentionPolicy == nil && in.CompactionPolicy == nil && in.CompressionPolicy == nil && in.EncryptionPolicy == nil && !in.RotateKey {
 		return nil, status.Error(codes.InvalidArgument, "no policies defined to set on topic")
 	}
 
@@ -390,8 +426,92 @@ func (s *Server) SetTopicPolicy(ctx context.Context, in *api.TopicPolicy) (out *
 		return nil, status.Error(codes.Unimplemented, "changing the sharding strategy of a topic is currently not supported")
 	}
 
-	// If there is no change to the deduplication strategy, then return READY
-	if topic.Deduplication.Equals(in.DeduplicationPolicy) {
+	// Validate the retention policy; an empty retention policy removes the limits.
+	var policiesChanged bool
+	if in.RetentionPolicy != nil {
+		if err = in.RetentionPolicy.Validate(); err != nil {
+			log.Debug().Err(err).Msg("invalid retention policy")
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if !topic.Retention.Equals(in.RetentionPolicy) {
+			topic.Retention = in.RetentionPolicy.Normalize()
+			policiesChanged = true
+		}
+	}
+
+	// Validate the compaction policy; a policy that is not enabled stops compaction.
+	if in.CompactionPolicy != nil {
+		if err = in.CompactionPolicy.Validate(); err != nil {
+			log.Debug().Err(err).Msg("invalid compaction policy")
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if !topic.Compaction.Equals(in.CompactionPolicy) {
+			topic.Compaction = in.CompactionPolicy.Normalize()
+			policiesChanged = true
+		}
+	}
+
+	// Retention and compaction cannot be used with consensus, as when topics are created.
+	if s.replica != nil && (in.RetentionPolicy.Enabled() || in.CompactionPolicy.GetEnabled()) {
+		return nil, status.Error(codes.FailedPrecondition, "retention and compaction policies are not supported with consensus")
+	}
+
+	// Validate the compression policy; changing the policy only affects events that
+	// are committed after the change, existing events are not recompressed.
+	if in.CompressionPolicy != nil {
+		if err = in.CompressionPolicy.Validate(); err != nil {
+			log.Debug().Err(err).Msg("invalid compression policy")
+			return nil, compressionError(err)
+		}
+
+		if !topic.Compression.Equals(in.CompressionPolicy) {
+			topic.Compression = in.CompressionPolicy.Normalize()
+			policiesChanged = true
+		}
+	}
+
+	// Like compression, the encryption policy only applies to events committed after
+	// the change; disabling encryption does not decrypt the events already on disk.
+	if in.EncryptionPolicy != nil {
+		if in.EncryptionPolicy.Enabled && s.keys == nil {
+			return nil, status.Error(codes.FailedPrecondition, "encryption at rest is not enabled on this server")
+		}
+
+		if topic.Encryption.GetEnabled() != in.EncryptionPolicy.Enabled {
+			topic.Encryption = in.EncryptionPolicy
+			policiesChanged = true
+		}
+	}
+
+	// Rotate the data key so that events committed from now on are sealed with a new key.
+	if in.RotateKey {
+		if s.keys == nil || !topic.Encryption.GetEnabled() {
+			return nil, status.Error(codes.FailedPrecondition, "cannot rotate the key of a topic that is not encrypted at rest")
+		}
+
+		if err = s.keys.Rotate(topicID); err != nil {
+			sentry.Error(ctx).Err(err).ULID("topic_id", topicID).Msg("could not rotate topic data key")
+			return nil, status.Error(codes.Internal, "could not process set topic policy request")
+		}
+	}
+
+	// If there is no change to the deduplication strategy then the topic does not have
+	// to be rehashed; the retention and compaction policies are enforced the next time
+	// the topic info is gathered so the topic remains READY.
+	if in.DeduplicationPolicy.GetStrategy() == api.Deduplication_UNKNOWN || topic.Deduplication.Equals(in.DeduplicationPolicy) {
+		if policiesChanged {
+			if err = s.meta.UpdateTopic(topic); err != nil {
+				sentry.Error(ctx).Err(err).Msg("could not update topic with policy")
+				return nil, status.Error(codes.Internal, "could not process set topic policy request")
+			}
+
+			// Events committed from now on are compressed with the new policy.
+			if err = s.broker.UpdateTopic(topic); err != nil {
+				sentry.Warn(ctx).Err(err).ULID("topic_id", topicID).Msg("could not update topic state in the broker")
+			}
+		}
 		return &api.TopicStatus{Id: topicID.String(), State: topic.Status}, nil
 	}
 
@@ -401,6 +521,12 @@ func (s *Server) SetTopicPolicy(ctx context.Context, in *api.TopicPolicy) (out *
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	// The events of the topic are rehashed by the node that handles the request, which
+	// would rewrite its events differently from the rest of the quorum.
+	if s.replica != nil {
+		return nil, status.Error(codes.FailedPrecondition, "changing the deduplication policy of a topic is not supported with consensus")
+	}
+
 	// Update the topic with the new policy
 	policy := in.DeduplicationPolicy.Normalize()
 	topic.Deduplication = policy
@@ -411,7 +537,12 @@ func (s *Server) SetTopicPolicy(ctx context.Context, in *api.TopicPolicy) (out *
 		return nil, status.Error(codes.Internal, "could not process set topic policy request")
 	}
 
-	// TODO: Update the broker with the new policy
+	// Stop accepting events while the topic is pending and stop deduplicating with the
+	// old policy; the broker will not deduplicate until the rehash is complete.
+	if err = s.broker.UpdateTopic(topic); err != nil {
+		sentry.Warn(ctx).Err(err).ULID("topic_id", topicID).Msg("could not update topic state in the broker")
+	}
+	s.broker.ResetDeduplication(topicID)
 
 	// Update duplicates in the topic info and rehash the events.
 	s.tasks.Queue(radish.TaskFunc(func(ctx context.Context) error {
@@ -430,6 +561,12 @@ func (s *Server) SetTopicPolicy(ctx context.Context, in *api.TopicPolicy) (out *
 		if err := s.meta.UpdateTopic(topic); err != nil {
 			return err
 		}
+
+		// Accept events again and deduplicate them with the new policy
+		if err := s.broker.UpdateTopic(topic); err != nil {
+			return err
+		}
+		s.broker.ResetDeduplication(topicID)
 		return nil
 	}), radish.WithErrorf("could not complete rehash of %s", topicID),
 		radish.WithRetries(1),
@@ -535,3 +672,164 @@ func (s *Server) TopicExists(ctx context.Context, in *api.TopicName) (out *api.T
 	}
 	return out, nil
 }
+
+// DestroyTopic queues a background task that deletes all of the data associated with a
+// topic that is in the DELETING state: its events, meta events, and index hashes, the
+// offsets of any consumer groups in the topic, and finally the topic record along with
+// its topic info, which frees the topic name to be used again. The topic remains in the
+// DELETING state until all of its data has been removed so that the task can be resumed
+// if it fails or the server restarts before it completes. Each step can be repeated
+// without error so the entire task is retried if any of the steps fail. The progress of
+// the task is recorded on the topic as each step is completed.
+func (s *Server) DestroyTopic(topic *api.Topic) {
+	topicID, err := topic.ParseTopicID()
+	if err != nil {
+		sentry.Error(nil).Err(err).Msg("could not parse topic id to destroy topic")
+		return
+	}
+
+	projectID, err := topic.ParseProjectID()
+	if err != nil {
+		sentry.Error(nil).Err(err).ULID("topic_id", topicID).Msg("could not parse project id to destroy topic")
+		return
+	}
+
+	// The topic is updated with the progress of the task from a copy so that the task
+	// does not modify the topic of the caller.
+	topic = proto.Clone(topic).(*api.Topic)
+	progress := func(steps float32) error {
+		topic.Progress = steps / destroySteps
+		return s.meta.UpdateTopic(topic)
+	}
+
+	s.tasks.Queue(radish.TaskFunc(func(ctx context.Context) (err error) {
+		log.Info().Str("topic_id", topicID.String()).Msg("destroying topic")
+		if err = progress(0); err != nil {
+			return fmt.Errorf("could not update destroy progress: %w", err)
+		}
+
+		if err = s.data.Destroy(topicID); err != nil {
+			return fmt.Errorf("could not destroy events: %w", err)
+		}
+		log.Debug().Str("topic_id", topicID.String()).Msg("topic events destroyed")
+
+		if err = progress(1); err != nil {
+			return fmt.Errorf("could not update destroy progress: %w", err)
+		}
+
+		if err = s.groups.DeleteTopic(projectID, topicID); err != nil {
+			return fmt.Errorf("could not delete consumer group offsets: %w", err)
+		}
+		log.Debug().Str("topic_id", topicID.String()).Msg("topic consumer group offsets deleted")
+
+		if err = progress(2); err != nil {
+			return fmt.Errorf("could not update destroy progress: %w", err)
+		}
+
+		if err = s.meta.DeleteTopic(topicID); err != nil {
+			return fmt.Errorf("could not delete topic: %w", err)
+		}
+
+		if s.keys != nil {
+			s.keys.Forget(topicID)
+		}
+
+		s.broker.DeleteTopic(topicID)
+		log.Info().Str("topic_id", topicID.String()).Msg("topic destroyed")
+		return nil
+	}), radish.WithErrorf("could not destroy topic %s", topicID),
+		radish.WithRetries(3),
+		radish.WithBackoff(backoff.NewConstantBackOff(10*time.Minute)),
+		radish.WithTimeout(30*time.Minute),
+	)
+}
+
+// The number of steps in the destroy task: destroying the events, deleting the consumer
+// group offsets, and deleting the topic.
+const destroySteps = 3
+
+// Queues destroy tasks for any topics that were left in the DELETING state, e.g. because
+// the server was stopped before the topic was completely destroyed.
+func (s *Server) resumeDestroyTopics() (err error) {
+	topics := s.meta.ListAllTopics()
+	defer topics.Release()
+
+	for topics.Next() {
+		var topic *api.Topic
+		if topic, err = topics.Topic(); err != nil {
+			return err
+		}
+
+		if topic.Status == api.TopicState_DELETING {
+			s.DestroyTopic(topic)
+		}
+	}
+	return topics.Error()
+}
+
+// RewrapTopicKeys rewraps the data keys of every topic that are wrapped by a master key
+// other than the active master key so that master keys can be rotated. Topics without
+// data keys are skipped.
+func (s *Server) RewrapTopicKeys(ctx context.Context) (err error) {
+	topics := s.meta.ListAllTopics()
+	defer topics.Release()
+
+	var nTopics, nKeys int
+	for topics.Next() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		var topic *api.Topic
+		if topic, err = topics.Topic(); err != nil {
+			return err
+		}
+
+		var topicID ulid.ULID
+		if topicID, err = topic.ParseTopicID(); err != nil {
+			return err
+		}
+
+		var rewrapped int
+		if rewrapped, err = s.keys.Rewrap(topicID); err != nil {
+			return fmt.Errorf("could not rewrap data keys of topic %s: %w", topicID, err)
+		}
+
+		if rewrapped > 0 {
+			nTopics++
+			nKeys += rewrapped
+		}
+	}
+
+	if err = topics.Error(); err != nil {
+		return err
+	}
+
+	log.Info().Int("topics", nTopics).Int("data_keys", nKeys).Msg("rewrapped topic data keys")
+	return nil
+}
+
+// Notify the open publish and subscribe streams of the topic's project that the state
+// of the topic has changed so they can update their topics without reconnecting.
+func (s *Server) notifyTopic(topic *api.Topic) {
+	projectID, err := topic.ParseProjectID()
+	if err != nil {
+		log.Warn().Err(err).Msg("could not parse project id to notify topic watchers")
+		return
+	}
+
+	s.watch.Notify(projectID, &api.TopicUpdate{
+		Name:    topic.Name,
+		TopicId: topic.Id,
+		State:   topic.Status,
+	})
+}
+
+// Returns the status error for an invalid compression policy; algorithms that are
+// defined by the API but not supported by the server are unimplemented.
+func compressionError(err error) error {
+	if errors.Is(err, api.ErrUnsupportedCompression) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
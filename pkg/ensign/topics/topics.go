@@ -61,6 +61,59 @@ func (g *NameGroup) AddTopic(topic *api.Topic) (err error) {
 	return g.Add(topic.Name, topicID)
 }
 
+// Remove the topic reference with the specified ID from the name group, returning false
+// if the topic is not in the group.
+func (g *NameGroup) Remove(topicID ulid.ULID) bool {
+	name, ok := g.ids[topicID]
+	if !ok {
+		return false
+	}
+
+	delete(g.ids, topicID)
+	delete(g.names, name)
+	return true
+}
+
+// Apply the topic update to the name group of topics that a stream can access. Topics
+// that are ready are added to the group if they match the filter, or if no filter is
+// specified, and topics that are being deleted are removed from the group. Archived
+// topics are not removed since they can still be read and publishers should be told
+// that the topic is archived rather than unknown. Returns true if the update is for a
+// topic in the group, including topics that were added or removed by the update.
+func (g *NameGroup) Apply(update *api.TopicUpdate, filter ...string) (_ bool, err error) {
+	var topicID ulid.ULID
+	if err = topicID.UnmarshalBinary(update.TopicId); err != nil {
+		return false, err
+	}
+
+	switch update.State {
+	case api.TopicState_READY:
+		if g.ContainsTopicID(topicID) {
+			return false, nil
+		}
+
+		if len(filter) > 0 {
+			topic := &NameGroup{}
+			if err = topic.Add(update.Name, topicID); err != nil {
+				return false, err
+			}
+
+			if topic.Filter(filter...).Length() == 0 {
+				return false, nil
+			}
+		}
+
+		if err = g.Add(update.Name, topicID); err != nil {
+			return false, err
+		}
+		return true, nil
+	case api.TopicState_DELETING:
+		return g.Remove(topicID), nil
+	default:
+		return g.ContainsTopicID(topicID), nil
+	}
+}
+
 // Contains checks if the string is contained by the name group. It first checks to see
 // if the string is a valid topic name, and if so it checks the names hash; then it
 // checks if the string is a parseable ulid, and if so it checks the ID field. Finally,
@@ -346,6 +346,82 @@ func TestAddTopicNameTwice(t *testing.T) {
 	require.Equal(t, 1, group.Length())
 }
 
+func TestRemoveTopic(t *testing.T) {
+	topicID := ulid.MustParse("01H78XH126J1XHRR2CAQBBT7RC")
+	group := &topics.NameGroup{}
+	require.False(t, group.Remove(topicID), "expected empty group not to contain topic")
+
+	require.NoError(t, group.Add("foo", topicID))
+	require.NoError(t, group.Add("bar", ulid.MustParse("01H78XT88RRYKX9SFQNN47B7WK")))
+	require.Equal(t, 2, group.Length())
+
+	require.True(t, group.Remove(topicID))
+	require.Equal(t, 1, group.Length())
+	require.False(t, group.Contains("foo"))
+	require.False(t, group.ContainsTopicID(topicID))
+	require.True(t, group.Contains("bar"))
+
+	// The name of a removed topic can be added again with a new ID
+	require.NoError(t, group.Add("foo", ulid.MustParse("01H78Y2J7KX4XPX4GJ6N1G8D0C")))
+	require.False(t, group.Remove(topicID))
+	require.Equal(t, 2, group.Length())
+}
+
+func TestApplyTopicUpdate(t *testing.T) {
+	topicA := ulid.MustParse("01H78XH126J1XHRR2CAQBBT7RC")
+	topicB := ulid.MustParse("01H78XT88RRYKX9SFQNN47B7WK")
+	update := func(name string, topicID ulid.ULID, state api.TopicState) *api.TopicUpdate {
+		return &api.TopicUpdate{Name: name, TopicId: topicID.Bytes(), State: state}
+	}
+
+	// Ready topics are added to a group without a filter
+	group := &topics.NameGroup{}
+	ok, err := group.Apply(update("foo", topicA, api.TopicState_READY))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, group.ContainsTopicID(topicA))
+
+	// Updates for topics already in the group do not modify it
+	ok, err = group.Apply(update("foo", topicA, api.TopicState_READY))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Archived topics are kept in the group
+	ok, err = group.Apply(update("foo", topicA, api.TopicState_READONLY))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, group.Length())
+
+	// Updates for archived topics that are not in the group are ignored
+	ok, err = group.Apply(update("bar", topicB, api.TopicState_READONLY))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Deleted topics are removed from the group
+	ok, err = group.Apply(update("foo", topicA, api.TopicState_DELETING))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, group.Length())
+
+	// Ready topics are only added if they match the filter by name or ID
+	ok, err = group.Apply(update("foo", topicA, api.TopicState_READY), "bar")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = group.Apply(update("bar", topicB, api.TopicState_READY), "bar")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = group.Apply(update("foo", topicA, api.TopicState_READY), "bar", topicA.String())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, group.Length())
+
+	// Invalid topic IDs cannot be applied
+	_, err = group.Apply(&api.TopicUpdate{Name: "baz", TopicId: []byte{42}, State: api.TopicState_READY})
+	require.Error(t, err)
+}
+
 func TestTopicIDs(t *testing.T) {
 	fixtures := []struct {
 		name    string
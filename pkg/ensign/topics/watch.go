@@ -0,0 +1,95 @@
+package topics
+
+import (
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rs/zerolog/log"
+)
+
+// The number of topic updates that can be queued for a watcher before updates to the
+// watcher are dropped.
+const WatchBufferSize = 64
+
+// Watchers allows long-lived streams to be notified when the topics in their project
+// are created, archived, or destroyed so that the streams can update the topics they
+// were opened with rather than having to be reconnected.
+type Watchers struct {
+	sync.RWMutex
+	projects map[ulid.ULID]map[*Watcher]struct{}
+}
+
+// A Watcher receives the topic updates for a single project. Every watcher must be
+// passed to Unwatch when the stream that created it closes.
+type Watcher struct {
+	projectID ulid.ULID
+	updates   chan *api.TopicUpdate
+}
+
+func NewWatchers() *Watchers {
+	return &Watchers{
+		projects: make(map[ulid.ULID]map[*Watcher]struct{}),
+	}
+}
+
+// Watch registers a watcher for the topics in the specified project.
+func (w *Watchers) Watch(projectID ulid.ULID) *Watcher {
+	watcher := &Watcher{
+		projectID: projectID,
+		updates:   make(chan *api.TopicUpdate, WatchBufferSize),
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.projects[projectID]; !ok {
+		w.projects[projectID] = make(map[*Watcher]struct{})
+	}
+	w.projects[projectID][watcher] = struct{}{}
+	return watcher
+}
+
+// Unwatch removes the watcher so that it no longer receives updates.
+func (w *Watchers) Unwatch(watcher *Watcher) {
+	w.Lock()
+	defer w.Unlock()
+
+	if watchers, ok := w.projects[watcher.projectID]; ok {
+		delete(watchers, watcher)
+		if len(watchers) == 0 {
+			delete(w.projects, watcher.projectID)
+		}
+	}
+}
+
+// Notify sends the topic update to every watcher of the project. Notify does not block;
+// if the updates of a watcher are not being received then the update is dropped for
+// that watcher.
+func (w *Watchers) Notify(projectID ulid.ULID, update *api.TopicUpdate) {
+	w.RLock()
+	defer w.RUnlock()
+
+	for watcher := range w.projects[projectID] {
+		select {
+		case watcher.updates <- update:
+		default:
+			log.Warn().Str("project_id", projectID.String()).Str("topic", update.Name).Msg("dropped topic update for watcher that is not receiving updates")
+		}
+	}
+}
+
+// Len returns the number of watchers that are currently registered.
+func (w *Watchers) Len() (n int) {
+	w.RLock()
+	defer w.RUnlock()
+	for _, watchers := range w.projects {
+		n += len(watchers)
+	}
+	return n
+}
+
+// Updates returns the channel that topic updates for the project are sent on.
+func (w *Watcher) Updates() <-chan *api.TopicUpdate {
+	return w.updates
+}
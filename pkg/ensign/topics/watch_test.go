@@ -0,0 +1,43 @@
+package topics_test
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	api "github.com/rotationalio/ensign/pkg/ensign/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/ensign/topics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchers(t *testing.T) {
+	projectA := ulid.MustParse("01H6PGFTK2X53RGG2KMSGR2M61")
+	projectB := ulid.MustParse("01H784KXZPKMDWRX2ZRP6FSXET")
+
+	watchers := topics.NewWatchers()
+	alice := watchers.Watch(projectA)
+	bob := watchers.Watch(projectA)
+	carol := watchers.Watch(projectB)
+	require.Equal(t, 3, watchers.Len())
+
+	// Updates are only sent to the watchers of the project
+	update := &api.TopicUpdate{Name: "testing.123", TopicId: ulid.Make().Bytes(), State: api.TopicState_READY}
+	watchers.Notify(projectA, update)
+	require.Equal(t, update, <-alice.Updates())
+	require.Equal(t, update, <-bob.Updates())
+	require.Empty(t, carol.Updates())
+
+	// Watchers that are removed no longer receive updates
+	watchers.Unwatch(bob)
+	watchers.Unwatch(carol)
+	require.Equal(t, 1, watchers.Len())
+
+	watchers.Notify(projectA, update)
+	require.Len(t, alice.Updates(), 1)
+	require.Empty(t, bob.Updates())
+
+	// Notify does not block if the watcher is not receiving updates
+	for i := 0; i < topics.WatchBufferSize*2; i++ {
+		watchers.Notify(projectA, update)
+	}
+	require.Len(t, alice.Updates(), topics.WatchBufferSize)
+}
@@ -3,6 +3,8 @@ package ensign_test
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/oklog/ulid/v2"
@@ -17,6 +19,7 @@ import (
 	"github.com/rotationalio/ensign/pkg/utils/ulids"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -176,6 +179,24 @@ func (s *serverTestSuite) TestCreateTopic() {
 	_, err = s.client.CreateTopic(context.Background(), topic, mock.PerRPCToken(token))
 	s.GRPCErrorIs(err, codes.InvalidArgument, "invalid project id field")
 
+	// Should not be able to create a topic with an invalid retention policy
+	topic.ProjectId = nil
+	topic.Retention = &api.Retention{MaxAge: durationpb.New(-24 * time.Hour)}
+	_, err = s.client.CreateTopic(context.Background(), topic, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, api.ErrInvalidMaxAge.Error())
+
+	// Should not be able to create a topic with an unsupported compression algorithm
+	topic.Retention = nil
+	topic.Compression = &api.Compression{Algorithm: api.Compression_BROTLI}
+	_, err = s.client.CreateTopic(context.Background(), topic, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.Unimplemented, api.ErrUnsupportedCompression.Error())
+
+	// Should not be able to create an encrypted topic if encryption at rest is not enabled
+	topic.Compression = nil
+	topic.Encryption = &api.EncryptionPolicy{Enabled: true}
+	_, err = s.client.CreateTopic(context.Background(), topic, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.FailedPrecondition, "encryption at rest is not enabled on this server")
+
 	// Unhandled database error should create an internal error
 	topic = &api.Topic{
 		ProjectId: ulids.MustBytes("01GQ7P8DNR9MR64RJR9D64FFNT"),
@@ -187,6 +208,133 @@ func (s *serverTestSuite) TestCreateTopic() {
 	s.GRPCErrorIs(err, codes.Internal, "could not process create topic request")
 }
 
+func (s *serverTestSuite) TestSetTopicPolicy() {
+	require := s.Require()
+	topicID := ulid.MustParse("01GTSMQ3V8ASAPNCFEN378T8RD")
+
+	s.store.OnRetrieveTopic = func(id ulid.ULID) (*api.Topic, error) {
+		if id.Compare(topicID) != 0 {
+			return nil, errors.ErrNotFound
+		}
+
+		return &api.Topic{
+			Id:            topicID.Bytes(),
+			ProjectId:     ulid.MustParse("01GTSMMC152Q95RD4TNYDFJGHT").Bytes(),
+			Name:          "testing.testapp.alerts",
+			Status:        api.TopicState_READY,
+			Deduplication: &api.Deduplication{Strategy: api.Deduplication_NONE, Offset: api.Deduplication_OFFSET_EARLIEST},
+			Retention:     &api.Retention{MaxEvents: 1000},
+		}, nil
+	}
+
+	var updated *api.Topic
+	s.store.OnUpdateTopic = func(topic *api.Topic) error {
+		updated = topic
+		return nil
+	}
+
+	claims := &tokens.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "DbIxBEtIUgNIClnFMDmvoZeMrLxUTJVa",
+		},
+		OrgID:       "01GKHJRF01YXHZ51YMMKV3RCMK",
+		ProjectID:   "01GTSMMC152Q95RD4TNYDFJGHT",
+		Permissions: []string{permissions.EditTopics},
+	}
+
+	token, err := s.quarterdeck.CreateAccessToken(claims)
+	require.NoError(err, "could not create access token for request")
+
+	// Should not be able to set an invalid retention policy
+	request := &api.TopicPolicy{
+		Id:              topicID.String(),
+		RetentionPolicy: &api.Retention{MaxAge: durationpb.New(-1 * time.Hour)},
+	}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, api.ErrInvalidMaxAge.Error())
+	require.Equal(0, s.store.Calls(store.UpdateTopic))
+
+	// If the retention policy is unchanged the topic should not be updated
+	request.RetentionPolicy = &api.Retention{MaxEvents: 1000}
+	out, err := s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	require.NoError(err, "could not set topic policy")
+	require.Equal(api.TopicState_READY, out.State)
+	require.Equal(0, s.store.Calls(store.UpdateTopic))
+
+	// Setting the retention policy should update the topic without making it pending
+	request.RetentionPolicy = &api.Retention{MaxAge: durationpb.New(30 * 24 * time.Hour)}
+	out, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	require.NoError(err, "could not set topic policy")
+	require.Equal(topicID.String(), out.Id)
+	require.Equal(api.TopicState_READY, out.State)
+	require.Equal(1, s.store.Calls(store.UpdateTopic))
+	require.Equal(api.TopicState_READY, updated.Status)
+	require.True(updated.Retention.Equals(request.RetentionPolicy), "expected retention policy to be replaced")
+
+	// An empty retention policy should remove the retention limits
+	request.RetentionPolicy = &api.Retention{}
+	out, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	require.NoError(err, "could not set topic policy")
+	require.Equal(api.TopicState_READY, out.State)
+	require.Equal(2, s.store.Calls(store.UpdateTopic))
+	require.False(updated.Retention.Enabled(), "expected retention policy to be removed")
+
+	// Should not be able to set an invalid compaction policy
+	request.RetentionPolicy = nil
+	request.CompactionPolicy = &api.Compaction{Enabled: true, TombstoneGracePeriod: durationpb.New(-1 * time.Hour)}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, api.ErrInvalidGracePeriod.Error())
+	require.Equal(2, s.store.Calls(store.UpdateTopic))
+
+	// Enabling compaction should update the topic without making it pending
+	request.CompactionPolicy = &api.Compaction{Enabled: true}
+	out, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	require.NoError(err, "could not set topic policy")
+	require.Equal(api.TopicState_READY, out.State)
+	require.Equal(3, s.store.Calls(store.UpdateTopic))
+	require.True(updated.Compaction.GetEnabled(), "expected compaction to be enabled")
+	require.Equal(api.DefaultTombstoneGracePeriod, updated.Compaction.GracePeriod())
+
+	// Should not be able to set an invalid or unsupported compression policy
+	request.CompactionPolicy = nil
+	request.CompressionPolicy = &api.Compression{Algorithm: api.Compression_GZIP, Level: 12}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.InvalidArgument, api.ErrInvalidCompressionLevel.Error())
+
+	request.CompressionPolicy = &api.Compression{Algorithm: api.Compression_BROTLI}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.Unimplemented, api.ErrUnsupportedCompression.Error())
+	require.Equal(3, s.store.Calls(store.UpdateTopic))
+
+	// Setting the compression policy should update the topic without making it pending
+	request.CompressionPolicy = &api.Compression{Algorithm: api.Compression_GZIP}
+	out, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	require.NoError(err, "could not set topic policy")
+	require.Equal(api.TopicState_READY, out.State)
+	require.Equal(4, s.store.Calls(store.UpdateTopic))
+	require.Equal(api.Compression_GZIP, updated.Compression.GetAlgorithm())
+
+	// Should not be able to encrypt a topic or rotate its key if encryption at rest is
+	// not enabled on the server.
+	request.CompressionPolicy = nil
+	request.EncryptionPolicy = &api.EncryptionPolicy{Enabled: true}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.FailedPrecondition, "encryption at rest is not enabled on this server")
+
+	request.EncryptionPolicy = nil
+	request.RotateKey = true
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.FailedPrecondition, "cannot rotate the key of a topic that is not encrypted at rest")
+	require.Equal(4, s.store.Calls(store.UpdateTopic))
+
+	// Database errors should return an internal error
+	s.store.UseError(store.UpdateTopic, errors.ErrNotFound)
+	request.RotateKey = false
+	request.RetentionPolicy = &api.Retention{MaxBytes: 1 << 30}
+	_, err = s.client.SetTopicPolicy(context.Background(), request, mock.PerRPCToken(token))
+	s.GRPCErrorIs(err, codes.Internal, "could not process set topic policy request")
+}
+
 func (s *serverTestSuite) TestRetrieveTopic() {
 	require := s.Require()
 
@@ -454,7 +602,7 @@ func (s *serverTestSuite) TestDeleteTopic_Archive() {
 
 	// Happy path: should be able to mark topic as read-only
 	s.store.OnUpdateTopic = func(topic *api.Topic) error {
-		if !topic.Readonly {
+		if !topic.Readonly || topic.Status != api.TopicState_READONLY {
 			return fmt.Errorf("expected topic to be readonly")
 		}
 		return nil
@@ -513,7 +661,23 @@ func (s *serverTestSuite) TestDeleteTopic_Destroy() {
 		return nil
 	}
 	s.store.OnDestroy = func(ulid.ULID) error { return nil }
-	s.store.OnUpdateTopic = func(*api.Topic) error { return nil }
+	s.store.OnListGroups = func(ulid.ULID) iterator.GroupIterator {
+		return store.NewGroupIterator(nil)
+	}
+	var (
+		mu       sync.Mutex
+		progress []float32
+	)
+	s.store.OnUpdateTopic = func(topic *api.Topic) error {
+		if topic.Status != api.TopicState_DELETING {
+			return fmt.Errorf("expected topic to be deleting")
+		}
+
+		mu.Lock()
+		progress = append(progress, topic.Progress)
+		mu.Unlock()
+		return nil
+	}
 
 	claims.Permissions = []string{permissions.DestroyTopics}
 	token, err = s.quarterdeck.CreateAccessToken(claims)
@@ -521,10 +685,19 @@ func (s *serverTestSuite) TestDeleteTopic_Destroy() {
 
 	out, err := s.client.DeleteTopic(context.Background(), request, mock.PerRPCToken(token))
 	require.NoError(err, "could not execute happy path request")
-	require.Equal(1, s.store.Calls(store.UpdateTopic))
-	// TODO: test that destroy and delete topic are queued in the tasks.
-	// require.Equal(1, s.store.Calls(store.Destroy))
-	// require.Equal(1, s.store.Calls(store.DeleteTopic))
 	require.Equal(out.State, api.TopicState_DELETING)
 	require.Equal("01GTSMQ3V8ASAPNCFEN378T8RD", out.Id)
+
+	// The events, group offsets, and the topic should be deleted in the background
+	require.Eventually(func() bool {
+		return s.store.Calls(store.DeleteTopic) == 1
+	}, 5*time.Second, 10*time.Millisecond, "expected topic to be deleted by the destroy task")
+	require.Equal(1, s.store.Calls(store.Destroy))
+	require.Equal(1, s.store.Calls(store.ListGroups))
+
+	// The progress of the destroy should be recorded on the topic as each step completes
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(4, s.store.Calls(store.UpdateTopic))
+	require.Equal([]float32{0, 0, float32(1) / 3, float32(2) / 3}, progress)
 }
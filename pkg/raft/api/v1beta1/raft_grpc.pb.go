@@ -19,8 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Raft_RequestVote_FullMethodName   = "/raft.v1beta1.Raft/RequestVote"
-	Raft_AppendEntries_FullMethodName = "/raft.v1beta1.Raft/AppendEntries"
+	Raft_RequestVote_FullMethodName     = "/raft.v1beta1.Raft/RequestVote"
+	Raft_AppendEntries_FullMethodName   = "/raft.v1beta1.Raft/AppendEntries"
+	Raft_InstallSnapshot_FullMethodName = "/raft.v1beta1.Raft/InstallSnapshot"
 )
 
 // RaftClient is the client API for Raft service.
@@ -29,6 +30,7 @@ const (
 type RaftClient interface {
 	RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteReply, error)
 	AppendEntries(ctx context.Context, opts ...grpc.CallOption) (Raft_AppendEntriesClient, error)
+	InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error)
 }
 
 type raftClient struct {
@@ -81,12 +83,23 @@ func (x *raftAppendEntriesClient) Recv() (*AppendReply, error) {
 	return m, nil
 }
 
+func (c *raftClient) InstallSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotReply)
+	err := c.cc.Invoke(ctx, Raft_InstallSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RaftServer is the server API for Raft service.
 // All implementations must embed UnimplementedRaftServer
 // for forward compatibility
 type RaftServer interface {
 	RequestVote(context.Context, *VoteRequest) (*VoteReply, error)
 	AppendEntries(Raft_AppendEntriesServer) error
+	InstallSnapshot(context.Context, *SnapshotRequest) (*SnapshotReply, error)
 	mustEmbedUnimplementedRaftServer()
 }
 
@@ -100,6 +113,9 @@ func (UnimplementedRaftServer) RequestVote(context.Context, *VoteRequest) (*Vote
 func (UnimplementedRaftServer) AppendEntries(Raft_AppendEntriesServer) error {
 	return status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
 }
+func (UnimplementedRaftServer) InstallSnapshot(context.Context, *SnapshotRequest) (*SnapshotReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
 func (UnimplementedRaftServer) mustEmbedUnimplementedRaftServer() {}
 
 // UnsafeRaftServer may be embedded to opt out of forward compatibility for this service.
@@ -157,6 +173,24 @@ func (x *raftAppendEntriesServer) Recv() (*AppendRequest, error) {
 	return m, nil
 }
 
+func _Raft_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_InstallSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).InstallSnapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Raft_ServiceDesc is the grpc.ServiceDesc for Raft service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -168,6 +202,10 @@ var Raft_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RequestVote",
 			Handler:    _Raft_RequestVote_Handler,
 		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _Raft_InstallSnapshot_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -179,3 +217,138 @@ var Raft_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "raft/v1beta1/raft.proto",
 }
+
+const (
+	RaftAdmin_AddPeer_FullMethodName    = "/raft.v1beta1.RaftAdmin/AddPeer"
+	RaftAdmin_RemovePeer_FullMethodName = "/raft.v1beta1.RaftAdmin/RemovePeer"
+)
+
+// RaftAdminClient is the client API for RaftAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Admin RPCs to change the membership of the quorum, handled by the leader. The admin
+// service is served separately from the raft service and requires mTLS client auth.
+type RaftAdminClient interface {
+	AddPeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipReply, error)
+	RemovePeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipReply, error)
+}
+
+type raftAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRaftAdminClient(cc grpc.ClientConnInterface) RaftAdminClient {
+	return &raftAdminClient{cc}
+}
+
+func (c *raftAdminClient) AddPeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembershipReply)
+	err := c.cc.Invoke(ctx, RaftAdmin_AddPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftAdminClient) RemovePeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembershipReply)
+	err := c.cc.Invoke(ctx, RaftAdmin_RemovePeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RaftAdminServer is the server API for RaftAdmin service.
+// All implementations must embed UnimplementedRaftAdminServer
+// for forward compatibility
+//
+// Admin RPCs to change the membership of the quorum, handled by the leader. The admin
+// service is served separately from the raft service and requires mTLS client auth.
+type RaftAdminServer interface {
+	AddPeer(context.Context, *Peer) (*MembershipReply, error)
+	RemovePeer(context.Context, *Peer) (*MembershipReply, error)
+	mustEmbedUnimplementedRaftAdminServer()
+}
+
+// UnimplementedRaftAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedRaftAdminServer struct {
+}
+
+func (UnimplementedRaftAdminServer) AddPeer(context.Context, *Peer) (*MembershipReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPeer not implemented")
+}
+func (UnimplementedRaftAdminServer) RemovePeer(context.Context, *Peer) (*MembershipReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeer not implemented")
+}
+func (UnimplementedRaftAdminServer) mustEmbedUnimplementedRaftAdminServer() {}
+
+// UnsafeRaftAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RaftAdminServer will
+// result in compilation errors.
+type UnsafeRaftAdminServer interface {
+	mustEmbedUnimplementedRaftAdminServer()
+}
+
+func RegisterRaftAdminServer(s grpc.ServiceRegistrar, srv RaftAdminServer) {
+	s.RegisterService(&RaftAdmin_ServiceDesc, srv)
+}
+
+func _RaftAdmin_AddPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Peer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftAdminServer).AddPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RaftAdmin_AddPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftAdminServer).AddPeer(ctx, req.(*Peer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftAdmin_RemovePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Peer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftAdminServer).RemovePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RaftAdmin_RemovePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftAdminServer).RemovePeer(ctx, req.(*Peer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RaftAdmin_ServiceDesc is the grpc.ServiceDesc for RaftAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RaftAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "raft.v1beta1.RaftAdmin",
+	HandlerType: (*RaftAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddPeer",
+			Handler:    _RaftAdmin_AddPeer_Handler,
+		},
+		{
+			MethodName: "RemovePeer",
+			Handler:    _RaftAdmin_RemovePeer_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "raft/v1beta1/raft.proto",
+}
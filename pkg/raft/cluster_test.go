@@ -0,0 +1,708 @@
+package raft_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rotationalio/ensign/pkg/raft"
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rotationalio/ensign/pkg/utils/mtls"
+	"github.com/rotationalio/ensign/pkg/utils/mtls/pem"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestLeaderElection(t *testing.T) {
+	cluster := newCluster(t, 3, 0)
+	leader := cluster.WaitForLeader(t)
+
+	// All of the replicas should agree on the leader and the term
+	require.Eventually(t, func() bool {
+		for _, replica := range cluster.replicas {
+			if replica.Leader() != leader.PID || replica.Term() != leader.Term() {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected all replicas to follow the leader")
+
+	for _, replica := range cluster.replicas {
+		if replica != leader {
+			require.Equal(t, raft.Follower, replica.State())
+		}
+	}
+
+	// If the leader fails, the remaining replicas should elect a new leader in a later term
+	term := leader.Term()
+	cluster.Stop(t, leader)
+
+	elected := cluster.WaitForLeader(t)
+	require.NotEqual(t, leader.PID, elected.PID)
+	require.Greater(t, elected.Term(), term)
+}
+
+func TestBootstrapLeader(t *testing.T) {
+	cluster := newCluster(t, 3, 2)
+	leader := cluster.replicas[1]
+	require.Equal(t, raft.Leader, leader.State(), "expected the bootstrap leader to lead immediately")
+	require.Equal(t, uint64(0), leader.Term())
+
+	require.Eventually(t, func() bool {
+		for _, replica := range cluster.replicas {
+			if replica.Leader() != leader.PID {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected all replicas to follow the bootstrap leader")
+}
+
+func TestLogReplication(t *testing.T) {
+	cluster := newCluster(t, 3, 0)
+	leader := cluster.WaitForLeader(t)
+
+	// Followers should not accept proposals
+	for _, replica := range cluster.replicas {
+		if replica != leader {
+			_, err := replica.Propose(context.Background(), []byte("key"), []byte("value"))
+			require.ErrorIs(t, err, raft.ErrNotLeader)
+		}
+	}
+
+	// Proposals to the leader should return once they have been committed
+	for i := 1; i <= 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		entry, err := leader.Propose(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		cancel()
+
+		require.NoError(t, err, "could not propose command")
+		require.Equal(t, uint64(i), entry.Index)
+		require.Equal(t, leader.Term(), entry.Term)
+		require.GreaterOrEqual(t, leader.CommitIndex(), entry.Index)
+	}
+
+	// All replicas should commit all entries in order
+	require.Eventually(t, func() bool {
+		for _, replica := range cluster.replicas {
+			if replica.CommitIndex() != 10 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected all replicas to commit the entries")
+
+	for _, sm := range cluster.machines {
+		require.Equal(t, []string{"key1", "key2", "key3", "key4", "key5", "key6", "key7", "key8", "key9", "key10"}, sm.Keys())
+	}
+
+	// A new leader should continue to replicate after the leader fails
+	cluster.Stop(t, leader)
+	leader = cluster.WaitForLeader(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	entry, err := leader.Propose(ctx, []byte("key11"), []byte("value11"))
+	require.NoError(t, err, "could not propose command to the new leader")
+	require.Equal(t, uint64(11), entry.Index)
+}
+
+func TestSingleReplica(t *testing.T) {
+	cluster := newCluster(t, 1, 0)
+	leader := cluster.WaitForLeader(t)
+
+	entry, err := leader.Propose(context.Background(), []byte("key"), []byte("value"))
+	require.NoError(t, err, "could not propose command")
+	require.Equal(t, uint64(1), leader.CommitIndex())
+	require.Equal(t, uint64(1), entry.Index)
+	require.Equal(t, []string{"key"}, cluster.machines[0].Keys())
+
+	require.NoError(t, leader.Shutdown())
+	require.Equal(t, raft.Stopped, leader.State())
+
+	_, err = leader.Propose(context.Background(), []byte("key"), []byte("value"))
+	require.ErrorIs(t, err, raft.ErrStopped)
+}
+
+func TestRestart(t *testing.T) {
+	cluster := newCluster(t, 3, 0)
+	leader := cluster.WaitForLeader(t)
+
+	for i := 1; i <= 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_, err := leader.Propose(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		cancel()
+		require.NoError(t, err, "could not propose command")
+	}
+
+	// Stop a follower, the leader may have changed so the follower is not guaranteed
+	// to have committed all of the entries.
+	follower := cluster.Follower(t)
+	term := cluster.replicas[follower].Term()
+	commit := cluster.replicas[follower].CommitIndex()
+	cluster.Stop(t, cluster.replicas[follower])
+
+	// The restarted replica should recover its term and log from disk
+	replica := cluster.Restart(t, follower)
+	require.GreaterOrEqual(t, replica.Term(), term)
+	require.Equal(t, commit, replica.CommitIndex())
+	require.Equal(t, keys(int(commit)), cluster.machines[follower].Keys(), "expected the committed entries to be reapplied")
+
+	// The replica should rejoin the quorum and replicate new entries
+	leader = cluster.WaitForLeader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := leader.Propose(ctx, []byte("key6"), []byte("value6"))
+	require.NoError(t, err, "could not propose command after restart")
+
+	require.Eventually(t, func() bool {
+		return replica.CommitIndex() == 6
+	}, 2*time.Second, 10*time.Millisecond, "expected the restarted replica to commit the new entry")
+}
+
+func TestSnapshot(t *testing.T) {
+	cluster := newCluster(t, 3, 0, func(conf *raft.Config) { conf.Snapshot = 4 })
+	cluster.WaitForLeader(t)
+
+	propose := func(start, end int) {
+		for i := start; i <= end; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			_, err := cluster.WaitForLeader(t).Propose(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+			cancel()
+			require.NoError(t, err, "could not propose command")
+		}
+	}
+
+	// Stop a follower after some entries have been replicated to it
+	propose(1, 2)
+	follower := cluster.Follower(t)
+	commit := cluster.replicas[follower].CommitIndex()
+	cluster.Stop(t, cluster.replicas[follower])
+
+	// The remaining replicas compact their logs while the follower is stopped
+	propose(3, 10)
+	require.Eventually(t, func() bool {
+		for i, replica := range cluster.replicas {
+			if i != follower && replica.CommitIndex() != 10 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected the replicas to commit the entries")
+
+	// The restarted follower should catch up from the leader's snapshot
+	replica := cluster.Restart(t, follower)
+	require.Equal(t, commit, replica.CommitIndex())
+
+	require.Eventually(t, func() bool {
+		return replica.CommitIndex() == 10
+	}, 5*time.Second, 10*time.Millisecond, "expected the restarted follower to catch up")
+
+	for _, sm := range cluster.machines {
+		require.Equal(t, keys(10), sm.Keys())
+	}
+
+	// Replication continues after the snapshot is installed
+	propose(11, 11)
+	require.Eventually(t, func() bool {
+		return replica.CommitIndex() == 11
+	}, 2*time.Second, 10*time.Millisecond, "expected the follower to commit new entries")
+	require.Equal(t, keys(11), cluster.machines[follower].Keys())
+}
+
+func TestMembership(t *testing.T) {
+	cluster := newCluster(t, 3, 0, func(conf *raft.Config) {
+		conf.PeersPath = filepath.Join(t.TempDir(), "peers.json")
+	})
+	leader := cluster.WaitForLeader(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The leader must commit an entry in its term before the membership can change
+	_, err := leader.Propose(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err, "could not propose command")
+
+	// Only the leader can change the membership of the quorum
+	peer := &api.Peer{Pid: 4, Name: "replica4", BindAddr: "replica4", Endpoint: "replica4"}
+	follower := cluster.Follower(t)
+	_, err = cluster.replicas[follower].AddPeer(ctx, peer)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = leader.AddPeer(ctx, &api.Peer{Pid: 5})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = leader.RemovePeer(ctx, &api.Peer{Pid: 5})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Add a new peer to the running quorum
+	cluster.Lock()
+	cluster.socks[peer.BindAddr] = bufconn.Listen(1024 * 1024)
+	cluster.Unlock()
+
+	// The peer is added as a learner so the configuration is committed by the current
+	// voters even though the peer is not running yet.
+	rep, err := leader.AddPeer(ctx, peer)
+	require.NoError(t, err, "could not add peer")
+	require.Len(t, rep.Configuration.Peers, 4)
+	require.True(t, rep.Configuration.Peers[3].Learner, "expected the peer to be added as a learner")
+	require.GreaterOrEqual(t, leader.CommitIndex(), rep.Index)
+	require.True(t, leader.Quorum().Contains(peer.Pid))
+	require.False(t, leader.Quorum().Voter(peer.Pid))
+
+	_, err = leader.AddPeer(ctx, peer)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// The committed configuration is written back to disk by the replicas
+	require.Eventually(t, func() bool {
+		for _, conf := range cluster.configs {
+			if quorum, err := peers.Load(conf.PeersPath); err != nil || len(quorum.Peers) != 4 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected the replicas to write the configuration")
+
+	// The new peer is started with the new configuration and catches up with the leader
+	conf := cluster.configs[0]
+	conf.ReplicaID = peer.Pid
+	conf.Quorum = leader.Quorum()
+	conf.DataPath = t.TempDir()
+	conf.PeersPath = filepath.Join(t.TempDir(), "peers.json")
+	cluster.configs = append(cluster.configs, conf)
+
+	replica, sm := cluster.newReplica(t, 3)
+	cluster.replicas = append(cluster.replicas, replica)
+	cluster.machines = append(cluster.machines, sm)
+	require.NoError(t, replica.Run(cluster.socks[peer.BindAddr]), "could not run added replica")
+
+	// Once the learner has caught up the leader promotes it to a voter
+	require.Eventually(t, func() bool {
+		quorum, err := peers.Load(cluster.configs[leader.PID-1].PeersPath)
+		return err == nil && quorum.Voter(peer.Pid) && replica.CommitIndex() == leader.CommitIndex()
+	}, 5*time.Second, 10*time.Millisecond, "expected the added replica to catch up and be promoted")
+	require.True(t, replica.Quorum().Voter(peer.Pid), "expected the added replica to be a voter")
+	require.Equal(t, []string{"key1"}, sm.Keys(), "configuration entries should not be applied to the state machine")
+
+	// Remove a follower from the quorum so that it can be stopped
+	removed := cluster.replicas[follower]
+	rep, err = leader.RemovePeer(ctx, &api.Peer{Pid: removed.PID})
+	require.NoError(t, err, "could not remove peer")
+	require.Len(t, rep.Configuration.Peers, 3)
+	require.False(t, leader.Quorum().Contains(removed.PID))
+	cluster.Stop(t, removed)
+
+	// The leader writes the configuration to disk when it is committed
+	quorum, err := peers.Load(cluster.configs[leader.PID-1].PeersPath)
+	require.NoError(t, err, "could not load configuration written by the leader")
+	require.Len(t, quorum.Peers, 3)
+	require.False(t, quorum.Contains(removed.PID))
+
+	// The quorum of three continues to commit entries
+	_, err = leader.Propose(ctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err, "could not propose command")
+	require.Eventually(t, func() bool {
+		return replica.CommitIndex() == leader.CommitIndex()
+	}, 2*time.Second, 10*time.Millisecond, "expected the added replica to commit the entry")
+	require.Equal(t, []string{"key1", "key2"}, sm.Keys())
+
+	// A leader that removes itself steps down once the configuration is committed
+	_, err = leader.RemovePeer(ctx, &api.Peer{Pid: leader.PID})
+	require.NoError(t, err, "could not remove leader")
+	require.Eventually(t, func() bool {
+		return leader.State() != raft.Leader
+	}, 2*time.Second, 10*time.Millisecond, "expected the removed leader to step down")
+	cluster.Stop(t, leader)
+
+	elected := cluster.WaitForLeader(t)
+	require.NotEqual(t, leader.PID, elected.PID)
+	require.Len(t, elected.Quorum().Peers, 2)
+
+	_, err = elected.Propose(ctx, []byte("key3"), []byte("value3"))
+	require.NoError(t, err, "could not propose command to the new leader")
+}
+
+func TestAddLearner(t *testing.T) {
+	cluster := newCluster(t, 1, 1)
+	leader := cluster.WaitForLeader(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := leader.Propose(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err, "could not propose command")
+
+	// Adding a second peer to a quorum of one does not prevent the leader from
+	// committing entries while the peer is not running.
+	peer := &api.Peer{Pid: 2, Name: "replica2", BindAddr: "replica2", Endpoint: "replica2"}
+	cluster.Lock()
+	cluster.socks[peer.BindAddr] = bufconn.Listen(1024 * 1024)
+	cluster.Unlock()
+
+	_, err = leader.AddPeer(ctx, peer)
+	require.NoError(t, err, "could not add peer")
+
+	_, err = leader.Propose(ctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err, "expected the leader to commit without the learner")
+	require.Len(t, leader.Election(), 1, "learners should not vote")
+
+	// The leader is the last voter so it cannot be removed while the peer is a learner
+	_, err = leader.RemovePeer(ctx, &api.Peer{Pid: leader.PID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The learner catches up and is promoted, after which entries are committed by both
+	conf := cluster.configs[0]
+	conf.ReplicaID = peer.Pid
+	conf.Quorum = leader.Quorum()
+	conf.DataPath = t.TempDir()
+	cluster.configs = append(cluster.configs, conf)
+
+	replica, sm := cluster.newReplica(t, 1)
+	cluster.replicas = append(cluster.replicas, replica)
+	cluster.machines = append(cluster.machines, sm)
+	require.NoError(t, replica.Run(cluster.socks[peer.BindAddr]), "could not run added replica")
+
+	require.Eventually(t, func() bool {
+		return leader.Quorum().Voter(peer.Pid) && replica.Quorum().Voter(peer.Pid)
+	}, 5*time.Second, 10*time.Millisecond, "expected the learner to be promoted")
+	require.Len(t, leader.Election(), 2)
+
+	_, err = leader.Propose(ctx, []byte("key3"), []byte("value3"))
+	require.NoError(t, err, "could not propose command")
+	require.Eventually(t, func() bool {
+		return replica.CommitIndex() == leader.CommitIndex()
+	}, 2*time.Second, 10*time.Millisecond, "expected the promoted replica to commit the entry")
+	require.Equal(t, keys(3), sm.Keys())
+}
+
+func TestAdmin(t *testing.T) {
+	dir := t.TempDir()
+	certs, untrusted := filepath.Join(dir, "admin.pem"), filepath.Join(dir, "untrusted.pem")
+	writeCerts(t, certs, "admin1", "replica1")
+	writeCerts(t, untrusted, "admin1", "replica1")
+
+	quorum := &peers.Quorum{QID: 42, BootstrapLeader: 1}
+	quorum.Peers = append(quorum.Peers, &peers.Peer{PID: 1, Name: "replica1", BindAddr: "replica1", Endpoint: "replica1"})
+
+	conf := raft.Config{
+		ReplicaID: 1,
+		Tick:      50 * time.Millisecond,
+		Timeout:   25 * time.Millisecond,
+		Quorum:    quorum,
+		AdminAddr: "admin1",
+		CertPath:  certs,
+	}
+
+	sock, admin := bufconn.Listen(1024*1024), bufconn.Listen(1024*1024)
+	replica, err := raft.New(conf, raft.WithStateMachine(&stateMachine{}), raft.WithAdminListener(admin))
+	require.NoError(t, err, "could not create replica")
+	require.NoError(t, replica.Run(sock), "could not run replica")
+	t.Cleanup(func() { replica.Shutdown() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = replica.Propose(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err, "could not propose command")
+
+	dial := func(lis *bufconn.Listener, creds grpc.DialOption) *grpc.ClientConn {
+		dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		})
+
+		cc, err := grpc.NewClient("passthrough:///admin1", dialer, creds)
+		require.NoError(t, err, "could not create client")
+		t.Cleanup(func() { cc.Close() })
+		return cc
+	}
+
+	clientCreds := func(path string) grpc.DialOption {
+		provider, err := mtls.Load(path)
+		require.NoError(t, err, "could not load client certs")
+		creds, err := mtls.ClientCreds("admin1", provider)
+		require.NoError(t, err, "could not create client credentials")
+		return creds
+	}
+
+	// The membership cannot be changed through the raft service that peers connect to
+	peer := &api.Peer{Pid: 5}
+	cc := dial(sock, clientCreds(certs))
+	err = cc.Invoke(ctx, api.RaftAdmin_RemovePeer_FullMethodName, peer, &api.MembershipReply{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// The admin service requires a client certificate that is trusted by the replica
+	_, err = api.NewRaftAdminClient(dial(admin, grpc.WithTransportCredentials(insecure.NewCredentials()))).RemovePeer(ctx, peer)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = api.NewRaftAdminClient(dial(admin, clientCreds(untrusted))).RemovePeer(ctx, peer)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = api.NewRaftAdminClient(dial(admin, clientCreds(certs))).RemovePeer(ctx, peer)
+	require.Equal(t, codes.NotFound, status.Code(err), "expected the request to be handled by the replica")
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certs, untrusted := filepath.Join(dir, "certs.pem"), filepath.Join(dir, "untrusted.pem")
+	writeCerts(t, certs, "replica1", "replica2", "replica3")
+	writeCerts(t, untrusted, "replica1", "replica2", "replica3")
+
+	cluster := newCluster(t, 3, 1, func(conf *raft.Config) {
+		conf.CertPath = certs
+		conf.Insecure = false
+	})
+
+	// Replicas with trusted certificates replicate entries to each other
+	leader := cluster.WaitForLeader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	entry, err := leader.Propose(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err, "could not propose command")
+	require.Eventually(t, func() bool {
+		for _, replica := range cluster.replicas {
+			if replica.CommitIndex() < entry.Index {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond, "expected all replicas to commit the entry")
+
+	// Callers without a trusted client certificate cannot send raft requests
+	term := cluster.replicas[0].Term()
+	vote := func(creds grpc.DialOption) error {
+		cc, err := grpc.NewClient("passthrough:///replica1", cluster.dialer, creds)
+		require.NoError(t, err, "could not create client")
+		defer cc.Close()
+
+		_, err = api.NewRaftClient(cc).RequestVote(ctx, &api.VoteRequest{Term: 100, Candidate: 2})
+		return err
+	}
+
+	require.Equal(t, codes.Unavailable, status.Code(vote(grpc.WithTransportCredentials(insecure.NewCredentials()))))
+
+	provider, err := mtls.Load(untrusted)
+	require.NoError(t, err, "could not load client certs")
+	creds, err := mtls.ClientCreds("replica1", provider)
+	require.NoError(t, err, "could not create client credentials")
+	require.Equal(t, codes.Unavailable, status.Code(vote(creds)))
+	require.Equal(t, term, cluster.replicas[0].Term(), "expected the term not to change")
+}
+
+// A cluster of replicas that communicate over in-process bufconn listeners.
+type cluster struct {
+	sync.Mutex
+	replicas []*raft.Replica
+	machines []*stateMachine
+	configs  []raft.Config
+	socks    map[string]*bufconn.Listener
+	dialer   grpc.DialOption
+	stopped  map[*raft.Replica]bool
+}
+
+// Create and run a cluster with the specified number of replicas. If bootstrap is not
+// zero, the replica with that PID is the bootstrap leader of the quorum. Each replica
+// stores its log in a temporary directory so that it can be restarted. Replicas are
+// insecure unless the options configure their mTLS certs.
+func newCluster(t *testing.T, n int, bootstrap uint32, opts ...func(*raft.Config)) *cluster {
+	c := &cluster{
+		socks:   make(map[string]*bufconn.Listener, n),
+		stopped: make(map[*raft.Replica]bool),
+	}
+
+	quorum := &peers.Quorum{QID: 42, BootstrapLeader: bootstrap}
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("replica%d", i)
+		quorum.Peers = append(quorum.Peers, &peers.Peer{PID: uint32(i), Name: name, BindAddr: name, Endpoint: name})
+		c.socks[name] = bufconn.Listen(1024 * 1024)
+	}
+
+	c.dialer = grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		c.Lock()
+		sock := c.socks[addr]
+		c.Unlock()
+		return sock.DialContext(ctx)
+	})
+
+	for _, peer := range quorum.Peers {
+		conf := raft.Config{
+			ReplicaID: peer.PID,
+			Tick:      50 * time.Millisecond,
+			Timeout:   25 * time.Millisecond,
+			Aggregate: false,
+			Quorum:    quorum,
+			DataPath:  t.TempDir(),
+			Insecure:  true,
+		}
+
+		for _, opt := range opts {
+			opt(&conf)
+		}
+		c.configs = append(c.configs, conf)
+
+		replica, sm := c.newReplica(t, len(c.configs)-1)
+		c.replicas = append(c.replicas, replica)
+		c.machines = append(c.machines, sm)
+	}
+
+	for i, replica := range c.replicas {
+		require.NoError(t, replica.Run(c.socks[quorum.Peers[i].BindAddr]), "could not run replica")
+	}
+
+	t.Cleanup(func() {
+		for _, replica := range c.replicas {
+			replica.Shutdown()
+		}
+	})
+	return c
+}
+
+func (c *cluster) newReplica(t *testing.T, i int) (*raft.Replica, *stateMachine) {
+	sm := &stateMachine{}
+	replica, err := raft.New(c.configs[i], raft.WithStateMachine(sm), raft.WithDialOptions(c.dialer))
+	require.NoError(t, err, "could not create replica")
+	return replica, sm
+}
+
+// Wait for one of the running replicas to become the leader.
+func (c *cluster) WaitForLeader(t *testing.T) (leader *raft.Replica) {
+	require.Eventually(t, func() bool {
+		for _, replica := range c.replicas {
+			if !c.stopped[replica] && replica.State() == raft.Leader {
+				leader = replica
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "no leader was elected")
+	return leader
+}
+
+// Returns the index of a running replica that is not the leader.
+func (c *cluster) Follower(t *testing.T) int {
+	leader := c.WaitForLeader(t)
+	for i, replica := range c.replicas {
+		if replica != leader && !c.stopped[replica] {
+			return i
+		}
+	}
+
+	require.Fail(t, "no running followers in the cluster")
+	return -1
+}
+
+func (c *cluster) Stop(t *testing.T, replica *raft.Replica) {
+	require.NoError(t, replica.Shutdown(), "could not shutdown replica")
+	c.stopped[replica] = true
+}
+
+// Restart the replica at index i from its log on disk, replacing the stopped replica.
+func (c *cluster) Restart(t *testing.T, i int) *raft.Replica {
+	replica, sm := c.newReplica(t, i)
+	c.replicas[i] = replica
+	c.machines[i] = sm
+
+	addr := c.configs[i].Quorum.Peers[i].BindAddr
+	sock := bufconn.Listen(1024 * 1024)
+
+	c.Lock()
+	c.socks[addr] = sock
+	c.Unlock()
+
+	require.NoError(t, replica.Run(sock), "could not run restarted replica")
+	return replica
+}
+
+// Writes a self-signed certificate for the names and its private key to the path. The
+// certificate is its own CA so that it is trusted when it is used by both the server
+// and the client.
+func writeCerts(t *testing.T, path string, names ...string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "could not generate key")
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: names[0]},
+		DNSNames:              names,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "could not create certificate")
+	crt, err := x509.ParseCertificate(der)
+	require.NoError(t, err, "could not parse certificate")
+
+	f, err := os.Create(path)
+	require.NoError(t, err, "could not create certs file")
+
+	writer := pem.NewWriter(f)
+	require.NoError(t, writer.EncodeCertificate(crt), "could not write certificate")
+	require.NoError(t, writer.EncodePrivateKey(key), "could not write private key")
+	require.NoError(t, writer.Close(), "could not close certs file")
+}
+
+// Returns the keys of the first n proposals made by the tests.
+func keys(n int) []string {
+	var keys []string
+	for i := 1; i <= n; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	return keys
+}
+
+// A state machine that records the keys of the committed entries.
+type stateMachine struct {
+	sync.Mutex
+	keys []string
+}
+
+func (s *stateMachine) CommitEntry(entry *api.LogEntry) error {
+	s.Lock()
+	defer s.Unlock()
+	s.keys = append(s.keys, string(entry.Key))
+	return nil
+}
+
+func (s *stateMachine) DropEntry(*api.LogEntry) error {
+	return nil
+}
+
+func (s *stateMachine) Snapshot() ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	return []byte(strings.Join(s.keys, "\n")), nil
+}
+
+func (s *stateMachine) Restore(data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.keys = strings.Split(string(data), "\n")
+	return nil
+}
+
+func (s *stateMachine) Keys() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string(nil), s.keys...)
+}
@@ -16,6 +16,12 @@ type Config struct {
 	Timeout   time.Duration `default:"500ms"`    // the timeout to wait for a response from a peer
 	Aggregate bool          `default:"true"`     // aggregate multiple commands into one append entries
 	PeersPath string        `split_words:"true"` // the path to the peers configuration (usually loaded from a config map), only loaded if quorum is nil
+	DataPath  string        `split_words:"true"` // the directory the raft log is stored in; if empty the log is only kept in memory
+	Snapshot  uint64        `default:"1024"`     // compact the log into a snapshot after this many entries are committed; 0 disables snapshots
+	AdminAddr string        `split_words:"true"` // the address to serve the admin service on; if empty the membership can only be changed in process
+	CertPath  string        `split_words:"true"` // the mTLS certificate chain and private key used to serve and connect to peers and to serve the admin service
+	PoolPath  string        `split_words:"true"` // additional certificates trusted to authenticate peers and admin clients
+	Insecure  bool          `default:"false"`    // serve and connect to peers without TLS so peers are not authenticated; only for testing
 	Quorum    *peers.Quorum `ignored:"true"`     // the peers configuration, will not be loaded from the environment
 }
 
@@ -26,6 +32,8 @@ var (
 	ErrTickTooSmall     = errors.New("invalid raft configuration: tick must be greater than 10ms")
 	ErrTimeoutTooBig    = errors.New("invalid raft configuration: timeout must be smaller than the tick")
 	ErrMissingReplica   = errors.New("invalid raft configuration: local replica is not defined in the quorum")
+	ErrMissingCerts     = errors.New("invalid raft configuration: mTLS certs are required unless insecure")
+	ErrMissingAdminCert = errors.New("invalid raft configuration: the admin service requires mTLS certs")
 )
 
 // Validate the raft configuration. This also loads the peers from disk and validates
@@ -47,6 +55,14 @@ func (c *Config) Validate() (err error) {
 		return ErrTimeoutTooBig
 	}
 
+	if !c.Insecure && c.CertPath == "" {
+		return ErrMissingCerts
+	}
+
+	if c.AdminAddr != "" && c.CertPath == "" {
+		return ErrMissingAdminCert
+	}
+
 	// Load the quorum from the peers path if it hasn't been specified by the user
 	if c.Quorum == nil {
 		if c.Quorum, err = peers.Load(c.PeersPath); err != nil {
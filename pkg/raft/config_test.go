@@ -21,6 +21,7 @@ func TestConfig(t *testing.T) {
 		Timeout:   25 * time.Millisecond,
 		Aggregate: false,
 		PeersPath: "testdata/quorum.json",
+		Insecure:  true,
 	}
 
 	require.NoError(t, conf.Validate(), "expected valid configuration to start test")
@@ -51,6 +52,21 @@ func TestConfig(t *testing.T) {
 	require.ErrorIs(t, conf.Validate(), raft.ErrTimeoutTooBig)
 
 	conf.Timeout = 750 * time.Millisecond
+	conf.AdminAddr = ":4437"
+	require.ErrorIs(t, conf.Validate(), raft.ErrMissingAdminCert)
+
+	conf.CertPath = "testdata/admin.pem"
+	require.NoError(t, conf.Validate(), "admin service with certs should be valid")
+
+	// Peers must be authenticated with mTLS unless the replica is insecure
+	conf.AdminAddr = ""
+	conf.CertPath = ""
+	conf.Insecure = false
+	require.ErrorIs(t, conf.Validate(), raft.ErrMissingCerts)
+
+	conf.CertPath = "testdata/admin.pem"
+	require.NoError(t, conf.Validate(), "replica with certs should be valid")
+
 	conf.Quorum.QID = 0
 	require.ErrorIs(t, conf.Validate(), peers.ErrMissingQID)
 
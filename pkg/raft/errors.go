@@ -4,4 +4,14 @@ import "errors"
 
 var (
 	ErrCannotSetRunningState = errors.New("can only set the running state from the initialized state")
+	ErrNotLeader             = errors.New("replica is not the leader of the quorum")
+	ErrStopped               = errors.New("replica has been stopped")
+	ErrDropped               = errors.New("entry was dropped from the log before it was committed")
+	ErrUnknownEvent          = errors.New("unknown event type")
+	ErrReservedKey           = errors.New("the key of the command is reserved for configuration entries")
+	ErrMembershipPending     = errors.New("a membership change has not been committed yet")
+	ErrNoCommitInTerm        = errors.New("the leader has not committed an entry in its term yet")
+	ErrPeerExists            = errors.New("a peer with the same id is already in the quorum")
+	ErrUnknownPeer           = errors.New("the peer is not in the quorum")
+	ErrRemoveLastPeer        = errors.New("cannot remove the last voter in the quorum")
 )
@@ -0,0 +1,472 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rs/zerolog/log"
+)
+
+// The number of events that can be queued for the event loop before dispatch blocks.
+const eventBufferSize = 64
+
+// Types of events handled by the replica's event loop.
+type eventType uint8
+
+const (
+	unknownEvent eventType = iota
+	voteRequestEvent
+	voteReplyEvent
+	appendRequestEvent
+	appendReplyEvent
+	proposeEvent
+	snapshotRequestEvent
+	snapshotReplyEvent
+	membershipEvent
+)
+
+// Events are dispatched to the replica's event loop, which is the only go routine that
+// modifies the consensus state of the replica. If the event expects a response it is
+// sent on the reply channel, which must be buffered so the event loop never blocks.
+type event struct {
+	etype eventType
+	value interface{}
+	reply chan<- interface{}
+}
+
+// A proposal is a command that a client wants the quorum to commit.
+type proposal struct {
+	key       []byte
+	value     []byte
+	committed chan<- error
+}
+
+// Dispatch an event to the event loop without waiting for it to be handled.
+func (r *Replica) dispatch(ctx context.Context, etype eventType, value interface{}) error {
+	return r.send(ctx, event{etype: etype, value: value})
+}
+
+// Request dispatches an event to the event loop and waits for its reply.
+func (r *Replica) request(ctx context.Context, etype eventType, value interface{}) (interface{}, error) {
+	reply := make(chan interface{}, 1)
+	if err := r.send(ctx, event{etype: etype, value: value, reply: reply}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case rep := <-reply:
+		if err, ok := rep.(error); ok {
+			return nil, err
+		}
+		return rep, nil
+	case <-r.done:
+		return nil, ErrStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *Replica) send(ctx context.Context, e event) error {
+	select {
+	case r.events <- e:
+		return nil
+	case <-r.done:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// The event loop handles ticks from the heartbeat and candidacy intervals and events
+// dispatched by the raft service and the remotes until the replica is shut down. Each
+// event is handled while holding the replica's lock.
+func (r *Replica) listen(done <-chan struct{}) {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-r.heartbeat.C:
+			r.onHeartbeat()
+		case <-r.candidacy.C:
+			r.onElectionTimeout()
+		case e := <-r.events:
+			r.handle(e)
+		}
+	}
+}
+
+func (r *Replica) handle(e event) {
+	r.Lock()
+	defer r.Unlock()
+
+	var rep interface{}
+	switch e.etype {
+	case voteRequestEvent:
+		rep = r.onVoteRequest(e.value.(*api.VoteRequest))
+	case voteReplyEvent:
+		r.onVoteReply(e.value.(*api.VoteReply))
+	case appendRequestEvent:
+		rep = r.onAppendRequest(e.value.(*api.AppendRequest))
+	case appendReplyEvent:
+		r.onAppendReply(e.value.(*api.AppendReply))
+	case proposeEvent:
+		rep = r.onPropose(e.value.(*proposal))
+	case snapshotRequestEvent:
+		rep = r.onSnapshotRequest(e.value.(*api.SnapshotRequest))
+	case snapshotReplyEvent:
+		r.onSnapshotReply(e.value.(*api.SnapshotReply))
+	case membershipEvent:
+		rep = r.onMembership(e.value.(*membership))
+	default:
+		log.Error().Uint8("type", uint8(e.etype)).Str("replica", r.Name).Msg("unknown event type")
+		rep = ErrUnknownEvent
+	}
+
+	if e.reply != nil {
+		e.reply <- rep
+	}
+}
+
+//===========================================================================
+// Timeouts
+//===========================================================================
+
+// The leader sends append entries to all followers on every heartbeat, which also
+// prevents the followers from starting an election.
+func (r *Replica) onHeartbeat() {
+	r.Lock()
+	defer r.Unlock()
+
+	// Ignore ticks that were queued before the replica stopped being the leader
+	if r.state != Leader {
+		return
+	}
+	r.broadcastAppend()
+}
+
+// If a follower or candidate does not hear from a leader before the candidacy timeout,
+// it starts an election for the next term.
+func (r *Replica) onElectionTimeout() {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.state != Follower && r.state != Candidate {
+		return
+	}
+
+	// Replicas that have been removed from the quorum or that are learners do not
+	// start elections
+	if !r.conf.Quorum.Voter(r.PID) {
+		return
+	}
+
+	if err := r.setState(Candidate); err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Msg("could not start election")
+		return
+	}
+
+	// A single replica quorum elects itself without any remote votes
+	if r.votes.Passed() {
+		if err := r.setState(Leader); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Msg("could not become leader")
+		}
+	}
+}
+
+//===========================================================================
+// Leader Election
+//===========================================================================
+
+// Vote for the candidate if it is in the current term, the replica has not voted for
+// another candidate in the term, and the candidate's log is at least as up to date.
+func (r *Replica) onVoteRequest(req *api.VoteRequest) *api.VoteReply {
+	if req.Term > r.term {
+		r.stepDown(req.Term)
+	}
+
+	rep := &api.VoteReply{Remote: r.PID, Term: r.term}
+	if req.Term == r.term && (r.votedFor == 0 || r.votedFor == req.Candidate) && r.log.AsUpToDate(req.LastLogIndex, req.LastLogTerm) {
+		if err := r.vote(r.term, req.Candidate); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Msg("could not save vote")
+			return rep
+		}
+
+		// Granting a vote resets the election timeout so the candidate can take over
+		rep.Granted = true
+		r.candidacy.Interrupt()
+	}
+
+	log.Debug().
+		Str("replica", r.Name).
+		Uint32("candidate", req.Candidate).
+		Uint64("term", req.Term).
+		Bool("granted", rep.Granted).
+		Msg("vote requested")
+	return rep
+}
+
+// Count the vote and become the leader if the election has passed.
+func (r *Replica) onVoteReply(rep *api.VoteReply) {
+	if rep.Term > r.term {
+		r.stepDown(rep.Term)
+		return
+	}
+
+	// Ignore votes for elections that are over or from earlier terms
+	if r.state != Candidate || rep.Term != r.term {
+		return
+	}
+
+	r.votes.Vote(rep.Remote, rep.Granted)
+	if r.votes.Passed() {
+		if err := r.setState(Leader); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Msg("could not become leader")
+		}
+	}
+}
+
+// Become a follower, updating the term if a peer is in a later term. The vote cast in
+// the current term is kept so that the replica cannot vote twice in the same term.
+func (r *Replica) stepDown(term uint64) {
+	if term > r.term {
+		if err := r.vote(term, 0); err != nil {
+			// The replica cannot remain in an earlier term, so continue in memory
+			log.Error().Err(err).Str("replica", r.Name).Uint64("term", term).Msg("could not save term")
+			r.term, r.votedFor = term, 0
+		}
+		r.leader = 0
+	}
+
+	if r.state != Follower {
+		if err := r.setState(Follower); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Msg("could not become follower")
+		}
+	}
+}
+
+// Update the term of the replica and its vote in the term, saving them with the log
+// before they are used so that the replica cannot vote twice in a term after a crash.
+func (r *Replica) vote(term uint64, votedFor uint32) error {
+	if err := r.log.SaveVote(term, votedFor); err != nil {
+		return err
+	}
+
+	r.term, r.votedFor = term, votedFor
+	return nil
+}
+
+// Broadcast a vote request for the current term to all remotes.
+func (r *Replica) broadcastVote() {
+	req := &api.VoteRequest{
+		Term:         r.term,
+		Candidate:    r.PID,
+		LastLogIndex: r.log.LastApplied(),
+		LastLogTerm:  r.log.LastTerm(),
+	}
+
+	for _, remote := range r.remotes {
+		go remote.RequestVote(req)
+	}
+}
+
+//===========================================================================
+// Log Replication
+//===========================================================================
+
+// Append the entries from the leader to the local log if the log matches the leader's
+// log at the previous index, truncating any conflicting entries, and commit the
+// entries that the leader has committed.
+func (r *Replica) onAppendRequest(req *api.AppendRequest) *api.AppendReply {
+	rep := &api.AppendReply{Remote: r.PID}
+	defer func() {
+		rep.Term = r.term
+		rep.CommitIndex = r.log.CommitIndex()
+	}()
+
+	// Reject requests from a leader of an earlier term
+	if req.Term < r.term {
+		rep.Index = r.log.LastApplied()
+		return rep
+	}
+
+	// The request is from the leader of the current term
+	if req.Term > r.term || r.state != Follower {
+		r.stepDown(req.Term)
+	}
+	r.leader = req.Leader
+	r.candidacy.Interrupt()
+
+	// The log must contain the leader's previous entry for the entries to be appended
+	if prev, err := r.log.Get(req.PrevLogIndex); err != nil || prev.Term != req.PrevLogTerm {
+		rep.Index = r.log.LastApplied()
+		return rep
+	}
+
+	// Configuration entries take effect when they are appended or dropped from the log
+	reconfigure := false
+	for _, entry := range req.Entries {
+		if bytes.Equal(entry.Key, configKey) {
+			reconfigure = true
+		}
+	}
+
+	defer func() {
+		if reconfigure {
+			r.updateConfiguration()
+		}
+	}()
+
+	for i, entry := range req.Entries {
+		if entry.Index <= r.log.LastApplied() {
+			existing, _ := r.log.Get(entry.Index)
+			if existing.Term == entry.Term {
+				continue
+			}
+
+			// Drop the conflicting entry and all entries that follow it
+			reconfigure = true
+			prev, _ := r.log.Get(entry.Index - 1)
+			if err := r.log.Truncate(prev.Index, prev.Term); err != nil {
+				log.Error().Err(err).Str("replica", r.Name).Uint64("index", prev.Index).Msg("could not truncate log")
+				rep.Index = r.log.LastApplied()
+				return rep
+			}
+		}
+
+		if err := r.log.Append(req.Entries[i:]...); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Uint64("index", entry.Index).Msg("could not append entries")
+			rep.Index = r.log.LastApplied()
+			return rep
+		}
+		break
+	}
+
+	// The index of the last entry known to match the leader's log; entries after it
+	// may be from an earlier term so they cannot be reported as replicated.
+	rep.Success = true
+	rep.Index = req.PrevLogIndex + uint64(len(req.Entries))
+
+	if req.LeaderCommit > r.log.CommitIndex() {
+		commit := req.LeaderCommit
+		if commit > rep.Index {
+			commit = rep.Index
+		}
+
+		if commit > r.log.CommitIndex() {
+			if err := r.log.Commit(commit); err != nil {
+				log.Error().Err(err).Str("replica", r.Name).Uint64("index", commit).Msg("could not commit entries")
+			}
+			r.compact()
+		}
+	}
+	return rep
+}
+
+// Update the replication state of the remote and advance the commit index if a
+// majority of the quorum has replicated the entries.
+func (r *Replica) onAppendReply(rep *api.AppendReply) {
+	if rep.Term > r.term {
+		r.stepDown(rep.Term)
+		return
+	}
+
+	remote, ok := r.remotes[rep.Remote]
+	if r.state != Leader || rep.Term != r.term || !ok {
+		return
+	}
+
+	if rep.Success {
+		if rep.Index > remote.matchIndex {
+			remote.matchIndex = rep.Index
+		}
+		remote.nextIndex = remote.matchIndex + 1
+		r.advanceCommit()
+		r.promote(remote)
+		return
+	}
+
+	// The remote's log does not match; back up and resend from an earlier entry
+	next := remote.nextIndex - 1
+	if rep.Index+1 < next {
+		next = rep.Index + 1
+	}
+
+	if next <= remote.matchIndex {
+		next = remote.matchIndex + 1
+	}
+
+	remote.nextIndex = next
+	r.appendTo(remote)
+}
+
+// Send append entries to all remotes; if there are no new entries for a remote the
+// request is a heartbeat.
+func (r *Replica) broadcastAppend() {
+	for _, remote := range r.remotes {
+		r.appendTo(remote)
+	}
+}
+
+// Send the entries the remote has not acknowledged to the remote.
+func (r *Replica) appendTo(remote *Remote) {
+	// Ensure the next index is in the log
+	if remote.nextIndex < 1 {
+		remote.nextIndex = 1
+	}
+
+	if remote.nextIndex > r.log.LastApplied()+1 {
+		remote.nextIndex = r.log.LastApplied() + 1
+	}
+
+	// The remote is missing entries that have been compacted so send the snapshot
+	if snap := r.log.LastSnapshot(); snap != nil && remote.nextIndex <= snap.Index {
+		go remote.InstallSnapshot(&api.SnapshotRequest{Term: r.term, Leader: r.PID, Snapshot: snap})
+		return
+	}
+
+	prev, err := r.log.Prev(remote.nextIndex)
+	if err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Str("remote", remote.Name).Msg("could not find previous entry for remote")
+		return
+	}
+
+	req := &api.AppendRequest{
+		Term:         r.term,
+		Leader:       r.PID,
+		PrevLogIndex: prev.Index,
+		PrevLogTerm:  prev.Term,
+		LeaderCommit: r.log.CommitIndex(),
+	}
+
+	// The entries are copied since the log may be truncated while the request is sent
+	if entries, err := r.log.After(remote.nextIndex); err == nil {
+		req.Entries = append(req.Entries, entries...)
+	}
+
+	remote.AppendEntries(req)
+}
+
+// Commit the highest entry in the current term that has been replicated to a majority
+// of the quorum; entries from earlier terms are committed along with it.
+func (r *Replica) advanceCommit() {
+	for index := r.log.LastApplied(); index > r.log.CommitIndex(); index-- {
+		if entry, _ := r.log.Get(index); entry.Term != r.term {
+			return
+		}
+
+		votes := r.Election()
+		for pid, remote := range r.remotes {
+			votes.Vote(pid, remote.matchIndex >= index)
+		}
+
+		if votes.Passed() {
+			if err := r.log.Commit(index); err != nil {
+				log.Error().Err(err).Str("replica", r.Name).Uint64("index", index).Msg("could not commit entries")
+			}
+			r.compact()
+			return
+		}
+	}
+}
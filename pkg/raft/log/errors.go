@@ -12,4 +12,15 @@ var (
 	ErrTruncCommittedIndex   = errors.New("cannot truncate already committed index")
 	ErrTruncTermMismatch     = errors.New("the first entry being truncated must match expected term")
 	ErrSyncRequired          = errors.New("cannot load log from disk without a sync")
+	ErrVoteEarlierTerm       = errors.New("cannot save vote for an earlier term")
+	ErrCompacted             = errors.New("entry has been compacted into a snapshot")
+	ErrSnapshotStateMachine  = errors.New("cannot snapshot log without a state machine")
+	ErrSnapshotUpToDate      = errors.New("no entries have been committed since the last snapshot")
+	ErrMissingNullEntry      = errors.New("invalid log: the first entry must have index and term 0")
+	ErrInvalidEntryIndex     = errors.New("invalid log: entries must have sequential indices")
+	ErrInvalidEntryTerm      = errors.New("invalid log: entry terms must be monotonically increasing")
+	ErrInvalidLastApplied    = errors.New("invalid log: last applied index and length must match the last entry")
+	ErrInvalidCommitIndex    = errors.New("invalid log: commit index is not in the log")
+	ErrInvalidSnapshot       = errors.New("invalid log: the first entry must match the snapshot")
+	ErrInvalidTerm           = errors.New("invalid log: entries cannot be from a term after the current term")
 )
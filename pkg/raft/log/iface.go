@@ -9,6 +9,13 @@ import (
 type StateMachine interface {
 	CommitEntry(*pb.LogEntry) error
 	DropEntry(*pb.LogEntry) error
+
+	// Snapshot should serialize the state of the state machine after all committed
+	// entries have been applied so that the log can be compacted.
+	Snapshot() ([]byte, error)
+
+	// Restore should replace the state of the state machine with the snapshot data.
+	Restore([]byte) error
 }
 
 type Sync interface {
@@ -27,6 +34,10 @@ type Writer interface {
 	// Trunc should delete all entries starting with the given index and all entries
 	// that follow. Note that this is different than the log.Truncate() semantics.
 	Trunc(startIndex uint64) error
+
+	// WriteSnapshot should store the snapshot and delete all entries up to and
+	// including the snapshot index since they are replaced by the snapshot.
+	WriteSnapshot(*pb.Snapshot) error
 }
 
 type Reader interface {
@@ -38,4 +49,7 @@ type Reader interface {
 
 	// ReadMeta should return the log metadata from disk.
 	ReadMeta() (*pb.LogMeta, error)
+
+	// ReadSnapshot should return the latest snapshot or nil if there is no snapshot.
+	ReadSnapshot() (*pb.Snapshot, error)
 }
@@ -0,0 +1,7 @@
+package leveldb
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("no entry in the log")
+)
@@ -0,0 +1,206 @@
+/*
+Package leveldb implements a durable log.Sync that stores the entries and meta data of
+a raft log in a LevelDB database so that a replica can recover its log after a crash.
+*/
+package leveldb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	pb "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/log"
+	ldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	metaKey     = []byte("meta")
+	snapshotKey = []byte("snapshot")
+	entryPrefix = []byte("e")
+	durable     = &opt.WriteOptions{Sync: true}
+)
+
+// Sync stores log entries keyed by their big endian index so that entries are stored in
+// log order, along with the log meta data. All writes are synced to disk before they
+// return since the log must be durable before a replica replies to its peers.
+type Sync struct {
+	db *ldb.DB
+}
+
+var _ log.Sync = &Sync{}
+
+// Open the LevelDB database at the specified path, creating it if it does not exist.
+func Open(path string) (_ *Sync, err error) {
+	var db *ldb.DB
+	if db, err = ldb.OpenFile(path, nil); err != nil {
+		return nil, err
+	}
+	return &Sync{db: db}, nil
+}
+
+// Write all of the log entries to disk in a single batch.
+func (s *Sync) Write(entries ...*pb.LogEntry) (err error) {
+	batch := new(ldb.Batch)
+	for _, entry := range entries {
+		var value []byte
+		if value, err = proto.Marshal(entry); err != nil {
+			return err
+		}
+		batch.Put(entryKey(entry.Index), value)
+	}
+	return s.db.Write(batch, durable)
+}
+
+// WriteMeta writes the log meta data to disk.
+func (s *Sync) WriteMeta(meta *pb.LogMeta) (err error) {
+	var value []byte
+	if value, err = proto.Marshal(meta); err != nil {
+		return err
+	}
+	return s.db.Put(metaKey, value, durable)
+}
+
+// Trunc deletes the entry at the start index and all entries that follow it.
+func (s *Sync) Trunc(startIndex uint64) (err error) {
+	iter := s.db.NewIterator(entryRange(startIndex), nil)
+	defer iter.Release()
+
+	batch := new(ldb.Batch)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	return s.db.Write(batch, durable)
+}
+
+// WriteSnapshot stores the snapshot and deletes the entries it replaces in a single
+// batch so that the compacted entries are never on disk without the snapshot.
+func (s *Sync) WriteSnapshot(snap *pb.Snapshot) (err error) {
+	var value []byte
+	if value, err = proto.Marshal(snap); err != nil {
+		return err
+	}
+
+	iter := s.db.NewIterator(&util.Range{Start: entryKey(0), Limit: entryKey(snap.Index + 1)}, nil)
+	defer iter.Release()
+
+	batch := new(ldb.Batch)
+	batch.Put(snapshotKey, value)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	return s.db.Write(batch, durable)
+}
+
+// Read the entry at the specified index. The null entry at index 0 is never written to
+// disk so it is returned without reading from the database.
+func (s *Sync) Read(index uint64) (_ *pb.LogEntry, err error) {
+	var value []byte
+	if value, err = s.db.Get(entryKey(index), nil); err != nil {
+		if errors.Is(err, ldb.ErrNotFound) {
+			if index == 0 {
+				return pb.NullEntry, nil
+			}
+			return nil, fmt.Errorf("%w at index %d", ErrNotFound, index)
+		}
+		return nil, err
+	}
+
+	entry := &pb.LogEntry{}
+	if err = proto.Unmarshal(value, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ReadFrom returns all entries starting at the given index in log order. If the index
+// is 0, the null entry is the first entry returned.
+func (s *Sync) ReadFrom(index uint64) (entries []*pb.LogEntry, err error) {
+	iter := s.db.NewIterator(entryRange(index), nil)
+	defer iter.Release()
+
+	entries = make([]*pb.LogEntry, 0, 16)
+	if index == 0 {
+		entries = append(entries, pb.NullEntry)
+	}
+
+	for iter.Next() {
+		entry := &pb.LogEntry{}
+		if err = proto.Unmarshal(iter.Value(), entry); err != nil {
+			return nil, err
+		}
+
+		if entry.Index == 0 {
+			entries[0] = entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = iter.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadMeta returns the log meta data from disk or empty meta data for a new log.
+func (s *Sync) ReadMeta() (_ *pb.LogMeta, err error) {
+	var value []byte
+	if value, err = s.db.Get(metaKey, nil); err != nil {
+		if errors.Is(err, ldb.ErrNotFound) {
+			return &pb.LogMeta{}, nil
+		}
+		return nil, err
+	}
+
+	meta := &pb.LogMeta{}
+	if err = proto.Unmarshal(value, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// ReadSnapshot returns the latest snapshot from disk or nil if the log has no snapshot.
+func (s *Sync) ReadSnapshot() (_ *pb.Snapshot, err error) {
+	var value []byte
+	if value, err = s.db.Get(snapshotKey, nil); err != nil {
+		if errors.Is(err, ldb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	snap := &pb.Snapshot{}
+	if err = proto.Unmarshal(value, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Close the database.
+func (s *Sync) Close() error {
+	return s.db.Close()
+}
+
+func entryKey(index uint64) []byte {
+	key := make([]byte, len(entryPrefix)+8)
+	copy(key, entryPrefix)
+	binary.BigEndian.PutUint64(key[len(entryPrefix):], index)
+	return key
+}
+
+// The range of entry keys from the entry at the start index to the end of the log.
+func entryRange(start uint64) *util.Range {
+	return &util.Range{Start: entryKey(start), Limit: util.BytesPrefix(entryPrefix).Limit}
+}
@@ -0,0 +1,205 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	pb "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/log"
+	"github.com/rotationalio/ensign/pkg/raft/log/leveldb"
+	"github.com/rotationalio/ensign/pkg/raft/log/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSync(t *testing.T) {
+	path := t.TempDir()
+	sync, err := leveldb.Open(path)
+	require.NoError(t, err, "could not open leveldb sync")
+
+	// An empty database should only contain the null entry
+	entries, err := sync.ReadFrom(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.True(t, proto.Equal(pb.NullEntry, entries[0]))
+
+	entry, err := sync.Read(0)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(pb.NullEntry, entry))
+
+	_, err = sync.Read(1)
+	require.ErrorIs(t, err, leveldb.ErrNotFound)
+
+	meta, err := sync.ReadMeta()
+	require.NoError(t, err)
+	require.Zero(t, meta.LastApplied)
+
+	// Write entries and meta data
+	require.NoError(t, sync.Write(makeEntry(1, 1), makeEntry(2, 1), makeEntry(3, 2)))
+	require.NoError(t, sync.Write(makeEntry(4, 2)))
+	require.NoError(t, sync.WriteMeta(&pb.LogMeta{LastApplied: 4, CommitIndex: 2, Length: 4, Term: 2, VotedFor: 3}))
+
+	entry, err = sync.Read(3)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(makeEntry(3, 2), entry))
+
+	entries, err = sync.ReadFrom(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		require.Equal(t, uint64(i+2), entry.Index)
+	}
+
+	// Truncate removes the entries from the start index onward
+	require.NoError(t, sync.Trunc(3))
+	entries, err = sync.ReadFrom(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	_, err = sync.Read(3)
+	require.ErrorIs(t, err, leveldb.ErrNotFound)
+
+	// The entries and meta data should be durable after the database is reopened
+	require.NoError(t, sync.Close())
+	sync, err = leveldb.Open(path)
+	require.NoError(t, err, "could not reopen leveldb sync")
+	defer sync.Close()
+
+	entries, err = sync.ReadFrom(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	meta, err = sync.ReadMeta()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), meta.LastApplied)
+	require.Equal(t, uint64(2), meta.CommitIndex)
+	require.Equal(t, uint64(2), meta.Term)
+	require.Equal(t, uint32(3), meta.VotedFor)
+}
+
+func TestLoad(t *testing.T) {
+	path := t.TempDir()
+	sync, err := leveldb.Open(path)
+	require.NoError(t, err, "could not open leveldb sync")
+
+	// Create a log on disk with entries in two terms
+	rlog, err := log.New(log.WithSync(sync))
+	require.NoError(t, err)
+	require.NoError(t, rlog.SaveVote(1, 2))
+	require.NoError(t, rlog.Append(makeEntry(1, 1), makeEntry(2, 1)))
+	require.NoError(t, rlog.SaveVote(2, 0))
+	require.NoError(t, rlog.Append(makeEntry(3, 2)))
+	require.NoError(t, rlog.Commit(2))
+	require.NoError(t, sync.Close())
+
+	// The log should be loaded from disk with its vote
+	sync, err = leveldb.Open(path)
+	require.NoError(t, err, "could not reopen leveldb sync")
+
+	rlog, err = log.Load(log.WithSync(sync))
+	require.NoError(t, err, "could not load log")
+	require.Equal(t, uint64(3), rlog.LastApplied())
+	require.Equal(t, uint64(2), rlog.CommitIndex())
+	require.Equal(t, uint64(2), rlog.LastTerm())
+
+	term, votedFor := rlog.Vote()
+	require.Equal(t, uint64(2), term)
+	require.Zero(t, votedFor)
+
+	// Simulate a crash after entries were written but before the meta data was
+	require.NoError(t, sync.Write(makeEntry(4, 2)))
+	require.NoError(t, sync.Close())
+
+	sync, err = leveldb.Open(path)
+	require.NoError(t, err, "could not reopen leveldb sync")
+	defer sync.Close()
+
+	rlog, err = log.Load(log.WithSync(sync))
+	require.NoError(t, err, "could not recover log")
+	require.Equal(t, uint64(4), rlog.LastApplied())
+	require.Equal(t, uint64(4), rlog.Length())
+	require.Equal(t, uint64(2), rlog.CommitIndex())
+
+	// Entries on disk that are out of order should fail validation
+	require.NoError(t, sync.Write(makeEntry(6, 2)))
+	_, err = log.Load(log.WithSync(sync))
+	require.ErrorIs(t, err, log.ErrInvalidEntryIndex)
+}
+
+func TestSnapshot(t *testing.T) {
+	path := t.TempDir()
+	sync, err := leveldb.Open(path)
+	require.NoError(t, err, "could not open leveldb sync")
+
+	snap, err := sync.ReadSnapshot()
+	require.NoError(t, err)
+	require.Nil(t, snap)
+
+	// Create a log and compact the committed entries into a snapshot
+	sm := mock.NewStateMachine()
+	sm.OnSnapshot = func() ([]byte, error) {
+		return []byte("state"), nil
+	}
+
+	rlog, err := log.New(log.WithStateMachine(sm), log.WithSync(sync))
+	require.NoError(t, err)
+	for i := uint64(1); i <= 6; i++ {
+		require.NoError(t, rlog.Append(makeEntry(i, 1)))
+	}
+	require.NoError(t, rlog.Commit(3))
+	_, err = rlog.Snapshot()
+	require.NoError(t, err, "could not snapshot log")
+	require.NoError(t, rlog.Commit(5))
+
+	// The compacted entries should be deleted from disk
+	snap, err = sync.ReadSnapshot()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), snap.Index)
+	require.Equal(t, []byte("state"), snap.Data)
+
+	_, err = sync.Read(3)
+	require.ErrorIs(t, err, leveldb.ErrNotFound)
+
+	entries, err := sync.ReadFrom(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.True(t, proto.Equal(pb.NullEntry, entries[0]))
+	require.Equal(t, uint64(4), entries[1].Index)
+	require.NoError(t, sync.Close())
+
+	// Loading the log should restore the snapshot and reapply the committed entries
+	sync, err = leveldb.Open(path)
+	require.NoError(t, err, "could not reopen leveldb sync")
+	defer sync.Close()
+
+	var restored []byte
+	applied := make([]uint64, 0)
+	sm = mock.NewStateMachine()
+	sm.OnRestore = func(data []byte) error {
+		restored = data
+		return nil
+	}
+	sm.OnCommitEntry = func(entry *pb.LogEntry) error {
+		applied = append(applied, entry.Index)
+		return nil
+	}
+
+	rlog, err = log.Load(log.WithStateMachine(sm), log.WithSync(sync))
+	require.NoError(t, err, "could not load compacted log")
+	require.Equal(t, []byte("state"), restored)
+	require.Equal(t, []uint64{4, 5}, applied)
+	require.Equal(t, uint64(6), rlog.LastApplied())
+	require.Equal(t, uint64(5), rlog.CommitIndex())
+	require.Equal(t, uint64(3), rlog.LastSnapshot().Index)
+
+	_, err = rlog.Get(2)
+	require.ErrorIs(t, err, log.ErrCompacted)
+}
+
+func makeEntry(index, term uint64) *pb.LogEntry {
+	return &pb.LogEntry{
+		Index: index,
+		Term:  term,
+		Key:   []byte("key"),
+		Value: []byte("value"),
+	}
+}
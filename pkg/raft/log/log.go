@@ -23,9 +23,10 @@ import (
 // go routines. Instead the log should be maintained by a single state machine that
 // updates it sequentially when entries are committed.
 //
+// Committed entries are compacted by taking a snapshot of the state machine, after which
+// the first entry in the log is a placeholder for the last entry in the snapshot.
+//
 // TODO: right now the log stores everything in-memory; refactor to store partial in-mem log
-// TODO: implement snapshotting functionality
-// TODO: implement log validation to ensure the log is in a correct state
 type Log struct {
 	sm          StateMachine   // State machine to apply commits to
 	sync        Sync           // Synchronize the log to disk
@@ -36,6 +37,9 @@ type Log struct {
 	created     time.Time      // Timestamp the log was created
 	modified    time.Time      // Timestamp of the last log modification
 	snapshot    time.Time      // Timestamp of the last log snapshot
+	term        uint64         // The current term of the replica, saved with the log
+	votedFor    uint32         // The replica voted for in the current term, saved with the log
+	snap        *pb.Snapshot   // The latest snapshot of the state machine, if any
 	meta        *pb.LogMeta    // Saved state; only updated on calls to Meta()
 }
 
@@ -80,9 +84,63 @@ func Load(opts ...Option) (l *Log, err error) {
 	l.created = l.meta.Created.AsTime()
 	l.modified = l.meta.Modified.AsTime()
 	l.snapshot = l.meta.Snapshot.AsTime()
+	l.term = l.meta.Term
+	l.votedFor = l.meta.VotedFor
+
+	if l.snap, err = l.sync.ReadSnapshot(); err != nil {
+		return nil, fmt.Errorf("could not read snapshot: %w", err)
+	}
+
+	if l.snap != nil {
+		// Entries up to the snapshot index have been compacted into the snapshot; the
+		// snapshot is written before the meta data so it may be ahead of the commit index.
+		var entries []*pb.LogEntry
+		if entries, err = l.sync.ReadFrom(l.snap.Index + 1); err != nil {
+			return nil, fmt.Errorf("could not read entries: %w", err)
+		}
+
+		l.entries = append([]*pb.LogEntry{snapshotEntry(l.snap)}, entries...)
+		if l.commitIndex < l.snap.Index {
+			l.commitIndex = l.snap.Index
+		}
+	} else {
+		if l.entries, err = l.sync.ReadFrom(0); err != nil {
+			return nil, fmt.Errorf("could not read entries: %w", err)
+		}
+	}
+
+	// Entries are written before the meta data, so if the process crashed between the
+	// two writes the entries on disk are more recent than the meta data.
+	if len(l.entries) == 0 {
+		return nil, ErrMissingNullEntry
+	}
+
+	if last := l.entries[len(l.entries)-1]; last.Index != l.lastApplied {
+		log.Warn().
+			Uint64("last_applied", l.lastApplied).
+			Uint64("last_entry", last.Index).
+			Msg("raft log meta data does not match entries on disk; recovering from entries")
+		l.length = l.length - l.lastApplied + last.Index
+		l.lastApplied = last.Index
+	}
 
-	if l.entries, err = l.sync.ReadFrom(0); err != nil {
-		return nil, fmt.Errorf("could not read entries: %w", err)
+	if err = l.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Rebuild the state machine from the snapshot and the committed entries after it
+	if l.sm != nil {
+		if l.snap != nil {
+			if err = l.sm.Restore(l.snap.Data); err != nil {
+				return nil, fmt.Errorf("could not restore snapshot: %w", err)
+			}
+		}
+
+		for i := l.entries[0].Index + 1; i <= l.commitIndex; i++ {
+			if err = l.sm.CommitEntry(l.entry(i)); err != nil {
+				return nil, fmt.Errorf("could not apply entry %d: %w", i, err)
+			}
+		}
 	}
 
 	log.Info().
@@ -97,6 +155,47 @@ func Load(opts ...Option) (l *Log, err error) {
 	return l, nil
 }
 
+// Validate that the log is in a consistent state, e.g. after it has been loaded from
+// disk: the log must start with the null entry (or the last entry in the snapshot),
+// entries must have sequential indices and monotonically increasing terms, and the
+// meta data must match the entries.
+func (l *Log) Validate() error {
+	if len(l.entries) == 0 {
+		return ErrMissingNullEntry
+	}
+
+	if l.snap == nil {
+		if l.entries[0].Index != 0 || l.entries[0].Term != 0 {
+			return ErrMissingNullEntry
+		}
+	} else if l.entries[0].Index != l.snap.Index || l.entries[0].Term != l.snap.Term {
+		return ErrInvalidSnapshot
+	}
+
+	for i := 1; i < len(l.entries); i++ {
+		if l.entries[i].Index != l.entries[i-1].Index+1 {
+			return fmt.Errorf("%w: entry at index %d follows index %d", ErrInvalidEntryIndex, l.entries[i].Index, l.entries[i-1].Index)
+		}
+
+		if l.entries[i].Term < l.entries[i-1].Term {
+			return fmt.Errorf("%w: entry at index %d has term %d after term %d", ErrInvalidEntryTerm, l.entries[i].Index, l.entries[i].Term, l.entries[i-1].Term)
+		}
+	}
+
+	if last := l.entries[len(l.entries)-1]; last.Index != l.lastApplied || l.length != l.lastApplied {
+		return ErrInvalidLastApplied
+	}
+
+	if l.commitIndex > l.lastApplied || l.commitIndex < l.entries[0].Index {
+		return ErrInvalidCommitIndex
+	}
+
+	if last := l.entries[len(l.entries)-1]; last.Term > l.term && l.term > 0 {
+		return ErrInvalidTerm
+	}
+	return nil
+}
+
 //===========================================================================
 // Index Management
 //===========================================================================
@@ -113,12 +212,12 @@ func (l *Log) CommitIndex() uint64 {
 
 // LastEntry returns the log entry at the last applied index.
 func (l *Log) LastEntry() *pb.LogEntry {
-	return l.entries[l.lastApplied]
+	return l.entry(l.lastApplied)
 }
 
 // LastCommit returns the log entry at the commit index.
 func (l *Log) LastCommit() *pb.LogEntry {
-	return l.entries[l.commitIndex]
+	return l.entry(l.commitIndex)
 }
 
 // LastTerm is a helper function to get the term of the entry at the last applied index.
@@ -151,6 +250,39 @@ func (l *Log) AsUpToDate(lastIndex, lastTerm uint64) bool {
 	return lastTerm > localTerm
 }
 
+//===========================================================================
+// Vote Management
+//===========================================================================
+
+// Vote returns the current term of the replica and the replica that it voted for in
+// that term, as saved with the log.
+func (l *Log) Vote() (term uint64, votedFor uint32) {
+	return l.term, l.votedFor
+}
+
+// SaveVote saves the current term of the replica and the replica it voted for in the
+// term. Raft requires the term and vote to be durable before the replica responds to
+// any requests so that it cannot vote twice in a term if it crashes and restarts.
+func (l *Log) SaveVote(term uint64, votedFor uint32) error {
+	if term < l.term {
+		return ErrVoteEarlierTerm
+	}
+
+	prevTerm, prevVote := l.term, l.votedFor
+	l.term = term
+	l.votedFor = votedFor
+	l.modified = time.Now()
+
+	if l.sync != nil {
+		if err := l.sync.WriteMeta(l.Meta()); err != nil {
+			// The vote is not saved so it must not be used
+			l.term, l.votedFor = prevTerm, prevVote
+			return err
+		}
+	}
+	return nil
+}
+
 //===========================================================================
 // Entry Management
 //===========================================================================
@@ -257,7 +389,7 @@ func (l *Log) Commit(index uint64) error {
 	// Create a commit event for all entries now committed
 	if l.sm != nil {
 		for i := l.commitIndex + 1; i <= index; i++ {
-			if err := l.sm.CommitEntry(l.entries[i]); err != nil {
+			if err := l.sm.CommitEntry(l.entry(i)); err != nil {
 				log.Warn().Uint64("error_index", i).Uint64("start_index", l.commitIndex).Uint64("end_index", index).Msg("partial raft commit")
 				return err
 			}
@@ -303,7 +435,7 @@ func (l *Log) Truncate(index, term uint64) error {
 	}
 
 	// Do not truncate if entry at index does not have matching term
-	entry := l.entries[index]
+	entry := l.entry(index)
 	if entry.Term != term {
 		log.Debug().Uint64("trunc_term", entry.Term).Uint64("term", term).Msg("the first entry being truncated must match expected term")
 		return ErrTruncTermMismatch
@@ -313,7 +445,7 @@ func (l *Log) Truncate(index, term uint64) error {
 	if index < l.lastApplied {
 		// Drop all entries that appear after the index
 		if l.sm != nil {
-			for _, droppedEntry := range l.entries[nextIndex:] {
+			for _, droppedEntry := range l.entries[nextIndex-l.entries[0].Index:] {
 				if err := l.sm.DropEntry(droppedEntry); err != nil {
 					log.Warn().Uint64("error_index", droppedEntry.Index).Uint64("start_index", nextIndex).Uint64("end_index", l.lastApplied).Msg("partial raft drop")
 					return err
@@ -323,7 +455,7 @@ func (l *Log) Truncate(index, term uint64) error {
 
 		// Update the entries and meta data
 		nEntries := l.lastApplied - index
-		l.entries = l.entries[0:nextIndex]
+		l.entries = l.entries[0 : nextIndex-l.entries[0].Index]
 		l.length -= l.lastApplied - index
 		l.lastApplied = index
 		l.modified = time.Now()
@@ -359,7 +491,11 @@ func (l *Log) Get(index uint64) (*pb.LogEntry, error) {
 	if index > l.lastApplied {
 		return nil, fmt.Errorf("no entry at index %d", index)
 	}
-	return l.entries[index], nil
+
+	if index < l.entries[0].Index {
+		return nil, ErrCompacted
+	}
+	return l.entry(index), nil
 }
 
 // Prev returns the entry before the specified index (whether or not it is
@@ -369,7 +505,10 @@ func (l *Log) Prev(index uint64) (*pb.LogEntry, error) {
 		return nil, fmt.Errorf("no entry before index %d", index)
 	}
 
-	return l.entries[index-1], nil
+	if index-1 < l.entries[0].Index {
+		return nil, ErrCompacted
+	}
+	return l.entry(index - 1), nil
 }
 
 // After returns all entries after the specified index, inclusive
@@ -378,7 +517,127 @@ func (l *Log) After(index uint64) ([]*pb.LogEntry, error) {
 		return make([]*pb.LogEntry, 0), fmt.Errorf("no entries after %d", index)
 	}
 
-	return l.entries[index:], nil
+	if index < l.entries[0].Index {
+		return make([]*pb.LogEntry, 0), ErrCompacted
+	}
+	return l.entries[index-l.entries[0].Index:], nil
+}
+
+// Returns the entry at the index, which must be in the in-memory log.
+func (l *Log) entry(index uint64) *pb.LogEntry {
+	return l.entries[index-l.entries[0].Index]
+}
+
+//===========================================================================
+// Snapshots
+//===========================================================================
+
+// LastSnapshot returns the latest snapshot of the state machine or nil if the log has
+// not been compacted.
+func (l *Log) LastSnapshot() *pb.Snapshot {
+	return l.snap
+}
+
+// Snapshot the state machine at the commit index and compact the log, discarding all
+// of the entries up to and including the commit index.
+func (l *Log) Snapshot() (snap *pb.Snapshot, err error) {
+	if l.sm == nil {
+		return nil, ErrSnapshotStateMachine
+	}
+
+	if l.commitIndex == l.entries[0].Index {
+		return nil, ErrSnapshotUpToDate
+	}
+
+	snap = &pb.Snapshot{
+		Index:   l.commitIndex,
+		Term:    l.CommitTerm(),
+		Created: timestamppb.Now(),
+	}
+
+	if snap.Data, err = l.sm.Snapshot(); err != nil {
+		return nil, err
+	}
+
+	if err = l.compact(snap, l.entries[l.commitIndex-l.entries[0].Index+1:]); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Install a snapshot from the leader, restoring the state machine from it. If the log
+// has the last entry in the snapshot, the entries that follow it are kept; otherwise
+// the entire log is discarded since it does not match the leader's log. Snapshots that
+// do not contain any entries after the commit index are ignored.
+func (l *Log) Install(snap *pb.Snapshot) (err error) {
+	if snap.Index <= l.commitIndex {
+		return nil
+	}
+
+	var remaining []*pb.LogEntry
+	if snap.Index <= l.lastApplied && l.entry(snap.Index).Term == snap.Term {
+		remaining = l.entries[snap.Index-l.entries[0].Index+1:]
+	} else {
+		if l.sm != nil {
+			for _, droppedEntry := range l.entries[l.commitIndex-l.entries[0].Index+1:] {
+				if err = l.sm.DropEntry(droppedEntry); err != nil {
+					return err
+				}
+			}
+		}
+
+		if l.sync != nil {
+			if err = l.sync.Trunc(snap.Index + 1); err != nil {
+				return err
+			}
+		}
+
+		l.lastApplied = snap.Index
+		l.length = snap.Index
+	}
+
+	if l.sm != nil {
+		if err = l.sm.Restore(snap.Data); err != nil {
+			return err
+		}
+	}
+
+	l.commitIndex = snap.Index
+	return l.compact(snap, remaining)
+}
+
+// Replace the entries up to and including the snapshot index with the snapshot. The
+// remaining entries are copied so that the compacted entries can be garbage collected.
+func (l *Log) compact(snap *pb.Snapshot, remaining []*pb.LogEntry) error {
+	nEntries := snap.Index - l.entries[0].Index
+	l.entries = append([]*pb.LogEntry{snapshotEntry(snap)}, remaining...)
+	l.snap = snap
+	l.snapshot = snap.Created.AsTime()
+	l.modified = time.Now()
+
+	if l.sync != nil {
+		if err := l.sync.WriteSnapshot(snap); err != nil {
+			return err
+		}
+
+		if err := l.sync.WriteMeta(l.Meta()); err != nil {
+			return err
+		}
+	}
+
+	log.Debug().
+		Uint64("num_entries", nEntries).
+		Uint64("snapshot_index", snap.Index).
+		Uint64("snapshot_term", snap.Term).
+		Int("inmem_length", len(l.entries)).
+		Bool("sync", l.sync != nil).
+		Msg("raft log compacted")
+	return nil
+}
+
+// The placeholder entry at the start of a compacted log.
+func snapshotEntry(snap *pb.Snapshot) *pb.LogEntry {
+	return &pb.LogEntry{Index: snap.Index, Term: snap.Term}
 }
 
 //===========================================================================
@@ -395,5 +654,7 @@ func (l *Log) Meta() *pb.LogMeta {
 	l.meta.Created = timestamppb.New(l.created)
 	l.meta.Modified = timestamppb.New(l.modified)
 	l.meta.Snapshot = timestamppb.New(l.snapshot)
+	l.meta.Term = l.term
+	l.meta.VotedFor = l.votedFor
 	return l.meta
 }
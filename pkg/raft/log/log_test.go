@@ -17,6 +17,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func init() {
@@ -212,6 +213,247 @@ func TestLoadError(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestLoadValidation(t *testing.T) {
+	entries := func(terms ...uint64) func(uint64) ([]*pb.LogEntry, error) {
+		return func(uint64) ([]*pb.LogEntry, error) {
+			out := []*pb.LogEntry{pb.NullEntry}
+			for i, term := range terms {
+				out = append(out, makeEntry(uint64(i+1), term))
+			}
+			return out, nil
+		}
+	}
+
+	meta := func(lastApplied, commitIndex, term uint64) func() (*pb.LogMeta, error) {
+		return func() (*pb.LogMeta, error) {
+			return &pb.LogMeta{LastApplied: lastApplied, CommitIndex: commitIndex, Length: lastApplied, Term: term}, nil
+		}
+	}
+
+	testCases := []struct {
+		entries func(uint64) ([]*pb.LogEntry, error)
+		meta    func() (*pb.LogMeta, error)
+		err     error
+	}{
+		{func(uint64) ([]*pb.LogEntry, error) { return nil, nil }, meta(0, 0, 0), ErrMissingNullEntry},
+		{func(uint64) ([]*pb.LogEntry, error) { return []*pb.LogEntry{makeEntry(1, 1)}, nil }, meta(1, 0, 1), ErrMissingNullEntry},
+		{func(uint64) ([]*pb.LogEntry, error) {
+			return []*pb.LogEntry{pb.NullEntry, makeEntry(1, 1), makeEntry(3, 1)}, nil
+		}, meta(3, 0, 1), ErrInvalidEntryIndex},
+		{entries(1, 2, 1), meta(3, 0, 2), ErrInvalidEntryTerm},
+		{entries(1, 1, 2), meta(3, 4, 2), ErrInvalidCommitIndex},
+		{entries(1, 1, 2), meta(3, 1, 1), ErrInvalidTerm},
+	}
+
+	for i, tc := range testCases {
+		sync := mock.NewSync()
+		sync.OnReadFrom = tc.entries
+		sync.OnReadMeta = tc.meta
+
+		_, err := Load(WithSync(sync))
+		require.ErrorIs(t, err, tc.err, "test case %d failed", i)
+	}
+
+	// If the meta data is behind the entries the log is recovered from the entries
+	sync := mock.NewSync()
+	sync.OnReadFrom = entries(1, 1, 2, 2)
+	sync.OnReadMeta = meta(2, 1, 2)
+
+	log, err := Load(WithSync(sync))
+	require.NoError(t, err, "could not recover log from entries")
+	require.Equal(t, uint64(4), log.LastApplied())
+	require.Equal(t, uint64(4), log.Length())
+	require.Equal(t, uint64(1), log.CommitIndex())
+	require.Equal(t, uint64(2), log.LastTerm())
+}
+
+func TestVote(t *testing.T) {
+	sync := mock.NewSync()
+	log, err := New(WithSync(sync))
+	require.NoError(t, err)
+
+	term, votedFor := log.Vote()
+	require.Zero(t, term)
+	require.Zero(t, votedFor)
+
+	// Saving the vote should write the meta data
+	var meta *pb.LogMeta
+	sync.OnWriteMeta = func(m *pb.LogMeta) error {
+		meta = m
+		return nil
+	}
+
+	require.NoError(t, log.SaveVote(3, 2))
+	require.Equal(t, 1, sync.Calls[mock.WriteMeta])
+	require.Equal(t, uint64(3), meta.Term)
+	require.Equal(t, uint32(2), meta.VotedFor)
+
+	term, votedFor = log.Vote()
+	require.Equal(t, uint64(3), term)
+	require.Equal(t, uint32(2), votedFor)
+
+	// The vote can be cleared in a later term but the term cannot go backwards
+	require.NoError(t, log.SaveVote(4, 0))
+	require.ErrorIs(t, log.SaveVote(3, 1), ErrVoteEarlierTerm)
+
+	term, votedFor = log.Vote()
+	require.Equal(t, uint64(4), term)
+	require.Zero(t, votedFor)
+
+	sync.UseError(mock.WriteMeta, errors.New("something bad happened during write meta"))
+	require.EqualError(t, log.SaveVote(5, 1), "something bad happened during write meta")
+
+	term, votedFor = log.Vote()
+	require.Equal(t, uint64(4), term, "expected the vote to be unchanged if it could not be saved")
+	require.Zero(t, votedFor)
+}
+
+func TestSnapshot(t *testing.T) {
+	// Cannot snapshot without a state machine
+	log, err := New()
+	require.NoError(t, err)
+	_, err = log.Snapshot()
+	require.ErrorIs(t, err, ErrSnapshotStateMachine)
+
+	sm := mock.NewStateMachine()
+	sm.OnSnapshot = func() ([]byte, error) {
+		return []byte("state"), nil
+	}
+
+	sync := mock.NewSync()
+	log, err = New(WithStateMachine(sm), WithSync(sync))
+	require.NoError(t, err)
+	require.Nil(t, log.LastSnapshot())
+
+	// Cannot snapshot if nothing has been committed
+	_, err = log.Snapshot()
+	require.ErrorIs(t, err, ErrSnapshotUpToDate)
+
+	for i := uint64(1); i <= 8; i++ {
+		require.NoError(t, log.Append(makeEntry(i, (i+1)/2)))
+	}
+	require.NoError(t, log.Commit(5))
+	sync.Reset()
+
+	// The snapshot should compact all of the committed entries
+	snap, err := log.Snapshot()
+	require.NoError(t, err, "could not snapshot log")
+	require.Equal(t, uint64(5), snap.Index)
+	require.Equal(t, uint64(3), snap.Term)
+	require.Equal(t, []byte("state"), snap.Data)
+	require.Equal(t, snap, log.LastSnapshot())
+	require.Equal(t, 1, sync.Calls[mock.WriteSnapshot])
+	require.Equal(t, 1, sync.Calls[mock.WriteMeta])
+	require.Equal(t, snap.Created.AsTime(), log.Meta().Snapshot.AsTime())
+
+	require.Equal(t, uint64(8), log.LastApplied())
+	require.Equal(t, uint64(5), log.CommitIndex())
+	require.Equal(t, uint64(3), log.CommitTerm())
+	require.Equal(t, uint64(8), log.Length())
+
+	_, err = log.Get(4)
+	require.ErrorIs(t, err, ErrCompacted)
+
+	_, err = log.Prev(5)
+	require.ErrorIs(t, err, ErrCompacted)
+
+	_, err = log.After(4)
+	require.ErrorIs(t, err, ErrCompacted)
+
+	// The last entry in the snapshot is kept as a placeholder for consistency checks
+	entry, err := log.Get(5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), entry.Index)
+	require.Equal(t, uint64(3), entry.Term)
+	require.Nil(t, entry.Value)
+
+	entries, err := log.After(6)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, uint64(6), entries[0].Index)
+
+	// Cannot snapshot again until more entries are committed
+	_, err = log.Snapshot()
+	require.ErrorIs(t, err, ErrSnapshotUpToDate)
+
+	// The compacted log can still be truncated, committed, and appended to
+	require.NoError(t, log.Truncate(6, 3))
+	require.Equal(t, uint64(6), log.LastApplied())
+	require.NoError(t, log.Append(makeEntry(7, 5)))
+	require.NoError(t, log.Commit(7))
+	require.Equal(t, 7, sm.Calls[mock.CommitEntry])
+
+	snap, err = log.Snapshot()
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), snap.Index)
+	require.Equal(t, uint64(5), snap.Term)
+
+	// Snapshot errors are returned
+	sm.UseError(mock.Snapshot, errors.New("could not serialize state"))
+	require.NoError(t, log.Append(makeEntry(8, 5)))
+	require.NoError(t, log.Commit(8))
+	_, err = log.Snapshot()
+	require.EqualError(t, err, "could not serialize state")
+}
+
+func TestInstall(t *testing.T) {
+	sm := mock.NewStateMachine()
+	sync := mock.NewSync()
+	log, err := New(WithStateMachine(sm), WithSync(sync))
+	require.NoError(t, err)
+
+	for i := uint64(1); i <= 6; i++ {
+		require.NoError(t, log.Append(makeEntry(i, 1)))
+	}
+	require.NoError(t, log.Commit(2))
+	sm.Reset()
+	sync.Reset()
+
+	var restored []byte
+	sm.OnRestore = func(data []byte) error {
+		restored = data
+		return nil
+	}
+
+	// Snapshots behind the commit index are ignored
+	require.NoError(t, log.Install(&pb.Snapshot{Index: 2, Term: 1, Data: []byte("old")}))
+	require.Nil(t, restored)
+	require.Nil(t, log.LastSnapshot())
+
+	// If the log contains the last entry of the snapshot the following entries are kept
+	require.NoError(t, log.Install(&pb.Snapshot{Index: 4, Term: 1, Data: []byte("match"), Created: timestamppb.Now()}))
+	require.Equal(t, []byte("match"), restored)
+	require.Equal(t, uint64(6), log.LastApplied())
+	require.Equal(t, uint64(4), log.CommitIndex())
+	require.Equal(t, 0, sm.Calls[mock.CommitEntry])
+	require.Equal(t, 0, sm.Calls[mock.DropEntry])
+	require.Equal(t, 0, sync.Calls[mock.Trunc])
+	require.Equal(t, 1, sync.Calls[mock.WriteSnapshot])
+
+	entries, err := log.After(5)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// If the log conflicts with the snapshot the entire log is replaced
+	require.NoError(t, log.Install(&pb.Snapshot{Index: 5, Term: 2, Data: []byte("conflict"), Created: timestamppb.Now()}))
+	require.Equal(t, []byte("conflict"), restored)
+	require.Equal(t, uint64(5), log.LastApplied())
+	require.Equal(t, uint64(5), log.CommitIndex())
+	require.Equal(t, uint64(2), log.LastTerm())
+	require.Equal(t, 2, sm.Calls[mock.DropEntry])
+	require.Equal(t, 1, sync.Calls[mock.Trunc])
+
+	// A snapshot that is ahead of the log also replaces the log
+	require.NoError(t, log.Install(&pb.Snapshot{Index: 10, Term: 3, Data: []byte("ahead"), Created: timestamppb.Now()}))
+	require.Equal(t, uint64(10), log.LastApplied())
+	require.Equal(t, uint64(10), log.CommitIndex())
+	require.Equal(t, uint64(10), log.Length())
+	require.NoError(t, log.Append(makeEntry(11, 3)))
+
+	sm.UseError(mock.Restore, errors.New("could not restore state"))
+	require.EqualError(t, log.Install(&pb.Snapshot{Index: 11, Term: 3}), "could not restore state")
+}
+
 func TestStateMachine(t *testing.T) {
 	sm := mock.NewStateMachine()
 	log, err := New(WithStateMachine(sm))
@@ -680,6 +922,13 @@ func (f *fixture) Trunc(startIndex uint64) error {
 	return nil
 }
 
+func (f *fixture) WriteSnapshot(snap *pb.Snapshot) error {
+	if f.writer != nil {
+		return f.writer.WriteSnapshot(snap)
+	}
+	return nil
+}
+
 func (f *fixture) Read(index uint64) (entry *pb.LogEntry, err error) {
 	var entries []*pb.LogEntry
 	if entries, err = f.ReadFrom(0); err != nil {
@@ -735,6 +984,10 @@ func (f *fixture) ReadMeta() (meta *pb.LogMeta, err error) {
 	return meta, nil
 }
 
+func (f *fixture) ReadSnapshot() (*pb.Snapshot, error) {
+	return nil, nil
+}
+
 func (f *fixture) Close() error {
 	return nil
 }
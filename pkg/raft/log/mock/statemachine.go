@@ -9,6 +9,8 @@ import (
 const (
 	CommitEntry = "CommitEntry"
 	DropEntry   = "DropEntry"
+	Snapshot    = "Snapshot"
+	Restore     = "Restore"
 )
 
 func NewStateMachine() *StateMachine {
@@ -21,6 +23,8 @@ type StateMachine struct {
 	Calls         map[string]int
 	OnCommitEntry func(*pb.LogEntry) error
 	OnDropEntry   func(*pb.LogEntry) error
+	OnSnapshot    func() ([]byte, error)
+	OnRestore     func([]byte) error
 }
 
 func (m *StateMachine) UseError(method string, err error) {
@@ -33,6 +37,14 @@ func (m *StateMachine) UseError(method string, err error) {
 		m.OnDropEntry = func(*pb.LogEntry) error {
 			return err
 		}
+	case Snapshot:
+		m.OnSnapshot = func() ([]byte, error) {
+			return nil, err
+		}
+	case Restore:
+		m.OnRestore = func([]byte) error {
+			return err
+		}
 	default:
 		panic(fmt.Errorf("unknown method %q", method))
 	}
@@ -41,6 +53,8 @@ func (m *StateMachine) UseError(method string, err error) {
 func (m *StateMachine) Reset() {
 	m.OnCommitEntry = nil
 	m.OnDropEntry = nil
+	m.OnSnapshot = nil
+	m.OnRestore = nil
 	for key := range m.Calls {
 		m.Calls[key] = 0
 	}
@@ -62,6 +76,22 @@ func (m *StateMachine) DropEntry(entry *pb.LogEntry) error {
 	return nil
 }
 
+func (m *StateMachine) Snapshot() ([]byte, error) {
+	m.incr(Snapshot)
+	if m.OnSnapshot != nil {
+		return m.OnSnapshot()
+	}
+	return nil, nil
+}
+
+func (m *StateMachine) Restore(data []byte) error {
+	m.incr(Restore)
+	if m.OnRestore != nil {
+		return m.OnRestore(data)
+	}
+	return nil
+}
+
 func (m *StateMachine) incr(name string) {
 	if m.Calls == nil {
 		m.Calls = make(map[string]int)
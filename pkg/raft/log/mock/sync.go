@@ -7,13 +7,15 @@ import (
 )
 
 const (
-	Write     = "Write"
-	WriteMeta = "WriteMeta"
-	Trunc     = "Trunc"
-	Read      = "Read"
-	ReadFrom  = "ReadFrom"
-	ReadMeta  = "ReadMeta"
-	Close     = "Close"
+	Write         = "Write"
+	WriteMeta     = "WriteMeta"
+	Trunc         = "Trunc"
+	WriteSnapshot = "WriteSnapshot"
+	Read          = "Read"
+	ReadFrom      = "ReadFrom"
+	ReadMeta      = "ReadMeta"
+	ReadSnapshot  = "ReadSnapshot"
+	Close         = "Close"
 )
 
 func NewSync() *Sync {
@@ -23,14 +25,16 @@ func NewSync() *Sync {
 }
 
 type Sync struct {
-	Calls       map[string]int
-	OnWrite     func(...*pb.LogEntry) error
-	OnWriteMeta func(*pb.LogMeta) error
-	OnTrunc     func(uint64) error
-	OnRead      func(uint64) (*pb.LogEntry, error)
-	OnReadFrom  func(uint64) ([]*pb.LogEntry, error)
-	OnReadMeta  func() (*pb.LogMeta, error)
-	OnClose     func() error
+	Calls           map[string]int
+	OnWrite         func(...*pb.LogEntry) error
+	OnWriteMeta     func(*pb.LogMeta) error
+	OnTrunc         func(uint64) error
+	OnWriteSnapshot func(*pb.Snapshot) error
+	OnRead          func(uint64) (*pb.LogEntry, error)
+	OnReadFrom      func(uint64) ([]*pb.LogEntry, error)
+	OnReadMeta      func() (*pb.LogMeta, error)
+	OnReadSnapshot  func() (*pb.Snapshot, error)
+	OnClose         func() error
 }
 
 func (m *Sync) UseError(method string, err error) {
@@ -47,6 +51,10 @@ func (m *Sync) UseError(method string, err error) {
 		m.OnTrunc = func(uint64) error {
 			return err
 		}
+	case WriteSnapshot:
+		m.OnWriteSnapshot = func(*pb.Snapshot) error {
+			return err
+		}
 	case Read:
 		m.OnRead = func(uint64) (*pb.LogEntry, error) {
 			return nil, err
@@ -59,6 +67,10 @@ func (m *Sync) UseError(method string, err error) {
 		m.OnReadMeta = func() (*pb.LogMeta, error) {
 			return nil, err
 		}
+	case ReadSnapshot:
+		m.OnReadSnapshot = func() (*pb.Snapshot, error) {
+			return nil, err
+		}
 	case Close:
 		m.OnClose = func() error {
 			return err
@@ -72,9 +84,11 @@ func (m *Sync) Reset() {
 	m.OnWrite = nil
 	m.OnWriteMeta = nil
 	m.OnTrunc = nil
+	m.OnWriteSnapshot = nil
 	m.OnRead = nil
 	m.OnReadFrom = nil
 	m.OnReadMeta = nil
+	m.OnReadSnapshot = nil
 	m.OnClose = nil
 	for key := range m.Calls {
 		m.Calls[key] = 0
@@ -105,6 +119,14 @@ func (m *Sync) Trunc(index uint64) error {
 	return nil
 }
 
+func (m *Sync) WriteSnapshot(snap *pb.Snapshot) error {
+	m.incr(WriteSnapshot)
+	if m.OnWriteSnapshot != nil {
+		return m.OnWriteSnapshot(snap)
+	}
+	return nil
+}
+
 func (m *Sync) Read(index uint64) (*pb.LogEntry, error) {
 	m.incr(Read)
 	if m.OnRead != nil {
@@ -131,6 +153,14 @@ func (m *Sync) ReadMeta() (*pb.LogMeta, error) {
 	return &pb.LogMeta{}, nil
 }
 
+func (m *Sync) ReadSnapshot() (*pb.Snapshot, error) {
+	m.incr(ReadSnapshot)
+	if m.OnReadSnapshot != nil {
+		return m.OnReadSnapshot()
+	}
+	return nil, nil
+}
+
 func (m *Sync) Close() error {
 	m.incr(Close)
 	if m.OnClose != nil {
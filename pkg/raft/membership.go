@@ -0,0 +1,320 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// The key of the log entries that change the configuration of the quorum. These entries
+// are handled by the replica and are not applied to the application state machine.
+var configKey = []byte("raft:configuration")
+
+// A request to add a peer to or remove a peer from the quorum.
+type membership struct {
+	peer      *api.Peer
+	remove    bool
+	committed chan<- error
+}
+
+// AddPeer adds the peer to the quorum as a learner and waits until the new configuration
+// has been committed. Only the leader can change the membership of the quorum and only
+// one change can be made at a time. The peer should be started with the configuration
+// of the reply; learners are replicated to but do not vote or count towards the
+// majority, so the quorum keeps committing entries while the peer catches up. Once the
+// peer has replicated every committed entry the leader promotes it to a voter.
+//
+// AddPeer and RemovePeer are served by the admin service, which requires mTLS client
+// auth, rather than by the raft service that peers connect to.
+func (r *Replica) AddPeer(ctx context.Context, in *api.Peer) (*api.MembershipReply, error) {
+	return r.changeMembership(ctx, &membership{peer: in})
+}
+
+// RemovePeer removes the peer with the PID of the request from the quorum and waits
+// until the new configuration has been committed; the peer can then be stopped. If the
+// leader removes itself it steps down once the configuration is committed.
+func (r *Replica) RemovePeer(ctx context.Context, in *api.Peer) (*api.MembershipReply, error) {
+	return r.changeMembership(ctx, &membership{peer: in, remove: true})
+}
+
+func (r *Replica) changeMembership(ctx context.Context, m *membership) (_ *api.MembershipReply, err error) {
+	committed := make(chan error, 1)
+	m.committed = committed
+
+	var rep interface{}
+	if rep, err = r.request(ctx, membershipEvent, m); err != nil {
+		return nil, rpcError(err)
+	}
+
+	select {
+	case err = <-committed:
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		return rep.(*api.MembershipReply), nil
+	case <-ctx.Done():
+		return nil, rpcError(ctx.Err())
+	}
+}
+
+// Quorum returns the configuration of the quorum that the replica is currently using,
+// which may not have been committed yet.
+func (r *Replica) Quorum() *peers.Quorum {
+	r.RLock()
+	defer r.RUnlock()
+	return r.conf.Quorum
+}
+
+// Append the new configuration to the leader's log and start using it immediately. A
+// change is only allowed once the previous change has been committed and the leader
+// has committed an entry in its term, which ensures that the majorities of the old and
+// new configurations overlap so that two leaders cannot be elected in the same term.
+func (r *Replica) onMembership(m *membership) interface{} {
+	if r.state != Leader {
+		return ErrNotLeader
+	}
+
+	if r.configIndex > r.log.CommitIndex() {
+		return ErrMembershipPending
+	}
+
+	if r.log.CommitTerm() != r.term {
+		return ErrNoCommitInTerm
+	}
+
+	quorum, err := m.apply(r.conf.Quorum)
+	if err != nil {
+		return err
+	}
+
+	var (
+		entry  *api.LogEntry
+		config *api.Configuration
+	)
+	if entry, config, err = r.appendConfiguration(quorum); err != nil {
+		return err
+	}
+
+	r.pending[entry.Index] = m.committed
+	log.Info().Str("replica", r.Name).Uint32("peer", m.peer.Pid).Bool("remove", m.remove).Uint64("index", entry.Index).Msg("membership change proposed")
+
+	r.broadcastAppend()
+	r.advanceCommit()
+	return &api.MembershipReply{Index: entry.Index, Configuration: config}
+}
+
+// Promote the learner to a voter once it has replicated every committed entry. The
+// promotion is a membership change so it waits until the previous change has been
+// committed; it is retried the next time the learner acknowledges entries.
+func (r *Replica) promote(remote *Remote) {
+	if r.state != Leader || remote.matchIndex < r.log.CommitIndex() {
+		return
+	}
+
+	if r.configIndex > r.log.CommitIndex() || r.log.CommitTerm() != r.term {
+		return
+	}
+
+	quorum := &peers.Quorum{QID: r.conf.Quorum.QID, BootstrapLeader: r.conf.Quorum.BootstrapLeader}
+	promoted := false
+	for _, peer := range r.conf.Quorum.Peers {
+		if peer.PID == remote.PID && peer.Learner {
+			voter := *peer
+			voter.Learner = false
+			peer, promoted = &voter, true
+		}
+		quorum.Peers = append(quorum.Peers, peer)
+	}
+
+	if !promoted {
+		return
+	}
+
+	entry, _, err := r.appendConfiguration(quorum)
+	if err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Uint32("peer", remote.PID).Msg("could not promote learner")
+		return
+	}
+
+	log.Info().Str("replica", r.Name).Uint32("peer", remote.PID).Uint64("index", entry.Index).Msg("learner promoted to voter")
+	r.broadcastAppend()
+	r.advanceCommit()
+}
+
+// Append a configuration entry for the quorum to the leader's log and start using it.
+func (r *Replica) appendConfiguration(quorum *peers.Quorum) (entry *api.LogEntry, config *api.Configuration, err error) {
+	var value []byte
+	config = configuration(quorum)
+	if value, err = proto.Marshal(config); err != nil {
+		return nil, nil, err
+	}
+
+	if entry, err = r.log.Create(configKey, value, r.term); err != nil {
+		return nil, nil, err
+	}
+
+	r.reconfigure(quorum, entry.Index)
+	return entry, config, nil
+}
+
+// Returns a new quorum with the peer added or removed; the current quorum is not
+// modified since it may be shared with the configuration of other replicas.
+func (m *membership) apply(current *peers.Quorum) (_ *peers.Quorum, err error) {
+	quorum := &peers.Quorum{QID: current.QID, BootstrapLeader: current.BootstrapLeader}
+	for _, peer := range current.Peers {
+		if m.remove && peer.PID == m.peer.Pid {
+			continue
+		}
+		quorum.Peers = append(quorum.Peers, peer)
+	}
+
+	if m.remove {
+		if len(quorum.Peers) == len(current.Peers) {
+			return nil, ErrUnknownPeer
+		}
+
+		if len(quorum.Voters()) == 0 {
+			return nil, ErrRemoveLastPeer
+		}
+
+		if quorum.BootstrapLeader == m.peer.Pid {
+			quorum.BootstrapLeader = 0
+		}
+		return quorum, nil
+	}
+
+	// The peer is added as a learner until it has caught up with the leader
+	peer := &peers.Peer{PID: m.peer.Pid, Name: m.peer.Name, BindAddr: m.peer.BindAddr, Endpoint: m.peer.Endpoint, Learner: true}
+	if err = peer.Validate(); err != nil {
+		return nil, err
+	}
+
+	if current.Contains(peer.PID) {
+		return nil, ErrPeerExists
+	}
+
+	quorum.Peers = append(quorum.Peers, peer)
+	return quorum, nil
+}
+
+// A configuration takes effect as soon as it is appended to the log, before it is
+// committed, so the replica uses the latest configuration entry in its log or the last
+// committed configuration if there are no configuration entries after the commit index.
+// This must be called when configuration entries are appended or dropped from the log.
+func (r *Replica) updateConfiguration() {
+	quorum, index := r.committed, r.committedIndex
+	for i := r.log.LastApplied(); i > r.log.CommitIndex(); i-- {
+		entry, err := r.log.Get(i)
+		if err != nil {
+			break
+		}
+
+		if bytes.Equal(entry.Key, configKey) {
+			if quorum, err = parseConfiguration(entry.Value); err != nil {
+				log.Error().Err(err).Str("replica", r.Name).Uint64("index", i).Msg("could not parse configuration")
+				return
+			}
+			index = i
+			break
+		}
+	}
+
+	r.reconfigure(quorum, index)
+}
+
+// Use the configuration from the log entry at the index, creating remotes for the
+// peers that are missing one and closing the remotes of peers that were removed. Remotes
+// are only connected if the replica is running, otherwise they are connected by Run.
+func (r *Replica) reconfigure(quorum *peers.Quorum, index uint64) {
+	r.conf.Quorum = quorum
+	r.configIndex = index
+
+	for _, peer := range quorum.Peers {
+		if _, ok := r.remotes[peer.PID]; ok || peer.PID == r.PID {
+			continue
+		}
+
+		remote := newRemote(*peer, r)
+		if r.state >= Running {
+			if err := r.connect(remote); err != nil {
+				log.Error().Err(err).Str("replica", r.Name).Str("remote", peer.Name).Msg("could not connect to added peer")
+				continue
+			}
+
+			r.wg.Add(1)
+			go remote.run(r.done)
+			remote.nextIndex = r.log.LastApplied() + 1
+		}
+		r.remotes[peer.PID] = remote
+	}
+
+	for pid, remote := range r.remotes {
+		if !quorum.Contains(pid) {
+			remote.Close()
+			delete(r.remotes, pid)
+		}
+	}
+}
+
+// Once a configuration is committed it is written back to the peers path so that the
+// replica uses it when it restarts. If the leader is not in the committed configuration
+// it steps down so that the remaining peers elect a new leader from among themselves.
+func (r *Replica) commitConfiguration(entry *api.LogEntry) {
+	quorum, err := parseConfiguration(entry.Value)
+	if err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Uint64("index", entry.Index).Msg("could not parse committed configuration")
+		return
+	}
+
+	r.committed, r.committedIndex = quorum, entry.Index
+	if r.conf.PeersPath != "" {
+		if err = quorum.Dump(r.conf.PeersPath); err != nil {
+			log.Error().Err(err).Str("replica", r.Name).Str("path", r.conf.PeersPath).Msg("could not write configuration to disk")
+		}
+	}
+
+	if r.state == Leader && !quorum.Contains(r.PID) {
+		r.stepDown(r.term)
+	}
+	log.Debug().Str("replica", r.Name).Uint64("index", entry.Index).Int("peers", len(quorum.Peers)).Msg("configuration committed")
+}
+
+// Converts the quorum into its replicated representation.
+func configuration(quorum *peers.Quorum) *api.Configuration {
+	config := &api.Configuration{
+		QuorumId:        quorum.QID,
+		BootstrapLeader: quorum.BootstrapLeader,
+		Peers:           make([]*api.Peer, 0, len(quorum.Peers)),
+	}
+
+	for _, peer := range quorum.Peers {
+		config.Peers = append(config.Peers, &api.Peer{Pid: peer.PID, Name: peer.Name, BindAddr: peer.BindAddr, Endpoint: peer.Endpoint, Learner: peer.Learner})
+	}
+	return config
+}
+
+// Converts the replicated representation of the configuration into a quorum.
+func quorumFrom(config *api.Configuration) *peers.Quorum {
+	quorum := &peers.Quorum{
+		QID:             config.QuorumId,
+		BootstrapLeader: config.BootstrapLeader,
+		Peers:           make([]*peers.Peer, 0, len(config.Peers)),
+	}
+
+	for _, peer := range config.Peers {
+		quorum.Peers = append(quorum.Peers, &peers.Peer{PID: peer.Pid, Name: peer.Name, BindAddr: peer.BindAddr, Endpoint: peer.Endpoint, Learner: peer.Learner})
+	}
+	return quorum
+}
+
+func parseConfiguration(value []byte) (*peers.Quorum, error) {
+	config := &api.Configuration{}
+	if err := proto.Unmarshal(value, config); err != nil {
+		return nil, err
+	}
+	return quorumFrom(config), nil
+}
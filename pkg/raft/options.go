@@ -0,0 +1,40 @@
+package raft
+
+import (
+	"net"
+
+	"github.com/rotationalio/ensign/pkg/raft/log"
+	"google.golang.org/grpc"
+)
+
+// Option configures a replica when it is created.
+type Option func(r *Replica) error
+
+// WithStateMachine sets the application state machine that log entries are applied to
+// once they have been committed by the quorum.
+func WithStateMachine(sm log.StateMachine) Option {
+	return func(r *Replica) error {
+		r.sm = sm
+		return nil
+	}
+}
+
+// WithDialOptions specifies additional options used to connect to the remote peers in
+// the quorum, e.g. a context dialer for an in-process network. The transport
+// credentials are determined by the configuration of the replica.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(r *Replica) error {
+		r.dialOpts = append(r.dialOpts, opts...)
+		return nil
+	}
+}
+
+// WithAdminListener serves the admin service on the listener rather than listening on
+// the admin address of the configuration, e.g. to serve it on a bufconn in tests. The
+// admin service still requires the mTLS certs of the configuration.
+func WithAdminListener(lis net.Listener) Option {
+	return func(r *Replica) error {
+		r.adminLis = lis
+		return nil
+	}
+}
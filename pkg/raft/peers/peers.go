@@ -20,10 +20,11 @@ type Quorum struct {
 
 // Peer is a configuration of a peer and contains all connection and helper information.
 type Peer struct {
-	PID      uint32 `json:"peer_id" yaml:"peer_id"`     // the unique ID of the peer in the system
-	Name     string `json:"name" yaml:"name"`           // a human readable name for the peer (e.g. hostname)
-	BindAddr string `json:"bind_addr" yaml:"bind_addr"` // the address to bind the peer on to listen for requests
-	Endpoint string `json:"endpoint" yaml:"endpoint"`   // the domain or ip address and port to connect to the peer on
+	PID      uint32 `json:"peer_id" yaml:"peer_id"`                     // the unique ID of the peer in the system
+	Name     string `json:"name" yaml:"name"`                           // a human readable name for the peer (e.g. hostname)
+	BindAddr string `json:"bind_addr" yaml:"bind_addr"`                 // the address to bind the peer on to listen for requests
+	Endpoint string `json:"endpoint" yaml:"endpoint"`                   // the domain or ip address and port to connect to the peer on
+	Learner  bool   `json:"learner,omitempty" yaml:"learner,omitempty"` // the peer is replicated to but does not vote until it has caught up
 }
 
 //===========================================================================
@@ -96,6 +97,28 @@ func (q *Quorum) Contains(pidorname interface{}) bool {
 	return false
 }
 
+// Voter checks if the peer specified by PID is in the quorum and is not a learner.
+func (q *Quorum) Voter(pid uint32) bool {
+	for _, peer := range q.Peers {
+		if peer.PID == pid {
+			return !peer.Learner
+		}
+	}
+	return false
+}
+
+// Voters returns the PIDs of the peers in the quorum that vote in elections and count
+// towards the majority that entries must be replicated to before they are committed.
+func (q *Quorum) Voters() []uint32 {
+	voters := make([]uint32, 0, len(q.Peers))
+	for _, peer := range q.Peers {
+		if !peer.Learner {
+			voters = append(voters, peer.PID)
+		}
+	}
+	return voters
+}
+
 //===========================================================================
 // Serialization
 //===========================================================================
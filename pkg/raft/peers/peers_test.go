@@ -90,6 +90,20 @@ func TestContains(t *testing.T) {
 
 }
 
+func TestVoters(t *testing.T) {
+	quorum, err := peers.Load("testdata/quorum.json")
+	require.NoError(t, err, "could not load quorum fixture")
+	require.Equal(t, []uint32{13, 21, 58}, quorum.Voters())
+	require.True(t, quorum.Voter(21))
+
+	// Learners are in the quorum but do not vote
+	quorum.Peers[1].Learner = true
+	require.Equal(t, []uint32{13, 58}, quorum.Voters())
+	require.True(t, quorum.Contains(uint32(21)))
+	require.False(t, quorum.Voter(21))
+	require.False(t, quorum.Voter(109))
+}
+
 func TestSerialization(t *testing.T) {
 	_, err := peers.Load("testdata/quorum.foo")
 	require.EqualError(t, err, "unknown file extension \".foo\"", "expected error for .foo extension")
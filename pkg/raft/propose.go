@@ -0,0 +1,128 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// Propose a command to the quorum and wait until it has been committed and applied to
+// the state machine of the local replica. Only the leader can accept proposals; other
+// replicas return ErrNotLeader and the client should retry with the leader. If the
+// leader loses its leadership, the entry may still be committed by the next leader or
+// it may be dropped from the log, in which case ErrDropped is returned.
+func (r *Replica) Propose(ctx context.Context, key, value []byte) (_ *api.LogEntry, err error) {
+	committed := make(chan error, 1)
+	var rep interface{}
+	if rep, err = r.request(ctx, proposeEvent, &proposal{key: key, value: value, committed: committed}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case err = <-committed:
+		if err != nil {
+			return nil, err
+		}
+		return rep.(*api.LogEntry), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Append the proposed command to the leader's log and replicate it to the followers,
+// either immediately or with the next heartbeat if commands are being aggregated.
+func (r *Replica) onPropose(p *proposal) interface{} {
+	if r.state != Leader {
+		return ErrNotLeader
+	}
+
+	if bytes.Equal(p.key, configKey) {
+		return ErrReservedKey
+	}
+
+	entry, err := r.log.Create(p.key, p.value, r.term)
+	if err != nil {
+		return err
+	}
+
+	r.pending[entry.Index] = p.committed
+	if !r.conf.Aggregate {
+		r.broadcastAppend()
+	}
+
+	// A single replica quorum commits the entry immediately
+	r.advanceCommit()
+	return entry
+}
+
+// Notify the proposal waiting for the entry at the index, if any.
+func (r *Replica) resolve(index uint64, err error) {
+	if committed, ok := r.pending[index]; ok {
+		committed <- err
+		delete(r.pending, index)
+	}
+}
+
+// Wraps the application state machine to notify proposals when their entries are
+// committed or dropped from the log and to handle the configuration entries of the
+// quorum, which are not applied to the application state machine. The log calls the
+// state machine from the event loop so the replica's state does not need to be locked.
+type stateMachine struct {
+	replica *Replica
+	sm      log.StateMachine
+}
+
+func (s *stateMachine) CommitEntry(entry *api.LogEntry) error {
+	if bytes.Equal(entry.Key, configKey) {
+		s.replica.commitConfiguration(entry)
+	} else if s.sm != nil {
+		if err := s.sm.CommitEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	s.replica.resolve(entry.Index, nil)
+	return nil
+}
+
+func (s *stateMachine) DropEntry(entry *api.LogEntry) error {
+	if s.sm != nil && !bytes.Equal(entry.Key, configKey) {
+		if err := s.sm.DropEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	s.replica.resolve(entry.Index, ErrDropped)
+	return nil
+}
+
+// The snapshot includes the last committed configuration of the quorum since the
+// configuration entries are compacted into the snapshot along with the other entries.
+func (s *stateMachine) Snapshot() (_ []byte, err error) {
+	snap := &api.SnapshotData{Configuration: configuration(s.replica.committed)}
+	if s.sm != nil {
+		if snap.Data, err = s.sm.Snapshot(); err != nil {
+			return nil, err
+		}
+	}
+	return proto.Marshal(snap)
+}
+
+func (s *stateMachine) Restore(data []byte) (err error) {
+	snap := &api.SnapshotData{}
+	if err = proto.Unmarshal(data, snap); err != nil {
+		return err
+	}
+
+	if snap.Configuration != nil {
+		s.replica.committed, s.replica.committedIndex = quorumFrom(snap.Configuration), 0
+	}
+
+	if s.sm != nil {
+		return s.sm.Restore(snap.Data)
+	}
+	return nil
+}
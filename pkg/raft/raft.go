@@ -1,33 +1,83 @@
 package raft
 
 import (
+	"net"
+	"sync"
+
 	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
 	"github.com/rotationalio/ensign/pkg/raft/election"
 	"github.com/rotationalio/ensign/pkg/raft/interval"
 	"github.com/rotationalio/ensign/pkg/raft/log"
+	"github.com/rotationalio/ensign/pkg/raft/log/leveldb"
 	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rotationalio/ensign/pkg/utils/mtls"
+	"google.golang.org/grpc"
 )
 
 // New creates a new replica from the configuration, validating it and setting the
 // replica to its initialized state. If the configuration is invalid or the replica
 // cannot be correctly initialized then an error is returned.
-func New(conf Config) (replica *Replica, err error) {
+func New(conf Config, opts ...Option) (replica *Replica, err error) {
 	if err = conf.Validate(); err != nil {
 		return nil, err
 	}
 
-	// TODO: document the heartbeat and candidacy interval tick rates.
+	// The leader sends a heartbeat every tick; followers wait between 2 and 4 ticks
+	// without hearing from the leader before starting an election. The random range
+	// makes it unlikely that two followers become candidates at the same time.
 	replica = &Replica{
 		conf:      conf,
 		heartbeat: interval.NewFixed(conf.Tick),
 		candidacy: interval.NewRandom(2*conf.Tick, 4*conf.Tick),
+		remotes:   make(map[uint32]*Remote, len(conf.Quorum.Peers)-1),
+		events:    make(chan event, eventBufferSize),
+		pending:   make(map[uint64]chan<- error),
+		done:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err = opt(replica); err != nil {
+			return nil, err
+		}
 	}
 
-	// TODO: set state-machine and sync from configuration
-	if replica.log, err = log.New(); err != nil {
+	if err = replica.loadCerts(); err != nil {
 		return nil, err
 	}
 
+	for _, peer := range conf.Quorum.Peers {
+		if peer.PID == conf.ReplicaID {
+			replica.Peer = *peer
+		}
+	}
+
+	// The configuration loaded from disk is the last committed configuration; it is
+	// replaced by configuration entries in the log when the log is loaded.
+	replica.committed = conf.Quorum
+
+	// Load the log from disk if a data path is configured, otherwise the log is in memory
+	logOpts := []log.Option{log.WithStateMachine(&stateMachine{replica: replica, sm: replica.sm})}
+	if conf.DataPath != "" {
+		if replica.sync, err = leveldb.Open(conf.DataPath); err != nil {
+			return nil, err
+		}
+
+		if replica.log, err = log.Load(append(logOpts, log.WithSync(replica.sync))...); err != nil {
+			replica.sync.Close()
+			return nil, err
+		}
+	} else {
+		if replica.log, err = log.New(logOpts...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Recover the term and vote of the replica if it is restarting
+	replica.term, replica.votedFor = replica.log.Vote()
+
+	// Create the remotes from the latest configuration of the quorum
+	replica.updateConfiguration()
+
 	if err = replica.setState(Initialized); err != nil {
 		return nil, err
 	}
@@ -35,31 +85,193 @@ func New(conf Config) (replica *Replica, err error) {
 }
 
 type Replica struct {
+	sync.RWMutex
 	api.UnimplementedRaftServer
+	api.UnimplementedRaftAdminServer
 	peers.Peer
 
-	conf Config // the configuration of the local replica
+	conf     Config                  // the configuration of the local replica, the quorum is the latest configuration in the log
+	sm       log.StateMachine        // the application state machine that committed entries are applied to
+	sync     log.Sync                // persists the log to disk, nil if the log is only kept in memory
+	dialOpts []grpc.DialOption       // options used to connect to remote peers
+	certs    *mtls.Provider          // the mTLS certificate chain and private key of the replica, nil if insecure without an admin service
+	trusted  []*mtls.Provider        // additional certificates trusted to authenticate peers and admin clients
+	srv      *grpc.Server            // serves the raft service to remote peers
+	admin    *grpc.Server            // serves the admin service to authenticated clients, nil if not enabled
+	adminLis net.Listener            // the listener to serve the admin service on instead of the admin address
+	remotes  map[uint32]*Remote      // the other peers in the quorum, keyed by PID
+	events   chan event              // events handled by the event loop
+	pending  map[uint64]chan<- error // proposals waiting to be committed, keyed by log index
+	done     chan struct{}           // closed when the replica is shut down
+	wg       sync.WaitGroup          // waits for the event loop and remotes to stop
 
 	// Consensus state
-	state     State             // the current state of the local replica
-	leader    uint32            // the PID of the leader of the quorum
-	term      uint64            // current term of the replica
-	log       *log.Log          // state machine command log maintained by consensus
-	votes     election.Election // the current leader election, if any
-	votedFor  uint32            // the PID of the replica we voted for in the current term
-	heartbeat interval.Interval // the heartbeat ticker
-	candidacy interval.Interval // the candidate timeout
+	state     State                    // the current state of the local replica
+	leader    uint32                   // the PID of the leader of the quorum
+	term      uint64                   // current term of the replica
+	log       *log.Log                 // state machine command log maintained by consensus
+	votes     election.Election        // the current leader election, if any
+	votedFor  uint32                   // the PID of the replica we voted for in the current term
+	heartbeat *interval.FixedInterval  // the heartbeat ticker
+	candidacy *interval.RandomInterval // the candidate timeout
+
+	// Membership state
+	configIndex    uint64        // the index of the configuration entry the quorum is from, 0 if loaded from disk
+	committed      *peers.Quorum // the last committed configuration of the quorum
+	committedIndex uint64        // the index of the last committed configuration entry
+}
+
+// Run the replica: serve the raft service to remote peers, connect to the remote peers
+// and start the event loop that drives consensus. Peers are served and connected to
+// with mTLS unless the replica is insecure. If the listener is nil, the replica listens
+// on the bind address of its peer configuration. If an admin address is configured
+// the admin service is also served on it. Run does not block; the replica
+// participates in the quorum until it is shut down.
+func (r *Replica) Run(lis net.Listener) (err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.state != Initialized {
+		return ErrCannotSetRunningState
+	}
+
+	var creds grpc.ServerOption
+	if creds, err = r.serverCreds(); err != nil {
+		return err
+	}
+
+	if lis == nil {
+		if lis, err = net.Listen("tcp", r.BindAddr); err != nil {
+			return err
+		}
+	}
+
+	r.srv = grpc.NewServer(creds)
+	api.RegisterRaftServer(r.srv, r)
+	go r.serve(r.srv, lis)
+
+	if r.conf.AdminAddr != "" || r.adminLis != nil {
+		if err = r.serveAdmin(); err != nil {
+			r.srv.Stop()
+			return err
+		}
+	}
+
+	for _, remote := range r.remotes {
+		if err = r.connect(remote); err != nil {
+			r.srv.Stop()
+			if r.admin != nil {
+				r.admin.Stop()
+			}
+			return err
+		}
+
+		r.wg.Add(1)
+		go remote.run(r.done)
+	}
+
+	if err = r.setState(Running); err != nil {
+		return err
+	}
+
+	// Bootstrap the leader of the quorum at term 0 so that the quorum does not have to
+	// wait for an election timeout the first time it starts.
+	if r.conf.Quorum.BootstrapLeader == r.PID && r.term == 0 {
+		if err = r.vote(0, r.PID); err != nil {
+			return err
+		}
+		err = r.setState(Leader)
+	} else {
+		err = r.setState(Follower)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go r.listen(r.done)
+	return nil
+}
+
+// Shutdown the replica, stopping the event loop and closing the connections to the
+// remote peers. Proposals that have not been committed return ErrStopped.
+func (r *Replica) Shutdown() (err error) {
+	r.Lock()
+	select {
+	case <-r.done:
+		r.Unlock()
+		return nil
+	default:
+		close(r.done)
+	}
+
+	srv, admin := r.srv, r.admin
+	remotes := make([]*Remote, 0, len(r.remotes))
+	for _, remote := range r.remotes {
+		remotes = append(remotes, remote)
+	}
+	r.Unlock()
+
+	if srv != nil {
+		srv.Stop()
+	}
+
+	if admin != nil {
+		admin.Stop()
+	}
+
+	for _, remote := range remotes {
+		if cerr := remote.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	r.wg.Wait()
+
+	r.Lock()
+	defer r.Unlock()
+	for index := range r.pending {
+		r.resolve(index, ErrStopped)
+	}
+
+	if serr := r.setState(Stopped); serr != nil {
+		return serr
+	}
+
+	if r.sync != nil {
+		if cerr := r.sync.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 // Election returns a new election from the replica's internal configuration with the
 // replica voting yes for itself automatically (e.g. to start its candidacy).
 func (r *Replica) Election() election.Election {
-	peers := make([]uint32, 0, len(r.conf.Quorum.Peers))
-	for _, peer := range r.conf.Quorum.Peers {
-		peers = append(peers, peer.PID)
-	}
-
-	votes := election.New(peers...)
+	// Learners are not part of elections or of the majority required to commit entries
+	votes := election.New(r.conf.Quorum.Voters()...)
 	votes.Vote(r.conf.ReplicaID, true)
 	return votes
 }
+
+// Leader returns the PID of the leader of the quorum or 0 if the leader is unknown.
+func (r *Replica) Leader() uint32 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.leader
+}
+
+// Term returns the current term of the replica.
+func (r *Replica) Term() uint64 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.term
+}
+
+// CommitIndex returns the index of the last entry committed to the local log.
+func (r *Replica) CommitIndex() uint64 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.log.CommitIndex()
+}
@@ -23,6 +23,7 @@ func (s *raftTestSuite) SetupSuite() {
 		Timeout:   50 * time.Millisecond,
 		Aggregate: false,
 		PeersPath: "testdata/quorum.json",
+		Insecure:  true,
 	}
 
 	s.replica, err = raft.New(conf)
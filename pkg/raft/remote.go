@@ -0,0 +1,200 @@
+package raft
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+// The number of append entries requests that can be queued for a remote before new
+// requests are dropped; dropped requests are resent by the next heartbeat.
+const remoteBufferSize = 8
+
+// Remote is a peer in the quorum that the local replica sends vote and append entries
+// requests to. Append entries requests are queued and sent on a long running stream by
+// the remote's own go routine so that the replica's event loop never blocks on the
+// network; replies are dispatched back to the event loop as events.
+type Remote struct {
+	peers.Peer
+	replica *Replica
+
+	// Volatile leader state, only accessed from the replica's event loop
+	nextIndex  uint64 // the index of the next log entry to send to the remote
+	matchIndex uint64 // the index of the highest log entry known to be replicated on the remote
+
+	installing atomic.Bool // set while a snapshot is being sent to the remote
+
+	sync.Mutex
+	cc       *grpc.ClientConn
+	client   api.RaftClient
+	messages chan *api.AppendRequest
+	stop     chan struct{} // closed when the remote is closed, e.g. when it is removed from the quorum
+}
+
+func newRemote(peer peers.Peer, replica *Replica) *Remote {
+	return &Remote{
+		Peer:     peer,
+		replica:  replica,
+		messages: make(chan *api.AppendRequest, remoteBufferSize),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Connect to the remote peer. The connection is established lazily by gRPC so the
+// remote does not have to be online when the local replica starts. The options must
+// include the transport credentials of the connection.
+func (r *Remote) Connect(opts ...grpc.DialOption) (err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.cc, err = grpc.NewClient("passthrough:///"+r.Endpoint, opts...); err != nil {
+		return err
+	}
+
+	r.client = api.NewRaftClient(r.cc)
+	return nil
+}
+
+// Close the connection to the remote peer and stop sending append entries to it.
+func (r *Remote) Close() (err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+
+	if r.cc == nil {
+		return nil
+	}
+
+	err = r.cc.Close()
+	r.cc = nil
+	return err
+}
+
+// RequestVote sends the vote request to the remote and dispatches the reply to the
+// replica's event loop. The request is abandoned if the remote does not respond before
+// the configured timeout; the candidacy timeout will start another election if needed.
+func (r *Remote) RequestVote(req *api.VoteRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.replica.conf.Timeout)
+	defer cancel()
+
+	rep, err := r.client.RequestVote(ctx, req)
+	if err != nil {
+		log.Debug().Err(err).Str("remote", r.Name).Uint64("term", req.Term).Msg("could not request vote")
+		return
+	}
+
+	r.replica.dispatch(context.Background(), voteReplyEvent, rep)
+}
+
+// InstallSnapshot sends the leader's snapshot to the remote and dispatches the reply to
+// the replica's event loop. Only one snapshot is sent to the remote at a time; while it
+// is being sent, heartbeats that would send the snapshot again are ignored. Snapshots
+// are much larger than other requests so the remote has until the election timeout to
+// install it rather than the request timeout.
+func (r *Remote) InstallSnapshot(req *api.SnapshotRequest) {
+	if !r.installing.CompareAndSwap(false, true) {
+		return
+	}
+	defer r.installing.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*r.replica.conf.Tick)
+	defer cancel()
+
+	rep, err := r.client.InstallSnapshot(ctx, req)
+	if err != nil {
+		log.Debug().Err(err).Str("remote", r.Name).Uint64("index", req.Snapshot.Index).Msg("could not install snapshot")
+		return
+	}
+
+	r.replica.dispatch(context.Background(), snapshotReplyEvent, rep)
+}
+
+// AppendEntries queues the request to be sent to the remote without blocking. If the
+// queue is full the request is dropped since the next heartbeat will resend entries
+// that have not been acknowledged.
+func (r *Remote) AppendEntries(req *api.AppendRequest) {
+	select {
+	case r.messages <- req:
+	default:
+		log.Trace().Str("remote", r.Name).Msg("append entries queue full, dropping request")
+	}
+}
+
+// Sends queued append entries requests to the remote on a stream, opening a new stream
+// when the previous one fails, until the replica is shut down or the remote is closed.
+func (r *Remote) run(done <-chan struct{}) {
+	defer r.replica.wg.Done()
+
+	var (
+		err    error
+		stream api.Raft_AppendEntriesClient
+		cancel context.CancelFunc
+	)
+
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-r.stop:
+			return
+		case req := <-r.messages:
+			if stream == nil {
+				if stream, cancel, err = r.open(); err != nil {
+					log.Debug().Err(err).Str("remote", r.Name).Msg("could not open append entries stream")
+					continue
+				}
+			}
+
+			if err = stream.Send(req); err != nil {
+				log.Debug().Err(err).Str("remote", r.Name).Msg("append entries stream closed")
+				cancel()
+				stream, cancel = nil, nil
+			}
+		}
+	}
+}
+
+// Open an append entries stream to the remote and start receiving replies on it.
+func (r *Remote) open() (_ api.Raft_AppendEntriesClient, _ context.CancelFunc, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stream api.Raft_AppendEntriesClient
+	if stream, err = r.client.AppendEntries(ctx); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	go r.recv(stream)
+	return stream, cancel, nil
+}
+
+// Dispatches append entries replies from the stream to the replica's event loop until
+// the stream is closed.
+func (r *Remote) recv(stream api.Raft_AppendEntriesClient) {
+	for {
+		rep, err := stream.Recv()
+		if err != nil {
+			return
+		}
+
+		if err = r.replica.dispatch(context.Background(), appendReplyEvent, rep); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rotationalio/ensign/pkg/raft/peers"
+	"github.com/rotationalio/ensign/pkg/utils/mtls"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// RequestVote is called by candidates to request the replica's vote for the term.
+func (r *Replica) RequestVote(ctx context.Context, in *api.VoteRequest) (_ *api.VoteReply, err error) {
+	var rep interface{}
+	if rep, err = r.request(ctx, voteRequestEvent, in); err != nil {
+		return nil, rpcError(err)
+	}
+	return rep.(*api.VoteReply), nil
+}
+
+// AppendEntries is a stream that the leader sends log entries and heartbeats on. Each
+// request is handled by the event loop in order and is replied to on the stream.
+func (r *Replica) AppendEntries(stream api.Raft_AppendEntriesServer) (err error) {
+	for {
+		var in *api.AppendRequest
+		if in, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var rep interface{}
+		if rep, err = r.request(stream.Context(), appendRequestEvent, in); err != nil {
+			return rpcError(err)
+		}
+
+		if err = stream.Send(rep.(*api.AppendReply)); err != nil {
+			return err
+		}
+	}
+}
+
+// InstallSnapshot is called by the leader when the replica's log is missing entries that
+// the leader has compacted into a snapshot.
+func (r *Replica) InstallSnapshot(ctx context.Context, in *api.SnapshotRequest) (_ *api.SnapshotReply, err error) {
+	var rep interface{}
+	if rep, err = r.request(ctx, snapshotRequestEvent, in); err != nil {
+		return nil, rpcError(err)
+	}
+	return rep.(*api.SnapshotReply), nil
+}
+
+// Load the mTLS certificates of the replica and the pool of additional certificates
+// that are trusted to authenticate peers and admin clients.
+func (r *Replica) loadCerts() (err error) {
+	if r.conf.CertPath == "" {
+		return nil
+	}
+
+	if r.certs, err = mtls.Load(r.conf.CertPath); err != nil {
+		return err
+	}
+
+	if r.conf.PoolPath != "" {
+		var pool *mtls.Provider
+		if pool, err = mtls.Load(r.conf.PoolPath); err != nil {
+			return err
+		}
+		r.trusted = append(r.trusted, pool)
+	}
+	return nil
+}
+
+// Returns the credentials of the raft service, which requires peers to connect with
+// mTLS so that only replicas with trusted certificates can take part in consensus.
+func (r *Replica) serverCreds() (grpc.ServerOption, error) {
+	if r.conf.Insecure {
+		return grpc.Creds(insecure.NewCredentials()), nil
+	}
+	return mtls.ServerCreds(r.certs, r.trusted...)
+}
+
+// Connect to the remote peer with the mTLS certificates of the replica, followed by any
+// dial options that the replica was created with.
+func (r *Replica) connect(remote *Remote) (err error) {
+	var creds grpc.DialOption
+	if r.conf.Insecure {
+		creds = grpc.WithTransportCredentials(insecure.NewCredentials())
+	} else if creds, err = mtls.ClientCreds(remote.Endpoint, r.certs, r.trusted...); err != nil {
+		return err
+	}
+	return remote.Connect(append([]grpc.DialOption{creds}, r.dialOpts...)...)
+}
+
+// Serve the admin service with mTLS so that only clients with certificates trusted by
+// the replica can change the membership of the quorum. The admin service is not served
+// on the raft service so that the membership cannot be changed by peers.
+func (r *Replica) serveAdmin() (err error) {
+	var creds grpc.ServerOption
+	if creds, err = mtls.ServerCreds(r.certs, r.trusted...); err != nil {
+		return err
+	}
+
+	lis := r.adminLis
+	if lis == nil {
+		if lis, err = net.Listen("tcp", r.conf.AdminAddr); err != nil {
+			return err
+		}
+	}
+
+	r.admin = grpc.NewServer(creds)
+	api.RegisterRaftAdminServer(r.admin, r)
+	go r.serve(r.admin, lis)
+	return nil
+}
+
+// Serve the gRPC server on the listener until the server is stopped.
+func (r *Replica) serve(srv *grpc.Server, lis net.Listener) {
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		log.Error().Err(err).Str("replica", r.Name).Msg("raft server stopped unexpectedly")
+	}
+}
+
+func rpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrStopped), errors.Is(err, ErrMembershipPending), errors.Is(err, ErrNoCommitInTerm), errors.Is(err, ErrDropped):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, ErrNotLeader):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrPeerExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrUnknownPeer):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrRemoveLastPeer), errors.Is(err, peers.ErrMissingPID), errors.Is(err, peers.ErrPeerMissingField):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.FromContextError(err).Err()
+}
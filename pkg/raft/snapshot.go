@@ -0,0 +1,85 @@
+package raft
+
+import (
+	api "github.com/rotationalio/ensign/pkg/raft/api/v1beta1"
+	"github.com/rs/zerolog/log"
+)
+
+// Compact the log into a snapshot of the state machine once the configured number of
+// entries have been committed since the last snapshot. Each replica compacts its own
+// log independently of the leader.
+func (r *Replica) compact() {
+	if r.conf.Snapshot == 0 {
+		return
+	}
+
+	var last uint64
+	if snap := r.log.LastSnapshot(); snap != nil {
+		last = snap.Index
+	}
+
+	if r.log.CommitIndex()-last < r.conf.Snapshot {
+		return
+	}
+
+	snap, err := r.log.Snapshot()
+	if err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Msg("could not snapshot log")
+		return
+	}
+	log.Debug().Str("replica", r.Name).Uint64("index", snap.Index).Uint64("term", snap.Term).Msg("log compacted")
+}
+
+// Install the leader's snapshot if it is from the current term, replacing the entries
+// in the log that it covers and restoring the state machine from it.
+func (r *Replica) onSnapshotRequest(req *api.SnapshotRequest) *api.SnapshotReply {
+	rep := &api.SnapshotReply{Remote: r.PID}
+	defer func() {
+		rep.Term = r.term
+		rep.Index = r.log.CommitIndex()
+	}()
+
+	// Reject requests from a leader of an earlier term
+	if req.Term < r.term {
+		return rep
+	}
+
+	if req.Term > r.term || r.state != Follower {
+		r.stepDown(req.Term)
+	}
+	r.leader = req.Leader
+	r.candidacy.Interrupt()
+
+	if err := r.log.Install(req.Snapshot); err != nil {
+		log.Error().Err(err).Str("replica", r.Name).Uint64("index", req.Snapshot.Index).Msg("could not install snapshot")
+		return rep
+	}
+
+	// The snapshot may contain a later configuration and uncommitted configuration
+	// entries may have been dropped from the log.
+	r.updateConfiguration()
+
+	log.Info().Str("replica", r.Name).Uint64("index", req.Snapshot.Index).Uint64("term", req.Snapshot.Term).Msg("snapshot installed")
+	return rep
+}
+
+// Entries up to the remote's commit index match the leader's log once the snapshot is
+// installed, so replication resumes with the entries that follow it on the next
+// heartbeat. If the snapshot could not be installed the heartbeat sends it again.
+func (r *Replica) onSnapshotReply(rep *api.SnapshotReply) {
+	if rep.Term > r.term {
+		r.stepDown(rep.Term)
+		return
+	}
+
+	remote, ok := r.remotes[rep.Remote]
+	if r.state != Leader || rep.Term != r.term || !ok {
+		return
+	}
+
+	if rep.Index > remote.matchIndex {
+		remote.matchIndex = rep.Index
+	}
+	remote.nextIndex = remote.matchIndex + 1
+	r.advanceCommit()
+}
@@ -37,8 +37,10 @@ func (s State) String() string {
 // State Transitions
 //===========================================================================
 
-// State returns the current state of the replica for testing.
+// State returns the current state of the replica.
 func (r *Replica) State() State {
+	r.RLock()
+	defer r.RUnlock()
 	return r.state
 }
 
@@ -86,29 +88,29 @@ func (r *Replica) setStoppedState() error {
 }
 
 // Resets any volatile variables on the local replica and is called when the replica
-// becomes a follower or a candidate.
+// becomes a follower or a candidate. The vote cast in the current term is not reset
+// since it must be kept until the term changes.
 func (r *Replica) setInitializedState() error {
 	r.votes = nil
-	r.votedFor = 0
 
-	// TODO: reset nextIndex and matchIndex on remotes
+	// Reset the leader state for the remote peers
+	for _, remote := range r.remotes {
+		remote.nextIndex = 0
+		remote.matchIndex = 0
+	}
 
 	log.Debug().Str("replica", r.Name).Msg("replica initialized")
 	return nil
 }
 
 // Should be called once after initialization to bootstrap the quorum by starting the
-// leader's heartbeat or starting the election timeout for all other replicas.
+// election timeout; the replica then becomes the leader if it is the bootstrap leader
+// of the quorum or a follower otherwise.
 func (r *Replica) setRunningState() error {
 	if r.state != Initialized {
 		return ErrCannotSetRunningState
 	}
 
-	// TODO: determine leader PID from quorum
-	// if r.conf.Leader == r.Name {
-	// 	return r.setLeaderState()
-	// }
-
 	// Start the election timeout
 	r.candidacy.Start()
 	log.Debug().Str("replica", r.Name).Msg("replica running")
@@ -121,7 +123,9 @@ func (r *Replica) setFollowerState() error {
 
 	// Update the tickers
 	r.heartbeat.Stop()
-	r.candidacy.Start()
+	if !r.candidacy.Interrupt() {
+		r.candidacy.Start()
+	}
 
 	log.Info().Str("replica", r.Name).Uint64("term", r.term).Msg("replica is now a follower")
 	return nil
@@ -132,11 +136,19 @@ func (r *Replica) setCandidateState() error {
 	r.setInitializedState()
 
 	// Create the election for the next term and vote for self
-	// TODO: create election and vote for self
-	r.term++
+	if err := r.vote(r.term+1, r.PID); err != nil {
+		return err
+	}
+
+	r.leader = 0
+	r.votes = r.Election()
 
-	// TODO: broadcast vote request
+	// Restart the election timeout so that a split vote starts another election
+	if !r.candidacy.Interrupt() {
+		r.candidacy.Start()
+	}
 
+	r.broadcastVote()
 	log.Info().Str("replica", r.Name).Uint64("term", r.term).Msg("replica is now a candidate")
 	return nil
 }
@@ -150,9 +162,14 @@ func (r *Replica) setLeaderState() error {
 	r.candidacy.Stop()
 	r.leader = r.PID
 
-	// TODO: set the volatile state for known followers
+	// Set the volatile state for known followers
+	for _, remote := range r.remotes {
+		remote.nextIndex = r.log.LastApplied() + 1
+		remote.matchIndex = 0
+	}
 
-	// TODO: broadcast heartbeat message
+	// Assert leadership with an immediate heartbeat
+	r.broadcastAppend()
 
 	// Start the heartbeat interval
 	r.heartbeat.Start()
@@ -929,12 +929,12 @@ func (s *Server) ProjectQuery(c *gin.Context) {
 		}
 
 		out.Results = append(out.Results, result)
-
-		// TODO: Total events count should come from the Explain RPC, which may be
-		// greater than the number of results returned by this endpoint
 		out.TotalEvents++
 	}
 
+	// TODO: if maxQueryResults was reached, call the Explain RPC and return its estimate
+	// of the number of results in a separate field once go-ensign exposes the estimate
+	// on the QueryExplanation. TotalEvents only counts the results that were returned.
 	c.JSON(http.StatusOK, out)
 }
 